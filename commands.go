@@ -0,0 +1,243 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/norby7/questions-rest-api/entities"
+	"github.com/norby7/questions-rest-api/interfaceAdapters/format"
+	"github.com/norby7/questions-rest-api/usecases/auth"
+	"github.com/norby7/questions-rest-api/usecases/repository"
+	ucService "github.com/norby7/questions-rest-api/usecases/service"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// runCommand executes the command line subcommand with the given name
+func runCommand(s ucService.Interactor, keys repository.APIKeyRepository, name string, args []string) error {
+	switch name {
+	case "import":
+		return runImport(s, args, os.Stdin, os.Stdout)
+	case "export":
+		return runExport(s, args, os.Stdout)
+	case "apikey":
+		return runAPIKey(keys, args, os.Stdout)
+	}
+
+	return fmt.Errorf("unknown command %q, available commands: import, export, apikey", name)
+}
+
+// localContext returns the context of the commands working on the tenant bank
+// The commands are run by a local admin, as they require access to the database itself.
+func localContext(tenant string) context.Context {
+	return auth.NewContext(context.Background(), entities.Principal{
+		Subject: "cli",
+		Method:  entities.AuthLocal,
+		Roles:   []entities.Role{entities.RoleAdmin},
+		Tenant:  tenant,
+	})
+}
+
+// runImport imports the questions from the file given as argument, or from stdin if the file is "-",
+// and writes the import report to out
+func runImport(s ucService.Interactor, args []string, in io.Reader, out io.Writer) error {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	formatName := fs.String("format", "", "stream format: jsonl, csv, yaml, moodle or gift, defaults to the file extension")
+	modeName := fs.String("mode", string(ucService.ImportAtomic), "import mode: atomic or best-effort")
+	restore := fs.Bool("restore", false, "keep the review status and the creation and update times and authors of the records")
+	tenant := fs.String("tenant", entities.DefaultTenant, "tenant whose bank the questions are imported into")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: import [-format jsonl|csv|yaml|moodle|gift] [-mode atomic|best-effort] [-tenant <tenant>] <file|->")
+	}
+
+	p := fs.Arg(0)
+
+	var f format.Format
+	var err error
+	if *formatName != "" || p == "-" {
+		f, err = format.Parse(*formatName)
+	} else {
+		f, err = format.FromExtension(p)
+	}
+	if err != nil {
+		return err
+	}
+
+	mode, err := ucService.ParseImportMode(*modeName)
+	if err != nil {
+		return err
+	}
+
+	if p != "-" {
+		file, err := os.Open(p)
+		if err != nil {
+			return fmt.Errorf("unable to open import file: %s", err.Error())
+		}
+
+		defer file.Close()
+		in = file
+	}
+
+	dec, err := format.NewDecoder(f, in)
+	if err != nil {
+		return err
+	}
+
+	report, err := s.Import(localContext(*tenant), dec, mode, *restore)
+	if err != nil {
+		return err
+	}
+
+	e := json.NewEncoder(out)
+	e.SetIndent("", "  ")
+	if err = e.Encode(report); err != nil {
+		return fmt.Errorf("unable to encode import report: %s", err.Error())
+	}
+
+	if report.Failed > 0 {
+		return fmt.Errorf("%d of %d records failed to import", report.Failed, report.Total)
+	}
+
+	return nil
+}
+
+// runExport writes every question to the file given as argument, or to out if there is no argument or it is "-"
+func runExport(s ucService.Interactor, args []string, out io.Writer) (err error) {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	formatName := fs.String("format", "", "stream format: jsonl, csv, yaml, markdown, moodle, gift or qti, defaults to the file extension or jsonl")
+	tenant := fs.String("tenant", entities.DefaultTenant, "tenant whose bank is exported")
+
+	if err = fs.Parse(args); err != nil {
+		return err
+	}
+
+	if fs.NArg() > 1 {
+		return fmt.Errorf("usage: export [-format jsonl|csv|yaml|markdown|moodle|gift|qti] [-tenant <tenant>] [file|-]")
+	}
+
+	p := fs.Arg(0)
+
+	f := format.JSONL
+	switch {
+	case *formatName != "":
+		f, err = format.Parse(*formatName)
+	case p != "" && p != "-":
+		f, err = format.FromExtension(p)
+	}
+	if err != nil {
+		return err
+	}
+
+	if p != "" && p != "-" {
+		file, err := os.Create(p)
+		if err != nil {
+			return fmt.Errorf("unable to create export file: %s", err.Error())
+		}
+
+		defer func() {
+			if cerr := file.Close(); cerr != nil && err == nil {
+				err = fmt.Errorf("unable to close export file: %s", cerr.Error())
+			}
+		}()
+		out = file
+	}
+
+	enc, err := format.NewEncoder(f, out)
+	if err != nil {
+		return err
+	}
+
+	if err = s.Export(localContext(*tenant), enc); err != nil {
+		return fmt.Errorf("unable to export questions: %s", err.Error())
+	}
+
+	return enc.Close()
+}
+
+// runAPIKey creates, lists or revokes the api keys accepted by the http server
+func runAPIKey(keys repository.APIKeyRepository, args []string, out io.Writer) error {
+	usage := fmt.Errorf("usage: apikey create -subject <subject> [-name <name>] [-roles viewer,editor,admin,candidate] [-tenant <tenant>] | apikey list | apikey revoke <id>")
+
+	if len(args) == 0 {
+		return usage
+	}
+
+	switch args[0] {
+	case "create":
+		fs := flag.NewFlagSet("apikey create", flag.ContinueOnError)
+		name := fs.String("name", "", "name describing what the key is used for")
+		subject := fs.String("subject", "", "subject of the principal authenticated by the key")
+		roleNames := fs.String("roles", string(entities.RoleViewer), "comma separated roles granted by the key: viewer, editor, admin or candidate")
+		tenant := fs.String("tenant", entities.DefaultTenant, "tenant whose bank the key gives access to")
+
+		if err := fs.Parse(args[1:]); err != nil {
+			return err
+		}
+
+		var roles []entities.Role
+		for _, r := range strings.Split(*roleNames, ",") {
+			role, err := entities.ParseRole(strings.TrimSpace(r))
+			if err != nil {
+				return err
+			}
+
+			roles = append(roles, role)
+		}
+
+		key, k, err := auth.IssueAPIKey(context.Background(), keys, *name, *subject, *tenant, roles)
+		if err != nil {
+			return fmt.Errorf("unable to create api key: %s", err.Error())
+		}
+
+		fmt.Fprintf(out, "created api key %d for %s (%s on %s), store it now, it won't be shown again:\n%s\n", k.Id, k.Subject, *roleNames, k.Tenant, key)
+
+		return nil
+	case "list":
+		kl, err := keys.ListAPIKeys(context.Background())
+		if err != nil {
+			return err
+		}
+
+		tw := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "ID\tNAME\tSUBJECT\tTENANT\tROLES\tCREATED\tREVOKED")
+		for _, k := range kl {
+			roles := make([]string, len(k.Roles))
+			for i, r := range k.Roles {
+				roles[i] = string(r)
+			}
+
+			fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\t%s\t%t\n", k.Id, k.Name, k.Subject, k.Tenant, strings.Join(roles, ","), time.Unix(k.CreatedAt, 0).Format(time.RFC3339), k.Revoked)
+		}
+
+		return tw.Flush()
+	case "revoke":
+		if len(args) != 2 {
+			return usage
+		}
+
+		id, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid api key id %q", args[1])
+		}
+
+		if err = keys.RevokeAPIKey(context.Background(), id); err != nil {
+			return fmt.Errorf("unable to revoke api key: %s", err.Error())
+		}
+
+		fmt.Fprintf(out, "revoked api key %d\n", id)
+
+		return nil
+	}
+
+	return usage
+}
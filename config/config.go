@@ -0,0 +1,657 @@
+package config
+
+import (
+	"bytes"
+	"crypto/tls"
+	"flag"
+	"fmt"
+	"github.com/BurntSushi/toml"
+	"github.com/norby7/questions-rest-api/logging"
+	"github.com/norby7/questions-rest-api/usecases/service"
+	"gopkg.in/yaml.v2"
+	"io/ioutil"
+	"net"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ConfigFormatError = fmt.Errorf("unsupported configuration file format, expected a .yaml, .yml or .toml file")
+)
+
+// Config is the configuration of the application
+// It's loaded from the defaults, a YAML or TOML file, the environment and the command-line flags, in this order of precedence.
+type Config struct {
+	Database   Database   `yaml:"database" toml:"database"`
+	Server     Server     `yaml:"server" toml:"server"`
+	HTTP       HTTP       `yaml:"http" toml:"http"`
+	Pagination Pagination `yaml:"pagination" toml:"pagination"`
+	Log        Log        `yaml:"log" toml:"log"`
+	Metrics    Metrics    `yaml:"metrics" toml:"metrics"`
+	Tracing    Tracing    `yaml:"tracing" toml:"tracing"`
+	RateLimit  RateLimit  `yaml:"rate_limit" toml:"rate_limit"`
+	Duplicates Duplicates `yaml:"duplicates" toml:"duplicates"`
+	Auth       Auth       `yaml:"auth" toml:"auth"`
+}
+
+// Database configures the connection to the question bank database
+type Database struct {
+	// Driver is the name of the database/sql driver, only sqlite3 is supported
+	Driver string `yaml:"driver" toml:"driver"`
+	// DSN is the data source name passed to the driver, the database file path for sqlite3
+	DSN string `yaml:"dsn" toml:"dsn"`
+}
+
+// Server configures the http server
+type Server struct {
+	Address      string        `yaml:"address" toml:"address"`
+	ReadTimeout  time.Duration `yaml:"read_timeout" toml:"read_timeout"`
+	WriteTimeout time.Duration `yaml:"write_timeout" toml:"write_timeout"`
+	// RequestTimeout is the deadline of the request contexts, it's shorter than WriteTimeout so the timeout response can still be written
+	RequestTimeout time.Duration `yaml:"request_timeout" toml:"request_timeout"`
+	// BulkTimeout replaces the request, read and write timeouts for the import, export, batch and duplicates requests
+	BulkTimeout time.Duration `yaml:"bulk_timeout" toml:"bulk_timeout"`
+	IdleTimeout time.Duration `yaml:"idle_timeout" toml:"idle_timeout"`
+	// DrainDelay is the time the server keeps handling requests while reported unready, before it's shut down
+	DrainDelay      time.Duration `yaml:"drain_delay" toml:"drain_delay"`
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" toml:"shutdown_timeout"`
+	TLS             TLS           `yaml:"tls" toml:"tls"`
+}
+
+// TLS configures the https server, it's enabled when a certificate file is set
+// The certificate, key and client CA files are read again when the process receives SIGHUP.
+type TLS struct {
+	CertFile string `yaml:"cert_file" toml:"cert_file"`
+	KeyFile  string `yaml:"key_file" toml:"key_file"`
+	// ClientCAFile is the bundle of the CAs the client certificates are verified with, for mutual TLS
+	ClientCAFile string `yaml:"client_ca_file" toml:"client_ca_file"`
+	// ClientAuth is none, optional to verify the client certificates that are sent, or require to reject the clients without one
+	ClientAuth string `yaml:"client_auth" toml:"client_auth"`
+	// MinVersion is the oldest TLS version accepted: 1.2 or 1.3
+	MinVersion string `yaml:"min_version" toml:"min_version"`
+	// CipherSuites is the comma separated list of the TLS 1.2 cipher suites, the Go defaults are used when empty
+	CipherSuites string `yaml:"cipher_suites" toml:"cipher_suites"`
+}
+
+// Enabled reports whether the server is configured with a certificate
+func (t TLS) Enabled() bool {
+	return t.CertFile != ""
+}
+
+// Version returns the minimum TLS version
+func (t TLS) Version() (uint16, error) {
+	switch t.MinVersion {
+	case "1.2":
+		return tls.VersionTLS12, nil
+	case "1.3":
+		return tls.VersionTLS13, nil
+	}
+
+	return 0, fmt.Errorf("unsupported TLS version %q, expected 1.2 or 1.3", t.MinVersion)
+}
+
+// Ciphers returns the ids of the cipher suites, nil when the Go defaults are used
+// Only the suites Go considers secure are accepted.
+func (t TLS) Ciphers() ([]uint16, error) {
+	suites := map[string]uint16{}
+	for _, s := range tls.CipherSuites() {
+		suites[s.Name] = s.ID
+	}
+
+	var ids []uint16
+	for _, name := range strings.Split(t.CipherSuites, ",") {
+		if name = strings.TrimSpace(name); name == "" {
+			continue
+		}
+
+		id, ok := suites[name]
+		if !ok {
+			return nil, fmt.Errorf("unsupported cipher suite %q", name)
+		}
+
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
+
+// ClientAuthType returns how the client certificates are verified
+func (t TLS) ClientAuthType() (tls.ClientAuthType, error) {
+	switch t.ClientAuth {
+	case "none":
+		return tls.NoClientCert, nil
+	case "optional":
+		return tls.VerifyClientCertIfGiven, nil
+	case "require":
+		return tls.RequireAndVerifyClientCert, nil
+	}
+
+	return 0, fmt.Errorf("unsupported client auth %q, expected none, optional or require", t.ClientAuth)
+}
+
+// HTTP configures the limits and the cross-origin access of the api requests
+type HTTP struct {
+	// MaxBodyBytes is the largest body accepted by the api routes, except the import and batch ones which are limited by MaxBulkBytes
+	MaxBodyBytes int  `yaml:"max_body_bytes" toml:"max_body_bytes"`
+	MaxBulkBytes int  `yaml:"max_bulk_bytes" toml:"max_bulk_bytes"`
+	CORS         CORS `yaml:"cors" toml:"cors"`
+}
+
+// CORS configures the origins whose browsers can call the api, it's disabled when no origin is allowed
+type CORS struct {
+	// AllowedOrigins is the comma separated list of the allowed origins, like https://authoring.example.com, * allows any origin
+	AllowedOrigins   string `yaml:"allowed_origins" toml:"allowed_origins"`
+	AllowCredentials bool   `yaml:"allow_credentials" toml:"allow_credentials"`
+	// MaxAge is how long the browsers can cache the response to a preflight request
+	MaxAge time.Duration `yaml:"max_age" toml:"max_age"`
+}
+
+// Origins returns the list of the allowed origins
+func (c CORS) Origins() []string {
+	var origins []string
+	for _, o := range strings.Split(c.AllowedOrigins, ",") {
+		if o = strings.TrimSpace(o); o != "" {
+			origins = append(origins, o)
+		}
+	}
+
+	return origins
+}
+
+// Pagination configures the size of the pages returned by the list endpoints
+type Pagination struct {
+	DefaultSize int `yaml:"default_size" toml:"default_size"`
+	MaxSize     int `yaml:"max_size" toml:"max_size"`
+}
+
+// Log configures the application logger, which writes JSON lines
+type Log struct {
+	// Level is the minimum level of the logged lines: debug, info, warn or error, it can be changed at runtime
+	Level string `yaml:"level" toml:"level"`
+	// Output is stdout, stderr or the path of a file the logs are appended to
+	Output string `yaml:"output" toml:"output"`
+}
+
+// Metrics configures the Prometheus metrics exposed on /metrics
+type Metrics struct {
+	Enabled bool `yaml:"enabled" toml:"enabled"`
+}
+
+// Tracing configures the OpenTelemetry traces of the requests, exported over OTLP/HTTP
+type Tracing struct {
+	Enabled bool `yaml:"enabled" toml:"enabled"`
+	// Endpoint is the host and port of the OTLP/HTTP collector, traces are sent to its /v1/traces path
+	Endpoint string `yaml:"endpoint" toml:"endpoint"`
+	// Insecure sends the traces over plain http instead of https
+	Insecure    bool    `yaml:"insecure" toml:"insecure"`
+	ServiceName string  `yaml:"service_name" toml:"service_name"`
+	SampleRatio float64 `yaml:"sample_ratio" toml:"sample_ratio"`
+}
+
+// RateLimit configures the limits of the requests of every client, by role
+// A client with several roles gets the most generous limit of its roles.
+type RateLimit struct {
+	Enabled   bool      `yaml:"enabled" toml:"enabled"`
+	Viewer    RoleLimit `yaml:"viewer" toml:"viewer"`
+	Editor    RoleLimit `yaml:"editor" toml:"editor"`
+	Admin     RoleLimit `yaml:"admin" toml:"admin"`
+	Candidate RoleLimit `yaml:"candidate" toml:"candidate"`
+	// Address limits the requests of every IP address before they're authenticated, the rejected credentials included
+	Address RoleLimit `yaml:"address" toml:"address"`
+}
+
+// RoleLimit is the number of read and write requests per minute of a client with the role, 0 disables the limit
+// All the requests of a minute can be made at once, the limit is then refilled evenly over the minute.
+type RoleLimit struct {
+	Reads  int `yaml:"reads" toml:"reads"`
+	Writes int `yaml:"writes" toml:"writes"`
+}
+
+// Duplicates configures the duplicate detection performed when questions are created
+type Duplicates struct {
+	Mode      string  `yaml:"mode" toml:"mode"`
+	Threshold float64 `yaml:"threshold" toml:"threshold"`
+}
+
+// Auth configures the authentication of the requests
+type Auth struct {
+	Disabled bool `yaml:"disabled" toml:"disabled"`
+	JWT      JWT  `yaml:"jwt" toml:"jwt"`
+}
+
+// JWT configures the validation of the bearer tokens, tokens are only accepted when a HMAC secret or a JWKS file is set
+type JWT struct {
+	HMACSecret string `yaml:"hmac_secret" toml:"hmac_secret"`
+	JWKSFile   string `yaml:"jwks_file" toml:"jwks_file"`
+	Issuer     string `yaml:"issuer" toml:"issuer"`
+	Audience   string `yaml:"audience" toml:"audience"`
+}
+
+// Default returns the default configuration
+func Default() Config {
+	return Config{
+		Database: Database{
+			Driver: "sqlite3",
+			DSN:    "./database/questions.db",
+		},
+		Server: Server{
+			Address:         ":3000",
+			ReadTimeout:     2 * time.Second,
+			WriteTimeout:    1 * time.Second,
+			RequestTimeout:  900 * time.Millisecond,
+			BulkTimeout:     5 * time.Minute,
+			IdleTimeout:     120 * time.Second,
+			DrainDelay:      5 * time.Second,
+			ShutdownTimeout: 30 * time.Second,
+			TLS: TLS{
+				ClientAuth: "none",
+				MinVersion: "1.2",
+			},
+		},
+		HTTP: HTTP{
+			MaxBodyBytes: 1 << 20,
+			MaxBulkBytes: 32 << 20,
+			CORS: CORS{
+				MaxAge: 10 * time.Minute,
+			},
+		},
+		Pagination: Pagination{
+			DefaultSize: 10,
+			MaxSize:     1000,
+		},
+		Log: Log{
+			Level:  "info",
+			Output: "stdout",
+		},
+		Metrics: Metrics{
+			Enabled: true,
+		},
+		Tracing: Tracing{
+			Endpoint:    "localhost:4318",
+			ServiceName: "questions-rest-api",
+			SampleRatio: 1,
+		},
+		RateLimit: RateLimit{
+			Enabled:   true,
+			Viewer:    RoleLimit{Reads: 600, Writes: 60},
+			Editor:    RoleLimit{Reads: 600, Writes: 120},
+			Admin:     RoleLimit{Reads: 1200, Writes: 300},
+			Candidate: RoleLimit{Reads: 120, Writes: 30},
+			Address:   RoleLimit{Reads: 2400, Writes: 600},
+		},
+		Duplicates: Duplicates{
+			Mode:      string(service.DefaultDuplicatePolicy.Mode),
+			Threshold: service.DefaultDuplicatePolicy.Threshold,
+		},
+	}
+}
+
+// setting is a single configuration value that can be set by an environment variable and a flag
+// The variable and flag names are derived from the key: server.read_timeout is set by SERVER_READ_TIMEOUT and -server-read-timeout.
+type setting struct {
+	key   string
+	usage string
+	value interface{}
+}
+
+// settings returns the settings of c, pointing to its fields
+func (c *Config) settings() []setting {
+	return []setting{
+		{key: "database.driver", usage: "database driver, only sqlite3 is supported", value: &c.Database.Driver},
+		{key: "database.dsn", usage: "database data source name, the database file path for sqlite3", value: &c.Database.DSN},
+		{key: "server.address", usage: "http server listen address", value: &c.Server.Address},
+		{key: "server.read_timeout", usage: "maximum duration for reading a request", value: &c.Server.ReadTimeout},
+		{key: "server.write_timeout", usage: "maximum duration for writing a response", value: &c.Server.WriteTimeout},
+		{key: "server.request_timeout", usage: "maximum duration for handling a request, shorter than the write timeout", value: &c.Server.RequestTimeout},
+		{key: "server.bulk_timeout", usage: "maximum duration for handling an import, export, batch or duplicates request", value: &c.Server.BulkTimeout},
+		{key: "server.idle_timeout", usage: "maximum duration a keep-alive connection is kept idle", value: &c.Server.IdleTimeout},
+		{key: "server.drain_delay", usage: "time the server is reported unready before it's shut down", value: &c.Server.DrainDelay},
+		{key: "server.shutdown_timeout", usage: "maximum duration to wait for the open connections on shutdown", value: &c.Server.ShutdownTimeout},
+		{key: "server.tls.cert_file", usage: "certificate file of the https server, https is disabled when empty", value: &c.Server.TLS.CertFile},
+		{key: "server.tls.key_file", usage: "private key file of the https server", value: &c.Server.TLS.KeyFile},
+		{key: "server.tls.client_ca_file", usage: "CA bundle file the client certificates are verified with", value: &c.Server.TLS.ClientCAFile},
+		{key: "server.tls.client_auth", usage: "client certificates: none, optional or require", value: &c.Server.TLS.ClientAuth},
+		{key: "server.tls.min_version", usage: "minimum TLS version: 1.2 or 1.3", value: &c.Server.TLS.MinVersion},
+		{key: "server.tls.cipher_suites", usage: "comma separated TLS 1.2 cipher suites, the Go defaults when empty", value: &c.Server.TLS.CipherSuites},
+		{key: "http.max_body_bytes", usage: "largest request body accepted, in bytes", value: &c.HTTP.MaxBodyBytes},
+		{key: "http.max_bulk_bytes", usage: "largest import or batch request body accepted, in bytes", value: &c.HTTP.MaxBulkBytes},
+		{key: "http.cors.allowed_origins", usage: "comma separated origins allowed to call the api from a browser, * for any", value: &c.HTTP.CORS.AllowedOrigins},
+		{key: "http.cors.allow_credentials", usage: "allow the browsers to send credentials with the cross-origin requests", value: &c.HTTP.CORS.AllowCredentials},
+		{key: "http.cors.max_age", usage: "how long the browsers can cache a preflight response", value: &c.HTTP.CORS.MaxAge},
+		{key: "pagination.default_size", usage: "page size used when a list request has no size", value: &c.Pagination.DefaultSize},
+		{key: "pagination.max_size", usage: "maximum page size of a list request", value: &c.Pagination.MaxSize},
+		{key: "log.level", usage: "minimum log level: debug, info, warn or error", value: &c.Log.Level},
+		{key: "log.output", usage: "log output: stdout, stderr or a file path", value: &c.Log.Output},
+		{key: "metrics.enabled", usage: "expose the Prometheus metrics on /metrics", value: &c.Metrics.Enabled},
+		{key: "tracing.enabled", usage: "export the traces of the requests to an OTLP/HTTP collector", value: &c.Tracing.Enabled},
+		{key: "tracing.endpoint", usage: "host and port of the OTLP/HTTP collector", value: &c.Tracing.Endpoint},
+		{key: "tracing.insecure", usage: "export the traces over http instead of https", value: &c.Tracing.Insecure},
+		{key: "tracing.service_name", usage: "service name the traces are reported under", value: &c.Tracing.ServiceName},
+		{key: "tracing.sample_ratio", usage: "fraction of the traces that are sampled, between 0 and 1", value: &c.Tracing.SampleRatio},
+		{key: "rate_limit.enabled", usage: "limit the requests of every client", value: &c.RateLimit.Enabled},
+		{key: "rate_limit.viewer.reads", usage: "read requests per minute of a viewer, 0 for no limit", value: &c.RateLimit.Viewer.Reads},
+		{key: "rate_limit.viewer.writes", usage: "write requests per minute of a viewer, 0 for no limit", value: &c.RateLimit.Viewer.Writes},
+		{key: "rate_limit.editor.reads", usage: "read requests per minute of an editor, 0 for no limit", value: &c.RateLimit.Editor.Reads},
+		{key: "rate_limit.editor.writes", usage: "write requests per minute of an editor, 0 for no limit", value: &c.RateLimit.Editor.Writes},
+		{key: "rate_limit.admin.reads", usage: "read requests per minute of an admin, 0 for no limit", value: &c.RateLimit.Admin.Reads},
+		{key: "rate_limit.admin.writes", usage: "write requests per minute of an admin, 0 for no limit", value: &c.RateLimit.Admin.Writes},
+		{key: "rate_limit.candidate.reads", usage: "read requests per minute of a candidate, 0 for no limit", value: &c.RateLimit.Candidate.Reads},
+		{key: "rate_limit.candidate.writes", usage: "write requests per minute of a candidate, 0 for no limit", value: &c.RateLimit.Candidate.Writes},
+		{key: "rate_limit.address.reads", usage: "read requests per minute of an IP address, before authentication, 0 for no limit", value: &c.RateLimit.Address.Reads},
+		{key: "rate_limit.address.writes", usage: "write requests per minute of an IP address, before authentication, 0 for no limit", value: &c.RateLimit.Address.Writes},
+		{key: "duplicates.mode", usage: "duplicate detection mode: warn, block or off", value: &c.Duplicates.Mode},
+		{key: "duplicates.threshold", usage: "minimum similarity of two questions to be considered duplicates", value: &c.Duplicates.Threshold},
+		{key: "auth.disabled", usage: "disable authentication, for local development only", value: &c.Auth.Disabled},
+		{key: "auth.jwt.hmac_secret", usage: "secret of the HMAC signed bearer tokens", value: &c.Auth.JWT.HMACSecret},
+		{key: "auth.jwt.jwks_file", usage: "JWKS file with the keys of the bearer tokens", value: &c.Auth.JWT.JWKSFile},
+		{key: "auth.jwt.issuer", usage: "expected iss claim of the bearer tokens", value: &c.Auth.JWT.Issuer},
+		{key: "auth.jwt.audience", usage: "expected aud claim of the bearer tokens", value: &c.Auth.JWT.Audience},
+	}
+}
+
+// envName returns the environment variable setting the value of a key
+func envName(key string) string {
+	return strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
+
+// flagName returns the command-line flag setting the value of a key
+func flagName(key string) string {
+	return strings.NewReplacer(".", "-", "_", "-").Replace(key)
+}
+
+// set parses s and stores it in the setting value
+func (s setting) set(v string) error {
+	var err error
+
+	switch p := s.value.(type) {
+	case *string:
+		*p = v
+	case *int:
+		*p, err = strconv.Atoi(v)
+	case *float64:
+		*p, err = strconv.ParseFloat(v, 64)
+	case *bool:
+		*p, err = strconv.ParseBool(v)
+	case *time.Duration:
+		*p, err = time.ParseDuration(v)
+	}
+
+	if err != nil {
+		return fmt.Errorf("invalid %s value %q: %s", s.key, v, err.Error())
+	}
+
+	return nil
+}
+
+// flagValue collects the value of a flag, it's only stored in the configuration after the file and environment are applied
+type flagValue struct {
+	setting setting
+	values  map[string]string
+}
+
+func (f *flagValue) String() string {
+	return ""
+}
+
+func (f *flagValue) Set(v string) error {
+	f.values[f.setting.key] = v
+	return nil
+}
+
+func (f *flagValue) IsBoolFlag() bool {
+	_, ok := f.setting.value.(*bool)
+	return ok
+}
+
+// Load returns the configuration of the application and the arguments left after the flags
+// The defaults are overridden by the configuration file given by the -config flag or the CONFIG_FILE variable,
+// then by the environment variables read with lookupEnv, and then by the flags parsed from args.
+func Load(args []string, lookupEnv func(string) (string, bool)) (Config, []string, error) {
+	c := Default()
+	settings := c.settings()
+
+	// the flags are parsed first to find the configuration file
+	fs := flag.NewFlagSet("questions-rest-api", flag.ContinueOnError)
+	file := fs.String("config", "", "YAML or TOML configuration file, read from CONFIG_FILE if not set")
+
+	flags := map[string]string{}
+	for _, s := range settings {
+		fs.Var(&flagValue{setting: s, values: flags}, flagName(s.key), fmt.Sprintf("%s (%s)", s.usage, envName(s.key)))
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return c, nil, err
+	}
+
+	if *file == "" {
+		*file, _ = lookupEnv("CONFIG_FILE")
+	}
+
+	if *file != "" {
+		if err := c.readFile(*file); err != nil {
+			return c, nil, err
+		}
+	}
+
+	for _, s := range settings {
+		if v, ok := lookupEnv(envName(s.key)); ok && v != "" {
+			if err := s.set(v); err != nil {
+				return c, nil, fmt.Errorf("%s: %s", envName(s.key), err.Error())
+			}
+		}
+	}
+
+	// PORT is still accepted, as a shorter way of setting the listen address
+	if port, ok := lookupEnv("PORT"); ok && port != "" {
+		if _, set := lookupEnv(envName("server.address")); !set {
+			c.Server.Address = ":" + port
+		}
+	}
+
+	for _, s := range settings {
+		if v, ok := flags[s.key]; ok {
+			if err := s.set(v); err != nil {
+				return c, nil, fmt.Errorf("-%s: %s", flagName(s.key), err.Error())
+			}
+		}
+	}
+
+	if err := c.Validate(); err != nil {
+		return c, nil, err
+	}
+
+	return c, fs.Args(), nil
+}
+
+// readFile reads the YAML or TOML configuration file, the settings missing from the file keep their current values
+// Unknown keys are reported as errors, to catch misspelled settings.
+func (c *Config) readFile(p string) error {
+	content, err := ioutil.ReadFile(p)
+	if err != nil {
+		return fmt.Errorf("unable to read configuration file: %s", err.Error())
+	}
+
+	switch strings.ToLower(filepath.Ext(p)) {
+	case ".yaml", ".yml":
+		if err = yaml.UnmarshalStrict(content, c); err != nil {
+			return fmt.Errorf("unable to parse configuration file %s: %s", p, err.Error())
+		}
+	case ".toml":
+		md, err := toml.NewDecoder(bytes.NewReader(content)).Decode(c)
+		if err != nil {
+			return fmt.Errorf("unable to parse configuration file %s: %s", p, err.Error())
+		}
+
+		if undecoded := md.Undecoded(); len(undecoded) > 0 {
+			keys := make([]string, len(undecoded))
+			for i, k := range undecoded {
+				keys[i] = k.String()
+			}
+
+			return fmt.Errorf("unable to parse configuration file %s: unknown keys %s", p, strings.Join(keys, ", "))
+		}
+	default:
+		return fmt.Errorf("%s: %w", p, ConfigFormatError)
+	}
+
+	return nil
+}
+
+// Validate checks every setting and returns an error listing all the invalid ones
+func (c Config) Validate() error {
+	problems := map[string]string{}
+
+	if c.Database.Driver != "sqlite3" {
+		problems["database.driver"] = fmt.Sprintf("unsupported driver %q, only sqlite3 is supported", c.Database.Driver)
+	}
+
+	if c.Database.DSN == "" {
+		problems["database.dsn"] = "must not be empty"
+	}
+
+	if _, port, err := net.SplitHostPort(c.Server.Address); err != nil {
+		problems["server.address"] = fmt.Sprintf("invalid address %q: %s", c.Server.Address, err.Error())
+	} else if n, err := strconv.Atoi(port); err != nil || n < 0 || n > 65535 {
+		problems["server.address"] = fmt.Sprintf("invalid port %q", port)
+	}
+
+	for key, d := range map[string]time.Duration{
+		"server.read_timeout":     c.Server.ReadTimeout,
+		"server.write_timeout":    c.Server.WriteTimeout,
+		"server.request_timeout":  c.Server.RequestTimeout,
+		"server.bulk_timeout":     c.Server.BulkTimeout,
+		"server.idle_timeout":     c.Server.IdleTimeout,
+		"server.shutdown_timeout": c.Server.ShutdownTimeout,
+	} {
+		if d <= 0 {
+			problems[key] = "must be a positive duration"
+		}
+	}
+
+	if c.Server.DrainDelay < 0 {
+		problems["server.drain_delay"] = "must not be negative"
+	}
+
+	if c.Server.RequestTimeout > 0 && c.Server.RequestTimeout >= c.Server.WriteTimeout {
+		problems["server.request_timeout"] = "must be shorter than server.write_timeout, so the timeout response can be written"
+	}
+
+	if t := c.Server.TLS; t.Enabled() || t.KeyFile != "" || t.ClientCAFile != "" {
+		if t.CertFile == "" || t.KeyFile == "" {
+			problems["server.tls.cert_file"] = "must be set with server.tls.key_file"
+		}
+
+		if _, err := t.Version(); err != nil {
+			problems["server.tls.min_version"] = err.Error()
+		}
+
+		if _, err := t.Ciphers(); err != nil {
+			problems["server.tls.cipher_suites"] = err.Error()
+		}
+
+		if ca, err := t.ClientAuthType(); err != nil {
+			problems["server.tls.client_auth"] = err.Error()
+		} else if (ca == tls.NoClientCert) != (t.ClientCAFile == "") {
+			problems["server.tls.client_ca_file"] = "must be set when the client certificates are verified, and only then"
+		}
+	}
+
+	if c.HTTP.MaxBodyBytes < 1 {
+		problems["http.max_body_bytes"] = "must be at least 1"
+	}
+
+	if c.HTTP.MaxBulkBytes < c.HTTP.MaxBodyBytes {
+		problems["http.max_bulk_bytes"] = "must not be smaller than http.max_body_bytes"
+	}
+
+	for _, o := range c.HTTP.CORS.Origins() {
+		if o == "*" {
+			if c.HTTP.CORS.AllowCredentials {
+				problems["http.cors.allow_credentials"] = "must be false when any origin is allowed"
+			}
+			continue
+		}
+
+		if u, err := url.Parse(o); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || u.Path != "" || u.RawQuery != "" {
+			problems["http.cors.allowed_origins"] = fmt.Sprintf("invalid origin %q, expected a scheme and a host like https://example.com", o)
+		}
+	}
+
+	if c.HTTP.CORS.MaxAge < 0 {
+		problems["http.cors.max_age"] = "must not be negative"
+	}
+
+	if c.Pagination.DefaultSize < 1 {
+		problems["pagination.default_size"] = "must be at least 1"
+	}
+
+	if c.Pagination.MaxSize < c.Pagination.DefaultSize {
+		problems["pagination.max_size"] = "must not be smaller than pagination.default_size"
+	}
+
+	if _, err := logging.ParseLevel(c.Log.Level); err != nil {
+		problems["log.level"] = err.Error()
+	}
+
+	if c.Log.Output == "" {
+		problems["log.output"] = "must be stdout, stderr or a file path"
+	}
+
+	if c.Tracing.Enabled && c.Tracing.Endpoint == "" {
+		problems["tracing.endpoint"] = "must not be empty when tracing is enabled"
+	}
+
+	if c.Tracing.ServiceName == "" {
+		problems["tracing.service_name"] = "must not be empty"
+	}
+
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		problems["tracing.sample_ratio"] = "must be between 0 and 1"
+	}
+
+	for key, n := range map[string]int{
+		"rate_limit.viewer.reads":     c.RateLimit.Viewer.Reads,
+		"rate_limit.viewer.writes":    c.RateLimit.Viewer.Writes,
+		"rate_limit.editor.reads":     c.RateLimit.Editor.Reads,
+		"rate_limit.editor.writes":    c.RateLimit.Editor.Writes,
+		"rate_limit.admin.reads":      c.RateLimit.Admin.Reads,
+		"rate_limit.admin.writes":     c.RateLimit.Admin.Writes,
+		"rate_limit.candidate.reads":  c.RateLimit.Candidate.Reads,
+		"rate_limit.candidate.writes": c.RateLimit.Candidate.Writes,
+		"rate_limit.address.reads":    c.RateLimit.Address.Reads,
+		"rate_limit.address.writes":   c.RateLimit.Address.Writes,
+	} {
+		if n < 0 {
+			problems[key] = "must not be negative, 0 disables the limit"
+		}
+	}
+
+	if _, err := service.ParseDuplicateMode(c.Duplicates.Mode); err != nil {
+		problems["duplicates.mode"] = err.Error()
+	}
+
+	if c.Duplicates.Threshold <= 0 || c.Duplicates.Threshold > 1 {
+		problems["duplicates.threshold"] = service.DuplicateThresholdError.Error()
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+
+	keys := make([]string, 0, len(problems))
+	for k := range problems {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	lines := make([]string, len(keys))
+	for i, k := range keys {
+		lines[i] = fmt.Sprintf("%s: %s", k, problems[k])
+	}
+
+	return fmt.Errorf("invalid configuration:\n  %s", strings.Join(lines, "\n  "))
+}
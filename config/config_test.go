@@ -0,0 +1,348 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// envMock returns a lookup function reading the variables from a map
+func envMock(vars map[string]string) func(string) (string, bool) {
+	return func(k string) (string, bool) {
+		v, ok := vars[k]
+		return v, ok
+	}
+}
+
+// writeConfig writes a configuration file with the given name and content into a temporary directory
+func writeConfig(t *testing.T, name, content string) string {
+	p := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(p, []byte(content), 0600); err != nil {
+		t.Fatalf("unable to write configuration file: %s", err.Error())
+	}
+
+	return p
+}
+
+func TestLoadDefaults(t *testing.T) {
+	c, args, err := Load(nil, envMock(nil))
+	if err != nil {
+		t.Fatalf("unable to load configuration: %s", err.Error())
+	}
+
+	if c != Default() {
+		t.Errorf("expected default configuration (%v), got configuration (%v)", Default(), c)
+	}
+
+	if len(args) != 0 {
+		t.Errorf("expected no arguments, got arguments (%v)", args)
+	}
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	yamlFile := writeConfig(t, "config.yaml", `
+database:
+  dsn: /var/lib/questions/file.db
+server:
+  address: ":8000"
+  read_timeout: 5s
+pagination:
+  max_size: 200
+log:
+  level: warn
+  output: file.log
+`)
+
+	tomlFile := writeConfig(t, "config.toml", `
+[database]
+dsn = "/var/lib/questions/file.db"
+
+[server]
+address = ":8000"
+read_timeout = "5s"
+
+[pagination]
+max_size = 200
+
+[log]
+level = "warn"
+output = "file.log"
+`)
+
+	for _, file := range []string{yamlFile, tomlFile} {
+		t.Run(filepath.Ext(file), func(t *testing.T) {
+			env := envMock(map[string]string{
+				"CONFIG_FILE":               file,
+				"SERVER_ADDRESS":            ":9000",
+				"LOG_LEVEL":                 "error",
+				"AUTH_DISABLED":             "true",
+				"DUPLICATES_THRESHOLD":      "0.7",
+				"RATE_LIMIT_VIEWER_READS":   "30",
+				"HTTP_CORS_ALLOWED_ORIGINS": "https://authoring.example.com",
+			})
+
+			c, args, err := Load([]string{"-log-level", "debug", "-pagination-default-size=20", "export", "-format", "csv"}, env)
+			if err != nil {
+				t.Fatalf("unable to load configuration: %s", err.Error())
+			}
+
+			expected := Default()
+			expected.Database.DSN = "/var/lib/questions/file.db"
+			expected.Server.Address = ":9000"
+			expected.Server.ReadTimeout = 5 * time.Second
+			expected.Pagination = Pagination{DefaultSize: 20, MaxSize: 200}
+			expected.Log = Log{Level: "debug", Output: "file.log"}
+			expected.Auth.Disabled = true
+			expected.Duplicates.Threshold = 0.7
+			expected.RateLimit.Viewer.Reads = 30
+			expected.HTTP.CORS.AllowedOrigins = "https://authoring.example.com"
+
+			if c != expected {
+				t.Errorf("expected configuration (%v), got configuration (%v)", expected, c)
+			}
+
+			if strings.Join(args, " ") != "export -format csv" {
+				t.Errorf("expected the command arguments, got arguments (%v)", args)
+			}
+		})
+	}
+}
+
+func TestLoadPort(t *testing.T) {
+	testCases := []struct {
+		name     string
+		env      map[string]string
+		args     []string
+		expected string
+	}{{
+		name:     "port",
+		env:      map[string]string{"PORT": "4000"},
+		expected: ":4000",
+	}, {
+		name:     "address overrides port",
+		env:      map[string]string{"PORT": "4000", "SERVER_ADDRESS": "127.0.0.1:5000"},
+		expected: "127.0.0.1:5000",
+	}, {
+		name:     "flag overrides port",
+		env:      map[string]string{"PORT": "4000"},
+		args:     []string{"-server-address", ":6000"},
+		expected: ":6000",
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c, _, err := Load(tc.args, envMock(tc.env))
+			if err != nil {
+				t.Fatalf("unable to load configuration: %s", err.Error())
+			}
+
+			if c.Server.Address != tc.expected {
+				t.Errorf("expected address (%s), got address (%s)", tc.expected, c.Server.Address)
+			}
+		})
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	testCases := []struct {
+		name    string
+		args    []string
+		env     map[string]string
+		file    string
+		content string
+		message string
+	}{{
+		name:    "unknown flag",
+		args:    []string{"-server-port", "80"},
+		message: "flag provided but not defined",
+	}, {
+		name:    "invalid flag value",
+		args:    []string{"-server-read-timeout", "10"},
+		message: "-server-read-timeout: invalid server.read_timeout value",
+	}, {
+		name:    "invalid environment value",
+		env:     map[string]string{"PAGINATION_MAX_SIZE": "many"},
+		message: "PAGINATION_MAX_SIZE: invalid pagination.max_size value",
+	}, {
+		name:    "missing file",
+		env:     map[string]string{"CONFIG_FILE": "/nonexistent/config.yaml"},
+		message: "unable to read configuration file",
+	}, {
+		name:    "unknown file format",
+		file:    "config.json",
+		content: `{}`,
+		message: ConfigFormatError.Error(),
+	}, {
+		name:    "unknown yaml key",
+		file:    "config.yaml",
+		content: "server:\n  port: 80\n",
+		message: "field port not found",
+	}, {
+		name:    "unknown toml key",
+		file:    "config.toml",
+		content: "[server]\nport = 80\n",
+		message: "unknown keys server.port",
+	}, {
+		name:    "invalid settings",
+		args:    []string{"-database-driver", "postgres", "-server-address", "localhost", "-server-write-timeout", "0s", "-pagination-max-size", "5", "-duplicates-mode", "ignore"},
+		message: "invalid configuration:\n  database.driver: unsupported driver \"postgres\", only sqlite3 is supported\n  duplicates.mode:",
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			args := tc.args
+			if tc.file != "" {
+				args = append([]string{"-config", writeConfig(t, tc.file, tc.content)}, args...)
+			}
+
+			_, _, err := Load(args, envMock(tc.env))
+			if err == nil || !strings.Contains(err.Error(), tc.message) {
+				t.Errorf("expected error containing (%s), got error (%v)", tc.message, err)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	c := Default()
+	c.Database.DSN = ""
+	c.Server.Address = "localhost"
+	c.Server.WriteTimeout = 0
+	c.Server.RequestTimeout = 2 * time.Second
+	c.Server.DrainDelay = -time.Second
+	c.Pagination.MaxSize = 5
+	c.Duplicates.Threshold = 2
+	c.Log.Level = "verbose"
+	c.Tracing.Enabled = true
+	c.Tracing.Endpoint = ""
+	c.Tracing.SampleRatio = 1.5
+	c.RateLimit.Editor.Writes = -1
+	c.HTTP.MaxBulkBytes = 1024
+	c.HTTP.CORS.AllowedOrigins = "https://authoring.example.com, authoring.example.com"
+	c.HTTP.CORS.MaxAge = -time.Minute
+
+	err := c.Validate()
+	if err == nil {
+		t.Fatalf("expected an invalid configuration")
+	}
+
+	for _, key := range []string{"database.dsn", "server.address", "server.write_timeout", "server.request_timeout", "server.drain_delay", "pagination.max_size", "duplicates.threshold", "log.level", "tracing.endpoint", "tracing.sample_ratio", "rate_limit.editor.writes", "http.max_bulk_bytes", "http.cors.allowed_origins", "http.cors.max_age"} {
+		if !strings.Contains(err.Error(), key+":") {
+			t.Errorf("expected (%s) in the error, got error (%s)", key, err.Error())
+		}
+	}
+
+	if err = Default().Validate(); err != nil {
+		t.Errorf("expected a valid default configuration, got error (%v)", err)
+	}
+}
+
+func TestCORSOrigins(t *testing.T) {
+	testCases := []struct {
+		name        string
+		origins     string
+		credentials bool
+		expected    []string
+		isError     bool
+	}{{
+		name: "disabled",
+	}, {
+		name:     "list",
+		origins:  "https://authoring.example.com, http://localhost:8080,",
+		expected: []string{"https://authoring.example.com", "http://localhost:8080"},
+	}, {
+		name:     "any origin",
+		origins:  "*",
+		expected: []string{"*"},
+	}, {
+		name:        "any origin with credentials",
+		origins:     "*",
+		credentials: true,
+		expected:    []string{"*"},
+		isError:     true,
+	}, {
+		name:     "origin with a path",
+		origins:  "https://authoring.example.com/questions",
+		expected: []string{"https://authoring.example.com/questions"},
+		isError:  true,
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := Default()
+			c.HTTP.CORS.AllowedOrigins = tc.origins
+			c.HTTP.CORS.AllowCredentials = tc.credentials
+
+			if origins := c.HTTP.CORS.Origins(); strings.Join(origins, " ") != strings.Join(tc.expected, " ") {
+				t.Errorf("expected origins (%v), got origins (%v)", tc.expected, origins)
+			}
+
+			if err := c.Validate(); (err != nil) != tc.isError {
+				t.Errorf("expected error (%v), got error (%v)", tc.isError, err)
+			}
+		})
+	}
+}
+
+func TestTLS(t *testing.T) {
+	testCases := []struct {
+		name    string
+		tls     TLS
+		ciphers int
+		isError bool
+	}{{
+		name: "disabled",
+		tls:  Default().Server.TLS,
+	}, {
+		name: "certificate",
+		tls:  TLS{CertFile: "cert.pem", KeyFile: "key.pem", ClientAuth: "none", MinVersion: "1.3"},
+	}, {
+		name:    "missing key",
+		tls:     TLS{CertFile: "cert.pem", ClientAuth: "none", MinVersion: "1.2"},
+		isError: true,
+	}, {
+		name:    "unsupported version",
+		tls:     TLS{CertFile: "cert.pem", KeyFile: "key.pem", ClientAuth: "none", MinVersion: "1.1"},
+		isError: true,
+	}, {
+		name:    "cipher suites",
+		tls:     TLS{CertFile: "cert.pem", KeyFile: "key.pem", ClientAuth: "none", MinVersion: "1.2", CipherSuites: "TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256, TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"},
+		ciphers: 2,
+	}, {
+		name:    "insecure cipher suite",
+		tls:     TLS{CertFile: "cert.pem", KeyFile: "key.pem", ClientAuth: "none", MinVersion: "1.2", CipherSuites: "TLS_RSA_WITH_RC4_128_SHA"},
+		isError: true,
+	}, {
+		name: "mutual tls",
+		tls:  TLS{CertFile: "cert.pem", KeyFile: "key.pem", ClientCAFile: "ca.pem", ClientAuth: "require", MinVersion: "1.2"},
+	}, {
+		name:    "mutual tls without client CA bundle",
+		tls:     TLS{CertFile: "cert.pem", KeyFile: "key.pem", ClientAuth: "optional", MinVersion: "1.2"},
+		isError: true,
+	}, {
+		name:    "client CA bundle without mutual tls",
+		tls:     TLS{CertFile: "cert.pem", KeyFile: "key.pem", ClientCAFile: "ca.pem", ClientAuth: "none", MinVersion: "1.2"},
+		isError: true,
+	}, {
+		name:    "client CA bundle without certificate",
+		tls:     TLS{ClientCAFile: "ca.pem", ClientAuth: "require", MinVersion: "1.2"},
+		isError: true,
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := Default()
+			c.Server.TLS = tc.tls
+
+			if err := c.Validate(); (err != nil) != tc.isError {
+				t.Errorf("expected error (%v), got error (%v)", tc.isError, err)
+			}
+
+			if ids, err := tc.tls.Ciphers(); err == nil && len(ids) != tc.ciphers {
+				t.Errorf("expected (%d) cipher suites, got cipher suites (%v)", tc.ciphers, ids)
+			}
+		})
+	}
+}
@@ -0,0 +1,14 @@
+create table api_keys
+(
+    id        integer
+        constraint api_keys_pk
+            primary key autoincrement,
+    name      text    default '',
+    subject   text    default '',
+    hash      text    not null,
+    createdAt integer default 0,
+    revoked   integer default 0
+);
+
+create unique index api_keys_hash_uindex
+    on api_keys (hash);
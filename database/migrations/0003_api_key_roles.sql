@@ -0,0 +1,2 @@
+alter table api_keys
+    add roles text default 'viewer';
@@ -0,0 +1,8 @@
+alter table questions
+    add tenantId text not null default 'default';
+
+create index questions_tenantId_index
+    on questions (tenantId, id);
+
+alter table api_keys
+    add tenantId text not null default 'default';
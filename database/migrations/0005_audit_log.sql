@@ -0,0 +1,34 @@
+create table audit_log
+(
+    id         integer
+        constraint audit_log_pk
+            primary key autoincrement,
+    tenantId   text    not null,
+    time       integer not null,
+    actor      text    default '',
+    requestId  text    default '',
+    action     text    not null,
+    questionId integer default 0,
+    diff       text    default '[]'
+);
+
+create index audit_log_tenantId_questionId_index
+    on audit_log (tenantId, questionId);
+
+create index audit_log_tenantId_time_index
+    on audit_log (tenantId, time);
+
+-- the audit log is append-only
+create trigger audit_log_no_update
+    before update
+    on audit_log
+begin
+    select raise(abort, 'the audit log is append-only');
+end;
+
+create trigger audit_log_no_delete
+    before delete
+    on audit_log
+begin
+    select raise(abort, 'the audit log is append-only');
+end;
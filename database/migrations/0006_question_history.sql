@@ -0,0 +1,46 @@
+alter table questions
+    add createdAt integer not null default 0;
+
+alter table questions
+    add createdBy text not null default '';
+
+alter table questions
+    add updatedAt integer not null default 0;
+
+alter table questions
+    add updatedBy text not null default '';
+
+-- the questions changed while the audit log was enabled take their history from it
+update questions
+set (createdAt, createdBy) = (select a.time, a.actor
+                              from audit_log a
+                              where a.tenantId = questions.tenantId
+                                and a.questionId = questions.id
+                                and a.action in ('create', 'import')
+                              order by a.id
+                              limit 1)
+where exists(select 1
+             from audit_log a
+             where a.tenantId = questions.tenantId
+               and a.questionId = questions.id
+               and a.action in ('create', 'import'));
+
+update questions
+set (updatedAt, updatedBy) = (select a.time, a.actor
+                              from audit_log a
+                              where a.tenantId = questions.tenantId
+                                and a.questionId = questions.id
+                                and a.action in ('create', 'import', 'update')
+                              order by a.id desc
+                              limit 1)
+where exists(select 1
+             from audit_log a
+             where a.tenantId = questions.tenantId
+               and a.questionId = questions.id
+               and a.action in ('create', 'import', 'update'));
+
+create index questions_tenantId_createdAt_index
+    on questions (tenantId, createdAt, id);
+
+create index questions_tenantId_updatedAt_index
+    on questions (tenantId, updatedAt, id);
@@ -0,0 +1,12 @@
+alter table questions
+    add status text not null default 'draft';
+
+alter table questions
+    add reviewComment text not null default '';
+
+-- the questions created before the review workflow were already served to candidates
+update questions
+set status = 'published';
+
+create index questions_tenantId_status_index
+    on questions (tenantId, status, id);
@@ -0,0 +1,18 @@
+create table tags
+(
+    questionId integer not null,
+    tag        text    not null,
+    constraint tags_pk
+        primary key (questionId, tag)
+);
+
+create index tags_tag_index
+    on tags (tag);
+
+-- the tags are deleted with their question
+create trigger questions_delete_tags
+    after delete
+    on questions
+begin
+    delete from tags where questionId = old.id;
+end;
@@ -0,0 +1,3 @@
+-- the questions created before the difficulty was recorded aren't rated
+alter table questions
+    add difficulty integer not null default 0;
@@ -0,0 +1,20 @@
+package entities
+
+// APIKey is a locally issued api key
+// Only the hash of the key is stored, the key itself is shown once when it's issued.
+type APIKey struct {
+	Id int64 `json:"id"`
+	// name describing what the key is used for
+	Name string `json:"name"`
+	// subject of the principal authenticated by the key
+	Subject string `json:"subject"`
+	// roles granted to the principal authenticated by the key
+	Roles []Role `json:"roles"`
+	// tenant of the principal authenticated by the key
+	Tenant string `json:"tenant"`
+	// hex encoded SHA-256 hash of the key
+	Hash string `json:"-"`
+	// unix time of the key creation
+	CreatedAt int64 `json:"created_at"`
+	Revoked   bool  `json:"revoked"`
+}
@@ -0,0 +1,47 @@
+package entities
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// AuditAction is the kind of change recorded by an audit entry
+type AuditAction string
+
+const (
+	AuditCreate AuditAction = "create"
+	AuditUpdate AuditAction = "update"
+	AuditDelete AuditAction = "delete"
+	AuditImport AuditAction = "import"
+)
+
+// AuditEntry records a change made to a question
+// swagger: model
+type AuditEntry struct {
+	Id int64 `json:"id"`
+	// the tenant owning the changed question
+	Tenant string `json:"-"`
+	// time of the change
+	Time time.Time `json:"time"`
+	// subject of the principal that made the change
+	Actor string `json:"actor"`
+	// id of the request that made the change
+	RequestId string      `json:"request_id"`
+	Action    AuditAction `json:"action"`
+	// id of the changed question
+	QuestionId int64 `json:"question_id"`
+	// list of changes between the question states before and after the change
+	Diff json.RawMessage `json:"diff"`
+}
+
+// AuditFilter selects the audit entries to return, zero valued fields aren't used
+type AuditFilter struct {
+	QuestionId int64
+	Actor      string
+	// entries recorded at or after this time
+	Since time.Time
+	// entries with an id greater than this one, used to page through the log
+	AfterId int64
+	// maximum number of entries
+	Limit int
+}
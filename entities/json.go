@@ -0,0 +1,36 @@
+package entities
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+var (
+	JSONTrailingDataError = fmt.Errorf("unexpected data after the JSON value")
+)
+
+// DecodeJSON deserializes a single JSON value from r into v
+// The fields that v doesn't have are rejected, like any data after the value.
+func DecodeJSON(r io.Reader, v interface{}) error {
+	d := json.NewDecoder(r)
+	d.DisallowUnknownFields()
+
+	if err := d.Decode(v); err != nil {
+		return err
+	}
+
+	_, err := d.Token()
+	if err == io.EOF {
+		return nil
+	}
+
+	var se *json.SyntaxError
+	if err == nil || errors.As(err, &se) {
+		return JSONTrailingDataError
+	}
+
+	// the stream failed after the value, when the body is too large for instance
+	return err
+}
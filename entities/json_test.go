@@ -0,0 +1,86 @@
+package entities
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+// failingReader returns the data of r, then err instead of io.EOF
+type failingReader struct {
+	r   io.Reader
+	err error
+}
+
+func (f failingReader) Read(p []byte) (int, error) {
+	n, err := f.r.Read(p)
+	if err == io.EOF {
+		return n, f.err
+	}
+
+	return n, err
+}
+
+func TestDecodeJSON(t *testing.T) {
+	readErr := errors.New("http: request body too large")
+
+	testCases := []struct {
+		name     string
+		input    io.Reader
+		isError  bool
+		expected error
+	}{{
+		name:  "valid object",
+		input: strings.NewReader(`{"body":"Where does the sun set?","options":[{"body":"East"},{"body":"West","correct":true}]}`),
+	}, {
+		name:  "trailing whitespace",
+		input: strings.NewReader("{\"body\":\"Where does the sun set?\"}\n\t "),
+	}, {
+		name:    "unknown field",
+		input:   strings.NewReader(`{"body":"Where does the sun set?","type":"single_choice"}`),
+		isError: true,
+	}, {
+		name:    "unknown option field",
+		input:   strings.NewReader(`{"body":"Where does the sun set?","options":[{"body":"East","order":1}]}`),
+		isError: true,
+	}, {
+		name:     "second object",
+		input:    strings.NewReader(`{"body":"Where does the sun set?"}{"body":"Where does the sun rise?"}`),
+		isError:  true,
+		expected: JSONTrailingDataError,
+	}, {
+		name:     "trailing garbage",
+		input:    strings.NewReader(`{"body":"Where does the sun set?"}}`),
+		isError:  true,
+		expected: JSONTrailingDataError,
+	}, {
+		name:    "invalid json",
+		input:   strings.NewReader(`"body":"Where does the sun set?"}`),
+		isError: true,
+	}, {
+		name:    "empty body",
+		input:   strings.NewReader(``),
+		isError: true,
+	}, {
+		name:     "read error after the object",
+		input:    failingReader{r: strings.NewReader(`{"body":"Where does the sun set?"}`), err: readErr},
+		isError:  true,
+		expected: readErr,
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var q Question
+			err := q.FromJSON(tc.input)
+
+			if (err != nil) != tc.isError {
+				t.Fatalf("expected error (%v), got error (%v)", tc.isError, err)
+			}
+
+			if tc.expected != nil && !errors.Is(err, tc.expected) {
+				t.Errorf("expected error (%v), got error (%v)", tc.expected, err)
+			}
+		})
+	}
+}
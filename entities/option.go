@@ -1,14 +1,19 @@
 package entities
 
-import "github.com/go-playground/validator"
+import (
+	"encoding/json"
+	"github.com/go-playground/validator"
+	"io"
+)
 
 // Option defines the structure for the option object
 // swagger: model
 type Option struct {
-	// the id for this option
+	// the id for this option, assigned when the option is stored
+	// Updates keep the options with an id, and add the ones without.
 	//
-	// required: true
-	Id int64 `json:"-"`
+	// min: 1
+	Id int64 `json:"id,omitempty" validate:"gte=0"`
 	// question foreign key
 	//
 	// required: true
@@ -35,3 +40,14 @@ func (o *Option) Validate() error {
 
 	return validate.Struct(o)
 }
+
+// ToJSON serializes the option into JSON
+func (o *Option) ToJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	return e.Encode(o)
+}
+
+// FromJSON deserializes the JSON into the option, rejecting the unknown fields and any data after the object
+func (o *Option) FromJSON(r io.Reader) error {
+	return DecodeJSON(r, o)
+}
@@ -0,0 +1,67 @@
+package entities
+
+import "fmt"
+
+// AuthMethod identifies how a principal was authenticated
+type AuthMethod string
+
+const (
+	AuthAPIKey AuthMethod = "api_key"
+	AuthJWT    AuthMethod = "jwt"
+	// AuthLocal identifies the principals of the command line and of servers running without authentication
+	AuthLocal AuthMethod = "local"
+)
+
+// DefaultTenant is the tenant of single tenant deployments and of the questions created before multi-tenancy
+const DefaultTenant = "default"
+
+// Role grants a principal access to a set of operations
+type Role string
+
+const (
+	// RoleViewer can read and export the questions
+	RoleViewer Role = "viewer"
+	// RoleEditor can also create and edit the questions
+	RoleEditor Role = "editor"
+	// RoleAdmin can also delete and import the questions
+	RoleAdmin Role = "admin"
+	// RoleCandidate takes the tests and can only read the published questions
+	RoleCandidate Role = "candidate"
+)
+
+var (
+	RoleError = fmt.Errorf("invalid role, valid roles are viewer, editor, admin and candidate")
+)
+
+// ParseRole returns the role with the given name
+func ParseRole(r string) (Role, error) {
+	switch Role(r) {
+	case RoleViewer, RoleEditor, RoleAdmin, RoleCandidate:
+		return Role(r), nil
+	}
+
+	return "", RoleError
+}
+
+// Principal is the authenticated identity that made a request
+type Principal struct {
+	// identifier of the user or client, the api key subject or the token sub claim
+	Subject string `json:"subject"`
+	// method used to authenticate the principal
+	Method AuthMethod `json:"method"`
+	// roles granted to the principal
+	Roles []Role `json:"roles"`
+	// organization owning the question bank the principal works on
+	Tenant string `json:"tenant"`
+}
+
+// HasRole reports whether the principal was granted the given role
+func (p *Principal) HasRole(r Role) bool {
+	for _, pr := range p.Roles {
+		if pr == r {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,56 @@
+package entities
+
+import "testing"
+
+func TestParseRole(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    string
+		expected Role
+		isError  bool
+	}{{
+		name:     "viewer",
+		input:    "viewer",
+		expected: RoleViewer,
+		isError:  false,
+	}, {
+		name:     "candidate",
+		input:    "candidate",
+		expected: RoleCandidate,
+		isError:  false,
+	}, {
+		name:    "unknown role",
+		input:   "owner",
+		isError: true,
+	}, {
+		name:    "wrong case",
+		input:   "Admin",
+		isError: true,
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r, err := ParseRole(tc.input)
+
+			if (err != nil) != tc.isError {
+				t.Fatalf("expected error (%v), got error (%v)", tc.isError, err)
+			}
+
+			if r != tc.expected {
+				t.Errorf("expected role (%s), got role (%s)", tc.expected, r)
+			}
+		})
+	}
+}
+
+func TestHasRole(t *testing.T) {
+	p := Principal{Subject: "alice", Roles: []Role{RoleViewer, RoleEditor}}
+
+	if !p.HasRole(RoleEditor) {
+		t.Errorf("expected principal to have role (%s)", RoleEditor)
+	}
+
+	if p.HasRole(RoleAdmin) {
+		t.Errorf("expected principal not to have role (%s)", RoleAdmin)
+	}
+}
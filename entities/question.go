@@ -5,16 +5,17 @@ import (
 	"fmt"
 	"github.com/go-playground/validator"
 	"io"
+	"time"
 )
 
 // Question defines the structure for the question object
 // swagger: model
 type Question struct {
-	// the id for this question
+	// the id for this question, assigned by the repository
 	//
-	// required: true
+	// read only: true
 	// min: 1
-	Id int64 `json:"-"`
+	Id int64 `json:"id,omitempty"`
 	// the actual question content
 	//
 	// required: true
@@ -25,11 +26,57 @@ type Question struct {
 	// required: true
 	// min: 2
 	Options []Option `json:"options" validate:"required"`
+	// labels used to organize the questions, without commas
+	//
+	// max items: 20
+	Tags []string `json:"tags,omitempty"`
+	// the difficulty of the question, from 1 for the easiest to 5 for the hardest, 0 when it isn't rated
+	//
+	// minimum: 0
+	// maximum: 5
+	Difficulty int `json:"difficulty,omitempty" validate:"min=0,max=5"`
+	// the tenant owning this question, set by the repository from the request tenant
+	TenantId string `json:"-"`
+	// the time the question was created, set by the repository
+	//
+	// read only: true
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+	// the subject of the principal who created the question, set by the repository
+	//
+	// read only: true
+	CreatedBy string `json:"created_by,omitempty"`
+	// the time the question or its options were last changed, set by the repository
+	//
+	// read only: true
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+	// the subject of the principal who last changed the question, set by the repository
+	//
+	// read only: true
+	UpdatedBy string `json:"updated_by,omitempty"`
+	// the stage of the question in the review workflow, changed by the workflow endpoints, edits move it back to draft
+	//
+	// read only: true
+	Status QuestionStatus `json:"status,omitempty"`
+	// the comment of the reviewer who last approved or rejected the question
+	//
+	// read only: true
+	ReviewComment string `json:"review_comment,omitempty"`
 }
 
+// QuestionType is the kind of question, derived from its options
+type QuestionType string
+
+const (
+	// SingleChoice questions have exactly one correct option
+	SingleChoice QuestionType = "single_choice"
+	// MultipleChoice questions have more than one correct option
+	MultipleChoice QuestionType = "multiple_choice"
+)
+
 var (
 	QuestionOptionsLengthError  = fmt.Errorf("question should have at least 2 options")
 	QuestionOptionsCorrectError = fmt.Errorf("there isn't a correct option in the list")
+	QuestionOptionsIdError      = fmt.Errorf("option ids should be unique")
 )
 
 // Validate checks and validates each field of the question object based on its definition
@@ -53,17 +100,75 @@ func (q *Question) Validate() error {
 		return QuestionOptionsCorrectError
 	}
 
+	// an option can't be kept twice by an update
+	ids := map[int64]bool{}
+	for _, v := range q.Options {
+		if v.Id != 0 && ids[v.Id] {
+			return fmt.Errorf("%w: %d", QuestionOptionsIdError, v.Id)
+		}
+		ids[v.Id] = true
+	}
+
+	if len(NormalizeTags(q.Tags)) > MaxTags {
+		return TagCountError
+	}
+
+	if err := ValidateTags(q.Tags); err != nil {
+		return err
+	}
+
 	return validate.Struct(q)
 }
 
+// Type returns MultipleChoice if more than one option is correct and SingleChoice otherwise
+func (q *Question) Type() QuestionType {
+	if q.CorrectCount() > 1 {
+		return MultipleChoice
+	}
+
+	return SingleChoice
+}
+
+// CorrectCount returns the number of correct options
+func (q *Question) CorrectCount() int {
+	n := 0
+	for _, o := range q.Options {
+		if o.Correct {
+			n++
+		}
+	}
+
+	return n
+}
+
+// WithoutOptionIds returns a copy of the question whose options have no id, like the options of a new question
+func (q *Question) WithoutOptionIds() Question {
+	c := *q
+	c.Options = make([]Option, len(q.Options))
+	for i, o := range q.Options {
+		o.Id = 0
+		c.Options[i] = o
+	}
+
+	return c
+}
+
+// WithoutMetadata returns a copy of the question without the creation and update times and authors
+// These fields are set by the repository, the values sent by clients are ignored.
+func (q *Question) WithoutMetadata() Question {
+	c := *q
+	c.CreatedAt, c.CreatedBy, c.UpdatedAt, c.UpdatedBy = nil, "", nil, ""
+
+	return c
+}
+
 // ToJSON serializes the contents of the object to JSON
 func (q *Question) ToJSON(w io.Writer) error {
 	e := json.NewEncoder(w)
 	return e.Encode(q)
 }
 
-// FromJSON deserializes the JSON into the object
+// FromJSON deserializes the JSON into the object, rejecting the unknown fields and any data after the object
 func (q *Question) FromJSON(r io.Reader) error {
-	e := json.NewDecoder(r)
-	return e.Decode(q)
+	return DecodeJSON(r, q)
 }
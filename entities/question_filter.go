@@ -0,0 +1,139 @@
+package entities
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// QuestionSortKey is the field the questions of a list are ordered by
+// Questions with the same value are ordered by id, in the same direction.
+type QuestionSortKey string
+
+const (
+	SortById         QuestionSortKey = "id"
+	SortByBody       QuestionSortKey = "body"
+	SortByCreatedAt  QuestionSortKey = "created_at"
+	SortByUpdatedAt  QuestionSortKey = "updated_at"
+	SortByDifficulty QuestionSortKey = "difficulty"
+)
+
+// SortDirection is the direction of the questions order
+type SortDirection string
+
+const (
+	Ascending  SortDirection = "asc"
+	Descending SortDirection = "desc"
+)
+
+var (
+	QuestionSortError   = fmt.Errorf("sort should be a sort key, optionally followed by :asc or :desc")
+	QuestionFilterError = fmt.Errorf("invalid question filter")
+)
+
+// QuestionSortKeys lists the keys the questions can be sorted by
+var QuestionSortKeys = []QuestionSortKey{SortById, SortByBody, SortByCreatedAt, SortByUpdatedAt, SortByDifficulty}
+
+// QuestionCursor is the position of a question in a sorted list, used to seek the next page
+type QuestionCursor struct {
+	// the value of the sort key of the question, the id isn't repeated when sorting by id
+	Value string `json:"value,omitempty"`
+	Id    int64  `json:"id"`
+}
+
+// QuestionFilter selects and orders the questions to return, zero valued fields aren't used
+type QuestionFilter struct {
+	Sort      QuestionSortKey
+	Direction SortDirection
+	// questions with at least and at most this number of options
+	MinOptions int
+	MaxOptions int
+	Type       QuestionType
+	Status     QuestionStatus
+	// questions created or last updated at or after the After time and before the Before time
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+	UpdatedAfter  time.Time
+	UpdatedBefore time.Time
+	// questions created, or last updated, by this subject
+	Author    string
+	UpdatedBy string
+	// questions following this one in the sort order, used to page through the list
+	After *QuestionCursor
+	// maximum number of questions
+	Limit int
+}
+
+// ParseQuestionSort parses a sort key optionally followed by a direction, like "body:desc"
+// The direction defaults to ascending.
+func ParseQuestionSort(s string) (QuestionSortKey, SortDirection, error) {
+	key, dir, found := strings.Cut(s, ":")
+	if !found {
+		dir = string(Ascending)
+	}
+
+	switch SortDirection(dir) {
+	case Ascending, Descending:
+	default:
+		return "", "", fmt.Errorf("%w: %s", QuestionSortError, s)
+	}
+
+	for _, k := range QuestionSortKeys {
+		if QuestionSortKey(key) == k {
+			return k, SortDirection(dir), nil
+		}
+	}
+
+	return "", "", fmt.Errorf("%w: %s isn't one of %v", QuestionSortError, key, QuestionSortKeys)
+}
+
+// Validate checks that the filter fields are consistent with each other
+func (f *QuestionFilter) Validate() error {
+	if f.MinOptions < 0 || f.MaxOptions < 0 || (f.MaxOptions != 0 && f.MaxOptions < f.MinOptions) {
+		return fmt.Errorf("%w: option counts should be positive and min_options at most max_options", QuestionFilterError)
+	}
+
+	if (!f.CreatedBefore.IsZero() && f.CreatedBefore.Before(f.CreatedAfter)) || (!f.UpdatedBefore.IsZero() && f.UpdatedBefore.Before(f.UpdatedAfter)) {
+		return fmt.Errorf("%w: the end of a time range should follow its start", QuestionFilterError)
+	}
+
+	switch f.Type {
+	case "", SingleChoice, MultipleChoice:
+	default:
+		return fmt.Errorf("%w: type should be either %s or %s", QuestionFilterError, SingleChoice, MultipleChoice)
+	}
+
+	if f.Status != "" && f.Status.Validate() != nil {
+		return fmt.Errorf("%w: status should be one of %v", QuestionFilterError, QuestionStatuses)
+	}
+
+	return nil
+}
+
+// CursorOf returns the cursor of q in a list sorted by the filter sort key
+func (f *QuestionFilter) CursorOf(q Question) QuestionCursor {
+	c := QuestionCursor{Id: q.Id}
+
+	switch f.Sort {
+	case SortByBody:
+		c.Value = q.Body
+	case SortByCreatedAt:
+		c.Value = unixNano(q.CreatedAt)
+	case SortByUpdatedAt:
+		c.Value = unixNano(q.UpdatedAt)
+	case SortByDifficulty:
+		c.Value = strconv.Itoa(q.Difficulty)
+	}
+
+	return c
+}
+
+// unixNano returns the unix nanoseconds of t as a cursor value, the missing times are 0
+func unixNano(t *time.Time) string {
+	if t == nil {
+		return "0"
+	}
+
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
@@ -0,0 +1,108 @@
+package entities
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseQuestionSort(t *testing.T) {
+	testCases := []struct {
+		input     string
+		key       QuestionSortKey
+		direction SortDirection
+		isError   bool
+	}{
+		{input: "body", key: SortByBody, direction: Ascending},
+		{input: "body:desc", key: SortByBody, direction: Descending},
+		{input: "id:asc", key: SortById, direction: Ascending},
+		{input: "created_at:desc", key: SortByCreatedAt, direction: Descending},
+		{input: "updated_at", key: SortByUpdatedAt, direction: Ascending},
+		{input: "difficulty:desc", key: SortByDifficulty, direction: Descending},
+		{input: "body:down", isError: true},
+		{input: "body:", isError: true},
+		{input: "rating", isError: true},
+		{input: "", isError: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			key, dir, err := ParseQuestionSort(tc.input)
+			if (err != nil) != tc.isError {
+				t.Fatalf("expected error (%v), got error (%v)", tc.isError, err)
+			}
+
+			if tc.isError && !errors.Is(err, QuestionSortError) {
+				t.Errorf("expected error (%v), got error (%v)", QuestionSortError, err)
+			}
+
+			if key != tc.key || dir != tc.direction {
+				t.Errorf("expected sort (%s, %s), got sort (%s, %s)", tc.key, tc.direction, key, dir)
+			}
+		})
+	}
+}
+
+func TestValidateQuestionFilter(t *testing.T) {
+	day := time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		name    string
+		input   QuestionFilter
+		isError bool
+	}{
+		{name: "empty filter", input: QuestionFilter{}},
+		{name: "option range", input: QuestionFilter{MinOptions: 2, MaxOptions: 4}},
+		{name: "minimum only", input: QuestionFilter{MinOptions: 3}},
+		{name: "inverted range", input: QuestionFilter{MinOptions: 4, MaxOptions: 3}, isError: true},
+		{name: "negative count", input: QuestionFilter{MaxOptions: -1}, isError: true},
+		{name: "multiple choice", input: QuestionFilter{Type: MultipleChoice}},
+		{name: "unknown type", input: QuestionFilter{Type: "essay"}, isError: true},
+		{name: "published", input: QuestionFilter{Status: Published}},
+		{name: "unknown status", input: QuestionFilter{Status: "archived"}, isError: true},
+		{name: "created range", input: QuestionFilter{CreatedAfter: day, CreatedBefore: day.Add(time.Hour)}},
+		{name: "created before only", input: QuestionFilter{CreatedBefore: day}},
+		{name: "inverted created range", input: QuestionFilter{CreatedAfter: day, CreatedBefore: day.Add(-time.Hour)}, isError: true},
+		{name: "inverted updated range", input: QuestionFilter{UpdatedAfter: day, UpdatedBefore: day.Add(-time.Hour)}, isError: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.input.Validate()
+			if (err != nil) != tc.isError {
+				t.Errorf("expected error (%v), got error (%v)", tc.isError, err)
+			}
+		})
+	}
+}
+
+func TestCursorOf(t *testing.T) {
+	q := Question{Id: 7, Body: "Where does the sun set?"}
+
+	byId := QuestionFilter{Sort: SortById}
+	if c := byId.CursorOf(q); c.Id != 7 || c.Value != "" {
+		t.Errorf("expected cursor (7), got cursor (%v)", c)
+	}
+
+	byBody := QuestionFilter{Sort: SortByBody}
+	if c := byBody.CursorOf(q); c.Id != 7 || c.Value != q.Body {
+		t.Errorf("expected cursor (7, %s), got cursor (%v)", q.Body, c)
+	}
+
+	byCreation := QuestionFilter{Sort: SortByCreatedAt}
+	if c := byCreation.CursorOf(q); c.Id != 7 || c.Value != "0" {
+		t.Errorf("expected cursor (7, 0), got cursor (%v)", c)
+	}
+
+	updated := time.Unix(0, 1614600000000000000)
+	q.UpdatedAt = &updated
+	byUpdate := QuestionFilter{Sort: SortByUpdatedAt}
+	if c := byUpdate.CursorOf(q); c.Id != 7 || c.Value != "1614600000000000000" {
+		t.Errorf("expected cursor (7, 1614600000000000000), got cursor (%v)", c)
+	}
+
+	q.Difficulty = 3
+	byDifficulty := QuestionFilter{Sort: SortByDifficulty}
+	if c := byDifficulty.CursorOf(q); c.Id != 7 || c.Value != "3" {
+		t.Errorf("expected cursor (7, 3), got cursor (%v)", c)
+	}
+}
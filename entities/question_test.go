@@ -1,6 +1,9 @@
 package entities
 
-import "testing"
+import (
+	"testing"
+	"time"
+)
 
 func TestValidateQuestion(t *testing.T) {
 	testCases := []struct {
@@ -85,6 +88,68 @@ func TestValidateQuestion(t *testing.T) {
 			},
 			isError: true,
 		},
+		{
+			name: "option ids",
+			input: Question{
+				Id:   1,
+				Body: "Where does the sun set?",
+				Options: []Option{
+					{Id: 2, QuestionId: 1, Body: "East"},
+					{Id: 1, QuestionId: 1, Body: "West", Correct: true},
+					{Body: "North"},
+					{Body: "South"},
+				},
+			},
+			isError: false,
+		},
+		{
+			name: "duplicate option ids",
+			input: Question{
+				Id:   1,
+				Body: "Where does the sun set?",
+				Options: []Option{
+					{Id: 1, QuestionId: 1, Body: "East"},
+					{Id: 1, QuestionId: 1, Body: "West", Correct: true},
+				},
+			},
+			isError: true,
+		},
+		{
+			name: "tags",
+			input: Question{
+				Body:    "Where does the sun set?",
+				Options: []Option{{Body: "East"}, {Body: "West", Correct: true}},
+				Tags:    []string{"geography", " astronomy "},
+			},
+			isError: false,
+		},
+		{
+			name: "invalid tag",
+			input: Question{
+				Body:    "Where does the sun set?",
+				Options: []Option{{Body: "East"}, {Body: "West", Correct: true}},
+				Tags:    []string{"geography,astronomy"},
+			},
+			isError: true,
+		},
+		{
+			name: "difficulty",
+			input: Question{
+				Body:       "Where does the sun set?",
+				Options:    []Option{{Body: "East"}, {Body: "West", Correct: true}},
+				Difficulty: 5,
+			},
+			isError: false,
+		},
+		{
+			name: "invalid difficulty",
+			input: Question{
+				Body:       "Where does the sun set?",
+				Options:    []Option{{Body: "East"}, {Body: "West", Correct: true}},
+				Difficulty: 6,
+			},
+			isError: true,
+		},
 	}
 
 	for _, tc := range testCases {
@@ -97,3 +162,66 @@ func TestValidateQuestion(t *testing.T) {
 		})
 	}
 }
+
+func TestQuestionType(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    Question
+		expected QuestionType
+	}{
+		{
+			name:     "no options",
+			input:    Question{},
+			expected: SingleChoice,
+		},
+		{
+			name: "one correct option",
+			input: Question{
+				Options: []Option{{Body: "East", Correct: false}, {Body: "West", Correct: true}},
+			},
+			expected: SingleChoice,
+		},
+		{
+			name: "two correct options",
+			input: Question{
+				Options: []Option{{Body: "Mars", Correct: true}, {Body: "Pluto", Correct: false}, {Body: "Venus", Correct: true}},
+			},
+			expected: MultipleChoice,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if qt := tc.input.Type(); qt != tc.expected {
+				t.Errorf("expected type (%v), got type (%v)", tc.expected, qt)
+			}
+		})
+	}
+}
+
+func TestWithoutOptionIds(t *testing.T) {
+	q := Question{Id: 1, Body: "Where does the sun set?", Options: []Option{{Id: 1, Body: "East"}, {Id: 2, Body: "West", Correct: true}}}
+
+	c := q.WithoutOptionIds()
+	if c.Id != 1 || len(c.Options) != 2 || c.Options[0].Id != 0 || c.Options[1].Id != 0 || c.Options[1].Body != "West" {
+		t.Errorf("expected the options without their ids, got question (%v)", c)
+	}
+
+	if q.Options[0].Id != 1 || q.Options[1].Id != 2 {
+		t.Errorf("expected the options of the question to keep their ids, got options (%v)", q.Options)
+	}
+}
+
+func TestWithoutMetadata(t *testing.T) {
+	now := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+	q := Question{Id: 1, Body: "Where does the sun set?", CreatedAt: &now, CreatedBy: "alice", UpdatedAt: &now, UpdatedBy: "bob"}
+
+	c := q.WithoutMetadata()
+	if c.Id != 1 || c.Body != q.Body || c.CreatedAt != nil || c.CreatedBy != "" || c.UpdatedAt != nil || c.UpdatedBy != "" {
+		t.Errorf("expected the question without its metadata, got question (%v)", c)
+	}
+
+	if q.CreatedAt == nil || q.UpdatedBy != "bob" {
+		t.Errorf("expected the question to keep its metadata, got question (%v)", q)
+	}
+}
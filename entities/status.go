@@ -0,0 +1,106 @@
+package entities
+
+import "fmt"
+
+// QuestionStatus is the stage of a question in the review workflow
+// New questions are drafts, and only the published questions are served to candidates.
+type QuestionStatus string
+
+const (
+	// Draft questions are written and changed by editors
+	Draft QuestionStatus = "draft"
+	// InReview questions wait for a reviewer to approve or reject them
+	InReview QuestionStatus = "in_review"
+	// Published questions can be used in quizzes
+	Published QuestionStatus = "published"
+	// Retired questions aren't used anymore and are kept for the record
+	Retired QuestionStatus = "retired"
+)
+
+// QuestionTransition is a change of status allowed by the review workflow
+type QuestionTransition string
+
+const (
+	// Submit sends a draft to review
+	Submit QuestionTransition = "submit"
+	// Approve publishes a question in review
+	Approve QuestionTransition = "approve"
+	// Reject sends a question in review back to draft
+	Reject QuestionTransition = "reject"
+	// Retire withdraws a published question
+	Retire QuestionTransition = "retire"
+)
+
+var (
+	StatusTransitionError   = fmt.Errorf("the question status doesn't allow this transition")
+	ReviewCommentError      = fmt.Errorf("an approval requires a reviewer comment")
+	QuestionStatusError     = fmt.Errorf("unknown question status")
+	QuestionTransitionError = fmt.Errorf("unknown question transition")
+)
+
+// transitions maps every transition to the status it starts from and the one it leads to
+var transitions = map[QuestionTransition]struct{ from, to QuestionStatus }{
+	Submit:  {Draft, InReview},
+	Approve: {InReview, Published},
+	Reject:  {InReview, Draft},
+	Retire:  {Published, Retired},
+}
+
+// QuestionStatuses lists the statuses of the review workflow, in order
+var QuestionStatuses = []QuestionStatus{Draft, InReview, Published, Retired}
+
+// Validate checks that s is one of the statuses of the review workflow
+func (s QuestionStatus) Validate() error {
+	for _, st := range QuestionStatuses {
+		if s == st {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%w: %s isn't one of %v", QuestionStatusError, s, QuestionStatuses)
+}
+
+// Edited returns the status a question with status s gets when its content is changed
+// The questions in review or published go back to draft, so that the change is reviewed before being served to candidates,
+// and the retired questions can't be changed.
+func (s QuestionStatus) Edited() (QuestionStatus, error) {
+	if s == Retired {
+		return "", fmt.Errorf("%w: can't change a question that is %s", StatusTransitionError, s)
+	}
+
+	return Draft, nil
+}
+
+// Validate checks that t is one of the transitions of the review workflow
+func (t QuestionTransition) Validate() error {
+	if _, ok := transitions[t]; !ok {
+		return fmt.Errorf("%w: %s", QuestionTransitionError, t)
+	}
+
+	return nil
+}
+
+// Apply returns the status a question with status s gets through the transition
+// It returns QuestionTransitionError if the transition is unknown and StatusTransitionError if it doesn't start from s.
+func (t QuestionTransition) Apply(s QuestionStatus) (QuestionStatus, error) {
+	if err := t.Validate(); err != nil {
+		return "", err
+	}
+
+	st := transitions[t]
+
+	if st.from != s {
+		return "", fmt.Errorf("%w: can't %s a question that is %s, only one that is %s", StatusTransitionError, t, s, st.from)
+	}
+
+	return st.to, nil
+}
+
+// ValidateComment checks the reviewer comment given with the transition
+func (t QuestionTransition) ValidateComment(comment string) error {
+	if t == Approve && comment == "" {
+		return ReviewCommentError
+	}
+
+	return nil
+}
@@ -0,0 +1,94 @@
+package entities
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestApplyTransition(t *testing.T) {
+	testCases := []struct {
+		transition QuestionTransition
+		from       QuestionStatus
+		expected   QuestionStatus
+		isError    bool
+	}{
+		{transition: Submit, from: Draft, expected: InReview},
+		{transition: Approve, from: InReview, expected: Published},
+		{transition: Reject, from: InReview, expected: Draft},
+		{transition: Retire, from: Published, expected: Retired},
+		{transition: Submit, from: InReview, isError: true},
+		{transition: Approve, from: Draft, isError: true},
+		{transition: Reject, from: Published, isError: true},
+		{transition: Retire, from: InReview, isError: true},
+		{transition: Submit, from: Retired, isError: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(string(tc.transition)+" "+string(tc.from), func(t *testing.T) {
+			status, err := tc.transition.Apply(tc.from)
+			if (err != nil) != tc.isError {
+				t.Fatalf("expected error (%v), got error (%v)", tc.isError, err)
+			}
+
+			if tc.isError && !errors.Is(err, StatusTransitionError) {
+				t.Errorf("expected error (%v), got error (%v)", StatusTransitionError, err)
+			}
+
+			if status != tc.expected {
+				t.Errorf("expected status (%s), got status (%s)", tc.expected, status)
+			}
+		})
+	}
+}
+
+func TestValidateTransition(t *testing.T) {
+	for _, tr := range []QuestionTransition{Submit, Approve, Reject, Retire} {
+		if err := tr.Validate(); err != nil {
+			t.Errorf("expected transition (%s) to be valid, got error (%v)", tr, err)
+		}
+	}
+
+	if _, err := QuestionTransition("publish").Apply(Draft); !errors.Is(err, QuestionTransitionError) {
+		t.Errorf("expected error (%v), got error (%v)", QuestionTransitionError, err)
+	}
+}
+
+func TestEditedStatus(t *testing.T) {
+	for _, s := range []QuestionStatus{Draft, InReview, Published} {
+		if edited, err := s.Edited(); err != nil || edited != Draft {
+			t.Errorf("expected an edited %s question to be a draft, got status (%s) and error (%v)", s, edited, err)
+		}
+	}
+
+	if _, err := Retired.Edited(); !errors.Is(err, StatusTransitionError) {
+		t.Errorf("expected error (%v), got error (%v)", StatusTransitionError, err)
+	}
+}
+
+func TestValidateComment(t *testing.T) {
+	if err := Approve.ValidateComment(""); err != ReviewCommentError {
+		t.Errorf("expected error (%v), got error (%v)", ReviewCommentError, err)
+	}
+
+	if err := Approve.ValidateComment("Clear and correct"); err != nil {
+		t.Errorf("expected error nil, got error (%v)", err)
+	}
+
+	if err := Reject.ValidateComment(""); err != nil {
+		t.Errorf("expected error nil, got error (%v)", err)
+	}
+}
+
+func TestValidateStatus(t *testing.T) {
+	for _, s := range QuestionStatuses {
+		if err := s.Validate(); err != nil {
+			t.Errorf("expected status (%s) to be valid, got error (%v)", s, err)
+		}
+	}
+
+	for _, s := range []QuestionStatus{"", "archived"} {
+		if err := s.Validate(); !errors.Is(err, QuestionStatusError) {
+			t.Errorf("expected error (%v) for status (%q), got error (%v)", QuestionStatusError, s, err)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package entities
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	// MaxTagLength is the maximum number of characters of a tag
+	MaxTagLength = 50
+	// MaxTags is the maximum number of tags of a question
+	MaxTags = 20
+)
+
+var (
+	QuestionTagError = fmt.Errorf("tags should have 1 to %d characters, without commas", MaxTagLength)
+	TagCountError    = fmt.Errorf("a question can have at most %d tags", MaxTags)
+)
+
+// ValidateTags checks that every tag is a non empty text of at most MaxTagLength characters, without commas
+// The surrounding spaces of the tags aren't counted, they're removed when the tags are stored.
+func ValidateTags(tags []string) error {
+	for _, t := range tags {
+		t = strings.TrimSpace(t)
+
+		if t == "" || len([]rune(t)) > MaxTagLength || strings.Contains(t, ",") {
+			return fmt.Errorf("%w: %q", QuestionTagError, t)
+		}
+	}
+
+	return nil
+}
+
+// NormalizeTags returns the tags without their surrounding spaces, sorted and without duplicates
+func NormalizeTags(tags []string) []string {
+	set := make(map[string]bool, len(tags))
+	nl := []string{}
+	for _, t := range tags {
+		t = strings.TrimSpace(t)
+		if t == "" || set[t] {
+			continue
+		}
+
+		set[t] = true
+		nl = append(nl, t)
+	}
+
+	sort.Strings(nl)
+
+	return nl
+}
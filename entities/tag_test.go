@@ -0,0 +1,47 @@
+package entities
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestValidateTags(t *testing.T) {
+	testCases := []struct {
+		name    string
+		input   []string
+		isError bool
+	}{
+		{name: "no tags", input: nil},
+		{name: "valid tags", input: []string{"geography", "solar system"}},
+		{name: "empty tag", input: []string{"geography", "  "}, isError: true},
+		{name: "comma", input: []string{"geography,astronomy"}, isError: true},
+		{name: "too long", input: []string{strings.Repeat("a", MaxTagLength+1)}, isError: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := ValidateTags(tc.input)
+
+			if (err != nil) != tc.isError {
+				t.Fatalf("expected error (%v), got error (%v)", tc.isError, err)
+			}
+
+			if err != nil && !errors.Is(err, QuestionTagError) {
+				t.Errorf("expected error (%v), got error (%v)", QuestionTagError, err)
+			}
+		})
+	}
+}
+
+func TestNormalizeTags(t *testing.T) {
+	tags := NormalizeTags([]string{" geography", "astronomy", "geography ", ""})
+
+	if strings.Join(tags, ",") != "astronomy,geography" {
+		t.Errorf("expected tags (astronomy,geography), got tags (%v)", tags)
+	}
+
+	if tags = NormalizeTags(nil); tags == nil || len(tags) != 0 {
+		t.Errorf("expected no tags, got tags (%v)", tags)
+	}
+}
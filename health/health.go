@@ -0,0 +1,149 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Status is the state of the application or of one of its dependencies
+type Status string
+
+const (
+	StatusOK       Status = "ok"
+	StatusDown     Status = "down"
+	StatusReady    Status = "ready"
+	StatusDegraded Status = "degraded"
+	StatusDraining Status = "draining"
+	StatusStarting Status = "starting"
+)
+
+// Check returns an error when the dependency it checks can't be used
+type Check func(context.Context) error
+
+// CheckResult is the outcome of the check of a dependency
+type CheckResult struct {
+	Status    Status  `json:"status"`
+	Error     string  `json:"error,omitempty"`
+	LatencyMs float64 `json:"latency_ms"`
+}
+
+// Report is the readiness of the application, with the result of every dependency check
+type Report struct {
+	Status Status                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks,omitempty"`
+}
+
+// Checker reports whether the application is alive and ready to handle requests
+// It isn't ready until SetReady is called, and stops being ready when the shutdown starts, so the traffic is drained first.
+type Checker struct {
+	mu       sync.Mutex
+	names    []string
+	checks   map[string]Check
+	ready    atomic.Bool
+	draining atomic.Bool
+}
+
+// New returns a checker without dependencies, which isn't ready yet
+func New() *Checker {
+	return &Checker{checks: map[string]Check{}}
+}
+
+// Add registers the check of a dependency, the application is only ready when every check passes
+func (c *Checker) Add(name string, check Check) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.checks[name]; !ok {
+		c.names = append(c.names, name)
+		sort.Strings(c.names)
+	}
+
+	c.checks[name] = check
+}
+
+// SetReady marks the application as ready to handle requests, once it's listening
+func (c *Checker) SetReady() {
+	c.ready.Store(true)
+}
+
+// Drain marks the application as no longer ready, before the server is shut down
+func (c *Checker) Drain() {
+	c.draining.Store(true)
+}
+
+// Ready runs every dependency check concurrently and returns the readiness of the application
+// The dependencies are checked even while starting or draining, so their state is always reported.
+func (c *Checker) Ready(ctx context.Context) Report {
+	c.mu.Lock()
+	names := append([]string(nil), c.names...)
+	checks := make([]Check, len(names))
+	for i, n := range names {
+		checks[i] = c.checks[n]
+	}
+	c.mu.Unlock()
+
+	results := make([]CheckResult, len(names))
+
+	var wg sync.WaitGroup
+	for i := range checks {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			start := time.Now()
+			err := checks[i](ctx)
+
+			results[i] = CheckResult{Status: StatusOK, LatencyMs: float64(time.Since(start).Microseconds()) / 1000}
+			if err != nil {
+				results[i].Status, results[i].Error = StatusDown, err.Error()
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusReady, Checks: map[string]CheckResult{}}
+	for i, n := range names {
+		report.Checks[n] = results[i]
+		if results[i].Status != StatusOK {
+			report.Status = StatusDegraded
+		}
+	}
+
+	switch {
+	case c.draining.Load():
+		report.Status = StatusDraining
+	case !c.ready.Load():
+		report.Status = StatusStarting
+	}
+
+	return report
+}
+
+// LiveHandler returns the http handler of the liveness probe, it succeeds as long as the process can handle requests
+func (c *Checker) LiveHandler() http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.Header().Set("Content-type", "application/json")
+		_ = json.NewEncoder(rw).Encode(Report{Status: StatusOK})
+	})
+}
+
+// ReadyHandler returns the http handler of the readiness probe
+// It responds with 503 while starting, draining or when a dependency is down, and reports the state of every dependency.
+func (c *Checker) ReadyHandler() http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		report := c.Ready(r.Context())
+
+		rw.Header().Set("Content-type", "application/json")
+		rw.Header().Set("Cache-Control", "no-store")
+		if report.Status != StatusReady {
+			rw.WriteHeader(http.StatusServiceUnavailable)
+		}
+
+		_ = json.NewEncoder(rw).Encode(report)
+	})
+}
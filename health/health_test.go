@@ -0,0 +1,102 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestReady(t *testing.T) {
+	ok := func(context.Context) error { return nil }
+	down := func(context.Context) error { return fmt.Errorf("unable to reach database") }
+
+	testCases := []struct {
+		name       string
+		checks     map[string]Check
+		ready      bool
+		draining   bool
+		status     Status
+		statusCode int
+		down       []string
+	}{{
+		name:       "starting",
+		checks:     map[string]Check{"database": ok},
+		status:     StatusStarting,
+		statusCode: http.StatusServiceUnavailable,
+	}, {
+		name:       "ready",
+		checks:     map[string]Check{"database": ok, "schema": ok},
+		ready:      true,
+		status:     StatusReady,
+		statusCode: http.StatusOK,
+	}, {
+		name:       "dependency down",
+		checks:     map[string]Check{"database": down, "schema": ok},
+		ready:      true,
+		status:     StatusDegraded,
+		statusCode: http.StatusServiceUnavailable,
+		down:       []string{"database"},
+	}, {
+		name:       "draining",
+		checks:     map[string]Check{"database": ok},
+		ready:      true,
+		draining:   true,
+		status:     StatusDraining,
+		statusCode: http.StatusServiceUnavailable,
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := New()
+			for name, check := range tc.checks {
+				c.Add(name, check)
+			}
+
+			if tc.ready {
+				c.SetReady()
+			}
+
+			if tc.draining {
+				c.Drain()
+			}
+
+			rec := httptest.NewRecorder()
+			c.ReadyHandler().ServeHTTP(rec, httptest.NewRequest("GET", "/readyz", nil))
+
+			if rec.Code != tc.statusCode {
+				t.Errorf("expected status code (%d), got status code (%d)", tc.statusCode, rec.Code)
+			}
+
+			var report Report
+			if err := json.NewDecoder(rec.Body).Decode(&report); err != nil {
+				t.Fatalf("unable to decode report: %s", err.Error())
+			}
+
+			if report.Status != tc.status || len(report.Checks) != len(tc.checks) {
+				t.Errorf("expected status (%s) with (%d) checks, got report (%v)", tc.status, len(tc.checks), report)
+			}
+
+			for _, name := range tc.down {
+				if res := report.Checks[name]; res.Status != StatusDown || res.Error == "" {
+					t.Errorf("expected (%s) to be reported down, got result (%v)", name, res)
+				}
+			}
+		})
+	}
+}
+
+func TestLive(t *testing.T) {
+	c := New()
+	c.Add("database", func(context.Context) error { return fmt.Errorf("unable to reach database") })
+	c.Drain()
+
+	rec := httptest.NewRecorder()
+	c.LiveHandler().ServeHTTP(rec, httptest.NewRequest("GET", "/healthz", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Errorf("expected status code (%d), got status code (%d)", http.StatusOK, rec.Code)
+	}
+}
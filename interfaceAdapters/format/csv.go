@@ -0,0 +1,257 @@
+package format
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"github.com/norby7/questions-rest-api/entities"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// csvHeader is the header of a CSV question stream
+// Each row holds the question id, body, tags, difficulty and metadata followed by one option,correct column pair for every option.
+// The tags are separated by commas inside their column.
+var csvHeader = []string{"id", "body", "tags", "difficulty", "status", "review_comment", "created_at", "created_by", "updated_at", "updated_by", "option", "correct"}
+
+// csvMetadata are the tags, difficulty and metadata columns, any of them can follow the id and body columns of an imported stream
+var csvMetadata = csvHeader[2:10]
+
+var (
+	CSVHeaderError = fmt.Errorf("csv stream should start with the id and body columns, followed by any of the %s columns and option,correct column pairs",
+		strings.Join(csvMetadata, ","))
+)
+
+type csvDecoder struct {
+	reader *csv.Reader
+	// metadata columns of the stream, in the order of the header
+	metadata []string
+	done     bool
+}
+
+func newCSVDecoder(r io.Reader) (*csvDecoder, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+
+	// the failures of the underlying reader are kept, so a body over the size limit can be told apart
+	var pe *csv.ParseError
+	if err != nil && err != io.EOF && !errors.As(err, &pe) {
+		return nil, fmt.Errorf("unable to read stream: %w", err)
+	}
+
+	if err != nil {
+		return nil, CSVHeaderError
+	}
+
+	metadata, ok := parseCSVHeader(header)
+	if !ok {
+		return nil, CSVHeaderError
+	}
+
+	return &csvDecoder{reader: cr, metadata: metadata}, nil
+}
+
+// parseCSVHeader checks that the header starts with the id and body columns, followed by metadata columns and option,correct pairs
+// It returns the names of the metadata columns, in the order of the header.
+func parseCSVHeader(header []string) ([]string, bool) {
+	if len(header) < 2 || !csvColumn(header[0], "id") || !csvColumn(header[1], "body") {
+		return nil, false
+	}
+
+	metadata := []string{}
+	i := 2
+	for ; i < len(header); i++ {
+		name := ""
+		for _, m := range csvMetadata {
+			if csvColumn(header[i], m) {
+				name = m
+			}
+		}
+
+		if name == "" {
+			break
+		}
+		metadata = append(metadata, name)
+	}
+
+	for j, h := range header[i:] {
+		if !csvColumn(h, csvHeader[len(csvHeader)-2+j%2]) {
+			return nil, false
+		}
+	}
+
+	return metadata, true
+}
+
+// csvColumn reports whether the header column h is the column with the given name
+func csvColumn(h, name string) bool {
+	return strings.EqualFold(strings.TrimSpace(h), name)
+}
+
+// Read decodes the next CSV row into a question
+func (d *csvDecoder) Read() (entities.Question, error) {
+	if d.done {
+		return entities.Question{}, io.EOF
+	}
+
+	row, err := d.reader.Read()
+	if err != nil {
+		var pe *csv.ParseError
+		if errors.As(err, &pe) {
+			return entities.Question{}, fmt.Errorf("unable to parse question: %s", err.Error())
+		}
+
+		d.done = true
+		if err == io.EOF {
+			return entities.Question{}, io.EOF
+		}
+
+		return entities.Question{}, fmt.Errorf("unable to read stream: %s", err.Error())
+	}
+
+	line, _ := d.reader.FieldPos(0)
+
+	r, err := parseCSVRow(row, d.metadata)
+	if err != nil {
+		return entities.Question{}, fmt.Errorf("line %d: %s", line, err.Error())
+	}
+
+	return r.toQuestion(), nil
+}
+
+// parseCSVRow converts the columns of a CSV row into a stream record, the metadata columns follow the id and body columns
+func parseCSVRow(row []string, metadata []string) (record, error) {
+	var r record
+
+	if len(row) < 2+len(metadata) {
+		return r, fmt.Errorf("row should contain at least the id, body and metadata columns")
+	}
+
+	if id := strings.TrimSpace(row[0]); id != "" {
+		var err error
+		if r.Id, err = strconv.ParseInt(id, 10, 64); err != nil {
+			return r, fmt.Errorf("invalid id value: %s", err.Error())
+		}
+	}
+
+	r.Body = row[1]
+
+	for i, m := range metadata {
+		if err := r.setMetadata(m, row[2+i]); err != nil {
+			return r, err
+		}
+	}
+
+	options := row[2+len(metadata):]
+	if len(options)%2 != 0 {
+		return r, fmt.Errorf("every option column should be followed by a correct column")
+	}
+
+	for i := 0; i < len(options); i += 2 {
+		body, correct := options[i], strings.TrimSpace(options[i+1])
+
+		// rows padded by spreadsheet editors end with empty option pairs
+		if body == "" && correct == "" {
+			continue
+		}
+
+		c := false
+		if correct != "" {
+			var err error
+			if c, err = strconv.ParseBool(correct); err != nil {
+				return r, fmt.Errorf("invalid correct value for option %d: %s", i/2+1, err.Error())
+			}
+		}
+
+		r.Options = append(r.Options, optionRecord{Body: body, Correct: c})
+	}
+
+	return r, nil
+}
+
+// setMetadata sets the metadata field of the record read from the CSV column with the given name
+func (r *record) setMetadata(name, value string) error {
+	switch name {
+	case "tags":
+		if value = strings.TrimSpace(value); value != "" {
+			r.Tags = strings.Split(value, ",")
+		}
+	case "difficulty":
+		if value = strings.TrimSpace(value); value != "" {
+			d, err := strconv.Atoi(value)
+			if err != nil {
+				return fmt.Errorf("invalid difficulty value: %s", err.Error())
+			}
+
+			r.Difficulty = d
+		}
+	case "status":
+		r.Status = entities.QuestionStatus(strings.TrimSpace(value))
+	case "review_comment":
+		r.ReviewComment = value
+	case "created_by":
+		r.CreatedBy = strings.TrimSpace(value)
+	case "updated_by":
+		r.UpdatedBy = strings.TrimSpace(value)
+	case "created_at", "updated_at":
+		value = strings.TrimSpace(value)
+		if value == "" {
+			return nil
+		}
+
+		t, err := time.Parse(time.RFC3339Nano, value)
+		if err != nil {
+			return fmt.Errorf("invalid %s value: %s", name, err.Error())
+		}
+
+		if name == "created_at" {
+			r.CreatedAt = &t
+		} else {
+			r.UpdatedAt = &t
+		}
+	}
+
+	return nil
+}
+
+// csvTime formats a time of the question metadata as a CSV column, the missing times are empty
+func csvTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+
+	return t.Format(time.RFC3339Nano)
+}
+
+type csvEncoder struct {
+	writer *csv.Writer
+}
+
+func newCSVEncoder(w io.Writer) *csvEncoder {
+	cw := csv.NewWriter(w)
+	_ = cw.Write(csvHeader)
+
+	return &csvEncoder{writer: cw}
+}
+
+// Write encodes the question as a CSV row with its tags, difficulty and metadata and an option,correct column pair for every option
+func (e *csvEncoder) Write(q entities.Question) error {
+	row := []string{strconv.FormatInt(q.Id, 10), q.Body, strings.Join(q.Tags, ","), strconv.Itoa(q.Difficulty), string(q.Status), q.ReviewComment, csvTime(q.CreatedAt), q.CreatedBy, csvTime(q.UpdatedAt), q.UpdatedBy}
+	for _, o := range q.Options {
+		row = append(row, o.Body, strconv.FormatBool(o.Correct))
+	}
+
+	return e.writer.Write(row)
+}
+
+// Close flushes the buffered rows to the underlying writer
+func (e *csvEncoder) Close() error {
+	e.writer.Flush()
+
+	return e.writer.Error()
+}
@@ -0,0 +1,247 @@
+// Package format contains the stream encoders and decoders used to move whole question banks in and out of the API
+package format
+
+import (
+	"fmt"
+	"github.com/norby7/questions-rest-api/entities"
+	"io"
+	"mime"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Format identifies a serialization format for a stream of questions
+type Format string
+
+const (
+	JSONL    Format = "jsonl"
+	CSV      Format = "csv"
+	YAML     Format = "yaml"
+	Markdown Format = "markdown"
+	Moodle   Format = "moodle"
+	GIFT     Format = "gift"
+	QTI      Format = "qti"
+)
+
+var (
+	UnknownFormatError = fmt.Errorf("unknown question stream format")
+	ImportFormatError  = fmt.Errorf("format can't be imported")
+)
+
+// Decoder reads questions one at a time from an underlying stream
+// Read returns io.EOF once the stream is exhausted. Any other error only concerns the current record, the
+// next call to Read continues with the following record.
+type Decoder interface {
+	Read() (entities.Question, error)
+}
+
+// Encoder writes questions one at a time to an underlying stream
+// Close must be called once all the questions were written, to flush any buffered output.
+type Encoder interface {
+	Write(entities.Question) error
+	Close() error
+}
+
+// Checker is implemented by the encoders of formats that can't represent every valid question
+// Check returns an error describing why the question can't be written.
+type Checker interface {
+	Check(entities.Question) error
+}
+
+// Quiz is implemented by the encoders of the quiz formats, whose questions are served to candidates
+// The service only writes the published questions to the encoders whose Quiz method returns true.
+type Quiz interface {
+	Quiz() bool
+}
+
+// record is the serialized representation of a question inside a stream
+// The tags and metadata of the question are kept by the imports, so a bank can be restored from its export.
+type record struct {
+	Id            int64                   `json:"id,omitempty" yaml:"id,omitempty"`
+	Body          string                  `json:"body" yaml:"body"`
+	Options       []optionRecord          `json:"options" yaml:"options"`
+	Tags          []string                `json:"tags,omitempty" yaml:"tags,omitempty"`
+	Difficulty    int                     `json:"difficulty,omitempty" yaml:"difficulty,omitempty"`
+	Status        entities.QuestionStatus `json:"status,omitempty" yaml:"status,omitempty"`
+	ReviewComment string                  `json:"review_comment,omitempty" yaml:"review_comment,omitempty"`
+	CreatedAt     *time.Time              `json:"created_at,omitempty" yaml:"created_at,omitempty"`
+	CreatedBy     string                  `json:"created_by,omitempty" yaml:"created_by,omitempty"`
+	UpdatedAt     *time.Time              `json:"updated_at,omitempty" yaml:"updated_at,omitempty"`
+	UpdatedBy     string                  `json:"updated_by,omitempty" yaml:"updated_by,omitempty"`
+}
+
+// optionRecord is the serialized representation of an option inside a stream
+type optionRecord struct {
+	Body    string `json:"body" yaml:"body"`
+	Correct bool   `json:"correct" yaml:"correct"`
+}
+
+// toQuestion converts a stream record into a question entity
+func (r record) toQuestion() entities.Question {
+	q := entities.Question{
+		Id:            r.Id,
+		Body:          r.Body,
+		Tags:          r.Tags,
+		Difficulty:    r.Difficulty,
+		Status:        r.Status,
+		ReviewComment: r.ReviewComment,
+		CreatedAt:     r.CreatedAt,
+		CreatedBy:     r.CreatedBy,
+		UpdatedAt:     r.UpdatedAt,
+		UpdatedBy:     r.UpdatedBy,
+	}
+	for i, o := range r.Options {
+		q.Options = append(q.Options, entities.Option{Body: o.Body, Correct: o.Correct, OptionOrder: i})
+	}
+
+	return q
+}
+
+// newRecord converts a question entity into a stream record
+func newRecord(q entities.Question) record {
+	r := record{
+		Id:            q.Id,
+		Body:          q.Body,
+		Options:       []optionRecord{},
+		Tags:          q.Tags,
+		Difficulty:    q.Difficulty,
+		Status:        q.Status,
+		ReviewComment: q.ReviewComment,
+		CreatedAt:     q.CreatedAt,
+		CreatedBy:     q.CreatedBy,
+		UpdatedAt:     q.UpdatedAt,
+		UpdatedBy:     q.UpdatedBy,
+	}
+	for _, o := range q.Options {
+		r.Options = append(r.Options, optionRecord{Body: o.Body, Correct: o.Correct})
+	}
+
+	return r
+}
+
+// Parse returns the format with the given name, accepting the common aliases of each format
+func Parse(name string) (Format, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "jsonl", "ndjson":
+		return JSONL, nil
+	case "csv":
+		return CSV, nil
+	case "yaml", "yml":
+		return YAML, nil
+	case "markdown", "md":
+		return Markdown, nil
+	case "moodle", "moodlexml", "xml":
+		return Moodle, nil
+	case "gift":
+		return GIFT, nil
+	case "qti", "zip":
+		return QTI, nil
+	}
+
+	return "", fmt.Errorf("%w: %s", UnknownFormatError, name)
+}
+
+// FromExtension returns the format matching the extension of the given file path
+func FromExtension(p string) (Format, error) {
+	return Parse(strings.TrimPrefix(filepath.Ext(p), "."))
+}
+
+// FromContentType returns the format matching the given Content-Type header value
+func FromContentType(ct string) (Format, error) {
+	mt, _, err := mime.ParseMediaType(ct)
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", UnknownFormatError, ct)
+	}
+
+	switch mt {
+	case "application/x-ndjson", "application/jsonl", "application/x-jsonlines":
+		return JSONL, nil
+	case "text/csv":
+		return CSV, nil
+	case "application/yaml", "application/x-yaml", "text/yaml":
+		return YAML, nil
+	case "application/xml", "text/xml":
+		return Moodle, nil
+	}
+
+	return "", fmt.Errorf("%w: %s", UnknownFormatError, ct)
+}
+
+// ContentType returns the media type used when serving a stream in the given format
+func (f Format) ContentType() string {
+	switch f {
+	case JSONL:
+		return "application/x-ndjson"
+	case CSV:
+		return "text/csv; charset=utf-8"
+	case YAML:
+		return "application/yaml"
+	case Markdown:
+		return "text/markdown; charset=utf-8"
+	case Moodle:
+		return "application/xml"
+	case GIFT:
+		return "text/plain; charset=utf-8"
+	case QTI:
+		return "application/zip"
+	}
+
+	return "application/octet-stream"
+}
+
+// Extension returns the file extension, without the leading dot, used for files in the given format
+func (f Format) Extension() string {
+	switch f {
+	case Markdown:
+		return "md"
+	case Moodle:
+		return "xml"
+	case QTI:
+		return "zip"
+	}
+
+	return string(f)
+}
+
+// NewDecoder returns a decoder that reads questions in the given format from r
+func NewDecoder(f Format, r io.Reader) (Decoder, error) {
+	switch f {
+	case JSONL:
+		return newJSONLDecoder(r), nil
+	case CSV:
+		return newCSVDecoder(r)
+	case YAML:
+		return newYAMLDecoder(r), nil
+	case Moodle:
+		return newMoodleDecoder(r), nil
+	case GIFT:
+		return newGIFTDecoder(r), nil
+	case Markdown, QTI:
+		return nil, fmt.Errorf("%w: %s", ImportFormatError, f)
+	}
+
+	return nil, fmt.Errorf("%w: %s", UnknownFormatError, f)
+}
+
+// NewEncoder returns an encoder that writes questions in the given format to w
+func NewEncoder(f Format, w io.Writer) (Encoder, error) {
+	switch f {
+	case JSONL:
+		return newJSONLEncoder(w), nil
+	case CSV:
+		return newCSVEncoder(w), nil
+	case YAML:
+		return newYAMLEncoder(w), nil
+	case Markdown:
+		return newMarkdownEncoder(w), nil
+	case Moodle:
+		return newMoodleEncoder(w), nil
+	case GIFT:
+		return newGIFTEncoder(w), nil
+	case QTI:
+		return newQTIEncoder(w), nil
+	}
+
+	return nil, fmt.Errorf("%w: %s", UnknownFormatError, f)
+}
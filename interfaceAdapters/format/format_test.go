@@ -0,0 +1,371 @@
+package format
+
+import (
+	"bytes"
+	"errors"
+	"github.com/norby7/questions-rest-api/entities"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+)
+
+// readAll reads the whole stream and returns the question bodies and the number of record errors
+func readAll(t *testing.T, d Decoder) ([]string, int) {
+	t.Helper()
+
+	var bodies []string
+	errCount := 0
+	for i := 0; i < 100; i++ {
+		q, err := d.Read()
+		if err == io.EOF {
+			return bodies, errCount
+		}
+
+		if err != nil {
+			errCount++
+			continue
+		}
+
+		bodies = append(bodies, q.Body)
+	}
+
+	t.Fatalf("decoder didn't reach the end of the stream")
+	return nil, 0
+}
+
+func TestParse(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected Format
+		isError  bool
+	}{
+		{input: "jsonl", expected: JSONL},
+		{input: "NDJSON", expected: JSONL},
+		{input: "csv", expected: CSV},
+		{input: "yml", expected: YAML},
+		{input: "docx", isError: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			f, err := Parse(tc.input)
+
+			if (err != nil) != tc.isError || f != tc.expected {
+				t.Errorf("expected format (%v) and error (%v), got (%v) and (%v)", tc.expected, tc.isError, f, err)
+			}
+
+			if err != nil && !errors.Is(err, UnknownFormatError) {
+				t.Errorf("expected error (%v), got error (%v)", UnknownFormatError, err)
+			}
+		})
+	}
+}
+
+func TestFromContentType(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected Format
+		isError  bool
+	}{
+		{input: "application/x-ndjson", expected: JSONL},
+		{input: "text/csv; charset=utf-8", expected: CSV},
+		{input: "application/yaml", expected: YAML},
+		{input: "application/json", isError: true},
+		{input: "", isError: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			f, err := FromContentType(tc.input)
+
+			if (err != nil) != tc.isError || f != tc.expected {
+				t.Errorf("expected format (%v) and error (%v), got (%v) and (%v)", tc.expected, tc.isError, f, err)
+			}
+		})
+	}
+}
+
+func TestDecoders(t *testing.T) {
+	testCases := []struct {
+		name     string
+		format   Format
+		input    string
+		bodies   []string
+		errCount int
+	}{
+		{
+			name:   "jsonl",
+			format: JSONL,
+			input: `{"body":"Where does the sun set?","options":[{"body":"East","correct":false},{"body":"West","correct":true}]}
+
+{"id":7,"body":"Where does the sun rise?","options":[]}`,
+			bodies: []string{"Where does the sun set?", "Where does the sun rise?"},
+		},
+		{
+			name:     "jsonl with invalid line",
+			format:   JSONL,
+			input:    "{\"body\":\"Where does the sun set?\"}\n{\"body\":\n{\"body\":\"Where does the sun rise?\"}",
+			bodies:   []string{"Where does the sun set?", "Where does the sun rise?"},
+			errCount: 1,
+		},
+		{
+			name:   "csv",
+			format: CSV,
+			input: `id,body,option,correct
+1,Where does the sun set?,East,false,West,true
+,"Where does the sun rise?, again",East,true,West,false,,`,
+			bodies: []string{"Where does the sun set?", "Where does the sun rise?, again"},
+		},
+		{
+			name:   "csv with invalid rows",
+			format: CSV,
+			input: `id,body,option,correct
+one,Where does the sun set?,East,false
+2,Where does the sun set?,East
+3,Where does the sun set?,East,maybe
+4,Where does the sun rise?,East,true`,
+			bodies:   []string{"Where does the sun rise?"},
+			errCount: 3,
+		},
+		{
+			name:   "csv with metadata",
+			format: CSV,
+			input: `id,body,status,created_at,option,correct
+1,Where does the sun set?,published,2021-03-01T12:00:00Z,East,false,West,true
+2,Where does the sun rise?,draft,yesterday,East,true`,
+			bodies:   []string{"Where does the sun set?"},
+			errCount: 1,
+		},
+		{
+			name:   "csv with difficulty",
+			format: CSV,
+			input: `id,body,difficulty,option,correct
+1,Where does the sun set?,3,East,false,West,true
+2,Where does the sun rise?,hard,East,true`,
+			bodies:   []string{"Where does the sun set?"},
+			errCount: 1,
+		},
+		{
+			name:   "yaml documents and lists",
+			format: YAML,
+			input: `body: Where does the sun set?
+options:
+  - body: East
+  - body: West
+    correct: true
+---
+- body: Where does the sun rise?
+- body: Where is the moon?
+`,
+			bodies: []string{"Where does the sun set?", "Where does the sun rise?", "Where is the moon?"},
+		},
+		{
+			name:   "yaml syntax error",
+			format: YAML,
+			input: `- body: Where does the sun set?
+---
+- body: [unclosed
+---
+- body: Where does the sun rise?
+`,
+			bodies:   []string{"Where does the sun set?"},
+			errCount: 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			d, err := NewDecoder(tc.format, strings.NewReader(tc.input))
+			if err != nil {
+				t.Fatalf("unable to create decoder: %s", err.Error())
+			}
+
+			bodies, errCount := readAll(t, d)
+
+			if strings.Join(bodies, "|") != strings.Join(tc.bodies, "|") || errCount != tc.errCount {
+				t.Errorf("expected bodies (%v) and (%d) errors, got (%v) and (%d)", tc.bodies, tc.errCount, bodies, errCount)
+			}
+		})
+	}
+}
+
+func TestCSVOptions(t *testing.T) {
+	d, err := NewDecoder(CSV, strings.NewReader("id,body,option,correct\n,Where does the sun set?,East,false,West,true\n"))
+	if err != nil {
+		t.Fatalf("unable to create decoder: %s", err.Error())
+	}
+
+	q, err := d.Read()
+	if err != nil {
+		t.Fatalf("unable to read question: %s", err.Error())
+	}
+
+	if len(q.Options) != 2 || q.Options[1].Body != "West" || !q.Options[1].Correct || q.Options[1].OptionOrder != 1 {
+		t.Errorf("unexpected options (%v)", q.Options)
+	}
+}
+
+func TestCSVHeader(t *testing.T) {
+	testCases := []struct {
+		input   string
+		isError bool
+	}{
+		{input: "id,body\n", isError: false},
+		{input: "ID, Body, Option, Correct, Option, Correct\n", isError: false},
+		{input: "body,id\n", isError: true},
+		{input: "id,body,option\n", isError: false},
+		{input: "id,body,correct\n", isError: true},
+		{input: "id,body,status,created_at,option,correct\n", isError: false},
+		{input: "id,body,tags,option,correct\n", isError: false},
+		{input: "id,body,difficulty,tags,option,correct\n", isError: false},
+		{input: "id,body,option,correct,status\n", isError: true},
+		{input: "", isError: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			_, err := NewDecoder(CSV, strings.NewReader(tc.input))
+
+			if (err != nil) != tc.isError {
+				t.Errorf("expected error (%v), got error (%v)", tc.isError, err)
+			}
+		})
+	}
+}
+
+func TestCSVHeaderReadError(t *testing.T) {
+	readErr := errors.New("http: request body too large")
+
+	_, err := NewDecoder(CSV, iotest.ErrReader(readErr))
+	if !errors.Is(err, readErr) {
+		t.Errorf("expected the read error (%v), got error (%v)", readErr, err)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	created := time.Date(2021, 3, 1, 12, 0, 0, 5, time.UTC)
+	updated := created.Add(time.Hour)
+
+	questions := []entities.Question{{
+		Id:            1,
+		Body:          "Where does the sun set?",
+		Tags:          []string{"geography", "solar system"},
+		Difficulty:    2,
+		Status:        entities.Published,
+		ReviewComment: "Clear, and correct",
+		CreatedAt:     &created,
+		CreatedBy:     "alice",
+		UpdatedAt:     &updated,
+		UpdatedBy:     "bob",
+		Options: []entities.Option{{
+			Body:    "East",
+			Correct: false,
+		}, {
+			Body:    "West, \"obviously\"",
+			Correct: true,
+		}},
+	}, {
+		Id:   2,
+		Body: "Which of these are planets?\nSelect all that apply.",
+		Options: []entities.Option{{
+			Body:    "Mars",
+			Correct: true,
+		}, {
+			Body:    "Pluto",
+			Correct: false,
+		}, {
+			Body:    "Venus",
+			Correct: true,
+		}},
+	}}
+
+	for _, f := range []Format{JSONL, CSV, YAML} {
+		t.Run(string(f), func(t *testing.T) {
+			var buf bytes.Buffer
+
+			enc, err := NewEncoder(f, &buf)
+			if err != nil {
+				t.Fatalf("unable to create encoder: %s", err.Error())
+			}
+
+			for _, q := range questions {
+				if err = enc.Write(q); err != nil {
+					t.Fatalf("unable to write question: %s", err.Error())
+				}
+			}
+
+			if err = enc.Close(); err != nil {
+				t.Fatalf("unable to close encoder: %s", err.Error())
+			}
+
+			dec, err := NewDecoder(f, &buf)
+			if err != nil {
+				t.Fatalf("unable to create decoder: %s", err.Error())
+			}
+
+			for _, expected := range questions {
+				q, err := dec.Read()
+				if err != nil {
+					t.Fatalf("unable to read question: %s", err.Error())
+				}
+
+				if q.Id != expected.Id || q.Body != expected.Body || len(q.Options) != len(expected.Options) {
+					t.Fatalf("expected question (%v), got (%v)", expected, q)
+				}
+
+				if strings.Join(q.Tags, "|") != strings.Join(expected.Tags, "|") || q.Difficulty != expected.Difficulty || q.Status != expected.Status || q.ReviewComment != expected.ReviewComment || q.CreatedBy != expected.CreatedBy || q.UpdatedBy != expected.UpdatedBy ||
+					!sameTime(q.CreatedAt, expected.CreatedAt) || !sameTime(q.UpdatedAt, expected.UpdatedAt) {
+					t.Errorf("expected question metadata (%v), got (%v)", expected, q)
+				}
+
+				for i, o := range q.Options {
+					if o.Body != expected.Options[i].Body || o.Correct != expected.Options[i].Correct || o.OptionOrder != i {
+						t.Errorf("expected option (%v), got (%v)", expected.Options[i], o)
+					}
+				}
+			}
+
+			if _, err = dec.Read(); err != io.EOF {
+				t.Errorf("expected end of stream, got error (%v)", err)
+			}
+		})
+	}
+}
+
+// sameTime reports whether a and b are both missing or the same instant
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	return a.Equal(*b)
+}
+
+func TestMarkdownEncoder(t *testing.T) {
+	var buf bytes.Buffer
+
+	enc, err := NewEncoder(Markdown, &buf)
+	if err != nil {
+		t.Fatalf("unable to create encoder: %s", err.Error())
+	}
+
+	err = enc.Write(entities.Question{Id: 3, Body: "Where does the sun set?", Options: []entities.Option{{Body: "East"}, {Body: "West", Correct: true}}, Tags: []string{"geography", "easy"}})
+	if err != nil {
+		t.Fatalf("unable to write question: %s", err.Error())
+	}
+
+	if err = enc.Close(); err != nil {
+		t.Fatalf("unable to close encoder: %s", err.Error())
+	}
+
+	expected := "## Question 3\n\nWhere does the sun set?\n\nTags: geography, easy\n\n- [ ] East\n- [x] West\n\n"
+	if buf.String() != expected {
+		t.Errorf("expected markdown (%q), got (%q)", expected, buf.String())
+	}
+
+	if _, err = NewDecoder(Markdown, &buf); !errors.Is(err, ImportFormatError) {
+		t.Errorf("expected error (%v), got error (%v)", ImportFormatError, err)
+	}
+}
@@ -0,0 +1,323 @@
+package format
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/norby7/questions-rest-api/entities"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// giftSpecialChars are the characters that have to be escaped inside GIFT texts
+const giftSpecialChars = `~=#{}:\`
+
+type giftEncoder struct {
+	writer *bufio.Writer
+}
+
+func newGIFTEncoder(w io.Writer) *giftEncoder {
+	return &giftEncoder{writer: bufio.NewWriter(w)}
+}
+
+// Quiz reports that the GIFT questions are served to candidates, only the published questions are written
+func (e *giftEncoder) Quiz() bool {
+	return true
+}
+
+// Check returns an error if the question grades can't be represented in GIFT
+func (e *giftEncoder) Check(q entities.Question) error {
+	return checkMoodleQuestion(q)
+}
+
+// Write encodes the question as a GIFT multiple choice question
+func (e *giftEncoder) Write(q entities.Question) error {
+	if err := e.Check(q); err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "// question: %d\n", q.Id)
+	fmt.Fprintf(&b, "::%s::[plain]%s {\n", giftEscape(questionTitle(q)), giftEscape(q.Body))
+
+	for _, o := range q.Options {
+		switch {
+		case q.Type() == entities.SingleChoice && o.Correct:
+			b.WriteString("\t=")
+		case q.Type() == entities.SingleChoice:
+			b.WriteString("\t~")
+		default:
+			fmt.Fprintf(&b, "\t~%%%s%%", formatFraction(moodleFraction(q, o)))
+		}
+
+		b.WriteString(giftEscape(o.Body))
+		b.WriteString("\n")
+	}
+
+	b.WriteString("}\n\n")
+
+	_, err := e.writer.WriteString(b.String())
+
+	return err
+}
+
+// Close flushes the buffered output to the underlying writer
+func (e *giftEncoder) Close() error {
+	return e.writer.Flush()
+}
+
+// giftEscape escapes the GIFT special characters and line breaks of s
+func giftEscape(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '\n':
+			b.WriteString(`\n`)
+		case r == '\r':
+		case strings.ContainsRune(giftSpecialChars, r):
+			b.WriteRune('\\')
+			b.WriteRune(r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+
+	return b.String()
+}
+
+// giftUnescape reverses giftEscape
+func giftUnescape(s string) string {
+	var b strings.Builder
+	escaped := false
+	for _, r := range s {
+		switch {
+		case escaped && r == 'n':
+			b.WriteRune('\n')
+		case escaped:
+			b.WriteRune(r)
+		case r == '\\':
+			escaped = true
+			continue
+		default:
+			b.WriteRune(r)
+		}
+
+		escaped = false
+	}
+
+	return b.String()
+}
+
+type giftDecoder struct {
+	scanner  *bufio.Scanner
+	question int
+	done     bool
+}
+
+func newGIFTDecoder(r io.Reader) *giftDecoder {
+	s := bufio.NewScanner(r)
+	s.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+
+	return &giftDecoder{scanner: s}
+}
+
+// Read parses the next blank line separated GIFT question
+// Only multiple choice and true/false questions are supported, other question types are reported as errors.
+func (d *giftDecoder) Read() (entities.Question, error) {
+	for !d.done {
+		block, err := d.nextBlock()
+		if err != nil {
+			d.done = true
+			return entities.Question{}, fmt.Errorf("unable to read gift stream: %s", err.Error())
+		}
+
+		if block == "" {
+			d.done = true
+			break
+		}
+
+		d.question++
+
+		q, err := parseGIFTQuestion(block)
+		if err != nil {
+			return entities.Question{}, fmt.Errorf("question %d: %s", d.question, err.Error())
+		}
+
+		return q, nil
+	}
+
+	return entities.Question{}, io.EOF
+}
+
+// nextBlock returns the lines of the next question, skipping comments and category commands
+func (d *giftDecoder) nextBlock() (string, error) {
+	var lines []string
+	for d.scanner.Scan() {
+		line := strings.TrimSpace(d.scanner.Text())
+
+		switch {
+		case strings.HasPrefix(line, "//"), strings.HasPrefix(line, "$CATEGORY:"):
+			continue
+		case line == "" && len(lines) > 0:
+			return strings.Join(lines, "\n"), nil
+		case line != "":
+			lines = append(lines, line)
+		}
+	}
+
+	return strings.Join(lines, "\n"), d.scanner.Err()
+}
+
+// parseGIFTQuestion converts a GIFT question block into a question entity
+func parseGIFTQuestion(block string) (entities.Question, error) {
+	// remove the optional question title
+	if strings.HasPrefix(block, "::") {
+		end := giftIndex(block[2:], "::")
+		if end < 0 {
+			return entities.Question{}, fmt.Errorf("unterminated question title")
+		}
+
+		block = block[end+4:]
+	}
+
+	start := giftIndex(block, "{")
+	if start < 0 {
+		return entities.Question{}, fmt.Errorf("unsupported gift question type: description")
+	}
+
+	end := giftIndex(block[start:], "}")
+	if end < 0 {
+		return entities.Question{}, fmt.Errorf("unterminated answers block")
+	}
+
+	end += start
+	body := strings.TrimSpace(block[:start]) + " " + strings.TrimSpace(block[end+1:])
+	body = strings.TrimSpace(giftStripTextFormat(body))
+
+	q := entities.Question{Body: giftUnescape(body)}
+
+	answers := strings.TrimSpace(block[start+1 : end])
+	switch strings.ToUpper(answers) {
+	case "T", "TRUE", "F", "FALSE":
+		correct := strings.HasPrefix(strings.ToUpper(answers), "T")
+		q.Options = []entities.Option{
+			{Body: "True", Correct: correct, OptionOrder: 0},
+			{Body: "False", Correct: !correct, OptionOrder: 1},
+		}
+		return q, nil
+	case "":
+		return entities.Question{}, fmt.Errorf("unsupported gift question type: essay")
+	}
+
+	if giftIndex(answers, "->") >= 0 {
+		return entities.Question{}, fmt.Errorf("unsupported gift question type: matching")
+	}
+
+	if strings.HasPrefix(answers, "#") {
+		return entities.Question{}, fmt.Errorf("unsupported gift question type: numerical")
+	}
+
+	wrongAnswer := false
+	for i, a := range giftSplitAnswers(answers) {
+		o, err := parseGIFTAnswer(a)
+		if err != nil {
+			return entities.Question{}, fmt.Errorf("answer %d: %s", i+1, err.Error())
+		}
+
+		wrongAnswer = wrongAnswer || !o.Correct
+		o.OptionOrder = i
+		q.Options = append(q.Options, o)
+	}
+
+	if !wrongAnswer {
+		return entities.Question{}, fmt.Errorf("unsupported gift question type: short answer")
+	}
+
+	return q, nil
+}
+
+// parseGIFTAnswer converts a single =answer or ~answer, with optional weight and feedback, into an option
+func parseGIFTAnswer(a string) (entities.Option, error) {
+	var o entities.Option
+
+	prefix, a := a[0], strings.TrimSpace(a[1:])
+	o.Correct = prefix == '='
+
+	if strings.HasPrefix(a, "%") {
+		end := strings.Index(a[1:], "%")
+		if end < 0 {
+			return o, fmt.Errorf("unterminated answer weight")
+		}
+
+		w, err := strconv.ParseFloat(a[1:end+1], 64)
+		if err != nil {
+			return o, fmt.Errorf("invalid answer weight: %s", err.Error())
+		}
+
+		o.Correct = w > 0
+		a = strings.TrimSpace(a[end+2:])
+	}
+
+	// drop the answer feedback
+	if i := giftIndex(a, "#"); i >= 0 {
+		a = a[:i]
+	}
+
+	o.Body = giftUnescape(strings.TrimSpace(a))
+
+	return o, nil
+}
+
+// giftSplitAnswers splits the content of an answers block at every unescaped = or ~
+func giftSplitAnswers(s string) []string {
+	var answers []string
+	start := -1
+	escaped := false
+	for i, r := range s {
+		switch {
+		case escaped:
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == '=' || r == '~':
+			if start >= 0 {
+				answers = append(answers, s[start:i])
+			}
+			start = i
+		}
+	}
+
+	if start >= 0 {
+		answers = append(answers, s[start:])
+	}
+
+	return answers
+}
+
+// giftIndex returns the index of the first unescaped occurrence of sub in s, or -1
+func giftIndex(s, sub string) int {
+	escaped := false
+	for i, r := range s {
+		switch {
+		case escaped:
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case strings.HasPrefix(s[i:], sub):
+			return i
+		}
+	}
+
+	return -1
+}
+
+// giftStripTextFormat removes the optional [plain], [html], [moodle] or [markdown] text format marker
+func giftStripTextFormat(s string) string {
+	for _, f := range []string{"[plain]", "[html]", "[moodle]", "[markdown]"} {
+		if strings.HasPrefix(s, f) {
+			return s[len(f):]
+		}
+	}
+
+	return s
+}
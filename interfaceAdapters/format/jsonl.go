@@ -0,0 +1,78 @@
+package format
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"github.com/norby7/questions-rest-api/entities"
+	"io"
+	"strings"
+)
+
+// maxLineSize is the longest JSONL line accepted by the decoder
+const maxLineSize = 1024 * 1024
+
+type jsonlDecoder struct {
+	scanner *bufio.Scanner
+	line    int
+	done    bool
+}
+
+func newJSONLDecoder(r io.Reader) *jsonlDecoder {
+	s := bufio.NewScanner(r)
+	s.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+
+	return &jsonlDecoder{scanner: s}
+}
+
+// Read decodes the next non empty line of the stream into a question
+func (d *jsonlDecoder) Read() (entities.Question, error) {
+	if d.done {
+		return entities.Question{}, io.EOF
+	}
+
+	for d.scanner.Scan() {
+		d.line++
+
+		line := strings.TrimSpace(d.scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		var r record
+		if err := json.Unmarshal([]byte(line), &r); err != nil {
+			return entities.Question{}, fmt.Errorf("line %d: unable to parse question: %s", d.line, err.Error())
+		}
+
+		return r.toQuestion(), nil
+	}
+
+	// the scanner can't recover from read errors, so the stream ends after reporting it
+	d.done = true
+	if err := d.scanner.Err(); err != nil {
+		return entities.Question{}, fmt.Errorf("line %d: unable to read stream: %s", d.line+1, err.Error())
+	}
+
+	return entities.Question{}, io.EOF
+}
+
+type jsonlEncoder struct {
+	encoder *json.Encoder
+}
+
+func newJSONLEncoder(w io.Writer) *jsonlEncoder {
+	e := json.NewEncoder(w)
+	e.SetEscapeHTML(false)
+
+	return &jsonlEncoder{encoder: e}
+}
+
+// Write encodes the question as a single JSON line
+func (e *jsonlEncoder) Write(q entities.Question) error {
+	return e.encoder.Encode(newRecord(q))
+}
+
+// Close does nothing, the JSONL encoder doesn't buffer its output
+func (e *jsonlEncoder) Close() error {
+	return nil
+}
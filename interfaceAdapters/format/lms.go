@@ -0,0 +1,91 @@
+package format
+
+import (
+	"fmt"
+	"github.com/norby7/questions-rest-api/entities"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	UnrepresentableError = fmt.Errorf("question can't be represented in the target format")
+)
+
+// moodleFractions are the grade percentages accepted by Moodle for a single answer
+var moodleFractions = []float64{100, 90, 83.33333, 80, 75, 70, 66.66667, 60, 50, 40, 33.33333, 30, 25, 20, 16.66667, 14.28571, 12.5, 11.11111, 10, 5}
+
+// moodleFraction returns the grade percentage of an option of the question, as accepted by Moodle
+// The correct options of a multiple choice question share the grade, while choosing a wrong option cancels it.
+func moodleFraction(q entities.Question, o entities.Option) float64 {
+	switch {
+	case o.Correct:
+		return 100 / float64(q.CorrectCount())
+	case q.Type() == entities.MultipleChoice:
+		return -100
+	}
+
+	return 0
+}
+
+// formatFraction formats a grade percentage the same way Moodle does, using at most 5 decimals
+func formatFraction(f float64) string {
+	s := strconv.FormatFloat(f, 'f', 5, 64)
+	s = strings.TrimRight(s, "0")
+
+	return strings.TrimSuffix(s, ".")
+}
+
+// checkMoodleQuestion checks that the question grades can be expressed with the percentages accepted by Moodle
+func checkMoodleQuestion(q entities.Question) error {
+	if q.CorrectCount() == 0 {
+		return fmt.Errorf("%w: the question has no correct option", UnrepresentableError)
+	}
+
+	f := 100 / float64(q.CorrectCount())
+	for _, mf := range moodleFractions {
+		if math.Abs(f-mf) < 0.0001 {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%w: %d correct options can't share the grade using the percentages accepted by Moodle", UnrepresentableError, q.CorrectCount())
+}
+
+// checkXMLText checks that the question and options bodies only contain characters allowed in XML 1.0 documents
+func checkXMLText(q entities.Question) error {
+	if err := xmlTextError(q.Body); err != nil {
+		return fmt.Errorf("%w: question body %s", UnrepresentableError, err.Error())
+	}
+
+	for i, o := range q.Options {
+		if err := xmlTextError(o.Body); err != nil {
+			return fmt.Errorf("%w: option %d body %s", UnrepresentableError, i+1, err.Error())
+		}
+	}
+
+	return nil
+}
+
+// xmlTextError returns an error describing the first character of s that isn't allowed in XML 1.0
+func xmlTextError(s string) error {
+	if !utf8.ValidString(s) {
+		return fmt.Errorf("is not valid UTF-8")
+	}
+
+	for i, r := range s {
+		valid := r == '\t' || r == '\n' || r == '\r' ||
+			(r >= 0x20 && r <= 0xD7FF) || (r >= 0xE000 && r <= 0xFFFD) || (r >= 0x10000 && r <= 0x10FFFF)
+		if !valid {
+			return fmt.Errorf("contains the character %U at position %d", r, i)
+		}
+	}
+
+	return nil
+}
+
+// questionTitle returns the name used for the question inside LMS exports
+func questionTitle(q entities.Question) string {
+	return fmt.Sprintf("Question %d", q.Id)
+}
@@ -0,0 +1,331 @@
+package format
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"github.com/norby7/questions-rest-api/entities"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func lmsQuestions() []entities.Question {
+	return []entities.Question{{
+		Id:   1,
+		Body: "Where does the sun set? {hint: ~west}",
+		Options: []entities.Option{{
+			Body:    "East",
+			Correct: false,
+		}, {
+			Body:    "West = sunset",
+			Correct: true,
+		}},
+	}, {
+		Id:   2,
+		Body: "Which of these are planets?\nSelect all that apply.",
+		Options: []entities.Option{{
+			Body:    "Mars",
+			Correct: true,
+		}, {
+			Body:    "Pluto",
+			Correct: false,
+		}, {
+			Body:    "Venus",
+			Correct: true,
+		}, {
+			Body:    "Saturn",
+			Correct: true,
+		}},
+	}}
+}
+
+func TestLMSRoundTrip(t *testing.T) {
+	for _, f := range []Format{Moodle, GIFT} {
+		t.Run(string(f), func(t *testing.T) {
+			var buf bytes.Buffer
+
+			enc, err := NewEncoder(f, &buf)
+			if err != nil {
+				t.Fatalf("unable to create encoder: %s", err.Error())
+			}
+
+			for _, q := range lmsQuestions() {
+				if err = enc.Write(q); err != nil {
+					t.Fatalf("unable to write question: %s", err.Error())
+				}
+			}
+
+			if err = enc.Close(); err != nil {
+				t.Fatalf("unable to close encoder: %s", err.Error())
+			}
+
+			dec, err := NewDecoder(f, &buf)
+			if err != nil {
+				t.Fatalf("unable to create decoder: %s", err.Error())
+			}
+
+			for _, expected := range lmsQuestions() {
+				q, err := dec.Read()
+				if err != nil {
+					t.Fatalf("unable to read question: %s", err.Error())
+				}
+
+				if q.Body != expected.Body || len(q.Options) != len(expected.Options) {
+					t.Fatalf("expected question (%v), got (%v)", expected, q)
+				}
+
+				for i, o := range q.Options {
+					if o.Body != expected.Options[i].Body || o.Correct != expected.Options[i].Correct {
+						t.Errorf("expected option (%v), got (%v)", expected.Options[i], o)
+					}
+				}
+			}
+
+			if _, err = dec.Read(); err != io.EOF {
+				t.Errorf("expected end of stream, got error (%v)", err)
+			}
+		})
+	}
+}
+
+func TestMoodleFractions(t *testing.T) {
+	var buf bytes.Buffer
+
+	enc, _ := NewEncoder(Moodle, &buf)
+	for _, q := range lmsQuestions() {
+		if err := enc.Write(q); err != nil {
+			t.Fatalf("unable to write question: %s", err.Error())
+		}
+	}
+	_ = enc.Close()
+
+	for _, expected := range []string{`<single>true</single>`, `<single>false</single>`, `fraction="100"`, `fraction="33.33333"`, `fraction="-100"`, `</quiz>`} {
+		if !strings.Contains(buf.String(), expected) {
+			t.Errorf("expected moodle xml to contain (%v), got (%v)", expected, buf.String())
+		}
+	}
+}
+
+func TestLMSChecks(t *testing.T) {
+	var manyCorrect []entities.Option
+	for i := 0; i < 11; i++ {
+		manyCorrect = append(manyCorrect, entities.Option{Body: "correct", Correct: true})
+	}
+
+	testCases := []struct {
+		name     string
+		question entities.Question
+		formats  []Format
+	}{
+		{
+			name:     "no correct option",
+			question: entities.Question{Body: "Where does the sun set?", Options: []entities.Option{{Body: "East"}, {Body: "North"}}},
+			formats:  []Format{Moodle, GIFT, QTI},
+		},
+		{
+			name:     "grade can't be split",
+			question: entities.Question{Body: "Which of these are correct?", Options: append(manyCorrect, entities.Option{Body: "wrong"})},
+			formats:  []Format{Moodle, GIFT},
+		},
+		{
+			name:     "control character",
+			question: entities.Question{Body: "Where does the sun set?\x07", Options: []entities.Option{{Body: "East"}, {Body: "West", Correct: true}}},
+			formats:  []Format{Moodle, QTI},
+		},
+	}
+
+	for _, tc := range testCases {
+		for _, f := range tc.formats {
+			t.Run(tc.name+" "+string(f), func(t *testing.T) {
+				enc, _ := NewEncoder(f, ioutil.Discard)
+
+				c, ok := enc.(Checker)
+				if !ok {
+					t.Fatalf("expected %s encoder to implement Checker", f)
+				}
+
+				if err := c.Check(tc.question); !errors.Is(err, UnrepresentableError) {
+					t.Errorf("expected error (%v), got error (%v)", UnrepresentableError, err)
+				}
+
+				if err := enc.Write(tc.question); !errors.Is(err, UnrepresentableError) {
+					t.Errorf("expected error (%v), got error (%v)", UnrepresentableError, err)
+				}
+			})
+		}
+	}
+}
+
+func TestQuizFormats(t *testing.T) {
+	for _, f := range []Format{JSONL, CSV, YAML, Markdown, Moodle, GIFT, QTI} {
+		t.Run(string(f), func(t *testing.T) {
+			enc, _ := NewEncoder(f, ioutil.Discard)
+
+			q, ok := enc.(Quiz)
+			quiz := ok && q.Quiz()
+			if expected := f == Moodle || f == GIFT || f == QTI; quiz != expected {
+				t.Errorf("expected quiz (%v), got quiz (%v)", expected, quiz)
+			}
+		})
+	}
+}
+
+func TestGIFTDecoder(t *testing.T) {
+	input := `// comment
+$CATEGORY: $course$/Geography
+
+::Sun::Where does the sun set?{=West ~East#wrong}
+
+The sun rises in the east.{T}
+
+Which are planets?{
+	~%50%Mars
+	~%50%Venus
+	~%-100%Pluto
+}
+
+Two plus two equals {=four =4}.
+
+Match the capitals.{=France -> Paris =Italy -> Rome}
+
+Write an essay.{}
+
+Just a description.
+`
+
+	d, err := NewDecoder(GIFT, strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("unable to create decoder: %s", err.Error())
+	}
+
+	expected := []struct {
+		body     string
+		options  []string
+		correct  int
+		hasError bool
+	}{
+		{body: "Where does the sun set?", options: []string{"West", "East"}, correct: 1},
+		{body: "The sun rises in the east.", options: []string{"True", "False"}, correct: 1},
+		{body: "Which are planets?", options: []string{"Mars", "Venus", "Pluto"}, correct: 2},
+		{hasError: true},
+		{hasError: true},
+		{hasError: true},
+		{hasError: true},
+	}
+
+	for i, e := range expected {
+		q, err := d.Read()
+		if (err != nil) != e.hasError {
+			t.Fatalf("question %d: expected error (%v), got error (%v)", i+1, e.hasError, err)
+		}
+
+		if e.hasError {
+			continue
+		}
+
+		var options []string
+		for _, o := range q.Options {
+			options = append(options, o.Body)
+		}
+
+		if q.Body != e.body || strings.Join(options, "|") != strings.Join(e.options, "|") || q.CorrectCount() != e.correct {
+			t.Errorf("question %d: expected (%v) (%v) with (%d) correct, got (%v)", i+1, e.body, e.options, e.correct, q)
+		}
+	}
+
+	if _, err = d.Read(); err != io.EOF {
+		t.Errorf("expected end of stream, got error (%v)", err)
+	}
+}
+
+func TestMoodleDecoder(t *testing.T) {
+	input := `<?xml version="1.0" encoding="UTF-8"?>
+<quiz>
+  <question type="category"><category><text>$course$/Geography</text></category></question>
+  <question type="multichoice">
+    <name><text>Sun</text></name>
+    <questiontext format="html"><text><![CDATA[<p>Where does the sun &amp; moon set?</p>]]></text></questiontext>
+    <answer fraction="0"><text>East</text></answer>
+    <answer fraction="100"><text>West</text></answer>
+  </question>
+  <question type="truefalse">
+    <questiontext format="plain_text"><text>The sun rises in the east.</text></questiontext>
+    <answer fraction="100"><text>true</text></answer>
+    <answer fraction="0"><text>false</text></answer>
+  </question>
+  <question type="essay">
+    <questiontext><text>Describe the sun.</text></questiontext>
+  </question>
+</quiz>`
+
+	d, err := NewDecoder(Moodle, strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("unable to create decoder: %s", err.Error())
+	}
+
+	q, err := d.Read()
+	if err != nil || q.Body != "Where does the sun & moon set?" || len(q.Options) != 2 || !q.Options[1].Correct {
+		t.Errorf("unexpected multichoice question (%v) with error (%v)", q, err)
+	}
+
+	q, err = d.Read()
+	if err != nil || len(q.Options) != 2 || !q.Options[0].Correct {
+		t.Errorf("unexpected truefalse question (%v) with error (%v)", q, err)
+	}
+
+	if _, err = d.Read(); err == nil {
+		t.Errorf("expected unsupported question type error, got nil")
+	}
+
+	if _, err = d.Read(); err != io.EOF {
+		t.Errorf("expected end of stream, got error (%v)", err)
+	}
+}
+
+func TestQTIPackage(t *testing.T) {
+	var buf bytes.Buffer
+
+	enc, _ := NewEncoder(QTI, &buf)
+	for _, q := range lmsQuestions() {
+		if err := enc.Write(q); err != nil {
+			t.Fatalf("unable to write question: %s", err.Error())
+		}
+	}
+
+	if err := enc.Close(); err != nil {
+		t.Fatalf("unable to close encoder: %s", err.Error())
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("unable to open package: %s", err.Error())
+	}
+
+	files := map[string]string{}
+	for _, f := range zr.File {
+		rc, _ := f.Open()
+		b, _ := ioutil.ReadAll(rc)
+		_ = rc.Close()
+		files[f.Name] = string(b)
+	}
+
+	expected := map[string][]string{
+		"items/Q1.xml":    {`cardinality="single"`, `maxChoices="1"`, `<value>CHOICE_2</value>`, `<prompt>Where does the sun set? {hint: ~west}</prompt>`},
+		"items/Q2.xml":    {`cardinality="multiple"`, `maxChoices="0"`, `<value>CHOICE_1</value>`, `<value>CHOICE_3</value>`, `<value>CHOICE_4</value>`},
+		"imsmanifest.xml": {`href="items/Q1.xml"`, `href="items/Q2.xml"`, `type="imsqti_item_xmlv2p1"`},
+	}
+
+	for name, contents := range expected {
+		for _, c := range contents {
+			if !strings.Contains(files[name], c) {
+				t.Errorf("expected %s to contain (%v), got (%v)", name, c, files[name])
+			}
+		}
+	}
+
+	if _, err = NewDecoder(QTI, &buf); !errors.Is(err, ImportFormatError) {
+		t.Errorf("expected error (%v), got error (%v)", ImportFormatError, err)
+	}
+}
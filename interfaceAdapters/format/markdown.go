@@ -0,0 +1,55 @@
+package format
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/norby7/questions-rest-api/entities"
+	"io"
+	"strings"
+)
+
+type markdownEncoder struct {
+	writer *bufio.Writer
+}
+
+func newMarkdownEncoder(w io.Writer) *markdownEncoder {
+	return &markdownEncoder{writer: bufio.NewWriter(w)}
+}
+
+// Write renders the question as a markdown section with its tags and a task list of its options, correct options are checked
+func (e *markdownEncoder) Write(q entities.Question) error {
+	if _, err := fmt.Fprintf(e.writer, "## Question %d\n\n%s\n\n", q.Id, markdownText(q.Body)); err != nil {
+		return err
+	}
+
+	if len(q.Tags) > 0 {
+		if _, err := fmt.Fprintf(e.writer, "Tags: %s\n\n", markdownText(strings.Join(q.Tags, ", "))); err != nil {
+			return err
+		}
+	}
+
+	for _, o := range q.Options {
+		mark := " "
+		if o.Correct {
+			mark = "x"
+		}
+
+		if _, err := fmt.Fprintf(e.writer, "- [%s] %s\n", mark, markdownText(o.Body)); err != nil {
+			return err
+		}
+	}
+
+	_, err := e.writer.WriteString("\n")
+
+	return err
+}
+
+// Close flushes the buffered output to the underlying writer
+func (e *markdownEncoder) Close() error {
+	return e.writer.Flush()
+}
+
+// markdownText keeps multiline bodies inside their list item or paragraph
+func markdownText(s string) string {
+	return strings.ReplaceAll(strings.TrimSpace(s), "\n", "  \n  ")
+}
@@ -0,0 +1,212 @@
+package format
+
+import (
+	"encoding/xml"
+	"fmt"
+	"github.com/norby7/questions-rest-api/entities"
+	"html"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// moodleText is a text element of a Moodle XML document, with an optional format attribute
+type moodleText struct {
+	Format string `xml:"format,attr,omitempty"`
+	Text   string `xml:"text"`
+}
+
+// moodleAnswer is an answer of a Moodle XML question
+type moodleAnswer struct {
+	Fraction string `xml:"fraction,attr"`
+	Format   string `xml:"format,attr,omitempty"`
+	Text     string `xml:"text"`
+}
+
+// moodleQuestion is a question of a Moodle XML document
+type moodleQuestion struct {
+	XMLName        xml.Name       `xml:"question"`
+	Type           string         `xml:"type,attr"`
+	Name           moodleText     `xml:"name"`
+	QuestionText   moodleText     `xml:"questiontext"`
+	DefaultGrade   string         `xml:"defaultgrade,omitempty"`
+	Single         string         `xml:"single,omitempty"`
+	ShuffleAnswers string         `xml:"shuffleanswers,omitempty"`
+	Numbering      string         `xml:"answernumbering,omitempty"`
+	Answers        []moodleAnswer `xml:"answer"`
+}
+
+type moodleEncoder struct {
+	writer  io.Writer
+	encoder *xml.Encoder
+	started bool
+}
+
+func newMoodleEncoder(w io.Writer) *moodleEncoder {
+	e := xml.NewEncoder(w)
+	e.Indent("", "  ")
+
+	return &moodleEncoder{writer: w, encoder: e}
+}
+
+// Quiz reports that the Moodle XML questions are served to candidates, only the published questions are written
+func (e *moodleEncoder) Quiz() bool {
+	return true
+}
+
+// Check returns an error if the question can't be represented as a Moodle multichoice question
+func (e *moodleEncoder) Check(q entities.Question) error {
+	if err := checkXMLText(q); err != nil {
+		return err
+	}
+
+	return checkMoodleQuestion(q)
+}
+
+// start writes the XML declaration and opens the quiz element
+func (e *moodleEncoder) start() error {
+	if e.started {
+		return nil
+	}
+
+	e.started = true
+	if _, err := io.WriteString(e.writer, xml.Header); err != nil {
+		return err
+	}
+
+	return e.encoder.EncodeToken(xml.StartElement{Name: xml.Name{Local: "quiz"}})
+}
+
+// Write encodes the question as a Moodle multichoice question
+func (e *moodleEncoder) Write(q entities.Question) error {
+	if err := e.Check(q); err != nil {
+		return err
+	}
+
+	if err := e.start(); err != nil {
+		return err
+	}
+
+	mq := moodleQuestion{
+		Type:           "multichoice",
+		Name:           moodleText{Text: questionTitle(q)},
+		QuestionText:   moodleText{Format: "plain_text", Text: q.Body},
+		DefaultGrade:   "1",
+		Single:         strconv.FormatBool(q.Type() == entities.SingleChoice),
+		ShuffleAnswers: "true",
+		Numbering:      "abc",
+	}
+
+	for _, o := range q.Options {
+		mq.Answers = append(mq.Answers, moodleAnswer{
+			Fraction: formatFraction(moodleFraction(q, o)),
+			Format:   "plain_text",
+			Text:     o.Body,
+		})
+	}
+
+	return e.encoder.Encode(mq)
+}
+
+// Close closes the quiz element and flushes the document
+func (e *moodleEncoder) Close() error {
+	if err := e.start(); err != nil {
+		return err
+	}
+
+	if err := e.encoder.EncodeToken(xml.EndElement{Name: xml.Name{Local: "quiz"}}); err != nil {
+		return err
+	}
+
+	if err := e.encoder.Flush(); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(e.writer, "\n")
+
+	return err
+}
+
+type moodleDecoder struct {
+	decoder  *xml.Decoder
+	question int
+	done     bool
+}
+
+func newMoodleDecoder(r io.Reader) *moodleDecoder {
+	return &moodleDecoder{decoder: xml.NewDecoder(r)}
+}
+
+// Read decodes the next question element of the document
+// Category pseudo questions are skipped, question types other than multichoice and truefalse are reported as errors.
+func (d *moodleDecoder) Read() (entities.Question, error) {
+	for !d.done {
+		t, err := d.decoder.Token()
+		if err != nil {
+			// the XML decoder can't resume after an error, so the stream ends after reporting it
+			d.done = true
+			if err == io.EOF {
+				break
+			}
+
+			return entities.Question{}, fmt.Errorf("unable to parse moodle xml: %s", err.Error())
+		}
+
+		se, ok := t.(xml.StartElement)
+		if !ok || se.Name.Local != "question" {
+			continue
+		}
+
+		var mq moodleQuestion
+		if err = d.decoder.DecodeElement(&mq, &se); err != nil {
+			d.done = true
+			return entities.Question{}, fmt.Errorf("unable to parse moodle xml question: %s", err.Error())
+		}
+
+		if mq.Type == "category" {
+			continue
+		}
+
+		d.question++
+
+		return mq.toQuestion(d.question)
+	}
+
+	return entities.Question{}, io.EOF
+}
+
+// toQuestion converts a Moodle question into a question entity
+func (mq moodleQuestion) toQuestion(n int) (entities.Question, error) {
+	if mq.Type != "multichoice" && mq.Type != "truefalse" {
+		return entities.Question{}, fmt.Errorf("question %d: unsupported moodle question type %q", n, mq.Type)
+	}
+
+	q := entities.Question{Body: moodlePlainText(mq.QuestionText)}
+	for i, a := range mq.Answers {
+		f, err := strconv.ParseFloat(strings.TrimSpace(a.Fraction), 64)
+		if err != nil {
+			return entities.Question{}, fmt.Errorf("question %d: invalid fraction for answer %d: %s", n, i+1, err.Error())
+		}
+
+		q.Options = append(q.Options, entities.Option{
+			Body:        moodlePlainText(moodleText{Format: a.Format, Text: a.Text}),
+			Correct:     f > 0,
+			OptionOrder: i,
+		})
+	}
+
+	return q, nil
+}
+
+var htmlTagPattern = regexp.MustCompile(`<[^>]*>`)
+
+// moodlePlainText returns the text content of a Moodle text element, stripping the markup of html texts
+func moodlePlainText(t moodleText) string {
+	s := strings.TrimSpace(t.Text)
+	if t.Format == "" || t.Format == "html" {
+		s = strings.TrimSpace(html.UnescapeString(htmlTagPattern.ReplaceAllString(s, "")))
+	}
+
+	return s
+}
@@ -0,0 +1,186 @@
+package format
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"github.com/norby7/questions-rest-api/entities"
+	"io"
+)
+
+const (
+	qtiNamespace      = "http://www.imsglobal.org/xsd/imsqti_v2p1"
+	qtiSchemaLocation = "http://www.imsglobal.org/xsd/imsqti_v2p1 http://www.imsglobal.org/xsd/qti/qtiv2p1/imsqti_v2p1.xsd"
+	qtiMatchCorrect   = "http://www.imsglobal.org/question/qti_v2p1/rptemplates/match_correct"
+	imsCPNamespace    = "http://www.imsglobal.org/xsd/imscp_v1p1"
+	qtiItemType       = "imsqti_item_xmlv2p1"
+)
+
+// qtiItem is a QTI 2.1 assessment item holding a single choice interaction
+type qtiItem struct {
+	XMLName        xml.Name    `xml:"assessmentItem"`
+	Namespace      string      `xml:"xmlns,attr"`
+	XSI            string      `xml:"xmlns:xsi,attr"`
+	SchemaLocation string      `xml:"xsi:schemaLocation,attr"`
+	Identifier     string      `xml:"identifier,attr"`
+	Title          string      `xml:"title,attr"`
+	Adaptive       bool        `xml:"adaptive,attr"`
+	TimeDependent  bool        `xml:"timeDependent,attr"`
+	Response       qtiResponse `xml:"responseDeclaration"`
+	Outcome        qtiOutcome  `xml:"outcomeDeclaration"`
+	Interaction    qtiChoice   `xml:"itemBody>choiceInteraction"`
+	Processing     qtiTemplate `xml:"responseProcessing"`
+}
+
+type qtiResponse struct {
+	Identifier  string   `xml:"identifier,attr"`
+	Cardinality string   `xml:"cardinality,attr"`
+	BaseType    string   `xml:"baseType,attr"`
+	Correct     []string `xml:"correctResponse>value"`
+}
+
+type qtiOutcome struct {
+	Identifier  string `xml:"identifier,attr"`
+	Cardinality string `xml:"cardinality,attr"`
+	BaseType    string `xml:"baseType,attr"`
+	Default     string `xml:"defaultValue>value"`
+}
+
+type qtiChoice struct {
+	ResponseIdentifier string            `xml:"responseIdentifier,attr"`
+	Shuffle            bool              `xml:"shuffle,attr"`
+	MaxChoices         int               `xml:"maxChoices,attr"`
+	Prompt             string            `xml:"prompt"`
+	Choices            []qtiSimpleChoice `xml:"simpleChoice"`
+}
+
+type qtiSimpleChoice struct {
+	Identifier string `xml:"identifier,attr"`
+	Text       string `xml:",chardata"`
+}
+
+type qtiTemplate struct {
+	Template string `xml:"template,attr"`
+}
+
+// qtiManifest is the IMS content package manifest listing every item of the package
+type qtiManifest struct {
+	XMLName       xml.Name      `xml:"manifest"`
+	Namespace     string        `xml:"xmlns,attr"`
+	Identifier    string        `xml:"identifier,attr"`
+	Organizations struct{}      `xml:"organizations"`
+	Resources     []qtiResource `xml:"resources>resource"`
+}
+
+type qtiResource struct {
+	Identifier string `xml:"identifier,attr"`
+	Type       string `xml:"type,attr"`
+	Href       string `xml:"href,attr"`
+	File       struct {
+		Href string `xml:"href,attr"`
+	} `xml:"file"`
+}
+
+// qtiEncoder writes an IMS content package, a zip archive with one QTI 2.1 item file per question and a manifest
+type qtiEncoder struct {
+	archive   *zip.Writer
+	resources []qtiResource
+}
+
+func newQTIEncoder(w io.Writer) *qtiEncoder {
+	return &qtiEncoder{archive: zip.NewWriter(w)}
+}
+
+// Quiz reports that the QTI questions are served to candidates, only the published questions are written
+func (e *qtiEncoder) Quiz() bool {
+	return true
+}
+
+// Check returns an error if the question can't be represented as a QTI choice interaction
+func (e *qtiEncoder) Check(q entities.Question) error {
+	if q.CorrectCount() == 0 {
+		return fmt.Errorf("%w: the question has no correct option", UnrepresentableError)
+	}
+
+	return checkXMLText(q)
+}
+
+// Write adds the question to the package as a separate item file
+func (e *qtiEncoder) Write(q entities.Question) error {
+	if err := e.Check(q); err != nil {
+		return err
+	}
+
+	id := fmt.Sprintf("ITEM_%d", len(e.resources)+1)
+	if q.Id > 0 {
+		id = fmt.Sprintf("Q%d", q.Id)
+	}
+
+	item := qtiItem{
+		Namespace:      qtiNamespace,
+		XSI:            "http://www.w3.org/2001/XMLSchema-instance",
+		SchemaLocation: qtiSchemaLocation,
+		Identifier:     id,
+		Title:          questionTitle(q),
+		Response:       qtiResponse{Identifier: "RESPONSE", Cardinality: "single", BaseType: "identifier"},
+		Outcome:        qtiOutcome{Identifier: "SCORE", Cardinality: "single", BaseType: "float", Default: "0"},
+		Interaction:    qtiChoice{ResponseIdentifier: "RESPONSE", Shuffle: false, MaxChoices: 1, Prompt: q.Body},
+		Processing:     qtiTemplate{Template: qtiMatchCorrect},
+	}
+
+	// multiple choice questions accept any number of choices and require all the correct ones
+	if q.Type() == entities.MultipleChoice {
+		item.Response.Cardinality = "multiple"
+		item.Interaction.MaxChoices = 0
+	}
+
+	for i, o := range q.Options {
+		choice := fmt.Sprintf("CHOICE_%d", i+1)
+		item.Interaction.Choices = append(item.Interaction.Choices, qtiSimpleChoice{Identifier: choice, Text: o.Body})
+
+		if o.Correct {
+			item.Response.Correct = append(item.Response.Correct, choice)
+		}
+	}
+
+	href := fmt.Sprintf("items/%s.xml", id)
+	if err := e.writeXML(href, item); err != nil {
+		return err
+	}
+
+	r := qtiResource{Identifier: id, Type: qtiItemType, Href: href}
+	r.File.Href = href
+	e.resources = append(e.resources, r)
+
+	return nil
+}
+
+// Close writes the package manifest and the zip central directory
+func (e *qtiEncoder) Close() error {
+	m := qtiManifest{Namespace: imsCPNamespace, Identifier: "MANIFEST_QUESTIONS", Resources: e.resources}
+	if err := e.writeXML("imsmanifest.xml", m); err != nil {
+		return err
+	}
+
+	return e.archive.Close()
+}
+
+// writeXML adds a file with the XML encoding of v to the archive
+func (e *qtiEncoder) writeXML(name string, v interface{}) error {
+	w, err := e.archive.Create(name)
+	if err != nil {
+		return fmt.Errorf("unable to add %s to the package: %s", name, err.Error())
+	}
+
+	if _, err = io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err = enc.Encode(v); err != nil {
+		return fmt.Errorf("unable to encode %s: %s", name, err.Error())
+	}
+
+	return enc.Flush()
+}
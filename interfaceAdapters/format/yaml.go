@@ -0,0 +1,93 @@
+package format
+
+import (
+	"errors"
+	"fmt"
+	"github.com/norby7/questions-rest-api/entities"
+	"gopkg.in/yaml.v2"
+	"io"
+)
+
+// yamlDocument is a single document of a YAML stream, holding either one question or a list of questions
+type yamlDocument []record
+
+// UnmarshalYAML accepts both a list of questions and a single question mapping
+func (d *yamlDocument) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var list []record
+	if err := unmarshal(&list); err == nil {
+		*d = list
+		return nil
+	}
+
+	var r record
+	if err := unmarshal(&r); err != nil {
+		return err
+	}
+
+	*d = yamlDocument{r}
+
+	return nil
+}
+
+type yamlDecoder struct {
+	decoder  *yaml.Decoder
+	pending  []record
+	document int
+	done     bool
+}
+
+func newYAMLDecoder(r io.Reader) *yamlDecoder {
+	return &yamlDecoder{decoder: yaml.NewDecoder(r)}
+}
+
+// Read returns the next question of the current YAML document, decoding the following document when needed
+func (d *yamlDecoder) Read() (entities.Question, error) {
+	for len(d.pending) == 0 {
+		if d.done {
+			return entities.Question{}, io.EOF
+		}
+
+		d.document++
+
+		var doc yamlDocument
+		if err := d.decoder.Decode(&doc); err != nil {
+			if err == io.EOF {
+				d.done = true
+				return entities.Question{}, io.EOF
+			}
+
+			// type errors leave the decoder positioned at the next document, syntax errors can't be recovered from
+			var te *yaml.TypeError
+			if !errors.As(err, &te) {
+				d.done = true
+			}
+
+			return entities.Question{}, fmt.Errorf("document %d: unable to parse questions: %s", d.document, err.Error())
+		}
+
+		d.pending = doc
+	}
+
+	r := d.pending[0]
+	d.pending = d.pending[1:]
+
+	return r.toQuestion(), nil
+}
+
+type yamlEncoder struct {
+	encoder *yaml.Encoder
+}
+
+func newYAMLEncoder(w io.Writer) *yamlEncoder {
+	return &yamlEncoder{encoder: yaml.NewEncoder(w)}
+}
+
+// Write encodes the question as a separate YAML document
+func (e *yamlEncoder) Write(q entities.Question) error {
+	return e.encoder.Encode(newRecord(q))
+}
+
+// Close flushes the remaining output to the underlying writer
+func (e *yamlEncoder) Close() error {
+	return e.encoder.Close()
+}
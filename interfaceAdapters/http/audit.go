@@ -0,0 +1,127 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/norby7/questions-rest-api/entities"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// DefaultAuditLimit is the number of audit entries returned when no limit is requested, unless it's above the controller MaxPageSize
+const DefaultAuditLimit = 100
+
+// Entries of the audit log, ordered by id
+// swagger:response auditLogResponse
+type auditLogResponse struct {
+	// in: body
+	Body []entities.AuditEntry
+}
+
+// swagger:parameters AuditLog
+type auditLogParams struct {
+	// only return the changes of the question with this id
+	// in: query
+	QuestionId int64 `json:"question_id"`
+	// only return the changes made by this principal subject
+	// in: query
+	Actor string `json:"actor"`
+	// only return the changes made at or after this RFC 3339 time
+	// in: query
+	Since string `json:"since"`
+	// only return the entries with an id greater than this one
+	// in: query
+	AfterId int64 `json:"after_id"`
+	// maximum number of entries, defaulted to 100 and capped at the maximum page size (1000 by default)
+	// in: query
+	Limit int `json:"limit"`
+}
+
+// swagger:route GET /audit audit AuditLog
+// Returns the audit log of the question changes, with the actor, the request id and the diff of every change
+// responses:
+// 200: auditLogResponse
+// 400: errorResponse
+// 401: errorResponse
+// 403: forbiddenResponse
+// 429: rateLimitedResponse
+// 500: errorResponse
+
+// AuditLog returns the audit entries matching the query parameters
+// It can accept the following query parameters:
+// - question_id: the id of the changed question
+// - actor: the subject of the principal that made the changes
+// - since: the RFC 3339 time of the oldest change
+// - after_id: the id of the last entry of the previous page
+// - limit: the maximum number of entries, defaulted to 100
+func (c *Controller) AuditLog(rw http.ResponseWriter, r *http.Request) {
+	rw.Header().Set("Content-type", "application/json")
+	c.Logger.DebugContext(r.Context(), "Handle AuditLog")
+
+	f, err := c.auditFilter(r)
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	el, err := c.Service.AuditLog(r.Context(), f)
+	if err != nil {
+		if writeAuthorizationError(rw, err) {
+			return
+		}
+
+		if writeContextError(rw, r) {
+			return
+		}
+
+		http.Error(rw, fmt.Sprintf("unable to read audit log: %s", err.Error()), http.StatusInternalServerError)
+		return
+	}
+
+	err = json.NewEncoder(rw).Encode(el)
+	if err != nil {
+		http.Error(rw, fmt.Sprintf("unable to encode audit log response: %s", err.Error()), http.StatusInternalServerError)
+		return
+	}
+}
+
+// auditFilter parses the audit log query parameters
+func (c *Controller) auditFilter(r *http.Request) (entities.AuditFilter, error) {
+	query := r.URL.Query()
+	f := entities.AuditFilter{Actor: query.Get("actor"), Limit: DefaultAuditLimit}
+	if f.Limit > c.MaxPageSize {
+		f.Limit = c.MaxPageSize
+	}
+
+	var err error
+	if v := query.Get("question_id"); v != "" {
+		if f.QuestionId, err = strconv.ParseInt(v, 10, 64); err != nil {
+			return f, fmt.Errorf("invalid question_id query parameter: %s", err.Error())
+		}
+	}
+
+	if v := query.Get("since"); v != "" {
+		if f.Since, err = time.Parse(time.RFC3339, v); err != nil {
+			return f, fmt.Errorf("invalid since query parameter: %s", err.Error())
+		}
+	}
+
+	if v := query.Get("after_id"); v != "" {
+		if f.AfterId, err = strconv.ParseInt(v, 10, 64); err != nil {
+			return f, fmt.Errorf("invalid after_id query parameter: %s", err.Error())
+		}
+	}
+
+	if v := query.Get("limit"); v != "" {
+		if f.Limit, err = strconv.Atoi(v); err != nil {
+			return f, fmt.Errorf("invalid limit query parameter: %s", err.Error())
+		}
+
+		if f.Limit < 1 || f.Limit > c.MaxPageSize {
+			return f, fmt.Errorf("invalid limit query parameter: must be between 1 and %d", c.MaxPageSize)
+		}
+	}
+
+	return f, nil
+}
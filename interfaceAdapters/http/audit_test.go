@@ -0,0 +1,76 @@
+package http
+
+import (
+	"github.com/norby7/questions-rest-api/logging"
+	"io/ioutil"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAuditLog(t *testing.T) {
+	s := ServiceMock{}
+	l := logging.Discard()
+	c := NewController(&s, l)
+
+	testCases := []struct {
+		name       string
+		input      string
+		statusCode int
+	}{
+		{
+			name:       "no filter",
+			input:      "",
+			statusCode: 200,
+		},
+		{
+			name:       "all filters",
+			input:      "?question_id=1&actor=alice&since=2021-03-01T12:00:00Z&after_id=10&limit=50",
+			statusCode: 200,
+		},
+		{
+			name:       "non numeric question id",
+			input:      "?question_id=one",
+			statusCode: 400,
+		},
+		{
+			name:       "invalid since",
+			input:      "?since=yesterday",
+			statusCode: 400,
+		},
+		{
+			name:       "non numeric after id",
+			input:      "?after_id=last",
+			statusCode: 400,
+		},
+		{
+			name:       "limit too large",
+			input:      "?limit=5000",
+			statusCode: 400,
+		},
+		{
+			name:       "forbidden",
+			input:      "?actor=forbidden",
+			statusCode: 403,
+		},
+		{
+			name:       "audit log error",
+			input:      "?question_id=500",
+			statusCode: 500,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/audit"+tc.input, nil)
+			rec := httptest.NewRecorder()
+
+			c.AuditLog(rec, req)
+			result := rec.Result()
+
+			if result.StatusCode != tc.statusCode {
+				resBody, _ := ioutil.ReadAll(result.Body)
+				t.Errorf("expected status code (%v), got (%v) with response: (%v)", tc.statusCode, result.StatusCode, string(resBody))
+			}
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/norby7/questions-rest-api/entities"
+	"github.com/norby7/questions-rest-api/usecases/service"
+	"net/http"
+)
+
+// Operation the authenticated principal isn't allowed to perform
+// swagger:response forbiddenResponse
+type forbiddenResponse struct {
+	Error         string          `json:"error"`
+	Message       string          `json:"message"`
+	Subject       string          `json:"subject"`
+	Action        service.Action  `json:"action"`
+	Roles         []entities.Role `json:"roles"`
+	RequiredRoles []entities.Role `json:"required_roles"`
+}
+
+// writeAuthorizationError writes the 401 or 403 response of the errors returned by the service policy checks
+// It returns false, without writing anything, for any other error.
+func writeAuthorizationError(rw http.ResponseWriter, err error) bool {
+	if errors.Is(err, service.UnauthenticatedError) {
+		rw.Header().Set("WWW-Authenticate", `Bearer realm="questions"`)
+		http.Error(rw, fmt.Sprintf("unable to authorize request: %s", err.Error()), http.StatusUnauthorized)
+		return true
+	}
+
+	var fe *service.ForbiddenError
+	if !errors.As(err, &fe) {
+		return false
+	}
+
+	rw.Header().Set("Content-type", "application/json")
+	rw.WriteHeader(http.StatusForbidden)
+	_ = json.NewEncoder(rw).Encode(forbiddenResponse{
+		Error:         "forbidden",
+		Message:       fe.Error(),
+		Subject:       fe.Subject,
+		Action:        fe.Action,
+		Roles:         fe.Roles,
+		RequiredRoles: fe.Required,
+	})
+
+	return true
+}
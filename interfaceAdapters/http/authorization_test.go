@@ -0,0 +1,80 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/gorilla/mux"
+	"github.com/norby7/questions-rest-api/entities"
+	"github.com/norby7/questions-rest-api/logging"
+	"github.com/norby7/questions-rest-api/usecases/service"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWriteAuthorizationError(t *testing.T) {
+	testCases := []struct {
+		name    string
+		input   error
+		written bool
+		status  int
+	}{{
+		name:    "forbidden",
+		input:   &service.ForbiddenError{Subject: "alice", Action: service.ActionImport, Required: []entities.Role{entities.RoleAdmin}},
+		written: true,
+		status:  http.StatusForbidden,
+	}, {
+		name:    "wrapped forbidden",
+		input:   fmt.Errorf("batch: %w", &service.ForbiddenError{Subject: "alice", Action: service.ActionRemove}),
+		written: true,
+		status:  http.StatusForbidden,
+	}, {
+		name:    "unauthenticated",
+		input:   service.UnauthenticatedError,
+		written: true,
+		status:  http.StatusUnauthorized,
+	}, {
+		name:    "other error",
+		input:   fmt.Errorf("database is locked"),
+		written: false,
+		status:  http.StatusOK,
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+
+			if written := writeAuthorizationError(rr, tc.input); written != tc.written {
+				t.Fatalf("expected written (%v), got written (%v)", tc.written, written)
+			}
+
+			if rr.Code != tc.status {
+				t.Errorf("expected status code (%d), got status code (%d)", tc.status, rr.Code)
+			}
+		})
+	}
+}
+
+func TestForbiddenDelete(t *testing.T) {
+	c := NewController(&ServiceMock{}, logging.Discard())
+
+	r := mux.NewRouter()
+	r.HandleFunc("/question/{id:[0-9]+}", c.Delete).Methods("DELETE")
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest("DELETE", "/question/403", nil))
+
+	if rr.Code != http.StatusForbidden {
+		t.Fatalf("expected status code (%d), got status code (%d)", http.StatusForbidden, rr.Code)
+	}
+
+	var res forbiddenResponse
+	if err := json.NewDecoder(rr.Body).Decode(&res); err != nil {
+		t.Fatalf("unable to decode forbidden response: %s", err.Error())
+	}
+
+	if res.Error != "forbidden" || res.Action != service.ActionRemove || res.Subject != "alice" ||
+		len(res.RequiredRoles) != 1 || res.RequiredRoles[0] != entities.RoleAdmin {
+		t.Errorf("expected structured forbidden error for (remove), got response (%v)", res)
+	}
+}
@@ -0,0 +1,85 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/norby7/questions-rest-api/entities"
+	"github.com/norby7/questions-rest-api/usecases/service"
+	"net/http"
+)
+
+// Report of a batch, with the outcome of every operation
+// swagger:response batchReportResponse
+type batchReportResponse struct {
+	// in: body
+	Body service.BatchReport
+}
+
+// swagger:parameters Batch
+type batchParams struct {
+	// batch mode: atomic (single transaction, default) or best-effort
+	// in: query
+	Mode string `json:"mode"`
+	// list of create, update and delete operations
+	// in: body
+	// required: true
+	Body []service.BatchOperation
+}
+
+// swagger:route POST /questions/batch questions Batch
+// Applies a list of create, update and delete operations and returns the status of every operation
+// responses:
+// 200: batchReportResponse
+// 400: errorResponse
+// 401: errorResponse
+// 403: forbiddenResponse
+// 413: errorResponse
+// 422: batchReportResponse
+// 429: rateLimitedResponse
+// 500: errorResponse
+
+// Batch applies the list of operations in the request body and returns a per operation report
+// It can accept one query parameter:
+// - mode: atomic applies all the operations in one transaction or none of them, best-effort applies every valid operation
+func (c *Controller) Batch(rw http.ResponseWriter, r *http.Request) {
+	rw.Header().Set("Content-type", "application/json")
+	c.Logger.DebugContext(r.Context(), "Handle Batch questions")
+
+	mode, err := service.ParseBatchMode(r.URL.Query().Get("mode"))
+	if err != nil {
+		http.Error(rw, fmt.Sprintf("invalid mode query parameter: %s", err.Error()), http.StatusBadRequest)
+		return
+	}
+
+	var ops []service.BatchOperation
+	err = entities.DecodeJSON(r.Body, &ops)
+	if err != nil {
+		writeBodyError(rw, "unable to parse batch operations", err)
+		return
+	}
+
+	report, err := c.Service.Batch(r.Context(), ops, mode)
+	if err != nil {
+		if writeAuthorizationError(rw, err) {
+			return
+		}
+
+		if writeContextError(rw, r) {
+			return
+		}
+
+		http.Error(rw, fmt.Sprintf("unable to apply batch: %s", err.Error()), http.StatusInternalServerError)
+		return
+	}
+
+	// in atomic mode nothing was applied if any operation failed
+	if mode == service.BatchAtomic && report.Failed > 0 {
+		rw.WriteHeader(http.StatusUnprocessableEntity)
+	}
+
+	err = json.NewEncoder(rw).Encode(report)
+	if err != nil {
+		http.Error(rw, fmt.Sprintf("unable to encode batch report: %s", err.Error()), http.StatusInternalServerError)
+		return
+	}
+}
@@ -0,0 +1,67 @@
+package http
+
+import (
+	"github.com/norby7/questions-rest-api/logging"
+	"io/ioutil"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestBatch(t *testing.T) {
+	s := ServiceMock{}
+	l := logging.Discard()
+	c := NewController(&s, l)
+
+	testCases := []struct {
+		name       string
+		query      string
+		input      string
+		statusCode int
+	}{{
+		name:       "invalid mode",
+		query:      "?mode=partial",
+		input:      `[]`,
+		statusCode: 400,
+	}, {
+		name:       "invalid json",
+		input:      `{"op":"delete","id":1}`,
+		statusCode: 400,
+	}, {
+		name:       "unknown field",
+		input:      `[{"op":"delete","id":1,"force":true}]`,
+		statusCode: 400,
+	}, {
+		name:       "batch error",
+		input:      `[{"op":"delete","id":-1}]`,
+		statusCode: 500,
+	}, {
+		name:       "atomic batch with failures",
+		input:      `[{"op":"delete","id":1},{"op":"delete","id":404}]`,
+		statusCode: 422,
+	}, {
+		name:       "best effort batch with failures",
+		query:      "?mode=best-effort",
+		input:      `[{"op":"delete","id":1},{"op":"delete","id":404}]`,
+		statusCode: 200,
+	}, {
+		name:       "valid batch",
+		input:      `[{"op":"create","question":{"body":"Where does the sun set?","options":[{"body":"East","correct":false},{"body":"West","correct":true}]}},{"op":"delete","id":2}]`,
+		statusCode: 200,
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/questions/batch"+tc.query, strings.NewReader(tc.input))
+			rec := httptest.NewRecorder()
+
+			c.Batch(rec, req)
+			result := rec.Result()
+
+			if result.StatusCode != tc.statusCode {
+				resBody, _ := ioutil.ReadAll(result.Body)
+				t.Errorf("expected status code (%v), got (%v) with response: (%v)", tc.statusCode, result.StatusCode, string(resBody))
+			}
+		})
+	}
+}
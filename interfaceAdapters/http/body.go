@@ -0,0 +1,19 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// writeBodyError writes the response of a request whose body can't be decoded, msg describes what failed
+// Bodies over the size limit of the route get a 413 response, the malformed ones a 400 response.
+func writeBodyError(rw http.ResponseWriter, msg string, err error) {
+	var mbe *http.MaxBytesError
+	if errors.As(err, &mbe) {
+		http.Error(rw, fmt.Sprintf("%s: request body larger than %d bytes", msg, mbe.Limit), http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	http.Error(rw, fmt.Sprintf("%s: %s", msg, err.Error()), http.StatusBadRequest)
+}
@@ -0,0 +1,66 @@
+package http
+
+import (
+	"github.com/norby7/questions-rest-api/logging"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestBodyError(t *testing.T) {
+	c := NewController(&ServiceMock{}, logging.Discard())
+
+	body := `{"body":"Where does the sun set?","options":[{"body":"East","correct":false},{"body":"West","correct":true}]}`
+
+	testCases := []struct {
+		name       string
+		handler    http.HandlerFunc
+		target     string
+		body       string
+		limit      int64
+		statusCode int
+	}{{
+		name:       "question within the limit",
+		handler:    c.Add,
+		target:     "/question",
+		body:       body,
+		limit:      int64(len(body)),
+		statusCode: http.StatusOK,
+	}, {
+		name:       "question over the limit",
+		handler:    c.Add,
+		target:     "/question",
+		body:       body,
+		limit:      int64(len(body)) - 1,
+		statusCode: http.StatusRequestEntityTooLarge,
+	}, {
+		name:       "batch over the limit",
+		handler:    c.Batch,
+		target:     "/questions/batch",
+		body:       `[{"op":"create","question":` + body + `}]`,
+		limit:      16,
+		statusCode: http.StatusRequestEntityTooLarge,
+	}, {
+		name:       "import header over the limit",
+		handler:    c.Import,
+		target:     "/questions/import?format=csv",
+		body:       "id,body,option,correct,option,correct\n",
+		limit:      16,
+		statusCode: http.StatusRequestEntityTooLarge,
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", tc.target, strings.NewReader(tc.body))
+			rec := httptest.NewRecorder()
+			req.Body = http.MaxBytesReader(rec, req.Body, tc.limit)
+
+			tc.handler(rec, req)
+
+			if rec.Code != tc.statusCode {
+				t.Errorf("expected status code (%d), got status code (%d) with response (%s)", tc.statusCode, rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
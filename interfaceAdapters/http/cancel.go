@@ -0,0 +1,25 @@
+package http
+
+import (
+	"context"
+	"net/http"
+)
+
+// StatusClientClosedRequest is the status of the requests canceled by the client, it's only seen in the logs and metrics since the client is gone
+const StatusClientClosedRequest = 499
+
+// writeContextError writes the response of a request that failed because its context is done
+// Requests that ran past their deadline get a 503 response, requests canceled by the client a 499 one.
+// It returns false, without writing anything, if the context of the request isn't done.
+func writeContextError(rw http.ResponseWriter, r *http.Request) bool {
+	switch r.Context().Err() {
+	case nil:
+		return false
+	case context.DeadlineExceeded:
+		http.Error(rw, "unable to complete request: request timed out", http.StatusServiceUnavailable)
+	default:
+		http.Error(rw, "unable to complete request: request canceled", StatusClientClosedRequest)
+	}
+
+	return true
+}
@@ -0,0 +1,51 @@
+package http
+
+import (
+	"context"
+	"github.com/norby7/questions-rest-api/logging"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestContextError(t *testing.T) {
+	c := NewController(&ServiceMock{}, logging.Discard())
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	expired, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+
+	testCases := []struct {
+		name       string
+		ctx        context.Context
+		statusCode int
+	}{{
+		name:       "active",
+		ctx:        context.Background(),
+		statusCode: http.StatusOK,
+	}, {
+		name:       "canceled by the client",
+		ctx:        canceled,
+		statusCode: StatusClientClosedRequest,
+	}, {
+		name:       "deadline exceeded",
+		ctx:        expired,
+		statusCode: http.StatusServiceUnavailable,
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/questions", nil).WithContext(tc.ctx)
+			rec := httptest.NewRecorder()
+
+			c.GetAll(rec, req)
+
+			if rec.Code != tc.statusCode {
+				t.Errorf("expected status code (%d), got status code (%d)", tc.statusCode, rec.Code)
+			}
+		})
+	}
+}
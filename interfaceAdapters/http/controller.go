@@ -1,8 +1,8 @@
 // Package http, classification of Question REST API
 //
-// Documentation for Question API
+// Documentation for Question API.
 //
-// Schemes: http
+// Schemes: http, https
 // BasePath: /question
 // Version: 1.0.0
 //
@@ -11,15 +11,24 @@
 //
 // Produces:
 // - application/json
+//
+// Security:
+// - api_key:
+// - bearer:
+//
+// SecurityDefinitions:
+// api_key: {type: apiKey, in: header, name: X-API-Key}
+// bearer: {type: apiKey, in: header, name: Authorization}
 // swagger:meta
 package http
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/norby7/questions-rest-api/entities"
 	"github.com/norby7/questions-rest-api/usecases/service"
-	"log"
+	"log/slog"
 	"net/http"
 	"path"
 	"strconv"
@@ -39,6 +48,12 @@ type errorResponse struct {
 	Message string `json:"message"`
 }
 
+// Too many requests error response, the client can retry after the number of seconds of the Retry-After header
+// swagger:response rateLimitedResponse
+type rateLimitedResponse struct {
+	Message string `json:"message"`
+}
+
 // swagger:response noContent
 type noContent struct {
 }
@@ -59,40 +74,90 @@ type questionParam struct {
 	Body entities.Question
 }
 
+const (
+	// DefaultPageSize is the number of questions returned when no size is requested
+	DefaultPageSize = 10
+	// MaxPageSize is the maximum number of items returned by a single list request
+	MaxPageSize = 1000
+)
+
 type Controller struct {
 	Service service.Interactor
-	Logger  *log.Logger
+	Logger  *slog.Logger
+	// Level is the level of the application logger, it can be changed at runtime by the admins
+	Level *slog.LevelVar
+	// Policy authorizes the operations that aren't performed by the service, like changing the log level
+	Policy service.Policy
+	// DefaultPageSize and MaxPageSize limit the size of the pages returned by the list endpoints
+	DefaultPageSize int
+	MaxPageSize     int
 }
 
-func NewController(s service.Interactor, l *log.Logger) *Controller {
-	return &Controller{Service: s, Logger: l}
+func NewController(s service.Interactor, l *slog.Logger) *Controller {
+	return &Controller{
+		Service:         s,
+		Logger:          l,
+		Level:           new(slog.LevelVar),
+		Policy:          service.DefaultPolicy,
+		DefaultPageSize: DefaultPageSize,
+		MaxPageSize:     MaxPageSize,
+	}
 }
 
 // swagger:route POST /question question Add
 // Creates a new question in the database and then returns it in the response
+// Near-duplicates of existing questions are reported in Warning headers, or rejected when duplicates are blocked
 // responses:
 // 200: questionResponse
+// 400: errorResponse
+// 401: errorResponse
+// 403: forbiddenResponse
+// 409: duplicatesErrorResponse
+// 413: errorResponse
 // 422: errorResponse
+// 429: rateLimitedResponse
 // 500: errorResponse
 
 // Add creates a new question in the database and returns it
 func (c *Controller) Add(rw http.ResponseWriter, r *http.Request) {
 	rw.Header().Set("Content-type", "application/json")
-	c.Logger.Println("Handle Add question")
+	c.Logger.DebugContext(r.Context(), "Handle Add question")
 
 	var q entities.Question
 	err := q.FromJSON(r.Body)
 	if err != nil {
-		http.Error(rw, fmt.Sprintf("unable to parse question object: %s", err.Error()), http.StatusUnprocessableEntity)
+		writeBodyError(rw, "unable to parse question object", err)
 		return
 	}
 
-	err = c.Service.Create(q)
+	// the option ids, the creation time and author are assigned when the question is stored
+	q = q.WithoutOptionIds()
+	q = q.WithoutMetadata()
+
+	q, duplicates, err := c.Service.Create(r.Context(), q)
 	if err != nil {
+		if writeAuthorizationError(rw, err) {
+			return
+		}
+
+		if writeContextError(rw, r) {
+			return
+		}
+
+		if errors.Is(err, service.DuplicateQuestionError) {
+			rw.WriteHeader(http.StatusConflict)
+			_ = json.NewEncoder(rw).Encode(duplicatesErrorResponse{Message: fmt.Sprintf("unable to add question: %s", err.Error()), Duplicates: duplicates})
+			return
+		}
+
 		http.Error(rw, fmt.Sprintf("unable to add question: %s", err.Error()), http.StatusInternalServerError)
 		return
 	}
 
+	for _, d := range duplicates {
+		rw.Header().Add("Warning", fmt.Sprintf(`299 - "possible duplicate of question %d, similarity %.3f"`, d.QuestionId, d.Similarity))
+	}
+
 	err = q.ToJSON(rw)
 	if err != nil {
 		http.Error(rw, fmt.Sprintf("unable to encode response: %s", err.Error()), http.StatusInternalServerError)
@@ -103,14 +168,21 @@ func (c *Controller) Add(rw http.ResponseWriter, r *http.Request) {
 // swagger:route PUT /question/{id} question Update
 // Updates an existing question and returns the updated question in the response
 // responses:
-// 200: noContent
+// 200: questionResponse
+// 400: errorResponse
+// 401: errorResponse
+// 403: forbiddenResponse
+// 404: errorResponse
+// 409: errorResponse
+// 413: errorResponse
 // 422: errorResponse
+// 429: rateLimitedResponse
 // 500: errorResponse
 
 // Update updates an existing question and returns the updated question in response
 func (c *Controller) Update(rw http.ResponseWriter, r *http.Request) {
 	rw.Header().Set("Content-type", "application/json")
-	c.Logger.Println("Handle Update question")
+	c.Logger.DebugContext(r.Context(), "Handle Update question")
 
 	id, err := strconv.Atoi(path.Base(r.URL.String()))
 	if err != nil {
@@ -121,14 +193,39 @@ func (c *Controller) Update(rw http.ResponseWriter, r *http.Request) {
 	var q entities.Question
 	err = q.FromJSON(r.Body)
 	if err != nil {
-		http.Error(rw, fmt.Sprintf("unable to parse question object: %s", err.Error()), http.StatusUnprocessableEntity)
+		writeBodyError(rw, "unable to parse question object", err)
 		return
 	}
 
 	q.Id = int64(id)
+	// the update time and author are set when the question is stored
+	q = q.WithoutMetadata()
 
-	err = c.Service.Update(q)
+	q, err = c.Service.Update(r.Context(), q)
 	if err != nil {
+		if writeAuthorizationError(rw, err) {
+			return
+		}
+
+		if writeContextError(rw, r) {
+			return
+		}
+
+		if errors.Is(err, service.QuestionNotFoundError) {
+			http.Error(rw, fmt.Sprintf("unable to update question: %s", err.Error()), http.StatusNotFound)
+			return
+		}
+
+		if errors.Is(err, service.OptionNotFoundError) {
+			http.Error(rw, fmt.Sprintf("unable to update question: %s", err.Error()), http.StatusUnprocessableEntity)
+			return
+		}
+
+		if errors.Is(err, entities.StatusTransitionError) {
+			http.Error(rw, fmt.Sprintf("unable to update question: %s", err.Error()), http.StatusConflict)
+			return
+		}
+
 		http.Error(rw, fmt.Sprintf("unable to update question: %s", err.Error()), http.StatusInternalServerError)
 		return
 	}
@@ -145,12 +242,16 @@ func (c *Controller) Update(rw http.ResponseWriter, r *http.Request) {
 // responses:
 // 200: noContent
 // 400: errorResponse
+// 401: errorResponse
+// 403: forbiddenResponse
+// 404: errorResponse
+// 429: rateLimitedResponse
 // 500: errorResponse
 
 // Delete removes a question from the database
 func (c *Controller) Delete(rw http.ResponseWriter, r *http.Request) {
 	rw.Header().Set("Content-type", "application/json")
-	c.Logger.Println("Handle Delete question")
+	c.Logger.DebugContext(r.Context(), "Handle Delete question")
 
 	id, err := strconv.Atoi(path.Base(r.URL.String()))
 	if err != nil {
@@ -158,51 +259,73 @@ func (c *Controller) Delete(rw http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = c.Service.Remove(int64(id))
+	err = c.Service.Remove(r.Context(), int64(id))
 	if err != nil {
+		if writeAuthorizationError(rw, err) {
+			return
+		}
+
+		if writeContextError(rw, r) {
+			return
+		}
+
+		if errors.Is(err, service.QuestionNotFoundError) {
+			http.Error(rw, fmt.Sprintf("unable to delete question: %s", err.Error()), http.StatusNotFound)
+			return
+		}
+
 		http.Error(rw, fmt.Sprintf("unable to delete question: %s", err.Error()), http.StatusInternalServerError)
 		return
 	}
 }
 
 // swagger:route GET /questions questions GetAll
-// Returns a list of all questions in the database
+// Returns a page of the questions, sorted and filtered by the query parameters, the Link header of a full page links to the next one
 // responses:
 // 200: questionsListResponse
+// 400: errorResponse
+// 401: errorResponse
+// 403: forbiddenResponse
+// 429: rateLimitedResponse
 // 500: errorResponse
 
 // GetAll returns a list of questions
-// It can accept two query parameters:
-// - last_id: if this parameter is passed, the data will be filtered using seek pagination
-// - size: this parameter determines the number of items on each page when using pagination, defaulted to 10
+// The query parameters select and order the questions:
+// - sort: the sort key (id, body, created_at, updated_at or difficulty) optionally followed by :asc or :desc, defaulted to id:desc
+// - min_options, max_options, type: only the questions with this number of options, or of this type, are returned
+// - created_after, created_before, updated_after, updated_before, author, updated_by: only the questions created
+// or last updated in this time range, or by this subject, are returned
+// - cursor: the position of the previous page, as found in the Link header of its response
+// - last_id: the id of the last question of the previous page, when sorting by id
+// - size: this parameter determines the number of items on each page, defaulted to the controller DefaultPageSize
+// When none of cursor, last_id and size is given the first MaxPageSize matching questions are returned.
 func (c *Controller) GetAll(rw http.ResponseWriter, r *http.Request) {
 	rw.Header().Set("Content-type", "application/json")
-	c.Logger.Println("Handle GetAll questions")
+	c.Logger.DebugContext(r.Context(), "Handle GetAll questions")
 
-	var err error
-	lastId, size := 0, 10
+	f, err := c.questionFilter(r)
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
+	}
 
-	lastIdParam := r.URL.Query().Get("last_id")
-	if lastIdParam != "" {
-		lastId, err = strconv.Atoi(lastIdParam)
-		if err != nil {
-			http.Error(rw, fmt.Sprintf("invalid last_id query parameter: %s", err.Error()), http.StatusBadRequest)
+	questions, err := c.Service.ListAll(r.Context(), f)
+	if err != nil {
+		if writeAuthorizationError(rw, err) {
 			return
 		}
-	}
 
-	sizeParam := r.URL.Query().Get("size")
-	if sizeParam != "" {
-		size, err = strconv.Atoi(sizeParam)
-		if err != nil {
-			http.Error(rw, fmt.Sprintf("invalid size query parameter: %s", err.Error()), http.StatusBadRequest)
+		if writeContextError(rw, r) {
 			return
 		}
+
+		http.Error(rw, fmt.Sprintf("unable to fetch questions: %s", err.Error()), http.StatusInternalServerError)
+		return
 	}
 
-	questions, err := c.Service.ListAll(lastId, size)
+	err = setNextLink(rw, r, f, questions)
 	if err != nil {
-		http.Error(rw, fmt.Sprintf("unable to fetch questions: %s", err.Error()), http.StatusInternalServerError)
+		http.Error(rw, fmt.Sprintf("unable to encode next page cursor: %s", err.Error()), http.StatusInternalServerError)
 		return
 	}
 
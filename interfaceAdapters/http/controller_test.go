@@ -1,12 +1,16 @@
 package http
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"github.com/norby7/questions-rest-api/entities"
+	"github.com/norby7/questions-rest-api/logging"
+	"github.com/norby7/questions-rest-api/usecases/service"
+	"io"
 	"io/ioutil"
-	"log"
 	"net/http/httptest"
-	"os"
+	"strconv"
 	"strings"
 	"testing"
 )
@@ -14,22 +18,183 @@ import (
 type ServiceMock struct {
 }
 
-func (s *ServiceMock) Create(u entities.Question) error {
+func (s *ServiceMock) Create(ctx context.Context, u entities.Question) (entities.Question, []service.Duplicate, error) {
 	if u.Body == "errQuestion" {
-		return fmt.Errorf("unable to add question")
+		return entities.Question{}, nil, fmt.Errorf("unable to add question")
 	}
 
-	return nil
+	if u.Body == "Where does the sun set??" {
+		return entities.Question{}, []service.Duplicate{{QuestionId: 1, Body: "Where does the sun set?", Similarity: 1}}, service.DuplicateQuestionError
+	}
+
+	if u.Body == "Where does the sun set ?" {
+		return storedMock(2, u), []service.Duplicate{{QuestionId: 1, Body: "Where does the sun set?", Similarity: 1}}, nil
+	}
+
+	return storedMock(2, u), nil, nil
 }
 
-func (s *ServiceMock) Update(u entities.Question) error {
+func (s *ServiceMock) Update(ctx context.Context, u entities.Question) (entities.Question, error) {
 	if u.Body == "errQuestion" {
+		return entities.Question{}, fmt.Errorf("unable to update question")
+	}
+
+	if u.Id == 404 {
+		return entities.Question{}, service.QuestionNotFoundError
+	}
+
+	if u.Id == 422 {
+		return entities.Question{}, fmt.Errorf("%w: 9", service.OptionNotFoundError)
+	}
+
+	return storedMock(u.Id, u), nil
+}
+
+// storedMock returns q as stored by the repository with the given id, its options get ids
+func storedMock(id int64, q entities.Question) entities.Question {
+	q.Id, q.Status = id, entities.Draft
+	q.Options = append([]entities.Option(nil), q.Options...)
+	for i := range q.Options {
+		q.Options[i].Id, q.Options[i].QuestionId = int64(i+1), id
+	}
+
+	return q
+}
+func (s *ServiceMock) Patch(ctx context.Context, id int64, t service.PatchType, patch []byte) (entities.Question, error) {
+	switch id {
+	case 403:
+		return entities.Question{}, &service.ForbiddenError{Subject: "viewer", Action: service.ActionUpdate, Roles: []entities.Role{entities.RoleViewer}, Required: []entities.Role{entities.RoleEditor}}
+	case 404:
+		return entities.Question{}, service.QuestionNotFoundError
+	case 409:
+		return entities.Question{}, fmt.Errorf("operation 0: %w: test failed for /body", service.PatchConflictError)
+	case 422:
+		return entities.Question{}, fmt.Errorf("%w: question should have at least 2 options", service.InvalidQuestionError)
+	case 500:
+		return entities.Question{}, fmt.Errorf("unable to update question")
+	}
+
+	if !json.Valid(patch) {
+		return entities.Question{}, fmt.Errorf("%w: invalid json", service.PatchDocumentError)
+	}
+
+	return entities.Question{Id: id, Body: "Where does the sun set?", Options: []entities.Option{{Body: "East"}, {Body: "West", Correct: true}}}, nil
+}
+
+func (s *ServiceMock) AddOption(ctx context.Context, questionId int64, o entities.Option) (entities.Option, error) {
+	if err := optionMockError(questionId); err != nil {
+		return entities.Option{}, err
+	}
+
+	if o.Body == "" {
+		return entities.Option{}, fmt.Errorf("%w: empty body", service.InvalidOptionError)
+	}
+
+	o.Id, o.QuestionId = 3, questionId
+	return o, nil
+}
+
+func (s *ServiceMock) UpdateOption(ctx context.Context, questionId int64, o entities.Option) (entities.Option, error) {
+	if err := optionMockError(questionId); err != nil {
+		return entities.Option{}, err
+	}
+
+	if o.Id != 1 && o.Id != 2 {
+		return entities.Option{}, fmt.Errorf("%w: %d", service.OptionNotFoundError, o.Id)
+	}
+
+	o.QuestionId = questionId
+	return o, nil
+}
+
+func (s *ServiceMock) RemoveOption(ctx context.Context, questionId, optionId int64) error {
+	if err := optionMockError(questionId); err != nil {
+		return err
+	}
+
+	if optionId != 1 && optionId != 2 {
+		return fmt.Errorf("%w: %d", service.OptionNotFoundError, optionId)
+	}
+
+	return nil
+}
+
+func (s *ServiceMock) ReorderOptions(ctx context.Context, questionId int64, ids []int64) (entities.Question, error) {
+	if err := optionMockError(questionId); err != nil {
+		return entities.Question{}, err
+	}
+
+	if len(ids) != 2 {
+		return entities.Question{}, fmt.Errorf("%w: %d ids for 2 options", service.OptionOrderError, len(ids))
+	}
+
+	q := entities.Question{Id: questionId, Body: "Where does the sun set?"}
+	for i, id := range ids {
+		if id != 1 && id != 2 {
+			return entities.Question{}, fmt.Errorf("%w: %d", service.OptionNotFoundError, id)
+		}
+		q.Options = append(q.Options, entities.Option{Id: id, Body: fmt.Sprintf("Option %d", id), OptionOrder: i})
+	}
+
+	return q, nil
+}
+
+func (s *ServiceMock) Transition(ctx context.Context, id int64, t entities.QuestionTransition, comment string) (entities.Question, error) {
+	switch id {
+	case 403:
+		return entities.Question{}, &service.ForbiddenError{Subject: "alice", Action: service.ActionReview}
+	case 404:
+		return entities.Question{}, service.QuestionNotFoundError
+	case 409:
+		return entities.Question{}, fmt.Errorf("%w: the question is retired", entities.StatusTransitionError)
+	case 500:
+		return entities.Question{}, fmt.Errorf("unable to change question status")
+	}
+
+	if err := t.ValidateComment(comment); err != nil {
+		return entities.Question{}, err
+	}
+
+	// the other questions have the status the transition starts from
+	from := map[entities.QuestionTransition]entities.QuestionStatus{
+		entities.Submit:  entities.Draft,
+		entities.Approve: entities.InReview,
+		entities.Reject:  entities.InReview,
+		entities.Retire:  entities.Published,
+	}
+	status, err := t.Apply(from[t])
+	if err != nil {
+		return entities.Question{}, err
+	}
+
+	return entities.Question{Id: id, Body: "Where does the sun set?", Status: status, ReviewComment: comment}, nil
+}
+
+// optionMockError returns the error of the option operations on the question with the given id
+func optionMockError(questionId int64) error {
+	switch questionId {
+	case 403:
+		return &service.ForbiddenError{Subject: "viewer", Action: service.ActionUpdate, Roles: []entities.Role{entities.RoleViewer}, Required: []entities.Role{entities.RoleEditor}}
+	case 404:
+		return service.QuestionNotFoundError
+	case 422:
+		return fmt.Errorf("%w: question should have at least 2 options", service.InvalidQuestionError)
+	case 500:
 		return fmt.Errorf("unable to update question")
 	}
 
 	return nil
 }
-func (s *ServiceMock) Remove(id int64) error {
+
+func (s *ServiceMock) Remove(ctx context.Context, id int64) error {
+	if id == 403 {
+		return &service.ForbiddenError{Subject: "alice", Action: service.ActionRemove, Roles: []entities.Role{entities.RoleEditor}, Required: []entities.Role{entities.RoleAdmin}}
+	}
+
+	if id == 404 {
+		return service.QuestionNotFoundError
+	}
+
 	if id != 1 {
 		return fmt.Errorf("unable to delete question")
 	}
@@ -37,27 +202,116 @@ func (s *ServiceMock) Remove(id int64) error {
 	return nil
 }
 
-func (s *ServiceMock) ListAll(lastId, size int) ([]entities.Question, error) {
-	if lastId == -2 {
+func (s *ServiceMock) ListAll(ctx context.Context, f entities.QuestionFilter) ([]entities.Question, error) {
+	// the repository errors don't wrap the context error
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("unable to query database: %s", err.Error())
+	}
+
+	if f.After != nil && f.After.Id == -2 {
 		return []entities.Question{}, fmt.Errorf("error, unable to fetch users")
 	}
 
-	return []entities.Question{}, nil
+	return []entities.Question{{Id: 2, Body: "Where does the sun set?"}, {Id: 1, Body: "Where does the sun rise?"}}, nil
+}
+
+func (s *ServiceMock) Import(ctx context.Context, r service.QuestionReader, mode service.ImportMode, restore bool) (service.ImportReport, error) {
+	report := service.ImportReport{Mode: mode}
+	for {
+		q, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+
+		if q.Body == "errQuestion" {
+			return service.ImportReport{}, fmt.Errorf("unable to import questions")
+		}
+
+		report.Total++
+		if err != nil {
+			report.Failed++
+		}
+	}
+
+	return report, nil
+}
+
+func (s *ServiceMock) Export(ctx context.Context, w service.QuestionWriter) error {
+	q := entities.Question{Id: 1, Body: "Where does the sun set?"}
+
+	if c, ok := w.(service.QuestionChecker); ok {
+		if err := c.Check(q); err != nil {
+			return &service.ExportError{Problems: []service.ExportProblem{{QuestionId: q.Id, Error: err.Error()}}}
+		}
+	}
+
+	if err := w.Write(q); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *ServiceMock) Duplicates(ctx context.Context, threshold float64) ([]service.DuplicateGroup, error) {
+	if threshold > 1 {
+		return nil, service.DuplicateThresholdError
+	}
+
+	if threshold == 0.5 {
+		return nil, fmt.Errorf("unable to search for duplicates")
+	}
+
+	return []service.DuplicateGroup{}, nil
+}
+
+func (s *ServiceMock) Batch(ctx context.Context, ops []service.BatchOperation, mode service.BatchMode) (service.BatchReport, error) {
+	report := service.BatchReport{Mode: mode}
+	for _, op := range ops {
+		if op.Id == -1 {
+			return service.BatchReport{}, fmt.Errorf("unable to apply batch")
+		}
+
+		if op.Id == 404 {
+			report.Failed++
+		}
+	}
+
+	return report, nil
+}
+
+func (s *ServiceMock) AuditLog(ctx context.Context, f entities.AuditFilter) ([]entities.AuditEntry, error) {
+	switch {
+	case f.Actor == "forbidden":
+		return nil, &service.ForbiddenError{Subject: "alice", Action: service.ActionAudit, Roles: []entities.Role{entities.RoleEditor}, Required: []entities.Role{entities.RoleAdmin}}
+	case f.QuestionId == 500:
+		return nil, fmt.Errorf("unable to read audit log")
+	}
+
+	return []entities.AuditEntry{}, nil
 }
 
 func TestAdd(t *testing.T) {
 	s := ServiceMock{}
-	l := log.New(os.Stdout, "question-api", log.LstdFlags)
+	l := logging.Discard()
 	c := NewController(&s, l)
 
 	testCases := []struct {
 		name       string
 		input      *strings.Reader
 		statusCode int
+		warning    bool
 	}{{
 		name:       "invalid json object",
 		input:      strings.NewReader(`"body":"Where does the sun set?","options":[{"body":"East","correct":false},{"body":"West","correct":true}]}`),
-		statusCode: 422,
+		statusCode: 400,
+	}, {
+		name:       "unknown field",
+		input:      strings.NewReader(`{"body":"Where does the sun set?","type":"single_choice","options":[{"body":"East","correct":false},{"body":"West","correct":true}]}`),
+		statusCode: 400,
+	}, {
+		name:       "trailing data",
+		input:      strings.NewReader(`{"body":"Where does the sun set?","options":[{"body":"East","correct":false},{"body":"West","correct":true}]}{}`),
+		statusCode: 400,
 	}, {
 		name:       "add error",
 		input:      strings.NewReader(`{"body":"errQuestion","options":[]}`),
@@ -66,6 +320,15 @@ func TestAdd(t *testing.T) {
 		name:       "valid request",
 		input:      strings.NewReader(`{"body":"Where does the sun set?","options":[{"body":"East","correct":false},{"body":"West","correct":true}]}`),
 		statusCode: 200,
+	}, {
+		name:       "duplicate warning",
+		input:      strings.NewReader(`{"body":"Where does the sun set ?","options":[{"body":"East","correct":false},{"body":"West","correct":true}]}`),
+		statusCode: 200,
+		warning:    true,
+	}, {
+		name:       "duplicate blocked",
+		input:      strings.NewReader(`{"body":"Where does the sun set??","options":[{"body":"East","correct":false},{"body":"West","correct":true}]}`),
+		statusCode: 409,
 	}}
 
 	for _, tc := range testCases {
@@ -81,13 +344,23 @@ func TestAdd(t *testing.T) {
 				resBody, _ := ioutil.ReadAll(result.Body)
 				t.Errorf("expected status code (%v), got (%v) with response: (%v)", tc.statusCode, result.StatusCode, string(resBody))
 			}
+
+			if (result.Header.Get("Warning") != "") != tc.warning {
+				t.Errorf("expected warning (%v), got warning (%v)", tc.warning, result.Header.Get("Warning"))
+			}
+
+			// the stored question is returned, with its id and the ids of its options
+			var q entities.Question
+			if result.StatusCode == 200 && (q.FromJSON(result.Body) != nil || q.Id != 2 || q.Options[0].Id != 1) {
+				t.Errorf("expected the stored question (2), got question (%v)", q)
+			}
 		})
 	}
 }
 
 func TestUpdate(t *testing.T) {
 	s := ServiceMock{}
-	l := log.New(os.Stdout, "question-api", log.LstdFlags)
+	l := logging.Discard()
 	c := NewController(&s, l)
 
 	testCases := []struct {
@@ -99,7 +372,7 @@ func TestUpdate(t *testing.T) {
 		name:       "invalid json object",
 		id:         "1",
 		input:      strings.NewReader(`"body":"Where does the sun set?","options":[{"body":"East","correct":false},{"body":"West","correct":true}]}`),
-		statusCode: 422,
+		statusCode: 400,
 	}, {
 		name:       "update error",
 		id:         "2",
@@ -110,11 +383,26 @@ func TestUpdate(t *testing.T) {
 		id:         "",
 		input:      strings.NewReader(`{"body":"errQuestion","options":[]}`),
 		statusCode: 400,
+	}, {
+		name:       "missing question",
+		id:         "404",
+		input:      strings.NewReader(`{"body":"Where does the sun set?","options":[{"body":"East","correct":false},{"body":"West","correct":true}]}`),
+		statusCode: 404,
+	}, {
+		name:       "unknown option",
+		id:         "422",
+		input:      strings.NewReader(`{"body":"Where does the sun set?","options":[{"id":9,"body":"East","correct":false},{"body":"West","correct":true}]}`),
+		statusCode: 422,
 	}, {
 		name:       "valid request",
 		id:         "1",
 		input:      strings.NewReader(`{"body":"Where does the sun set?","options":[{"body":"East","correct":false},{"body":"West","correct":true}]}`),
 		statusCode: 200,
+	}, {
+		name:       "option ids",
+		id:         "1",
+		input:      strings.NewReader(`{"body":"Where does the sun set?","options":[{"id":2,"body":"West","correct":true},{"id":1,"body":"East","correct":false},{"body":"North"}]}`),
+		statusCode: 200,
 	}, {
 		name:       "zero id",
 		id:         "0",
@@ -134,13 +422,18 @@ func TestUpdate(t *testing.T) {
 				resBody, _ := ioutil.ReadAll(result.Body)
 				t.Errorf("expected status code (%v), got (%v) with response: (%v)", tc.statusCode, result.StatusCode, string(resBody))
 			}
+
+			var q entities.Question
+			if result.StatusCode == 200 && (q.FromJSON(result.Body) != nil || strconv.FormatInt(q.Id, 10) != tc.id || q.Options[0].Id == 0) {
+				t.Errorf("expected the stored question (%s), got question (%v)", tc.id, q)
+			}
 		})
 	}
 }
 
 func TestDelete(t *testing.T) {
 	s := ServiceMock{}
-	l := log.New(os.Stdout, "question-api", log.LstdFlags)
+	l := logging.Discard()
 	c := NewController(&s, l)
 
 	testCases := []struct {
@@ -163,6 +456,11 @@ func TestDelete(t *testing.T) {
 			input:      "0",
 			statusCode: 500,
 		},
+		{
+			name:       "missing question",
+			input:      "404",
+			statusCode: 404,
+		},
 		{
 			name:       "valid request",
 			input:      "1",
@@ -188,7 +486,7 @@ func TestDelete(t *testing.T) {
 
 func TestGetAll(t *testing.T) {
 	s := ServiceMock{}
-	l := log.New(os.Stdout, "question-api", log.LstdFlags)
+	l := logging.Discard()
 	c := NewController(&s, l)
 
 	testCases := []struct {
@@ -226,6 +524,76 @@ func TestGetAll(t *testing.T) {
 			input:      "?last_id=-2",
 			statusCode: 500,
 		},
+		{
+			name:       "size too large",
+			input:      "?size=1001",
+			statusCode: 400,
+		},
+		{
+			name:       "zero size",
+			input:      "?size=0",
+			statusCode: 400,
+		},
+		{
+			name:       "sort with direction",
+			input:      "?sort=body:desc",
+			statusCode: 200,
+		},
+		{
+			name:       "unknown sort key",
+			input:      "?sort=rating",
+			statusCode: 400,
+		},
+		{
+			name:       "option count and type filters",
+			input:      "?min_options=3&max_options=5&type=multiple_choice",
+			statusCode: 200,
+		},
+		{
+			name:       "inverted option counts",
+			input:      "?min_options=5&max_options=3",
+			statusCode: 400,
+		},
+		{
+			name:       "unknown type",
+			input:      "?type=essay",
+			statusCode: 400,
+		},
+		{
+			name:       "status filter",
+			input:      "?status=in_review",
+			statusCode: 200,
+		},
+		{
+			name:       "unknown status",
+			input:      "?status=archived",
+			statusCode: 400,
+		},
+		{
+			name:       "history filters",
+			input:      "?sort=updated_at:desc&created_after=2021-03-01T00:00:00Z&updated_before=2021-04-01T00:00:00%2B02:00&author=alice&updated_by=bob",
+			statusCode: 200,
+		},
+		{
+			name:       "invalid time",
+			input:      "?created_after=yesterday",
+			statusCode: 400,
+		},
+		{
+			name:       "inverted time range",
+			input:      "?updated_after=2021-04-01T00:00:00Z&updated_before=2021-03-01T00:00:00Z",
+			statusCode: 400,
+		},
+		{
+			name:       "last_id with another sort",
+			input:      "?sort=body&last_id=10",
+			statusCode: 400,
+		},
+		{
+			name:       "invalid cursor",
+			input:      "?cursor=not-a-cursor",
+			statusCode: 400,
+		},
 	}
 
 	for _, tc := range testCases {
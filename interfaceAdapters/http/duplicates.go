@@ -0,0 +1,83 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/norby7/questions-rest-api/usecases/service"
+	"net/http"
+	"strconv"
+)
+
+// Groups of questions that are likely duplicates of each other
+// swagger:response duplicatesResponse
+type duplicatesResponse struct {
+	// in: body
+	Body []service.DuplicateGroup
+}
+
+// Existing questions the new question is a near-duplicate of
+// swagger:response duplicatesErrorResponse
+type duplicatesErrorResponse struct {
+	Message    string              `json:"message"`
+	Duplicates []service.Duplicate `json:"duplicates"`
+}
+
+// swagger:parameters Duplicates
+type duplicatesParams struct {
+	// minimum similarity, between 0 and 1, of two questions to be reported as duplicates, defaulted to 0.8
+	// in: query
+	Threshold float64 `json:"threshold"`
+}
+
+// swagger:route GET /questions/duplicates questions Duplicates
+// Returns the groups of questions that are likely duplicates of each other, with their similarity scores
+// responses:
+// 200: duplicatesResponse
+// 400: errorResponse
+// 401: errorResponse
+// 403: forbiddenResponse
+// 429: rateLimitedResponse
+// 500: errorResponse
+
+// Duplicates returns the groups of near-duplicate questions
+// It can accept one query parameter:
+// - threshold: the minimum similarity of two questions to be considered duplicates, defaulted to 0.8
+func (c *Controller) Duplicates(rw http.ResponseWriter, r *http.Request) {
+	rw.Header().Set("Content-type", "application/json")
+	c.Logger.DebugContext(r.Context(), "Handle Duplicates questions")
+
+	threshold := service.DefaultDuplicateThreshold
+	if thresholdParam := r.URL.Query().Get("threshold"); thresholdParam != "" {
+		var err error
+		threshold, err = strconv.ParseFloat(thresholdParam, 64)
+		if err != nil {
+			http.Error(rw, fmt.Sprintf("invalid threshold query parameter: %s", err.Error()), http.StatusBadRequest)
+			return
+		}
+	}
+
+	groups, err := c.Service.Duplicates(r.Context(), threshold)
+	if err != nil {
+		if writeAuthorizationError(rw, err) {
+			return
+		}
+
+		if writeContextError(rw, r) {
+			return
+		}
+
+		if err == service.DuplicateThresholdError {
+			http.Error(rw, fmt.Sprintf("invalid threshold query parameter: %s", err.Error()), http.StatusBadRequest)
+			return
+		}
+
+		http.Error(rw, fmt.Sprintf("unable to search for duplicates: %s", err.Error()), http.StatusInternalServerError)
+		return
+	}
+
+	err = json.NewEncoder(rw).Encode(groups)
+	if err != nil {
+		http.Error(rw, fmt.Sprintf("unable to encode duplicates response: %s", err.Error()), http.StatusInternalServerError)
+		return
+	}
+}
@@ -0,0 +1,61 @@
+package http
+
+import (
+	"github.com/norby7/questions-rest-api/logging"
+	"io/ioutil"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDuplicates(t *testing.T) {
+	s := ServiceMock{}
+	l := logging.Discard()
+	c := NewController(&s, l)
+
+	testCases := []struct {
+		name       string
+		input      string
+		statusCode int
+	}{
+		{
+			name:       "default threshold",
+			input:      "",
+			statusCode: 200,
+		},
+		{
+			name:       "custom threshold",
+			input:      "?threshold=0.9",
+			statusCode: 200,
+		},
+		{
+			name:       "non numeric threshold",
+			input:      "?threshold=high",
+			statusCode: 400,
+		},
+		{
+			name:       "out of range threshold",
+			input:      "?threshold=2",
+			statusCode: 400,
+		},
+		{
+			name:       "duplicates error",
+			input:      "?threshold=0.5",
+			statusCode: 500,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/questions/duplicates"+tc.input, nil)
+			rec := httptest.NewRecorder()
+
+			c.Duplicates(rec, req)
+			result := rec.Result()
+
+			if result.StatusCode != tc.statusCode {
+				resBody, _ := ioutil.ReadAll(result.Body)
+				t.Errorf("expected status code (%v), got (%v) with response: (%v)", tc.statusCode, result.StatusCode, string(resBody))
+			}
+		})
+	}
+}
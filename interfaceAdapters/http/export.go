@@ -0,0 +1,121 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/norby7/questions-rest-api/interfaceAdapters/format"
+	"github.com/norby7/questions-rest-api/usecases/service"
+	"net/http"
+)
+
+// swagger:parameters Export
+type exportParams struct {
+	// stream format: jsonl (default), csv, yaml, markdown, moodle (Moodle XML), gift or qti (QTI 2.1 content package)
+	// in: query
+	Format string `json:"format"`
+}
+
+// Stream containing every question in the requested format
+// swagger:response exportResponse
+type exportResponse struct {
+	// in: body
+	Body string
+}
+
+// Questions that can't be represented in the requested format
+// swagger:response exportErrorResponse
+type exportErrorResponse struct {
+	Message  string                  `json:"message"`
+	Problems []service.ExportProblem `json:"problems"`
+}
+
+// swagger:route GET /questions/export questions Export
+// Exports every question in the database, with its options, review status and metadata, in JSONL, CSV, YAML, Markdown, Moodle XML, GIFT or QTI 2.1 format
+// The Moodle XML, GIFT and QTI quizzes only hold the published questions, without their metadata
+// produces:
+// - application/x-ndjson
+// - text/csv
+// - application/yaml
+// - text/markdown
+// - application/xml
+// - text/plain
+// - application/zip
+// responses:
+// 200: exportResponse
+// 400: errorResponse
+// 401: errorResponse
+// 403: forbiddenResponse
+// 422: exportErrorResponse
+// 429: rateLimitedResponse
+// 500: errorResponse
+
+// Export streams every question in the database in the format given by the format query parameter
+// The JSONL, CSV, YAML, Moodle XML and GIFT exports can be imported back with the import endpoint.
+// For the LMS formats the export fails with 422, before writing anything, if some questions can't be represented.
+func (c *Controller) Export(rw http.ResponseWriter, r *http.Request) {
+	c.Logger.DebugContext(r.Context(), "Handle Export questions")
+
+	f := format.JSONL
+	if formatParam := r.URL.Query().Get("format"); formatParam != "" {
+		var err error
+		if f, err = format.Parse(formatParam); err != nil {
+			http.Error(rw, fmt.Sprintf("invalid format query parameter: %s", err.Error()), http.StatusBadRequest)
+			return
+		}
+	}
+
+	rw.Header().Set("Content-type", f.ContentType())
+	rw.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="questions.%s"`, f.Extension()))
+
+	tw := &trackingWriter{ResponseWriter: rw}
+	enc, err := format.NewEncoder(f, tw)
+	if err != nil {
+		http.Error(rw, fmt.Sprintf("unable to create export stream: %s", err.Error()), http.StatusBadRequest)
+		return
+	}
+
+	err = c.Service.Export(r.Context(), enc)
+	if err == nil {
+		err = enc.Close()
+	}
+
+	if err != nil {
+		// once the stream started the status code can't be changed anymore, so the error can only be logged
+		if tw.written {
+			c.Logger.ErrorContext(r.Context(), "unable to export questions", "error", err.Error())
+			return
+		}
+
+		if writeAuthorizationError(rw, err) {
+			return
+		}
+
+		if writeContextError(rw, r) {
+			return
+		}
+
+		var exportErr *service.ExportError
+		if errors.As(err, &exportErr) {
+			rw.Header().Del("Content-Disposition")
+			rw.Header().Set("Content-type", "application/json")
+			rw.WriteHeader(http.StatusUnprocessableEntity)
+			_ = json.NewEncoder(rw).Encode(exportErrorResponse{Message: "some questions can't be exported in the requested format", Problems: exportErr.Problems})
+			return
+		}
+
+		http.Error(rw, fmt.Sprintf("unable to export questions: %s", err.Error()), http.StatusInternalServerError)
+		return
+	}
+}
+
+// trackingWriter records whether anything was written to the response
+type trackingWriter struct {
+	http.ResponseWriter
+	written bool
+}
+
+func (w *trackingWriter) Write(b []byte) (int, error) {
+	w.written = true
+	return w.ResponseWriter.Write(b)
+}
@@ -0,0 +1,74 @@
+package http
+
+import (
+	"github.com/norby7/questions-rest-api/logging"
+	"io/ioutil"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestExport(t *testing.T) {
+	s := ServiceMock{}
+	l := logging.Discard()
+	c := NewController(&s, l)
+
+	testCases := []struct {
+		name        string
+		query       string
+		statusCode  int
+		contentType string
+		contains    string
+	}{{
+		name:       "unknown format",
+		query:      "?format=docx",
+		statusCode: 400,
+	}, {
+		name:        "default format",
+		query:       "",
+		statusCode:  200,
+		contentType: "application/x-ndjson",
+		contains:    `{"id":1,"body":"Where does the sun set?","options":[]}`,
+	}, {
+		name:        "csv format",
+		query:       "?format=csv",
+		statusCode:  200,
+		contentType: "text/csv; charset=utf-8",
+		contains:    "1,Where does the sun set?",
+	}, {
+		name:        "markdown format",
+		query:       "?format=md",
+		statusCode:  200,
+		contentType: "text/markdown; charset=utf-8",
+		contains:    "## Question 1",
+	}, {
+		name:        "unrepresentable questions",
+		query:       "?format=moodle",
+		statusCode:  422,
+		contentType: "application/json",
+		contains:    `"question_id":1`,
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/questions/export"+tc.query, nil)
+			rec := httptest.NewRecorder()
+
+			c.Export(rec, req)
+			result := rec.Result()
+			resBody, _ := ioutil.ReadAll(result.Body)
+
+			if result.StatusCode != tc.statusCode {
+				t.Fatalf("expected status code (%v), got (%v) with response: (%v)", tc.statusCode, result.StatusCode, string(resBody))
+			}
+
+			if tc.contentType != "" && result.Header.Get("Content-type") != tc.contentType {
+				t.Errorf("expected content type (%v), got (%v)", tc.contentType, result.Header.Get("Content-type"))
+			}
+
+			if !strings.Contains(string(resBody), tc.contains) {
+				t.Errorf("expected response to contain (%v), got (%v)", tc.contains, string(resBody))
+			}
+		})
+	}
+}
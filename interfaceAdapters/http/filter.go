@@ -0,0 +1,215 @@
+package http
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"github.com/norby7/questions-rest-api/entities"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// swagger:parameters GetAll
+type questionsListParams struct {
+	// sort key, id, body, created_at, updated_at or difficulty, optionally followed by :asc or :desc
+	// in: query
+	// default: id:desc
+	Sort string `json:"sort"`
+	// only the questions with at least this number of options
+	// in: query
+	MinOptions int `json:"min_options"`
+	// only the questions with at most this number of options
+	// in: query
+	MaxOptions int `json:"max_options"`
+	// only the questions of this type
+	// in: query
+	// enum: single_choice,multiple_choice
+	Type string `json:"type"`
+	// only the questions with this review status, candidates only get the published ones
+	// in: query
+	// enum: draft,in_review,published,retired
+	Status string `json:"status"`
+	// only the questions created at or after this RFC 3339 time
+	// in: query
+	CreatedAfter string `json:"created_after"`
+	// only the questions created before this RFC 3339 time
+	// in: query
+	CreatedBefore string `json:"created_before"`
+	// only the questions last updated at or after this RFC 3339 time
+	// in: query
+	UpdatedAfter string `json:"updated_after"`
+	// only the questions last updated before this RFC 3339 time
+	// in: query
+	UpdatedBefore string `json:"updated_before"`
+	// only the questions created by this subject
+	// in: query
+	Author string `json:"author"`
+	// only the questions last updated by this subject
+	// in: query
+	UpdatedBy string `json:"updated_by"`
+	// position of the previous page, taken from the next link of its response
+	// in: query
+	Cursor string `json:"cursor"`
+	// id of the last question of the previous page, when sorting by id
+	// in: query
+	LastId int64 `json:"last_id"`
+	// number of questions of the page, without size, cursor and last_id at most the maximum page size of questions are returned
+	// in: query
+	Size int `json:"size"`
+}
+
+// pageCursor is the position of the last question of a page, encoded in the cursor query parameter of the next page
+// It carries the sort it was made for, so that it isn't used to seek a list sorted differently.
+type pageCursor struct {
+	Sort string `json:"sort"`
+	entities.QuestionCursor
+}
+
+// encodeCursor returns the cursor query parameter seeking the questions after q in the filter order
+func encodeCursor(f entities.QuestionFilter, q entities.Question) (string, error) {
+	data, err := json.Marshal(pageCursor{Sort: sortParam(f), QuestionCursor: f.CursorOf(q)})
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// decodeCursor returns the position encoded in the cursor query parameter, which must match the filter sort
+func decodeCursor(f entities.QuestionFilter, s string) (*entities.QuestionCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+
+	var c pageCursor
+	if err = json.Unmarshal(data, &c); err != nil {
+		return nil, err
+	}
+
+	if c.Sort != sortParam(f) {
+		return nil, fmt.Errorf("the cursor was made for sort %s", c.Sort)
+	}
+
+	return &c.QuestionCursor, nil
+}
+
+// sortParam returns the sort query parameter of the filter
+func sortParam(f entities.QuestionFilter) string {
+	return fmt.Sprintf("%s:%s", f.Sort, f.Direction)
+}
+
+// questionFilter parses the questions list query parameters
+// The questions are sorted by descending id unless the sort parameter says otherwise. They're paginated when the size,
+// cursor or last_id parameter is given, the pages hold DefaultPageSize questions unless size says otherwise. Otherwise
+// the first MaxPageSize questions are returned, the next link of a full list leads to the following ones.
+func (c *Controller) questionFilter(r *http.Request) (entities.QuestionFilter, error) {
+	query := r.URL.Query()
+	f := entities.QuestionFilter{
+		Sort:      entities.SortById,
+		Direction: entities.Descending,
+		Type:      entities.QuestionType(query.Get("type")),
+		Status:    entities.QuestionStatus(query.Get("status")),
+		Author:    query.Get("author"),
+		UpdatedBy: query.Get("updated_by"),
+	}
+
+	var err error
+	if v := query.Get("sort"); v != "" {
+		if f.Sort, f.Direction, err = entities.ParseQuestionSort(v); err != nil {
+			return f, fmt.Errorf("invalid sort query parameter: %s", err.Error())
+		}
+	}
+
+	if v := query.Get("size"); v != "" {
+		if f.Limit, err = strconv.Atoi(v); err != nil {
+			return f, fmt.Errorf("invalid size query parameter: %s", err.Error())
+		}
+
+		if f.Limit < 1 || f.Limit > c.MaxPageSize {
+			return f, fmt.Errorf("invalid size query parameter: must be between 1 and %d", c.MaxPageSize)
+		}
+	}
+
+	if v := query.Get("min_options"); v != "" {
+		if f.MinOptions, err = strconv.Atoi(v); err != nil {
+			return f, fmt.Errorf("invalid min_options query parameter: %s", err.Error())
+		}
+	}
+
+	if v := query.Get("max_options"); v != "" {
+		if f.MaxOptions, err = strconv.Atoi(v); err != nil {
+			return f, fmt.Errorf("invalid max_options query parameter: %s", err.Error())
+		}
+	}
+
+	for _, t := range []struct {
+		param string
+		value *time.Time
+	}{
+		{"created_after", &f.CreatedAfter},
+		{"created_before", &f.CreatedBefore},
+		{"updated_after", &f.UpdatedAfter},
+		{"updated_before", &f.UpdatedBefore},
+	} {
+		if v := query.Get(t.param); v != "" {
+			if *t.value, err = time.Parse(time.RFC3339, v); err != nil {
+				return f, fmt.Errorf("invalid %s query parameter: %s", t.param, err.Error())
+			}
+		}
+	}
+
+	if err = f.Validate(); err != nil {
+		return f, err
+	}
+
+	if v := query.Get("last_id"); v != "" {
+		if f.Sort != entities.SortById {
+			return f, fmt.Errorf("invalid last_id query parameter: only used when sorting by id, use cursor instead")
+		}
+
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return f, fmt.Errorf("invalid last_id query parameter: %s", err.Error())
+		}
+
+		f.After = &entities.QuestionCursor{Id: id}
+	}
+
+	if v := query.Get("cursor"); v != "" {
+		if f.After, err = decodeCursor(f, v); err != nil {
+			return f, fmt.Errorf("invalid cursor query parameter: %s", err.Error())
+		}
+	}
+
+	if f.Limit == 0 {
+		f.Limit = c.MaxPageSize
+		if f.After != nil {
+			f.Limit = c.DefaultPageSize
+		}
+	}
+
+	return f, nil
+}
+
+// setNextLink sets the Link header of the next page of questions when the page is full
+func setNextLink(rw http.ResponseWriter, r *http.Request, f entities.QuestionFilter, ql []entities.Question) error {
+	if len(ql) == 0 || len(ql) < f.Limit {
+		return nil
+	}
+
+	cursor, err := encodeCursor(f, ql[len(ql)-1])
+	if err != nil {
+		return err
+	}
+
+	u := *r.URL
+	query := u.Query()
+	query.Del("last_id")
+	query.Set("cursor", cursor)
+	u.RawQuery = query.Encode()
+
+	rw.Header().Set("Link", fmt.Sprintf(`<%s>; rel="next"`, u.RequestURI()))
+	return nil
+}
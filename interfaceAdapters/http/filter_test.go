@@ -0,0 +1,171 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/norby7/questions-rest-api/entities"
+	"github.com/norby7/questions-rest-api/logging"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestGetAllNextLink(t *testing.T) {
+	s := ServiceMock{}
+	c := NewController(&s, logging.Discard())
+
+	testCases := []struct {
+		name  string
+		query string
+		next  bool
+	}{{
+		name:  "full page",
+		query: "?size=2&sort=body:asc&type=single_choice",
+		next:  true,
+	}, {
+		name:  "last page",
+		query: "?size=3",
+	}, {
+		name:  "last_id replaced by the cursor",
+		query: "?size=2&last_id=10",
+		next:  true,
+	}, {
+		name:  "not paginated",
+		query: "?sort=body:asc",
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			c.GetAll(rec, httptest.NewRequest("GET", "/questions"+tc.query, nil))
+
+			link := rec.Header().Get("Link")
+			if (link != "") != tc.next {
+				t.Fatalf("expected next link (%v), got Link header (%s)", tc.next, link)
+			}
+
+			if !tc.next {
+				return
+			}
+
+			if !strings.HasPrefix(link, "</questions?") || !strings.HasSuffix(link, `>; rel="next"`) {
+				t.Fatalf("expected a next link to the questions, got Link header (%s)", link)
+			}
+
+			next, err := url.Parse(strings.TrimSuffix(strings.TrimPrefix(link, "<"), `>; rel="next"`))
+			if err != nil {
+				t.Fatalf("unable to parse next link: %s", err.Error())
+			}
+
+			if next.Query().Get("last_id") != "" {
+				t.Errorf("expected the next link without last_id, got link (%s)", next)
+			}
+
+			// the next page is requested with the same filter and the cursor of the last question
+			f, err := c.questionFilter(httptest.NewRequest("GET", next.String(), nil))
+			if err != nil {
+				t.Fatalf("unable to parse the next page filter: %s", err.Error())
+			}
+
+			if f.After == nil || f.After.Id != 1 {
+				t.Errorf("expected the cursor of question (1), got cursor (%v)", f.After)
+			}
+
+			// a cursor can't seek a list sorted differently
+			other := strings.Replace(next.String(), "sort=body%3Aasc", "sort=body%3Adesc", 1)
+			if other != next.String() {
+				if _, err = c.questionFilter(httptest.NewRequest("GET", other, nil)); err == nil {
+					t.Errorf("expected an error using the cursor with another sort")
+				}
+			}
+		})
+	}
+}
+
+func TestQuestionFilterPagination(t *testing.T) {
+	c := NewController(&ServiceMock{}, logging.Discard())
+	c.DefaultPageSize = 5
+	c.MaxPageSize = 1000
+
+	cursor, err := encodeCursor(entities.QuestionFilter{Sort: entities.SortByDifficulty, Direction: entities.Ascending}, entities.Question{Id: 3, Difficulty: 2})
+	if err != nil {
+		t.Fatalf("unable to encode cursor: %s", err.Error())
+	}
+
+	testCases := []struct {
+		name  string
+		query string
+		limit int
+	}{
+		{name: "not paginated", query: "?sort=difficulty", limit: 1000},
+		{name: "size", query: "?size=20", limit: 20},
+		{name: "last_id", query: "?last_id=10", limit: 5},
+		{name: "cursor", query: "?sort=difficulty&cursor=" + cursor, limit: 5},
+		{name: "cursor and size", query: "?sort=difficulty&size=2&cursor=" + cursor, limit: 2},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			f, err := c.questionFilter(httptest.NewRequest("GET", "/questions"+tc.query, nil))
+			if err != nil {
+				t.Fatalf("unable to parse filter: %s", err.Error())
+			}
+
+			if f.Limit != tc.limit {
+				t.Errorf("expected limit (%d), got limit (%d)", tc.limit, f.Limit)
+			}
+		})
+	}
+}
+
+// bankMock is a service listing a bank of questions, ids descending
+type bankMock struct {
+	ServiceMock
+	size int
+}
+
+func (b *bankMock) ListAll(ctx context.Context, f entities.QuestionFilter) ([]entities.Question, error) {
+	var ql []entities.Question
+	for id := int64(b.size); id > 0 && len(ql) < f.Limit; id-- {
+		if f.After == nil || id < f.After.Id {
+			ql = append(ql, entities.Question{Id: id, Body: fmt.Sprintf("Question %d?", id)})
+		}
+	}
+
+	return ql, nil
+}
+
+func TestGetAllMaxPageSize(t *testing.T) {
+	c := NewController(&bankMock{size: 7}, logging.Discard())
+	c.MaxPageSize = 5
+
+	// the list without pagination parameters is capped, the next link leads to the remaining questions
+	target, pages := "/questions", [][]int64{{7, 6, 5, 4, 3}, {2, 1}}
+	for i, expected := range pages {
+		rec := httptest.NewRecorder()
+		c.GetAll(rec, httptest.NewRequest("GET", target, nil))
+
+		var ql []entities.Question
+		if err := json.NewDecoder(rec.Body).Decode(&ql); err != nil {
+			t.Fatalf("unable to decode questions: %s", err.Error())
+		}
+
+		ids := make([]int64, len(ql))
+		for j, q := range ql {
+			ids[j] = q.Id
+		}
+
+		if fmt.Sprint(ids) != fmt.Sprint(expected) {
+			t.Errorf("expected questions (%v) in page (%d), got questions (%v)", expected, i, ids)
+		}
+
+		link := rec.Header().Get("Link")
+		if (link != "") != (i < len(pages)-1) {
+			t.Fatalf("expected next link (%v) in page (%d), got Link header (%s)", i < len(pages)-1, i, link)
+		}
+
+		target = strings.TrimSuffix(strings.TrimPrefix(link, "<"), `>; rel="next"`)
+	}
+}
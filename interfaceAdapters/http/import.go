@@ -0,0 +1,109 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/norby7/questions-rest-api/interfaceAdapters/format"
+	"github.com/norby7/questions-rest-api/usecases/service"
+	"net/http"
+	"strconv"
+)
+
+// Report of a bulk import, with the outcome of every record
+// swagger:response importReportResponse
+type importReportResponse struct {
+	// in: body
+	Body service.ImportReport
+}
+
+// swagger:parameters Import
+type importParams struct {
+	// stream format: jsonl, csv, yaml, moodle (Moodle XML) or gift, defaults to the format matching the Content-Type header
+	// in: query
+	Format string `json:"format"`
+	// import mode: atomic (all-or-nothing, default) or best-effort
+	// in: query
+	Mode string `json:"mode"`
+	// keep the review status, the review comment and the creation and update times and authors of the records, admins only
+	// in: query
+	Restore bool `json:"restore"`
+}
+
+// swagger:route POST /questions/import questions Import
+// Imports a stream of questions in JSONL, CSV, YAML, Moodle XML or GIFT format and returns a report for every record
+// responses:
+// 200: importReportResponse
+// 400: errorResponse
+// 401: errorResponse
+// 403: forbiddenResponse
+// 413: errorResponse
+// 422: importReportResponse
+// 429: rateLimitedResponse
+// 500: errorResponse
+
+// Import creates the questions read from the request body and returns a per record report
+// It can accept two query parameters:
+// - format: the format of the request body, if missing it is determined from the Content-Type header
+// - mode: atomic imports all questions or none of them, best-effort imports every valid question
+// - restore: true keeps the review status and metadata of the records, the questions are new drafts otherwise
+func (c *Controller) Import(rw http.ResponseWriter, r *http.Request) {
+	rw.Header().Set("Content-type", "application/json")
+	c.Logger.DebugContext(r.Context(), "Handle Import questions")
+
+	var f format.Format
+	var err error
+	if formatParam := r.URL.Query().Get("format"); formatParam != "" {
+		f, err = format.Parse(formatParam)
+	} else {
+		f, err = format.FromContentType(r.Header.Get("Content-Type"))
+	}
+	if err != nil {
+		http.Error(rw, fmt.Sprintf("invalid import format: %s", err.Error()), http.StatusBadRequest)
+		return
+	}
+
+	mode, err := service.ParseImportMode(r.URL.Query().Get("mode"))
+	if err != nil {
+		http.Error(rw, fmt.Sprintf("invalid mode query parameter: %s", err.Error()), http.StatusBadRequest)
+		return
+	}
+
+	restore := false
+	if v := r.URL.Query().Get("restore"); v != "" {
+		if restore, err = strconv.ParseBool(v); err != nil {
+			http.Error(rw, fmt.Sprintf("invalid restore query parameter: %s", err.Error()), http.StatusBadRequest)
+			return
+		}
+	}
+
+	dec, err := format.NewDecoder(f, r.Body)
+	if err != nil {
+		writeBodyError(rw, "unable to read import stream", err)
+		return
+	}
+
+	report, err := c.Service.Import(r.Context(), dec, mode, restore)
+	if err != nil {
+		if writeAuthorizationError(rw, err) {
+			return
+		}
+
+		if writeContextError(rw, r) {
+			return
+		}
+
+		http.Error(rw, fmt.Sprintf("unable to import questions: %s", err.Error()), http.StatusInternalServerError)
+		return
+	}
+
+	// in atomic mode nothing was imported if any record failed
+	if mode == service.ImportAtomic && report.Failed > 0 {
+		rw.WriteHeader(http.StatusUnprocessableEntity)
+	}
+
+	err = json.NewEncoder(rw).Encode(report)
+	if err != nil {
+		http.Error(rw, fmt.Sprintf("unable to encode import report: %s", err.Error()), http.StatusInternalServerError)
+		return
+	}
+}
@@ -0,0 +1,89 @@
+package http
+
+import (
+	"github.com/norby7/questions-rest-api/logging"
+	"io/ioutil"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestImport(t *testing.T) {
+	s := ServiceMock{}
+	l := logging.Discard()
+	c := NewController(&s, l)
+
+	testCases := []struct {
+		name        string
+		query       string
+		contentType string
+		input       string
+		statusCode  int
+	}{{
+		name:       "unknown format",
+		query:      "?format=docx",
+		input:      `<question/>`,
+		statusCode: 400,
+	}, {
+		name:        "missing format",
+		contentType: "application/json",
+		input:       `{"body":"Where does the sun set?"}`,
+		statusCode:  400,
+	}, {
+		name:       "invalid mode",
+		query:      "?format=jsonl&mode=partial",
+		input:      `{"body":"Where does the sun set?"}`,
+		statusCode: 400,
+	}, {
+		name:       "invalid restore",
+		query:      "?format=jsonl&restore=maybe",
+		input:      `{"body":"Where does the sun set?"}`,
+		statusCode: 400,
+	}, {
+		name:       "restore",
+		query:      "?format=jsonl&restore=true",
+		input:      `{"body":"Where does the sun set?"}`,
+		statusCode: 200,
+	}, {
+		name:       "invalid csv header",
+		query:      "?format=csv",
+		input:      "question,answer\n",
+		statusCode: 400,
+	}, {
+		name:       "import error",
+		query:      "?format=jsonl",
+		input:      `{"body":"errQuestion","options":[]}`,
+		statusCode: 500,
+	}, {
+		name:       "atomic import with invalid records",
+		query:      "?format=jsonl",
+		input:      "{\"body\":\"Where does the sun set?\"}\n{invalid\n",
+		statusCode: 422,
+	}, {
+		name:       "best effort import with invalid records",
+		query:      "?format=jsonl&mode=best-effort",
+		input:      "{\"body\":\"Where does the sun set?\"}\n{invalid\n",
+		statusCode: 200,
+	}, {
+		name:        "format from content type",
+		contentType: "application/yaml",
+		input:       "- body: Where does the sun set?\n",
+		statusCode:  200,
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/questions/import"+tc.query, strings.NewReader(tc.input))
+			req.Header.Set("Content-Type", tc.contentType)
+			rec := httptest.NewRecorder()
+
+			c.Import(rec, req)
+			result := rec.Result()
+
+			if result.StatusCode != tc.statusCode {
+				resBody, _ := ioutil.ReadAll(result.Body)
+				t.Errorf("expected status code (%v), got (%v) with response: (%v)", tc.statusCode, result.StatusCode, string(resBody))
+			}
+		})
+	}
+}
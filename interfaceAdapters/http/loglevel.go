@@ -0,0 +1,99 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/norby7/questions-rest-api/entities"
+	"github.com/norby7/questions-rest-api/logging"
+	"github.com/norby7/questions-rest-api/usecases/service"
+	"net/http"
+)
+
+// LogLevel is the level of the application logger: debug, info, warn or error
+// swagger:model
+type LogLevel struct {
+	Level string `json:"level"`
+}
+
+// Current level of the application logger
+// swagger:response logLevelResponse
+type logLevelResponse struct {
+	// in: body
+	Body LogLevel
+}
+
+// swagger:parameters SetLogLevel
+type logLevelParam struct {
+	// New level of the application logger
+	// in: body
+	// required: true
+	Body LogLevel
+}
+
+// swagger:route GET /log/level log GetLogLevel
+// Returns the current level of the application logger
+// responses:
+// 200: logLevelResponse
+// 401: errorResponse
+// 403: forbiddenResponse
+// 429: rateLimitedResponse
+
+// GetLogLevel returns the current level of the application logger
+func (c *Controller) GetLogLevel(rw http.ResponseWriter, r *http.Request) {
+	rw.Header().Set("Content-type", "application/json")
+	c.Logger.DebugContext(r.Context(), "Handle GetLogLevel")
+
+	if writeAuthorizationError(rw, c.Policy.Authorize(r.Context(), service.ActionLogLevel)) {
+		return
+	}
+
+	c.writeLogLevel(rw)
+}
+
+// swagger:route PUT /log/level log SetLogLevel
+// Changes the level of the application logger, the change takes effect immediately and lasts until the application is restarted
+// responses:
+// 200: logLevelResponse
+// 400: errorResponse
+// 401: errorResponse
+// 403: forbiddenResponse
+// 413: errorResponse
+// 422: errorResponse
+// 429: rateLimitedResponse
+
+// SetLogLevel changes the level of the application logger and returns the new level
+func (c *Controller) SetLogLevel(rw http.ResponseWriter, r *http.Request) {
+	rw.Header().Set("Content-type", "application/json")
+	c.Logger.DebugContext(r.Context(), "Handle SetLogLevel")
+
+	if writeAuthorizationError(rw, c.Policy.Authorize(r.Context(), service.ActionLogLevel)) {
+		return
+	}
+
+	var l LogLevel
+	if err := entities.DecodeJSON(r.Body, &l); err != nil {
+		writeBodyError(rw, "unable to parse log level object", err)
+		return
+	}
+
+	level, err := logging.ParseLevel(l.Level)
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	previous := c.Level.Level()
+	c.Level.Set(level)
+	c.Logger.InfoContext(r.Context(), "log level changed", "from", logging.LevelName(previous), "to", logging.LevelName(level))
+
+	c.writeLogLevel(rw)
+}
+
+// writeLogLevel writes the current log level as response
+func (c *Controller) writeLogLevel(rw http.ResponseWriter) {
+	err := json.NewEncoder(rw).Encode(LogLevel{Level: logging.LevelName(c.Level.Level())})
+	if err != nil {
+		http.Error(rw, fmt.Sprintf("unable to encode log level response: %s", err.Error()), http.StatusInternalServerError)
+		return
+	}
+}
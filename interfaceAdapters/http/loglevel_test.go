@@ -0,0 +1,102 @@
+package http
+
+import (
+	"github.com/norby7/questions-rest-api/entities"
+	"github.com/norby7/questions-rest-api/logging"
+	"github.com/norby7/questions-rest-api/usecases/auth"
+	"io/ioutil"
+	"log/slog"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestLogLevel(t *testing.T) {
+	c := NewController(&ServiceMock{}, logging.Discard())
+
+	testCases := []struct {
+		name       string
+		method     string
+		body       string
+		role       entities.Role
+		statusCode int
+		expected   slog.Level
+	}{
+		{
+			name:       "get level",
+			method:     "GET",
+			role:       entities.RoleAdmin,
+			statusCode: 200,
+			expected:   slog.LevelInfo,
+		},
+		{
+			name:       "set level",
+			method:     "PUT",
+			body:       `{"level": "debug"}`,
+			role:       entities.RoleAdmin,
+			statusCode: 200,
+			expected:   slog.LevelDebug,
+		},
+		{
+			name:       "unknown level",
+			method:     "PUT",
+			body:       `{"level": "verbose"}`,
+			role:       entities.RoleAdmin,
+			statusCode: 422,
+			expected:   slog.LevelDebug,
+		},
+		{
+			name:       "invalid body",
+			method:     "PUT",
+			body:       `debug`,
+			role:       entities.RoleAdmin,
+			statusCode: 400,
+			expected:   slog.LevelDebug,
+		},
+		{
+			name:       "editor",
+			method:     "PUT",
+			body:       `{"level": "error"}`,
+			role:       entities.RoleEditor,
+			statusCode: 403,
+			expected:   slog.LevelDebug,
+		},
+		{
+			name:       "unauthenticated",
+			method:     "GET",
+			statusCode: 401,
+			expected:   slog.LevelDebug,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, "/log/level", strings.NewReader(tc.body))
+			if tc.role != "" {
+				req = req.WithContext(auth.NewContext(req.Context(), entities.Principal{Subject: "alice", Roles: []entities.Role{tc.role}}))
+			}
+
+			rec := httptest.NewRecorder()
+			if tc.method == "GET" {
+				c.GetLogLevel(rec, req)
+			} else {
+				c.SetLogLevel(rec, req)
+			}
+
+			result := rec.Result()
+			resBody, _ := ioutil.ReadAll(result.Body)
+
+			if result.StatusCode != tc.statusCode {
+				t.Errorf("expected status code (%v), got (%v) with response: (%v)", tc.statusCode, result.StatusCode, string(resBody))
+			}
+
+			if c.Level.Level() != tc.expected {
+				t.Errorf("expected level (%v), got level (%v)", tc.expected, c.Level.Level())
+			}
+
+			if tc.statusCode == 200 && !strings.Contains(string(resBody), `"level":"`+logging.LevelName(tc.expected)+`"`) {
+				t.Errorf("expected level (%v) in the response, got response (%s)", tc.expected, string(resBody))
+			}
+		})
+	}
+}
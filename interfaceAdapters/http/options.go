@@ -0,0 +1,254 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/norby7/questions-rest-api/entities"
+	"github.com/norby7/questions-rest-api/usecases/service"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Data structure representing a single option of a question
+// swagger:response optionResponse
+type optionResponse struct {
+	// A single option object
+	// in body:
+	Body entities.Option
+}
+
+// swagger:parameters AddOption UpdateOption
+type optionParam struct {
+	// Option object used for AddOption or UpdateOption
+	// Note: the ID field is ignored, the option id is taken from the path
+	// in: body
+	// required: true
+	Body entities.Option
+}
+
+// swagger:parameters ReorderOptions
+type reorderParam struct {
+	// Ids of every option of the question, in their new order
+	// in: body
+	// required: true
+	Body []int64
+}
+
+// swagger:route POST /question/{id}/options option AddOption
+// Adds an option after the other options of the question and returns it, with its new id, in the response
+// responses:
+// 200: optionResponse
+// 400: errorResponse
+// 401: errorResponse
+// 403: forbiddenResponse
+// 404: errorResponse
+// 409: errorResponse
+// 413: errorResponse
+// 422: errorResponse
+// 429: rateLimitedResponse
+// 500: errorResponse
+
+// AddOption adds the option of the request body to the question and returns the stored option in response
+func (c *Controller) AddOption(rw http.ResponseWriter, r *http.Request) {
+	rw.Header().Set("Content-type", "application/json")
+	c.Logger.DebugContext(r.Context(), "Handle Add option")
+
+	id, err := pathId(r, 1)
+	if err != nil {
+		http.Error(rw, fmt.Sprintf("invalid question id value: %s", err.Error()), http.StatusBadRequest)
+		return
+	}
+
+	var o entities.Option
+	err = o.FromJSON(r.Body)
+	if err != nil {
+		writeBodyError(rw, "unable to parse option object", err)
+		return
+	}
+
+	o, err = c.Service.AddOption(r.Context(), id, o)
+	if err != nil {
+		writeOptionError(rw, r, "unable to add option", err, http.StatusUnprocessableEntity)
+		return
+	}
+
+	err = o.ToJSON(rw)
+	if err != nil {
+		http.Error(rw, fmt.Sprintf("unable to encode response: %s", err.Error()), http.StatusInternalServerError)
+		return
+	}
+}
+
+// swagger:route PUT /question/{id}/options/{optionId} option UpdateOption
+// Updates the body and correctness of an option, which keeps its position, and returns it in the response
+// responses:
+// 200: optionResponse
+// 400: errorResponse
+// 401: errorResponse
+// 403: forbiddenResponse
+// 404: errorResponse
+// 409: errorResponse
+// 413: errorResponse
+// 422: errorResponse
+// 429: rateLimitedResponse
+// 500: errorResponse
+
+// UpdateOption changes the option of the path with the option of the request body and returns it in response
+func (c *Controller) UpdateOption(rw http.ResponseWriter, r *http.Request) {
+	rw.Header().Set("Content-type", "application/json")
+	c.Logger.DebugContext(r.Context(), "Handle Update option")
+
+	id, err := pathId(r, 1)
+	if err != nil {
+		http.Error(rw, fmt.Sprintf("invalid question id value: %s", err.Error()), http.StatusBadRequest)
+		return
+	}
+
+	optionId, err := pathId(r, 3)
+	if err != nil {
+		http.Error(rw, fmt.Sprintf("invalid option id value: %s", err.Error()), http.StatusBadRequest)
+		return
+	}
+
+	var o entities.Option
+	err = o.FromJSON(r.Body)
+	if err != nil {
+		writeBodyError(rw, "unable to parse option object", err)
+		return
+	}
+
+	o.Id = optionId
+
+	o, err = c.Service.UpdateOption(r.Context(), id, o)
+	if err != nil {
+		writeOptionError(rw, r, "unable to update option", err, http.StatusNotFound)
+		return
+	}
+
+	err = o.ToJSON(rw)
+	if err != nil {
+		http.Error(rw, fmt.Sprintf("unable to encode response: %s", err.Error()), http.StatusInternalServerError)
+		return
+	}
+}
+
+// swagger:route DELETE /question/{id}/options/{optionId} option RemoveOption
+// Deletes an option, the following options move up
+// responses:
+// 200: noContent
+// 400: errorResponse
+// 401: errorResponse
+// 403: forbiddenResponse
+// 404: errorResponse
+// 409: errorResponse
+// 422: errorResponse
+// 429: rateLimitedResponse
+// 500: errorResponse
+
+// RemoveOption deletes the option of the path from its question
+func (c *Controller) RemoveOption(rw http.ResponseWriter, r *http.Request) {
+	rw.Header().Set("Content-type", "application/json")
+	c.Logger.DebugContext(r.Context(), "Handle Remove option")
+
+	id, err := pathId(r, 1)
+	if err != nil {
+		http.Error(rw, fmt.Sprintf("invalid question id value: %s", err.Error()), http.StatusBadRequest)
+		return
+	}
+
+	optionId, err := pathId(r, 3)
+	if err != nil {
+		http.Error(rw, fmt.Sprintf("invalid option id value: %s", err.Error()), http.StatusBadRequest)
+		return
+	}
+
+	err = c.Service.RemoveOption(r.Context(), id, optionId)
+	if err != nil {
+		writeOptionError(rw, r, "unable to remove option", err, http.StatusNotFound)
+		return
+	}
+}
+
+// swagger:route POST /question/{id}/options/reorder option ReorderOptions
+// Sorts the options of a question in the order of the ids of the request body and returns the question in the response
+// responses:
+// 200: questionResponse
+// 400: errorResponse
+// 401: errorResponse
+// 403: forbiddenResponse
+// 404: errorResponse
+// 409: errorResponse
+// 413: errorResponse
+// 422: errorResponse
+// 429: rateLimitedResponse
+// 500: errorResponse
+
+// ReorderOptions sorts the options of the question with the list of option ids of the request body
+// The list holds every option id of the question once.
+func (c *Controller) ReorderOptions(rw http.ResponseWriter, r *http.Request) {
+	rw.Header().Set("Content-type", "application/json")
+	c.Logger.DebugContext(r.Context(), "Handle Reorder options")
+
+	id, err := pathId(r, 1)
+	if err != nil {
+		http.Error(rw, fmt.Sprintf("invalid question id value: %s", err.Error()), http.StatusBadRequest)
+		return
+	}
+
+	var ids []int64
+	err = entities.DecodeJSON(r.Body, &ids)
+	if err != nil {
+		writeBodyError(rw, "unable to parse option ids", err)
+		return
+	}
+
+	q, err := c.Service.ReorderOptions(r.Context(), id, ids)
+	if err != nil {
+		writeOptionError(rw, r, "unable to reorder options", err, http.StatusUnprocessableEntity)
+		return
+	}
+
+	err = json.NewEncoder(rw).Encode(q)
+	if err != nil {
+		http.Error(rw, fmt.Sprintf("unable to encode response: %s", err.Error()), http.StatusInternalServerError)
+		return
+	}
+}
+
+// writeOptionError writes the response of the errors returned by the option operations
+// An unknown option id is answered with notFound: 404 when the path names the option, 422 when the body does.
+func writeOptionError(rw http.ResponseWriter, r *http.Request, prefix string, err error, notFound int) {
+	if writeAuthorizationError(rw, err) {
+		return
+	}
+
+	if writeContextError(rw, r) {
+		return
+	}
+
+	msg := fmt.Sprintf("%s: %s", prefix, err.Error())
+	switch {
+	case errors.Is(err, service.QuestionNotFoundError):
+		http.Error(rw, msg, http.StatusNotFound)
+	case errors.Is(err, service.OptionNotFoundError):
+		http.Error(rw, msg, notFound)
+	case errors.Is(err, entities.StatusTransitionError):
+		http.Error(rw, msg, http.StatusConflict)
+	case errors.Is(err, service.InvalidQuestionError), errors.Is(err, service.InvalidOptionError), errors.Is(err, service.OptionOrderError):
+		http.Error(rw, msg, http.StatusUnprocessableEntity)
+	default:
+		http.Error(rw, msg, http.StatusInternalServerError)
+	}
+}
+
+// pathId parses the i-th segment of the request path as an id
+func pathId(r *http.Request, i int) (int64, error) {
+	segments := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if i >= len(segments) {
+		return 0, fmt.Errorf("missing path segment %d", i)
+	}
+
+	return strconv.ParseInt(segments[i], 10, 64)
+}
@@ -0,0 +1,163 @@
+package http
+
+import (
+	"github.com/norby7/questions-rest-api/logging"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestOptions(t *testing.T) {
+	s := ServiceMock{}
+	l := logging.Discard()
+	c := NewController(&s, l)
+
+	testCases := []struct {
+		name       string
+		method     string
+		path       string
+		input      string
+		handler    http.HandlerFunc
+		statusCode int
+	}{{
+		name:       "add option",
+		method:     "POST",
+		path:       "/question/1/options",
+		input:      `{"body":"North","correct":false}`,
+		handler:    c.AddOption,
+		statusCode: 200,
+	}, {
+		name:       "add invalid option",
+		method:     "POST",
+		path:       "/question/1/options",
+		input:      `{"body":""}`,
+		handler:    c.AddOption,
+		statusCode: 422,
+	}, {
+		name:       "add unknown field",
+		method:     "POST",
+		path:       "/question/1/options",
+		input:      `{"body":"North","order":2}`,
+		handler:    c.AddOption,
+		statusCode: 400,
+	}, {
+		name:       "add to missing question",
+		method:     "POST",
+		path:       "/question/404/options",
+		input:      `{"body":"North"}`,
+		handler:    c.AddOption,
+		statusCode: 404,
+	}, {
+		name:       "add forbidden",
+		method:     "POST",
+		path:       "/question/403/options",
+		input:      `{"body":"North"}`,
+		handler:    c.AddOption,
+		statusCode: 403,
+	}, {
+		name:       "update option",
+		method:     "PUT",
+		path:       "/question/1/options/2",
+		input:      `{"body":"West","correct":true}`,
+		handler:    c.UpdateOption,
+		statusCode: 200,
+	}, {
+		name:       "update missing option",
+		method:     "PUT",
+		path:       "/question/1/options/9",
+		input:      `{"body":"West","correct":true}`,
+		handler:    c.UpdateOption,
+		statusCode: 404,
+	}, {
+		name:       "update leaving no correct option",
+		method:     "PUT",
+		path:       "/question/422/options/2",
+		input:      `{"body":"West","correct":false}`,
+		handler:    c.UpdateOption,
+		statusCode: 422,
+	}, {
+		name:       "update invalid option id",
+		method:     "PUT",
+		path:       "/question/1/options/",
+		input:      `{"body":"West","correct":true}`,
+		handler:    c.UpdateOption,
+		statusCode: 400,
+	}, {
+		name:       "update error",
+		method:     "PUT",
+		path:       "/question/500/options/2",
+		input:      `{"body":"West","correct":true}`,
+		handler:    c.UpdateOption,
+		statusCode: 500,
+	}, {
+		name:       "remove option",
+		method:     "DELETE",
+		path:       "/question/1/options/1",
+		handler:    c.RemoveOption,
+		statusCode: 200,
+	}, {
+		name:       "remove missing option",
+		method:     "DELETE",
+		path:       "/question/1/options/9",
+		handler:    c.RemoveOption,
+		statusCode: 404,
+	}, {
+		name:       "remove leaving one option",
+		method:     "DELETE",
+		path:       "/question/422/options/1",
+		handler:    c.RemoveOption,
+		statusCode: 422,
+	}, {
+		name:       "reorder options",
+		method:     "POST",
+		path:       "/question/1/options/reorder",
+		input:      `[2,1]`,
+		handler:    c.ReorderOptions,
+		statusCode: 200,
+	}, {
+		name:       "reorder missing option",
+		method:     "POST",
+		path:       "/question/1/options/reorder",
+		input:      `[2]`,
+		handler:    c.ReorderOptions,
+		statusCode: 422,
+	}, {
+		name:       "reorder unknown option",
+		method:     "POST",
+		path:       "/question/1/options/reorder",
+		input:      `[2,9]`,
+		handler:    c.ReorderOptions,
+		statusCode: 422,
+	}, {
+		name:       "reorder invalid list",
+		method:     "POST",
+		path:       "/question/1/options/reorder",
+		input:      `{"ids":[2,1]}`,
+		handler:    c.ReorderOptions,
+		statusCode: 400,
+	}, {
+		name:       "reorder missing question",
+		method:     "POST",
+		path:       "/question/404/options/reorder",
+		input:      `[2,1]`,
+		handler:    c.ReorderOptions,
+		statusCode: 404,
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.input))
+			rec := httptest.NewRecorder()
+
+			tc.handler(rec, req)
+			result := rec.Result()
+
+			if result.StatusCode != tc.statusCode {
+				resBody, _ := ioutil.ReadAll(result.Body)
+				t.Errorf("expected status code (%v), got (%v) with response: (%v)", tc.statusCode, result.StatusCode, string(resBody))
+			}
+		})
+	}
+}
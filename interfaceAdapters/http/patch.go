@@ -0,0 +1,96 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"github.com/norby7/questions-rest-api/entities"
+	"github.com/norby7/questions-rest-api/usecases/service"
+	"io"
+	"net/http"
+	"path"
+	"strconv"
+)
+
+// swagger:parameters Patch
+type patchParams struct {
+	// JSON Merge Patch document (application/merge-patch+json) or list of JSON Patch operations (application/json-patch+json)
+	// in: body
+	// required: true
+	Body interface{}
+}
+
+// swagger:route PATCH /question/{id} question Patch
+// Applies a JSON Merge Patch or a JSON Patch document to an existing question and returns the patched question in the response
+// consumes:
+// - application/merge-patch+json
+// - application/json-patch+json
+// responses:
+// 200: questionResponse
+// 400: errorResponse
+// 401: errorResponse
+// 403: forbiddenResponse
+// 404: errorResponse
+// 409: errorResponse
+// 413: errorResponse
+// 415: errorResponse
+// 422: errorResponse
+// 429: rateLimitedResponse
+// 500: errorResponse
+
+// Patch changes an existing question with the patch document of the request body and returns the patched question in response
+// The Content-Type header defines the kind of document: application/merge-patch+json or application/json-patch+json.
+func (c *Controller) Patch(rw http.ResponseWriter, r *http.Request) {
+	rw.Header().Set("Content-type", "application/json")
+	c.Logger.DebugContext(r.Context(), "Handle Patch question")
+
+	id, err := strconv.Atoi(path.Base(r.URL.String()))
+	if err != nil {
+		http.Error(rw, fmt.Sprintf("invalid question id value: %s", err.Error()), http.StatusBadRequest)
+		return
+	}
+
+	t, err := service.ParsePatchType(r.Header.Get("Content-Type"))
+	if err != nil {
+		rw.Header().Set("Accept-Patch", fmt.Sprintf("%s, %s", service.MergePatch, service.JSONPatch))
+		http.Error(rw, fmt.Sprintf("unsupported patch document: %s", err.Error()), http.StatusUnsupportedMediaType)
+		return
+	}
+
+	patch, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeBodyError(rw, "unable to read patch document", err)
+		return
+	}
+
+	q, err := c.Service.Patch(r.Context(), int64(id), t, patch)
+	if err != nil {
+		if writeAuthorizationError(rw, err) {
+			return
+		}
+
+		if writeContextError(rw, r) {
+			return
+		}
+
+		msg := fmt.Sprintf("unable to patch question: %s", err.Error())
+		switch {
+		case errors.Is(err, service.PatchDocumentError):
+			http.Error(rw, msg, http.StatusBadRequest)
+		case errors.Is(err, service.QuestionNotFoundError):
+			http.Error(rw, msg, http.StatusNotFound)
+		case errors.Is(err, service.PatchConflictError), errors.Is(err, entities.StatusTransitionError):
+			http.Error(rw, msg, http.StatusConflict)
+		case errors.Is(err, service.InvalidQuestionError), errors.Is(err, service.OptionNotFoundError):
+			http.Error(rw, msg, http.StatusUnprocessableEntity)
+		default:
+			http.Error(rw, msg, http.StatusInternalServerError)
+		}
+		return
+	}
+
+	err = q.ToJSON(rw)
+	if err != nil {
+		http.Error(rw, fmt.Sprintf("unable to encode response: %s", err.Error()), http.StatusInternalServerError)
+		return
+	}
+}
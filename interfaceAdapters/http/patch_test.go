@@ -0,0 +1,122 @@
+package http
+
+import (
+	"github.com/norby7/questions-rest-api/logging"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestPatch(t *testing.T) {
+	s := ServiceMock{}
+	l := logging.Discard()
+	c := NewController(&s, l)
+
+	testCases := []struct {
+		name        string
+		id          string
+		contentType string
+		input       string
+		limit       int64
+		statusCode  int
+	}{{
+		name:        "merge patch",
+		id:          "1",
+		contentType: "application/merge-patch+json",
+		input:       `{"body":"Where does the sun set?"}`,
+		statusCode:  200,
+	}, {
+		name:        "json patch",
+		id:          "1",
+		contentType: "application/json-patch+json; charset=utf-8",
+		input:       `[{"op":"replace","path":"/body","value":"Where does the sun set?"}]`,
+		statusCode:  200,
+	}, {
+		name:        "missing id",
+		id:          "",
+		contentType: "application/merge-patch+json",
+		input:       `{}`,
+		statusCode:  400,
+	}, {
+		name:        "plain json",
+		id:          "1",
+		contentType: "application/json",
+		input:       `{"body":"Where does the sun set?"}`,
+		statusCode:  415,
+	}, {
+		name:       "missing content type",
+		id:         "1",
+		input:      `{"body":"Where does the sun set?"}`,
+		statusCode: 415,
+	}, {
+		name:        "invalid document",
+		id:          "1",
+		contentType: "application/merge-patch+json",
+		input:       `{"body":`,
+		statusCode:  400,
+	}, {
+		name:        "body too large",
+		id:          "1",
+		contentType: "application/merge-patch+json",
+		input:       `{"body":"Where does the sun set?"}`,
+		limit:       8,
+		statusCode:  413,
+	}, {
+		name:        "forbidden",
+		id:          "403",
+		contentType: "application/merge-patch+json",
+		input:       `{}`,
+		statusCode:  403,
+	}, {
+		name:        "missing question",
+		id:          "404",
+		contentType: "application/merge-patch+json",
+		input:       `{}`,
+		statusCode:  404,
+	}, {
+		name:        "failed test operation",
+		id:          "409",
+		contentType: "application/json-patch+json",
+		input:       `[{"op":"test","path":"/body","value":"Where does the sun rise?"}]`,
+		statusCode:  409,
+	}, {
+		name:        "invalid question",
+		id:          "422",
+		contentType: "application/json-patch+json",
+		input:       `[{"op":"remove","path":"/options/1"}]`,
+		statusCode:  422,
+	}, {
+		name:        "patch error",
+		id:          "500",
+		contentType: "application/merge-patch+json",
+		input:       `{}`,
+		statusCode:  500,
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest("PATCH", "/question/"+tc.id, strings.NewReader(tc.input))
+			if tc.contentType != "" {
+				req.Header.Set("Content-Type", tc.contentType)
+			}
+			rec := httptest.NewRecorder()
+			if tc.limit > 0 {
+				req.Body = http.MaxBytesReader(rec, req.Body, tc.limit)
+			}
+
+			c.Patch(rec, req)
+			result := rec.Result()
+
+			if result.StatusCode != tc.statusCode {
+				resBody, _ := ioutil.ReadAll(result.Body)
+				t.Errorf("expected status code (%v), got (%v) with response: (%v)", tc.statusCode, result.StatusCode, string(resBody))
+			}
+
+			if tc.statusCode == 415 && result.Header.Get("Accept-Patch") == "" {
+				t.Errorf("expected the Accept-Patch header with the supported patch types")
+			}
+		})
+	}
+}
@@ -0,0 +1,157 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/norby7/questions-rest-api/entities"
+	"github.com/norby7/questions-rest-api/usecases/service"
+	"io"
+	"net/http"
+)
+
+// ReviewRequest is the body of the approve and reject requests
+// swagger:model
+type ReviewRequest struct {
+	// comment of the reviewer, required to approve the question
+	Comment string `json:"comment"`
+}
+
+// swagger:parameters ApproveQuestion RejectQuestion
+type reviewParam struct {
+	// Reviewer comment, the body can be omitted to reject a question
+	// in: body
+	Body ReviewRequest
+}
+
+// swagger:route POST /question/{id}/submit review SubmitQuestion
+// Sends a draft question to review and returns it in the response
+// responses:
+// 200: questionResponse
+// 400: errorResponse
+// 401: errorResponse
+// 403: forbiddenResponse
+// 404: errorResponse
+// 409: errorResponse
+// 429: rateLimitedResponse
+// 500: errorResponse
+
+// SubmitQuestion sends the draft question of the path to review
+func (c *Controller) SubmitQuestion(rw http.ResponseWriter, r *http.Request) {
+	c.transition(rw, r, entities.Submit)
+}
+
+// swagger:route POST /question/{id}/approve review ApproveQuestion
+// Publishes a question in review with the comment of the reviewer and returns it in the response
+// responses:
+// 200: questionResponse
+// 400: errorResponse
+// 401: errorResponse
+// 403: forbiddenResponse
+// 404: errorResponse
+// 409: errorResponse
+// 413: errorResponse
+// 422: errorResponse
+// 429: rateLimitedResponse
+// 500: errorResponse
+
+// ApproveQuestion publishes the question of the path, the request body holds the reviewer comment
+func (c *Controller) ApproveQuestion(rw http.ResponseWriter, r *http.Request) {
+	c.transition(rw, r, entities.Approve)
+}
+
+// swagger:route POST /question/{id}/reject review RejectQuestion
+// Sends a question in review back to draft, with an optional comment of the reviewer, and returns it in the response
+// responses:
+// 200: questionResponse
+// 400: errorResponse
+// 401: errorResponse
+// 403: forbiddenResponse
+// 404: errorResponse
+// 409: errorResponse
+// 413: errorResponse
+// 429: rateLimitedResponse
+// 500: errorResponse
+
+// RejectQuestion sends the question of the path back to draft, the request body can hold the reviewer comment
+func (c *Controller) RejectQuestion(rw http.ResponseWriter, r *http.Request) {
+	c.transition(rw, r, entities.Reject)
+}
+
+// swagger:route POST /question/{id}/retire review RetireQuestion
+// Withdraws a published question from the quizzes and returns it in the response
+// responses:
+// 200: questionResponse
+// 400: errorResponse
+// 401: errorResponse
+// 403: forbiddenResponse
+// 404: errorResponse
+// 409: errorResponse
+// 429: rateLimitedResponse
+// 500: errorResponse
+
+// RetireQuestion withdraws the published question of the path
+func (c *Controller) RetireQuestion(rw http.ResponseWriter, r *http.Request) {
+	c.transition(rw, r, entities.Retire)
+}
+
+// transition moves the question of the path through the review workflow transition and returns it in response
+// The approvals and rejections read the reviewer comment from the request body.
+func (c *Controller) transition(rw http.ResponseWriter, r *http.Request, t entities.QuestionTransition) {
+	rw.Header().Set("Content-type", "application/json")
+	c.Logger.DebugContext(r.Context(), "Handle question transition", "transition", t)
+
+	id, err := pathId(r, 1)
+	if err != nil {
+		http.Error(rw, fmt.Sprintf("invalid question id value: %s", err.Error()), http.StatusBadRequest)
+		return
+	}
+
+	var review ReviewRequest
+	if t == entities.Approve || t == entities.Reject {
+		// the body is optional, a missing comment is refused by the approval
+		if err = entities.DecodeJSON(r.Body, &review); err != nil && err != io.EOF {
+			writeBodyError(rw, "unable to parse review object", err)
+			return
+		}
+	}
+
+	q, err := c.Service.Transition(r.Context(), id, t, review.Comment)
+	if err != nil {
+		writeTransitionError(rw, r, fmt.Sprintf("unable to %s question", t), err)
+		return
+	}
+
+	err = json.NewEncoder(rw).Encode(q)
+	if err != nil {
+		http.Error(rw, fmt.Sprintf("unable to encode response: %s", err.Error()), http.StatusInternalServerError)
+		return
+	}
+}
+
+// writeTransitionError writes the response of the errors returned by the review workflow transitions
+// A transition the question status doesn't allow is a conflict with the current state of the question, an unknown
+// transition is a bad request.
+func writeTransitionError(rw http.ResponseWriter, r *http.Request, prefix string, err error) {
+	if writeAuthorizationError(rw, err) {
+		return
+	}
+
+	if writeContextError(rw, r) {
+		return
+	}
+
+	msg := fmt.Sprintf("%s: %s", prefix, err.Error())
+	switch {
+	case errors.Is(err, entities.QuestionTransitionError):
+		http.Error(rw, msg, http.StatusBadRequest)
+	case errors.Is(err, service.QuestionNotFoundError):
+		http.Error(rw, msg, http.StatusNotFound)
+	case errors.Is(err, entities.StatusTransitionError):
+		http.Error(rw, msg, http.StatusConflict)
+	case errors.Is(err, entities.ReviewCommentError):
+		http.Error(rw, msg, http.StatusUnprocessableEntity)
+	default:
+		http.Error(rw, msg, http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,125 @@
+package http
+
+import (
+	"encoding/json"
+	"github.com/norby7/questions-rest-api/entities"
+	"github.com/norby7/questions-rest-api/logging"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestTransitions(t *testing.T) {
+	s := ServiceMock{}
+	l := logging.Discard()
+	c := NewController(&s, l)
+
+	testCases := []struct {
+		name       string
+		path       string
+		input      string
+		handler    http.HandlerFunc
+		statusCode int
+		status     entities.QuestionStatus
+	}{{
+		name:       "submit",
+		path:       "/question/1/submit",
+		handler:    c.SubmitQuestion,
+		statusCode: 200,
+		status:     entities.InReview,
+	}, {
+		name:       "approve",
+		path:       "/question/1/approve",
+		input:      `{"comment":"Clear and correct"}`,
+		handler:    c.ApproveQuestion,
+		statusCode: 200,
+		status:     entities.Published,
+	}, {
+		name:       "approve without comment",
+		path:       "/question/1/approve",
+		input:      `{}`,
+		handler:    c.ApproveQuestion,
+		statusCode: 422,
+	}, {
+		name:       "approve without body",
+		path:       "/question/1/approve",
+		handler:    c.ApproveQuestion,
+		statusCode: 422,
+	}, {
+		name:       "approve unknown field",
+		path:       "/question/1/approve",
+		input:      `{"comment":"Clear and correct","status":"published"}`,
+		handler:    c.ApproveQuestion,
+		statusCode: 400,
+	}, {
+		name:       "reject",
+		path:       "/question/1/reject",
+		input:      `{"comment":"West is ambiguous"}`,
+		handler:    c.RejectQuestion,
+		statusCode: 200,
+		status:     entities.Draft,
+	}, {
+		name:       "reject without body",
+		path:       "/question/1/reject",
+		handler:    c.RejectQuestion,
+		statusCode: 200,
+		status:     entities.Draft,
+	}, {
+		name:       "retire",
+		path:       "/question/1/retire",
+		handler:    c.RetireQuestion,
+		statusCode: 200,
+		status:     entities.Retired,
+	}, {
+		name:       "transition not allowed",
+		path:       "/question/409/submit",
+		handler:    c.SubmitQuestion,
+		statusCode: 409,
+	}, {
+		name:       "missing question",
+		path:       "/question/404/retire",
+		handler:    c.RetireQuestion,
+		statusCode: 404,
+	}, {
+		name:       "forbidden",
+		path:       "/question/403/approve",
+		input:      `{"comment":"Clear and correct"}`,
+		handler:    c.ApproveQuestion,
+		statusCode: 403,
+	}, {
+		name:       "service error",
+		path:       "/question/500/submit",
+		handler:    c.SubmitQuestion,
+		statusCode: 500,
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", tc.path, strings.NewReader(tc.input))
+			rec := httptest.NewRecorder()
+
+			tc.handler(rec, req)
+			result := rec.Result()
+
+			if result.StatusCode != tc.statusCode {
+				resBody, _ := ioutil.ReadAll(result.Body)
+				t.Fatalf("expected status code (%v), got (%v) with response: (%v)", tc.statusCode, result.StatusCode, string(resBody))
+			}
+
+			if tc.statusCode != 200 {
+				return
+			}
+
+			var q entities.Question
+			if err := json.NewDecoder(result.Body).Decode(&q); err != nil {
+				t.Fatalf("unable to decode question: %s", err.Error())
+			}
+
+			if q.Status != tc.status {
+				t.Errorf("expected status (%s), got status (%s)", tc.status, q.Status)
+			}
+		})
+	}
+}
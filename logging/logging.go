@@ -0,0 +1,75 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"github.com/norby7/questions-rest-api/usecases/requestid"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+// attrsKey is the context key of the attributes added to every line logged with the context
+type attrsKey struct{}
+
+// New returns a logger writing JSON lines to w, the lines below level are discarded
+// The level can be changed while the logger is in use, every line logged with a context carries the request id and attributes of the context.
+func New(w io.Writer, level *slog.LevelVar) *slog.Logger {
+	return slog.New(&contextHandler{Handler: slog.NewJSONHandler(w, &slog.HandlerOptions{Level: level})})
+}
+
+// Discard returns a logger that drops every line
+func Discard() *slog.Logger {
+	return slog.New(&contextHandler{Handler: slog.NewJSONHandler(io.Discard, nil)})
+}
+
+// ParseLevel returns the level with the given name: debug, info, warn or error
+func ParseLevel(s string) (slog.Level, error) {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(s)); err != nil {
+		return l, fmt.Errorf("unknown log level %q, expected debug, info, warn or error", s)
+	}
+
+	return l, nil
+}
+
+// LevelName returns the lower case name of a level
+func LevelName(l slog.Level) string {
+	return strings.ToLower(l.String())
+}
+
+// NewContext returns a copy of ctx carrying the given attributes, in addition to the ones already carried by ctx
+func NewContext(ctx context.Context, attrs ...slog.Attr) context.Context {
+	parent, _ := ctx.Value(attrsKey{}).([]slog.Attr)
+
+	merged := make([]slog.Attr, 0, len(parent)+len(attrs))
+	merged = append(merged, parent...)
+	merged = append(merged, attrs...)
+
+	return context.WithValue(ctx, attrsKey{}, merged)
+}
+
+// contextHandler adds the request id and the attributes carried by the context to the records
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h *contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := requestid.FromContext(ctx); id != "" {
+		r.AddAttrs(slog.String("request_id", id))
+	}
+
+	if attrs, ok := ctx.Value(attrsKey{}).([]slog.Attr); ok {
+		r.AddAttrs(attrs...)
+	}
+
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithGroup(name)}
+}
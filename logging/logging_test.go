@@ -0,0 +1,87 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/norby7/questions-rest-api/usecases/requestid"
+	"log/slog"
+	"testing"
+)
+
+func TestLogger(t *testing.T) {
+	var buf bytes.Buffer
+	level := new(slog.LevelVar)
+	l := New(&buf, level)
+
+	ctx := requestid.NewContext(context.Background(), "request-1")
+	ctx = NewContext(ctx, slog.String("method", "GET"))
+	ctx = NewContext(ctx, slog.String("route", "/questions"))
+
+	l.DebugContext(ctx, "dropped")
+	l.With("component", "test").InfoContext(ctx, "kept")
+
+	level.Set(slog.LevelDebug)
+	l.DebugContext(context.Background(), "debug")
+
+	dec := json.NewDecoder(&buf)
+
+	var line map[string]interface{}
+	if err := dec.Decode(&line); err != nil {
+		t.Fatalf("unable to decode log line: %s", err.Error())
+	}
+
+	expected := map[string]string{"msg": "kept", "level": "INFO", "request_id": "request-1", "method": "GET", "route": "/questions", "component": "test"}
+	for k, v := range expected {
+		if line[k] != v {
+			t.Errorf("expected (%s) to be (%s), got line (%v)", k, v, line)
+		}
+	}
+
+	line = nil
+	if err := dec.Decode(&line); err != nil {
+		t.Fatalf("unable to decode log line: %s", err.Error())
+	}
+
+	if line["msg"] != "debug" || line["request_id"] != nil {
+		t.Errorf("expected a debug line without request id, got line (%v)", line)
+	}
+
+	if dec.More() {
+		t.Errorf("expected two log lines")
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    string
+		expected slog.Level
+		isError  bool
+	}{{
+		name:     "debug",
+		input:    "debug",
+		expected: slog.LevelDebug,
+	}, {
+		name:     "upper case",
+		input:    "WARN",
+		expected: slog.LevelWarn,
+	}, {
+		name:    "unknown",
+		input:   "verbose",
+		isError: true,
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			l, err := ParseLevel(tc.input)
+			if (err != nil) != tc.isError {
+				t.Fatalf("expected error (%v), got error (%v)", tc.isError, err)
+			}
+
+			if !tc.isError && l != tc.expected {
+				t.Errorf("expected level (%v), got level (%v)", tc.expected, l)
+			}
+		})
+	}
+}
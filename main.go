@@ -1,48 +1,220 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
 	"github.com/gorilla/mux"
+	"github.com/norby7/questions-rest-api/config"
+	"github.com/norby7/questions-rest-api/health"
 	httpController "github.com/norby7/questions-rest-api/interfaceAdapters/http"
+	"github.com/norby7/questions-rest-api/logging"
+	"github.com/norby7/questions-rest-api/metrics"
+	"github.com/norby7/questions-rest-api/ratelimit"
 	httpServer "github.com/norby7/questions-rest-api/server/http"
+	"github.com/norby7/questions-rest-api/tracing"
+	"github.com/norby7/questions-rest-api/usecases/auth"
 	"github.com/norby7/questions-rest-api/usecases/repository"
 	ucService "github.com/norby7/questions-rest-api/usecases/service"
-	"log"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"io"
+	"log/slog"
 	"os"
-	"strconv"
+	"os/signal"
+	"strings"
+	"time"
 )
 
 func main() {
-	dbPath := "./database/questions.db"
-	l := log.New(os.Stdout, "question-api", log.LstdFlags)
+	cfg, args, err := config.Load(os.Args[1:], os.LookupEnv)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return
+		}
+
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
 
-	err := repository.CreateDatabase(dbPath)
+	out, err := logOutput(cfg.Log.Output)
 	if err != nil {
-		l.Fatalln(err.Error())
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+
+	// the level is checked when the configuration is loaded, and can then be changed at runtime
+	level := new(slog.LevelVar)
+	lvl, _ := logging.ParseLevel(cfg.Log.Level)
+	level.Set(lvl)
+
+	l := logging.New(out, level)
+
+	// when tracing is enabled the SQL statements of the traced requests are children of their repository spans
+	var tp *sdktrace.TracerProvider
+	if cfg.Tracing.Enabled {
+		tp, err = tracing.NewProvider(context.Background(), cfg.Tracing)
+		if err != nil {
+			fatal(l, err.Error())
+		}
+
+		defer shutdownTracing(tp, cfg.Server.ShutdownTimeout, l)
+
+		repository.SqlOpen = tracing.OpenDB(tp)
 	}
 
-	repo, err := repository.NewSqliteRepository(dbPath)
+	// sqlite URI data source names are created by the driver itself
+	if !strings.HasPrefix(cfg.Database.DSN, "file:") {
+		err = repository.CreateDatabase(cfg.Database.DSN)
+		if err != nil {
+			fatal(l, err.Error())
+		}
+	}
+
+	repo, err := repository.NewSqliteRepository(cfg.Database.DSN)
 	if err != nil {
-		l.Fatalln("unable to create new repository: " + err.Error())
+		fatal(l, "unable to create new repository: "+err.Error())
 	}
 
 	defer repo.Handler.Close()
 
 	err = repository.ValidateSchema(repo.Handler)
 	if err != nil {
-		l.Fatalln(err.Error())
+		fatal(l, err.Error())
+	}
+
+	// the repository operations are measured by decorators, when the metrics are enabled
+	var m *metrics.Metrics
+	var questions repository.Repository = repo
+	var audit repository.AuditRepository = repo
+	if cfg.Metrics.Enabled {
+		m = metrics.New()
+		m.RegisterDB(repo.Handler, "questions")
+		m.RegisterBank(repo)
+
+		questions = metrics.NewRepository(repo, m)
+		audit = metrics.NewAuditRepository(repo, m)
+	}
+
+	if tp != nil {
+		questions = tracing.NewRepository(questions, tp)
+		audit = tracing.NewAuditRepository(audit, tp)
+	}
+
+	// the duplicate detection settings are checked when the configuration is loaded
+	service := ucService.NewService(questions)
+	service.Audit = audit
+	service.DuplicatePolicy.Mode = ucService.DuplicateMode(cfg.Duplicates.Mode)
+	service.DuplicatePolicy.Threshold = cfg.Duplicates.Threshold
+
+	// run the requested subcommand instead of the http server
+	if len(args) > 0 {
+		if err = runCommand(service, repo, args[0], args[1:]); err != nil {
+			fatal(l, err.Error())
+		}
+
+		return
+	}
+
+	// the api keys stored in the database are always accepted, bearer tokens only when a HMAC secret or a JWKS file is configured
+	var authenticator *auth.Authenticator
+	if !cfg.Auth.Disabled {
+		var validator *auth.JWTValidator
+		if jwt := cfg.Auth.JWT; jwt.HMACSecret != "" || jwt.JWKSFile != "" {
+			validator, err = auth.NewJWTValidator(jwt.HMACSecret, jwt.JWKSFile, jwt.Issuer, jwt.Audience)
+			if err != nil {
+				fatal(l, err.Error())
+			}
+		}
+
+		authenticator = auth.NewAuthenticator(repo, validator)
+	} else {
+		l.Warn("authentication is disabled, every request is accepted")
 	}
 
-	service := ucService.NewService(repo)
-	controller := httpController.NewController(service, l)
+	var interactor ucService.Interactor = service
+	var provider trace.TracerProvider
+	if tp != nil {
+		interactor = tracing.NewInteractor(service, tp)
+		provider = tp
+	}
+
+	controller := httpController.NewController(interactor, l)
+	controller.Level = level
+	controller.DefaultPageSize = cfg.Pagination.DefaultSize
+	controller.MaxPageSize = cfg.Pagination.MaxSize
+
+	// the schema is migrated on startup, a different version means the database was migrated by another version of the application
+	schemaVersion, err := repository.LatestSchemaVersion()
+	if err != nil {
+		fatal(l, err.Error())
+	}
+
+	checker := health.New()
+	checker.Add("database", repo.Ping)
+	checker.Add("schema", func(ctx context.Context) error {
+		return repo.CheckSchema(ctx, schemaVersion)
+	})
+
+	// the buckets are kept in memory, so the limits apply to each instance
+	var limiter *ratelimit.Limiter
+	if cfg.RateLimit.Enabled {
+		limiter = ratelimit.NewLimiter(ratelimit.NewMemoryStore(), ratelimit.NewPolicy(cfg.RateLimit))
+		limiter.Address = ratelimit.NewAddressLimits(cfg.RateLimit)
+	}
 
 	muxRouter := mux.NewRouter()
-	httpServer.RegisterRoutes(muxRouter, *controller)
+	httpServer.RegisterRoutes(muxRouter, *controller, authenticator, m, provider, checker, limiter, cfg.HTTP)
+
+	srv, err := httpServer.NewServer(muxRouter, cfg.Server, l, checker)
+	if err != nil {
+		fatal(l, err.Error())
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), httpServer.ShutdownSignals...)
+	defer stop()
 
-	port := os.Getenv("PORT")
+	// once the shutdown started, a second signal stops the process without waiting
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
 
-	portAdr, err := strconv.Atoi(port)
+	if err = srv.Run(ctx); err != nil {
+		fatal(l, err.Error())
+	}
+}
+
+// fatal logs the error and exits
+func fatal(l *slog.Logger, msg string) {
+	l.Error(msg)
+	os.Exit(1)
+}
+
+// shutdownTracing exports the traces that are still buffered and stops the tracer provider
+func shutdownTracing(tp *sdktrace.TracerProvider, timeout time.Duration, l *slog.Logger) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if err := tp.Shutdown(ctx); err != nil {
+		l.Error("unable to shut down tracing", "error", err.Error())
+	}
+}
+
+// logOutput returns the writer of the configured log output: stdout, stderr or a file the logs are appended to
+func logOutput(output string) (io.Writer, error) {
+	switch output {
+	case "stdout":
+		return os.Stdout, nil
+	case "stderr":
+		return os.Stderr, nil
+	}
+
+	f, err := os.OpenFile(output, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
 	if err != nil {
-		portAdr = 3000
+		return nil, fmt.Errorf("unable to open log file: %s", err.Error())
 	}
-	httpServer.StartServer(muxRouter, portAdr)
+
+	return f, nil
 }
@@ -0,0 +1,136 @@
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// namespace prefixes the name of every application metric
+const namespace = "questions"
+
+// Metrics holds the application metrics and the registry they are exposed from
+type Metrics struct {
+	Registry *prometheus.Registry
+
+	requests        *prometheus.CounterVec
+	requestDuration *prometheus.HistogramVec
+	queryDuration   *prometheus.HistogramVec
+	scrapeErrors    prometheus.Counter
+}
+
+// New returns the application metrics, registered with a new registry along with the Go runtime and process metrics
+func New() *Metrics {
+	m := &Metrics{
+		Registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "requests_total",
+			Help:      "Number of handled http requests, by method, route and status code.",
+		}, []string{"method", "route", "status"}),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "request_duration_seconds",
+			Help:      "Latency of the http requests, by method, route and status code.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		queryDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "repository",
+			Name:      "query_duration_seconds",
+			Help:      "Latency of the repository operations, by operation and outcome.",
+			Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+		}, []string{"operation", "outcome"}),
+		scrapeErrors: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "scrape_errors_total",
+			Help:      "Number of domain metrics that couldn't be collected.",
+		}),
+	}
+
+	m.Registry.MustRegister(
+		m.requests,
+		m.requestDuration,
+		m.queryDuration,
+		m.scrapeErrors,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+
+	return m
+}
+
+// Handler returns the http handler exposing the metrics in the Prometheus text format
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.Registry, promhttp.HandlerOpts{Registry: m.Registry})
+}
+
+// ObserveRequest records a handled http request
+func (m *Metrics) ObserveRequest(method, route string, status int, d time.Duration) {
+	s := strconv.Itoa(status)
+
+	m.requests.WithLabelValues(method, route, s).Inc()
+	m.requestDuration.WithLabelValues(method, route, s).Observe(d.Seconds())
+}
+
+// observeQuery records a repository operation started at start
+func (m *Metrics) observeQuery(operation string, start time.Time, err error) {
+	outcome := "ok"
+	if err != nil {
+		outcome = "error"
+	}
+
+	m.queryDuration.WithLabelValues(operation, outcome).Observe(time.Since(start).Seconds())
+}
+
+// RegisterDB registers the connection pool gauges of the database, read from sql.DBStats
+func (m *Metrics) RegisterDB(db *sql.DB, name string) {
+	m.Registry.MustRegister(collectors.NewDBStatsCollector(db, name))
+}
+
+// QuestionCounter returns the number of questions of all the tenants
+type QuestionCounter interface {
+	QuestionCount(context.Context) (int64, error)
+}
+
+// RegisterBank registers the domain gauges of the question bank, they are read from c on every scrape
+func (m *Metrics) RegisterBank(c QuestionCounter) {
+	m.Registry.MustRegister(&bankCollector{counter: c, errors: m.scrapeErrors})
+}
+
+// bankCollector collects the domain gauges of the question bank
+type bankCollector struct {
+	counter QuestionCounter
+	errors  prometheus.Counter
+}
+
+var questionsDesc = prometheus.NewDesc(
+	prometheus.BuildFQName(namespace, "bank", "questions"),
+	"Number of questions in the bank, of all the tenants.",
+	nil, nil,
+)
+
+func (b *bankCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- questionsDesc
+}
+
+func (b *bankCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// the tenants aren't labels, the metrics are served without authentication and must not disclose them
+	n, err := b.counter.QuestionCount(ctx)
+	if err != nil {
+		b.errors.Inc()
+		return
+	}
+
+	ch <- prometheus.MustNewConstMetric(questionsDesc, prometheus.GaugeValue, float64(n))
+}
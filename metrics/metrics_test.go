@@ -0,0 +1,98 @@
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"io/ioutil"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// counterMock returns a fixed question count, or an error
+type counterMock struct {
+	count int64
+	err   error
+}
+
+func (c *counterMock) QuestionCount(context.Context) (int64, error) {
+	return c.count, c.err
+}
+
+func TestObserveRequest(t *testing.T) {
+	m := New()
+	m.ObserveRequest("GET", "/questions", 200, 20*time.Millisecond)
+	m.ObserveRequest("GET", "/questions", 200, 30*time.Millisecond)
+	m.ObserveRequest("DELETE", "/question/{id:[0-9]+}", 404, time.Millisecond)
+
+	if n := testutil.ToFloat64(m.requests.WithLabelValues("GET", "/questions", "200")); n != 2 {
+		t.Errorf("expected (2) requests, got (%v)", n)
+	}
+
+	if n := testutil.CollectAndCount(m.requestDuration); n != 2 {
+		t.Errorf("expected (2) latency histograms, got (%d)", n)
+	}
+}
+
+func TestBankCollector(t *testing.T) {
+	testCases := []struct {
+		name     string
+		counter  *counterMock
+		expected string
+		errors   float64
+	}{{
+		name:    "count",
+		counter: &counterMock{count: 3},
+		expected: `
+# HELP questions_bank_questions Number of questions in the bank, of all the tenants.
+# TYPE questions_bank_questions gauge
+questions_bank_questions 3
+`,
+	}, {
+		name:     "count error",
+		counter:  &counterMock{err: fmt.Errorf("unable to count questions")},
+		expected: "",
+		errors:   1,
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			m := New()
+			m.RegisterBank(tc.counter)
+
+			if err := testutil.GatherAndCompare(m.Registry, strings.NewReader(tc.expected), "questions_bank_questions"); err != nil {
+				t.Errorf("unexpected bank metrics: %s", err.Error())
+			}
+
+			if n := testutil.ToFloat64(m.scrapeErrors); n != tc.errors {
+				t.Errorf("expected (%v) scrape errors, got (%v)", tc.errors, n)
+			}
+		})
+	}
+}
+
+func TestHandler(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("unable to open database: %s", err.Error())
+	}
+	defer db.Close()
+
+	m := New()
+	m.RegisterDB(db, "questions")
+	m.ObserveRequest("GET", "/questions", 200, time.Millisecond)
+
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+	body, _ := ioutil.ReadAll(rec.Result().Body)
+	for _, name := range []string{"questions_http_requests_total", "questions_http_request_duration_seconds_bucket", "go_sql_open_connections", "go_goroutines"} {
+		if !strings.Contains(string(body), name) {
+			t.Errorf("expected metric (%s) in the response, got response (%s)", name, string(body))
+		}
+	}
+}
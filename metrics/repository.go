@@ -0,0 +1,127 @@
+package metrics
+
+import (
+	"context"
+	"github.com/norby7/questions-rest-api/entities"
+	"github.com/norby7/questions-rest-api/usecases/repository"
+	"time"
+)
+
+// Repository decorates a question repository, recording the latency of every operation
+type Repository struct {
+	Next    repository.Repository
+	metrics *Metrics
+}
+
+// NewRepository returns a repository recording the latency of the operations of next
+func NewRepository(next repository.Repository, m *Metrics) *Repository {
+	return &Repository{Next: next, metrics: m}
+}
+
+func (r *Repository) Add(ctx context.Context, q entities.Question) (int64, error) {
+	start := time.Now()
+	id, err := r.Next.Add(ctx, q)
+	r.metrics.observeQuery("add", start, err)
+
+	return id, err
+}
+
+func (r *Repository) AddAll(ctx context.Context, ql []entities.Question) ([]int64, error) {
+	start := time.Now()
+	ids, err := r.Next.AddAll(ctx, ql)
+	r.metrics.observeQuery("add_all", start, err)
+
+	return ids, err
+}
+
+func (r *Repository) Update(ctx context.Context, q entities.Question) error {
+	start := time.Now()
+	err := r.Next.Update(ctx, q)
+	r.metrics.observeQuery("update", start, err)
+
+	return err
+}
+
+func (r *Repository) Delete(ctx context.Context, id int64) error {
+	start := time.Now()
+	err := r.Next.Delete(ctx, id)
+	r.metrics.observeQuery("delete", start, err)
+
+	return err
+}
+
+func (r *Repository) Get(ctx context.Context, id int64) (entities.Question, error) {
+	start := time.Now()
+	q, err := r.Next.Get(ctx, id)
+	r.metrics.observeQuery("get", start, err)
+
+	return q, err
+}
+
+func (r *Repository) GetAll(ctx context.Context, f entities.QuestionFilter) ([]entities.Question, error) {
+	start := time.Now()
+	ql, err := r.Next.GetAll(ctx, f)
+	r.metrics.observeQuery("get_all", start, err)
+
+	return ql, err
+}
+
+// ForEach records the time spent iterating over all the questions, including the time spent in fn
+func (r *Repository) ForEach(ctx context.Context, fn func(entities.Question) error) error {
+	start := time.Now()
+	err := r.Next.ForEach(ctx, fn)
+	r.metrics.observeQuery("for_each", start, err)
+
+	return err
+}
+
+func (r *Repository) Batch(ctx context.Context, ops []repository.Operation) ([]int64, error) {
+	start := time.Now()
+	ids, err := r.Next.Batch(ctx, ops)
+	r.metrics.observeQuery("batch", start, err)
+
+	return ids, err
+}
+
+func (r *Repository) SetStatus(ctx context.Context, id int64, from, to entities.QuestionStatus, comment string) error {
+	start := time.Now()
+	err := r.Next.SetStatus(ctx, id, from, to, comment)
+	r.metrics.observeQuery("set_status", start, err)
+
+	return err
+}
+
+func (r *Repository) InTransaction(ctx context.Context, fn func(context.Context) error) error {
+	start := time.Now()
+	err := r.Next.InTransaction(ctx, fn)
+	r.metrics.observeQuery("transaction", start, err)
+
+	return err
+}
+
+// AuditRepository decorates an audit repository, recording the latency of every operation
+type AuditRepository struct {
+	Next    repository.AuditRepository
+	metrics *Metrics
+}
+
+// NewAuditRepository returns an audit repository recording the latency of the operations of next
+func NewAuditRepository(next repository.AuditRepository, m *Metrics) *AuditRepository {
+	return &AuditRepository{Next: next, metrics: m}
+}
+
+func (r *AuditRepository) AppendAudit(ctx context.Context, e entities.AuditEntry) error {
+	start := time.Now()
+	err := r.Next.AppendAudit(ctx, e)
+	r.metrics.observeQuery("append_audit", start, err)
+
+	return err
+}
+
+func (r *AuditRepository) ListAudit(ctx context.Context, f entities.AuditFilter) ([]entities.AuditEntry, error) {
+	start := time.Now()
+	el, err := r.Next.ListAudit(ctx, f)
+	r.metrics.observeQuery("list_audit", start, err)
+
+	return el, err
+}
@@ -0,0 +1,87 @@
+package metrics
+
+import (
+	"context"
+	"github.com/norby7/questions-rest-api/entities"
+	"github.com/norby7/questions-rest-api/usecases/repository"
+	"testing"
+)
+
+// repositoryStub is an empty question bank where only the deletes fail
+type repositoryStub struct {
+	repository.Repository
+}
+
+func (r *repositoryStub) Add(context.Context, entities.Question) (int64, error) {
+	return 1, nil
+}
+
+func (r *repositoryStub) Delete(context.Context, int64) error {
+	return repository.QuestionNotFoundError
+}
+
+func TestRepository(t *testing.T) {
+	m := New()
+	r := NewRepository(&repositoryStub{}, m)
+
+	if id, err := r.Add(context.Background(), entities.Question{}); id != 1 || err != nil {
+		t.Errorf("expected id (1) and no error, got id (%d) and error (%v)", id, err)
+	}
+
+	if err := r.Delete(context.Background(), 1); err != repository.QuestionNotFoundError {
+		t.Errorf("expected error (%v), got error (%v)", repository.QuestionNotFoundError, err)
+	}
+
+	testCases := []struct {
+		operation string
+		outcome   string
+		expected  uint64
+	}{{
+		operation: "add",
+		outcome:   "ok",
+		expected:  1,
+	}, {
+		operation: "delete",
+		outcome:   "error",
+		expected:  1,
+	}, {
+		operation: "delete",
+		outcome:   "ok",
+		expected:  0,
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.operation+" "+tc.outcome, func(t *testing.T) {
+			if n := queryCount(t, m, tc.operation, tc.outcome); n != tc.expected {
+				t.Errorf("expected (%d) observations, got (%d)", tc.expected, n)
+			}
+		})
+	}
+}
+
+// queryCount returns the number of repository operations recorded with the given labels
+func queryCount(t *testing.T, m *Metrics, operation, outcome string) uint64 {
+	families, err := m.Registry.Gather()
+	if err != nil {
+		t.Fatalf("unable to gather metrics: %s", err.Error())
+	}
+
+	for _, f := range families {
+		if f.GetName() != "questions_repository_query_duration_seconds" {
+			continue
+		}
+
+		for _, metric := range f.GetMetric() {
+			labels := map[string]string{}
+			for _, l := range metric.GetLabel() {
+				labels[l.GetName()] = l.GetValue()
+			}
+
+			if labels["operation"] == operation && labels["outcome"] == outcome {
+				return metric.GetHistogram().GetSampleCount()
+			}
+		}
+	}
+
+	return 0
+}
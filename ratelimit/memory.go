@@ -0,0 +1,96 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// sweepInterval is how often the buckets that are full again are removed from a MemoryStore
+const sweepInterval = time.Minute
+
+// bucket is the state of the token bucket of a client
+type bucket struct {
+	limit  Limit
+	tokens float64
+	last   time.Time
+}
+
+// refill adds the tokens earned since the last update of the bucket
+func (b *bucket) refill(now time.Time) {
+	b.tokens = math.Min(float64(b.limit.Burst), b.tokens+now.Sub(b.last).Seconds()*b.limit.Rate)
+	b.last = now
+}
+
+// MemoryStore keeps the token buckets in the memory of the process, the limits are per instance
+type MemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	// Now returns the current time, it can be replaced in tests
+	Now func() time.Time
+}
+
+// NewMemoryStore returns an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: map[string]*bucket{}, Now: time.Now}
+}
+
+func (s *MemoryStore) Take(_ context.Context, key string, l Limit) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.Now()
+	s.sweep(now)
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{limit: l, tokens: float64(l.Burst), last: now}
+		s.buckets[key] = b
+	}
+
+	// the limit of a client changes with its roles, the tokens it already has are kept
+	b.limit = l
+	b.refill(now)
+
+	res := Result{Limit: l, Allowed: b.tokens >= 1}
+	if res.Allowed {
+		b.tokens--
+	} else {
+		res.RetryAfter = time.Duration((1 - b.tokens) / l.Rate * float64(time.Second))
+	}
+
+	res.Remaining = int(b.tokens)
+	res.Reset = time.Duration((float64(l.Burst) - b.tokens) / l.Rate * float64(time.Second))
+
+	return res, nil
+}
+
+// Len returns the number of buckets kept by the store
+func (s *MemoryStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.buckets)
+}
+
+// sweep removes the buckets that are full again, they are the same as new buckets
+func (s *MemoryStore) sweep(now time.Time) {
+	if s.lastSweep.IsZero() {
+		s.lastSweep = now
+	}
+
+	if now.Sub(s.lastSweep) < sweepInterval {
+		return
+	}
+
+	for k, b := range s.buckets {
+		b.refill(now)
+		if b.tokens >= float64(b.limit.Burst) {
+			delete(s.buckets, k)
+		}
+	}
+
+	s.lastSweep = now
+}
@@ -0,0 +1,90 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// clock is a fake time source advanced by the tests
+type clock struct {
+	now time.Time
+}
+
+func (c *clock) Now() time.Time {
+	return c.now
+}
+
+func TestMemoryStore(t *testing.T) {
+	c := &clock{now: time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)}
+	s := NewMemoryStore()
+	s.Now = c.Now
+
+	l := Limit{Rate: 1, Burst: 2}
+
+	testCases := []struct {
+		name       string
+		advance    time.Duration
+		key        string
+		allowed    bool
+		remaining  int
+		retryAfter time.Duration
+	}{{
+		name:      "first request",
+		key:       "alice",
+		allowed:   true,
+		remaining: 1,
+	}, {
+		name:      "burst",
+		key:       "alice",
+		allowed:   true,
+		remaining: 0,
+	}, {
+		name:       "over the limit",
+		key:        "alice",
+		allowed:    false,
+		retryAfter: time.Second,
+	}, {
+		name:      "other client",
+		key:       "bob",
+		allowed:   true,
+		remaining: 1,
+	}, {
+		name:       "partially refilled",
+		advance:    500 * time.Millisecond,
+		key:        "alice",
+		allowed:    false,
+		retryAfter: 500 * time.Millisecond,
+	}, {
+		name:      "refilled",
+		advance:   500 * time.Millisecond,
+		key:       "alice",
+		allowed:   true,
+		remaining: 0,
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c.now = c.now.Add(tc.advance)
+
+			res, err := s.Take(context.Background(), tc.key, l)
+			if err != nil {
+				t.Fatalf("unable to take token: %s", err.Error())
+			}
+
+			if res.Allowed != tc.allowed || res.Remaining != tc.remaining || res.RetryAfter != tc.retryAfter {
+				t.Errorf("expected allowed (%v), remaining (%d) and retry after (%v), got result (%+v)", tc.allowed, tc.remaining, tc.retryAfter, res)
+			}
+		})
+	}
+
+	// the buckets that are full again are removed
+	c.now = c.now.Add(sweepInterval)
+	if _, err := s.Take(context.Background(), "carol", l); err != nil {
+		t.Fatalf("unable to take token: %s", err.Error())
+	}
+
+	if s.Len() != 1 {
+		t.Errorf("expected a single bucket after the sweep, got (%d) buckets", s.Len())
+	}
+}
@@ -0,0 +1,169 @@
+package ratelimit
+
+import (
+	"context"
+	"github.com/norby7/questions-rest-api/config"
+	"github.com/norby7/questions-rest-api/entities"
+	"math"
+	"net/http"
+	"time"
+)
+
+// Limit is a token bucket: up to Burst requests can be made at once, and the bucket refills at Rate requests per second
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// PerMinute returns the limit of n requests per minute, the bucket holds a whole minute of requests
+func PerMinute(n int) Limit {
+	return Limit{Rate: float64(n) / 60, Burst: n}
+}
+
+// Window returns the time the bucket takes to refill completely
+func (l Limit) Window() time.Duration {
+	return time.Duration(float64(l.Burst) / l.Rate * float64(time.Second))
+}
+
+// Result is the outcome of taking a token from a bucket
+type Result struct {
+	// Limit is the limit of the bucket
+	Limit   Limit
+	Allowed bool
+	// Remaining is the number of requests that can still be made at once
+	Remaining int
+	// Reset is the time until the bucket is full again
+	Reset time.Duration
+	// RetryAfter is the time until the next request is allowed, it's zero when Allowed is true
+	RetryAfter time.Duration
+}
+
+// Store keeps the token buckets of the clients
+// The buckets are kept in memory by MemoryStore, a store backed by a shared database makes the limits global to all the instances.
+type Store interface {
+	// Take takes a token from the bucket of key, which has the limit l
+	Take(ctx context.Context, key string, l Limit) (Result, error)
+}
+
+// Class is the kind of a request, reads and writes have separate limits
+type Class string
+
+const (
+	ClassRead  Class = "read"
+	ClassWrite Class = "write"
+)
+
+// RequestClass returns the class of a request from its method
+func RequestClass(r *http.Request) Class {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return ClassRead
+	}
+
+	return ClassWrite
+}
+
+// Policy holds the limits of every role, for each class of requests
+// A role without limit for a class isn't limited for it.
+type Policy map[entities.Role]map[Class]Limit
+
+// NewPolicy returns the policy of the configured limits, a limit of 0 requests per minute disables the limit
+func NewPolicy(c config.RateLimit) Policy {
+	p := Policy{}
+	for role, rl := range map[entities.Role]config.RoleLimit{
+		entities.RoleViewer:    c.Viewer,
+		entities.RoleEditor:    c.Editor,
+		entities.RoleAdmin:     c.Admin,
+		entities.RoleCandidate: c.Candidate,
+	} {
+		p[role] = classLimits(rl)
+	}
+
+	return p
+}
+
+// NewAddressLimits returns the configured limits of every IP address, a limit of 0 requests per minute disables the limit
+func NewAddressLimits(c config.RateLimit) map[Class]Limit {
+	return classLimits(c.Address)
+}
+
+// classLimits returns the limits of each class of requests, the classes with a limit of 0 requests per minute aren't limited
+func classLimits(rl config.RoleLimit) map[Class]Limit {
+	l := map[Class]Limit{}
+	if rl.Reads > 0 {
+		l[ClassRead] = PerMinute(rl.Reads)
+	}
+	if rl.Writes > 0 {
+		l[ClassWrite] = PerMinute(rl.Writes)
+	}
+
+	return l
+}
+
+// Limit returns the limit of a principal for a class of requests, the most generous one of its roles
+// It returns false if one of the roles isn't limited, or if the principal has no role.
+func (p Policy) Limit(principal entities.Principal, c Class) (Limit, bool) {
+	var best Limit
+	for _, r := range principal.Roles {
+		l, ok := p[r][c]
+		if !ok {
+			return Limit{}, false
+		}
+
+		if l.Rate > best.Rate || (l.Rate == best.Rate && l.Burst > best.Burst) {
+			best = l
+		}
+	}
+
+	return best, best.Rate > 0
+}
+
+// Limiter checks the requests of the clients against the limits of their roles, and of their IP address
+type Limiter struct {
+	Store  Store
+	Policy Policy
+	// Address holds the limits of every IP address, a class without limit isn't limited
+	Address map[Class]Limit
+}
+
+// NewLimiter returns a limiter applying p, with buckets kept by s
+func NewLimiter(s Store, p Policy) *Limiter {
+	return &Limiter{Store: s, Policy: p}
+}
+
+// Allow takes a token from the bucket of the client for the class of the request
+// It returns false, without a result, when the principal isn't limited for the class.
+func (l *Limiter) Allow(ctx context.Context, client string, p entities.Principal, c Class) (Result, bool, error) {
+	limit, ok := l.Policy.Limit(p, c)
+	if !ok {
+		return Result{}, false, nil
+	}
+
+	res, err := l.Store.Take(ctx, string(c)+":"+client, limit)
+	if err != nil {
+		return Result{}, false, err
+	}
+
+	return res, true, nil
+}
+
+// AllowAddress takes a token from the bucket of the IP address for the class of the request
+// It returns false, without a result, when the addresses aren't limited for the class.
+func (l *Limiter) AllowAddress(ctx context.Context, address string, c Class) (Result, bool, error) {
+	limit, ok := l.Address[c]
+	if !ok {
+		return Result{}, false, nil
+	}
+
+	res, err := l.Store.Take(ctx, string(c)+":address:"+address, limit)
+	if err != nil {
+		return Result{}, false, err
+	}
+
+	return res, true, nil
+}
+
+// Seconds returns d rounded up to whole seconds, as sent in the rate limit headers
+func Seconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
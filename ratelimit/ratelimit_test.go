@@ -0,0 +1,114 @@
+package ratelimit
+
+import (
+	"context"
+	"github.com/norby7/questions-rest-api/config"
+	"github.com/norby7/questions-rest-api/entities"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestPolicyLimit(t *testing.T) {
+	c := config.Default().RateLimit
+	c.Admin.Writes = 0
+	p := NewPolicy(c)
+
+	testCases := []struct {
+		name     string
+		roles    []entities.Role
+		class    Class
+		expected Limit
+		limited  bool
+	}{{
+		name:     "single role",
+		roles:    []entities.Role{entities.RoleViewer},
+		class:    ClassWrite,
+		expected: PerMinute(c.Viewer.Writes),
+		limited:  true,
+	}, {
+		name:     "most generous role",
+		roles:    []entities.Role{entities.RoleViewer, entities.RoleEditor},
+		class:    ClassWrite,
+		expected: PerMinute(c.Editor.Writes),
+		limited:  true,
+	}, {
+		name:     "disabled limit",
+		roles:    []entities.Role{entities.RoleViewer, entities.RoleAdmin},
+		class:    ClassWrite,
+		expected: Limit{},
+	}, {
+		name:     "no role",
+		class:    ClassRead,
+		expected: Limit{},
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			l, limited := p.Limit(entities.Principal{Roles: tc.roles}, tc.class)
+
+			if l != tc.expected || limited != tc.limited {
+				t.Errorf("expected limit (%v) limited (%v), got limit (%v) limited (%v)", tc.expected, tc.limited, l, limited)
+			}
+		})
+	}
+}
+
+func TestLimiter(t *testing.T) {
+	p := Policy{entities.RoleViewer: {ClassRead: Limit{Rate: 1, Burst: 1}, ClassWrite: Limit{Rate: 1, Burst: 1}}}
+	l := NewLimiter(NewMemoryStore(), p)
+	viewer := entities.Principal{Roles: []entities.Role{entities.RoleViewer}}
+
+	// reads and writes have separate buckets
+	for _, c := range []Class{ClassRead, ClassWrite} {
+		res, limited, err := l.Allow(context.Background(), "alice", viewer, c)
+		if err != nil || !limited || !res.Allowed {
+			t.Errorf("expected the first (%s) to be allowed, got result (%+v) limited (%v) error (%v)", c, res, limited, err)
+		}
+	}
+
+	res, _, _ := l.Allow(context.Background(), "alice", viewer, ClassRead)
+	if res.Allowed {
+		t.Errorf("expected the second read to be rejected")
+	}
+}
+
+func TestAllowAddress(t *testing.T) {
+	l := NewLimiter(NewMemoryStore(), nil)
+	l.Address = NewAddressLimits(config.RateLimit{Address: config.RoleLimit{Reads: 1}})
+
+	if res, limited, err := l.AllowAddress(context.Background(), "192.0.2.1", ClassRead); err != nil || !limited || !res.Allowed {
+		t.Errorf("expected the first read to be allowed, got result (%+v) limited (%v) error (%v)", res, limited, err)
+	}
+
+	if res, _, _ := l.AllowAddress(context.Background(), "192.0.2.1", ClassRead); res.Allowed {
+		t.Errorf("expected the second read to be rejected")
+	}
+
+	// the client of the same name keeps its own bucket
+	viewer := entities.Principal{Roles: []entities.Role{entities.RoleViewer}}
+	l.Policy = Policy{entities.RoleViewer: {ClassRead: PerMinute(1)}}
+	if res, _, _ := l.Allow(context.Background(), "192.0.2.1", viewer, ClassRead); !res.Allowed {
+		t.Errorf("expected the read of the client to be allowed")
+	}
+
+	if _, limited, _ := l.AllowAddress(context.Background(), "192.0.2.1", ClassWrite); limited {
+		t.Errorf("expected the writes not to be limited")
+	}
+}
+
+func TestRequestClass(t *testing.T) {
+	for method, expected := range map[string]Class{"GET": ClassRead, "HEAD": ClassRead, "POST": ClassWrite, "PUT": ClassWrite, "DELETE": ClassWrite} {
+		if c := RequestClass(httptest.NewRequest(method, "/questions", nil)); c != expected {
+			t.Errorf("expected (%s) to be a (%s) request, got (%s)", method, expected, c)
+		}
+	}
+}
+
+func TestPerMinute(t *testing.T) {
+	l := PerMinute(120)
+
+	if l.Burst != 120 || l.Window() != time.Minute {
+		t.Errorf("expected a burst of (120) refilled in a minute, got limit (%v) window (%v)", l, l.Window())
+	}
+}
@@ -0,0 +1,87 @@
+package http
+
+import (
+	"github.com/gorilla/mux"
+	"github.com/norby7/questions-rest-api/logging"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// statusRecorder records the status code and the size of a response
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+func (s *statusRecorder) WriteHeader(status int) {
+	if s.status == 0 {
+		s.status = status
+	}
+
+	s.ResponseWriter.WriteHeader(status)
+}
+
+func (s *statusRecorder) Write(b []byte) (int, error) {
+	if s.status == 0 {
+		s.status = http.StatusOK
+	}
+
+	n, err := s.ResponseWriter.Write(b)
+	s.bytes += n
+
+	return n, err
+}
+
+// Unwrap returns the original response writer, used by http.ResponseController
+func (s *statusRecorder) Unwrap() http.ResponseWriter {
+	return s.ResponseWriter
+}
+
+// AccessLog returns a middleware that logs a line for every request, with its method, route, path, status, size and latency
+// The method and route are also added to the context, so they are part of every line logged while handling the request.
+// Server errors are logged at error level, the other requests at info level.
+func AccessLog(l *slog.Logger) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+
+			ctx := logging.NewContext(r.Context(), slog.String("method", r.Method), slog.String("route", routeTemplate(r)))
+			rec := &statusRecorder{ResponseWriter: rw}
+
+			next.ServeHTTP(rec, r.WithContext(ctx))
+
+			if rec.status == 0 {
+				rec.status = http.StatusOK
+			}
+
+			level := slog.LevelInfo
+			if rec.status >= http.StatusInternalServerError {
+				level = slog.LevelError
+			}
+
+			l.LogAttrs(ctx, level, "request",
+				slog.String("path", r.URL.Path),
+				slog.Int("status", rec.status),
+				slog.Int("bytes", rec.bytes),
+				slog.Float64("latency_ms", float64(time.Since(start).Microseconds())/1000),
+			)
+		})
+	}
+}
+
+// unmatchedRoute is the route of the requests matching no path template
+const unmatchedRoute = "unmatched"
+
+// routeTemplate returns the path template of the route matched by the request, or unmatchedRoute if it has none
+// The route is a metric label, so it never comes from the request path.
+func routeTemplate(r *http.Request) string {
+	if cr := mux.CurrentRoute(r); cr != nil {
+		if tpl, err := cr.GetPathTemplate(); err == nil {
+			return tpl
+		}
+	}
+
+	return unmatchedRoute
+}
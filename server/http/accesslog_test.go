@@ -0,0 +1,53 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/gorilla/mux"
+	"github.com/norby7/questions-rest-api/logging"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAccessLog(t *testing.T) {
+	var buf bytes.Buffer
+	l := logging.New(&buf, new(slog.LevelVar))
+
+	r := mux.NewRouter()
+	r.Use(RequestID(), AccessLog(l))
+	r.HandleFunc("/question/{id:[0-9]+}", func(rw http.ResponseWriter, r *http.Request) {
+		l.InfoContext(r.Context(), "handling")
+		http.Error(rw, "unable to delete question", http.StatusInternalServerError)
+	}).Methods("DELETE")
+
+	req := httptest.NewRequest("DELETE", "/question/7", nil)
+	req.Header.Set(RequestIDHeader, "request-1")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	dec := json.NewDecoder(&buf)
+
+	var handling, access map[string]interface{}
+	if err := dec.Decode(&handling); err != nil {
+		t.Fatalf("unable to decode handler log line: %s", err.Error())
+	}
+
+	if err := dec.Decode(&access); err != nil {
+		t.Fatalf("unable to decode access log line: %s", err.Error())
+	}
+
+	for _, line := range []map[string]interface{}{handling, access} {
+		if line["request_id"] != "request-1" || line["method"] != "DELETE" || line["route"] != "/question/{id:[0-9]+}" {
+			t.Errorf("expected the request id, method and route in the line, got line (%v)", line)
+		}
+	}
+
+	if access["path"] != "/question/7" || handling["path"] != nil {
+		t.Errorf("expected the request path in the access line only, got lines (%v) and (%v)", handling, access)
+	}
+
+	if access["msg"] != "request" || access["level"] != "ERROR" || access["status"] != float64(500) || access["latency_ms"] == nil || access["bytes"] == float64(0) {
+		t.Errorf("expected an error access line with status, size and latency, got line (%v)", access)
+	}
+}
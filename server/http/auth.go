@@ -0,0 +1,63 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gorilla/mux"
+	"github.com/norby7/questions-rest-api/entities"
+	"github.com/norby7/questions-rest-api/usecases/auth"
+	"net/http"
+	"strings"
+)
+
+// Authenticate returns a middleware that rejects the requests without valid credentials
+// and adds the authenticated principal to the context of the other requests.
+// The credential is read from the "Authorization: Bearer" header, or from the "X-API-Key" header.
+func Authenticate(a *auth.Authenticator) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			p, err := a.Authenticate(r.Context(), credential(r))
+			if err != nil {
+				if errors.Is(err, auth.MissingCredentialsError) {
+					rw.Header().Set("WWW-Authenticate", `Bearer realm="questions"`)
+					http.Error(rw, fmt.Sprintf("unable to authenticate request: %s", err.Error()), http.StatusUnauthorized)
+					return
+				}
+
+				if errors.Is(err, auth.InvalidAPIKeyError) || errors.Is(err, auth.InvalidTokenError) {
+					rw.Header().Set("WWW-Authenticate", `Bearer realm="questions", error="invalid_token"`)
+					http.Error(rw, fmt.Sprintf("unable to authenticate request: %s", err.Error()), http.StatusUnauthorized)
+					return
+				}
+
+				http.Error(rw, fmt.Sprintf("unable to authenticate request: %s", err.Error()), http.StatusInternalServerError)
+				return
+			}
+
+			next.ServeHTTP(rw, r.WithContext(auth.NewContext(r.Context(), p)))
+		})
+	}
+}
+
+// Anonymous returns a middleware that adds the given principal to the context of every request
+// It's used in place of Authenticate when authentication is disabled.
+func Anonymous(p entities.Principal) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(rw, r.WithContext(auth.NewContext(r.Context(), p)))
+		})
+	}
+}
+
+// credential returns the bearer token or api key sent with the request
+func credential(r *http.Request) string {
+	if h := r.Header.Get("Authorization"); h != "" {
+		if len(h) > len("Bearer ") && strings.EqualFold(h[:len("Bearer ")], "Bearer ") {
+			return strings.TrimSpace(h[len("Bearer "):])
+		}
+
+		return ""
+	}
+
+	return strings.TrimSpace(r.Header.Get("X-API-Key"))
+}
@@ -0,0 +1,23 @@
+package http
+
+import (
+	"fmt"
+	"github.com/gorilla/mux"
+	"net/http"
+)
+
+// BodyLimit returns a middleware that rejects the request bodies larger than n bytes with a 413 response
+// The bodies announcing a larger Content-Length are rejected before being read, the others fail once n bytes are read.
+func BodyLimit(n int64) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			if r.ContentLength > n {
+				http.Error(rw, fmt.Sprintf("request body larger than %d bytes", n), http.StatusRequestEntityTooLarge)
+				return
+			}
+
+			r.Body = http.MaxBytesReader(rw, r.Body, n)
+			next.ServeHTTP(rw, r)
+		})
+	}
+}
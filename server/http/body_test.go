@@ -0,0 +1,89 @@
+package http
+
+import (
+	"github.com/gorilla/mux"
+	"github.com/norby7/questions-rest-api/config"
+	hc "github.com/norby7/questions-rest-api/interfaceAdapters/http"
+	"github.com/norby7/questions-rest-api/logging"
+	"github.com/norby7/questions-rest-api/usecases/service"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestBodyLimit(t *testing.T) {
+	c := hc.NewController(service.NewService(&repositoryStub{}), logging.Discard())
+
+	s := config.Default().HTTP
+	s.MaxBodyBytes = len(questionJSON)
+	s.MaxBulkBytes = 4 * len(questionJSON)
+
+	r := mux.NewRouter()
+	RegisterRoutes(r, *c, nil, nil, nil, nil, nil, s)
+
+	batch := `[{"op":"create","question":` + questionJSON + `}]`
+
+	testCases := []struct {
+		name    string
+		method  string
+		url     string
+		body    string
+		chunked bool
+		status  int
+	}{{
+		name:   "within the limit",
+		method: "POST",
+		url:    "/question",
+		body:   questionJSON,
+		status: http.StatusOK,
+	}, {
+		name:   "content length over the limit",
+		method: "POST",
+		url:    "/question",
+		body:   questionJSON + " ",
+		status: http.StatusRequestEntityTooLarge,
+	}, {
+		name:    "chunked body over the limit",
+		method:  "PUT",
+		url:     "/question/1",
+		body:    questionJSON + strings.Repeat(" ", 16),
+		chunked: true,
+		status:  http.StatusRequestEntityTooLarge,
+	}, {
+		name:   "batch within the bulk limit",
+		method: "POST",
+		url:    "/questions/batch",
+		body:   batch,
+		status: http.StatusOK,
+	}, {
+		name:   "batch over the bulk limit",
+		method: "POST",
+		url:    "/questions/batch",
+		body:   "[" + strings.Repeat(batch[1:len(batch)-1]+",", 4) + "]",
+		status: http.StatusRequestEntityTooLarge,
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var body io.Reader = strings.NewReader(tc.body)
+			if tc.chunked {
+				// hides the length of the body, like a chunked request
+				body = io.MultiReader(body)
+			}
+
+			req := httptest.NewRequest(tc.method, tc.url, body)
+			if tc.chunked {
+				req.ContentLength = -1
+			}
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			if rr.Code != tc.status {
+				t.Errorf("expected status code (%d), got status code (%d) with response (%s)", tc.status, rr.Code, rr.Body.String())
+			}
+		})
+	}
+}
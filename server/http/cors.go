@@ -0,0 +1,86 @@
+package http
+
+import (
+	"github.com/gorilla/mux"
+	"github.com/norby7/questions-rest-api/config"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// corsMethods are the methods the browsers can use in the cross-origin requests
+var corsMethods = []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete}
+
+// corsHeaders are the request headers the browsers can send in the cross-origin requests
+var corsHeaders = []string{"Authorization", "Content-Type", "X-API-Key", RequestIDHeader, "traceparent", "tracestate"}
+
+// corsExposedHeaders are the response headers the scripts can read from the cross-origin responses
+var corsExposedHeaders = []string{RequestIDHeader, "Content-Disposition", "Warning", "Retry-After", "RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "RateLimit-Policy"}
+
+// CORS returns a middleware that lets the browsers of the allowed origins call the api
+// The preflight requests of the allowed origins are answered with a 204 response, without reaching the routes.
+// The requests of the other origins get no CORS headers, so their browsers don't let the scripts read the responses.
+func CORS(c config.CORS) mux.MiddlewareFunc {
+	anyOrigin := false
+	allowed := map[string]bool{}
+	for _, o := range c.Origins() {
+		if o == "*" {
+			anyOrigin = true
+		}
+		allowed[strings.ToLower(o)] = true
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			h := rw.Header()
+			h.Add("Vary", "Origin")
+
+			origin := r.Header.Get("Origin")
+			if origin == "" || !(anyOrigin || allowed[strings.ToLower(origin)]) {
+				next.ServeHTTP(rw, r)
+				return
+			}
+
+			h.Set("Access-Control-Allow-Origin", origin)
+			if c.AllowCredentials {
+				h.Set("Access-Control-Allow-Credentials", "true")
+			}
+
+			if r.Method != http.MethodOptions || r.Header.Get("Access-Control-Request-Method") == "" {
+				h.Set("Access-Control-Expose-Headers", strings.Join(corsExposedHeaders, ", "))
+				next.ServeHTTP(rw, r)
+				return
+			}
+
+			h.Add("Vary", "Access-Control-Request-Method")
+			h.Add("Vary", "Access-Control-Request-Headers")
+			h.Set("Access-Control-Allow-Methods", strings.Join(corsMethods, ", "))
+			h.Set("Access-Control-Allow-Headers", strings.Join(corsHeaders, ", "))
+			h.Set("Access-Control-Max-Age", strconv.Itoa(int(c.MaxAge.Seconds())))
+			rw.WriteHeader(http.StatusNoContent)
+		})
+	}
+}
+
+// preflightRoutes adds an OPTIONS route for the path of every route of r, registered on r itself
+// The preflight requests of the existing paths match them, so they are answered by the CORS middleware without
+// authentication, the OPTIONS requests of the other paths match no route and get a 404 response.
+func preflightRoutes(r *mux.Router) {
+	var paths []string
+	seen := map[string]bool{}
+	_ = r.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		// the subrouters have no path
+		if tpl, err := route.GetPathTemplate(); err == nil && !seen[tpl] {
+			seen[tpl] = true
+			paths = append(paths, tpl)
+		}
+
+		return nil
+	})
+
+	for _, p := range paths {
+		r.Path(p).Methods(http.MethodOptions).HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			rw.WriteHeader(http.StatusNoContent)
+		})
+	}
+}
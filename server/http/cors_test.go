@@ -0,0 +1,186 @@
+package http
+
+import (
+	"github.com/gorilla/mux"
+	"github.com/norby7/questions-rest-api/config"
+	"github.com/norby7/questions-rest-api/entities"
+	hc "github.com/norby7/questions-rest-api/interfaceAdapters/http"
+	"github.com/norby7/questions-rest-api/logging"
+	"github.com/norby7/questions-rest-api/metrics"
+	"github.com/norby7/questions-rest-api/usecases/auth"
+	"github.com/norby7/questions-rest-api/usecases/service"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCORS(t *testing.T) {
+	c := hc.NewController(service.NewService(&repositoryStub{}), logging.Discard())
+
+	s := config.Default().HTTP
+	s.CORS = config.CORS{AllowedOrigins: "https://authoring.example.com", AllowCredentials: true, MaxAge: time.Hour}
+
+	// authentication is enabled without any key, the preflight requests must succeed anyway
+	r := mux.NewRouter()
+	RegisterRoutes(r, *c, auth.NewAuthenticator(&keyStoreMock{keys: map[string]entities.APIKey{}}, nil), nil, nil, nil, nil, s)
+
+	testCases := []struct {
+		name        string
+		method      string
+		origin      string
+		preflight   bool
+		status      int
+		allowOrigin string
+	}{{
+		name:        "preflight",
+		method:      "OPTIONS",
+		origin:      "https://authoring.example.com",
+		preflight:   true,
+		status:      http.StatusNoContent,
+		allowOrigin: "https://authoring.example.com",
+	}, {
+		name:      "preflight of another origin",
+		method:    "OPTIONS",
+		origin:    "https://evil.example.com",
+		preflight: true,
+		status:    http.StatusNoContent,
+	}, {
+		name:        "request",
+		method:      "DELETE",
+		origin:      "https://authoring.example.com",
+		status:      http.StatusUnauthorized,
+		allowOrigin: "https://authoring.example.com",
+	}, {
+		name:   "request of another origin",
+		method: "DELETE",
+		origin: "https://evil.example.com",
+		status: http.StatusUnauthorized,
+	}, {
+		name:   "same origin request",
+		method: "DELETE",
+		status: http.StatusUnauthorized,
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, "/question/1", nil)
+			if tc.origin != "" {
+				req.Header.Set("Origin", tc.origin)
+			}
+			if tc.preflight {
+				req.Header.Set("Access-Control-Request-Method", "DELETE")
+				req.Header.Set("Access-Control-Request-Headers", "authorization")
+			}
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			if rr.Code != tc.status {
+				t.Errorf("expected status code (%d), got status code (%d)", tc.status, rr.Code)
+			}
+
+			h := rr.Header()
+			if h.Get("Access-Control-Allow-Origin") != tc.allowOrigin {
+				t.Errorf("expected allowed origin (%s), got allowed origin (%s)", tc.allowOrigin, h.Get("Access-Control-Allow-Origin"))
+			}
+
+			if h.Get("Vary") != "Origin" {
+				t.Errorf("expected the responses to vary by origin, got (%s)", h.Get("Vary"))
+			}
+
+			if tc.allowOrigin == "" {
+				return
+			}
+
+			if h.Get("Access-Control-Allow-Credentials") != "true" {
+				t.Errorf("expected the credentials to be allowed")
+			}
+
+			if tc.preflight {
+				if h.Get("Access-Control-Allow-Methods") != "GET, POST, PUT, PATCH, DELETE" {
+					t.Errorf("expected the api methods to be allowed, got (%s)", h.Get("Access-Control-Allow-Methods"))
+				}
+				if h.Get("Access-Control-Max-Age") != "3600" {
+					t.Errorf("expected max age (3600), got max age (%s)", h.Get("Access-Control-Max-Age"))
+				}
+			} else if h.Get("Access-Control-Expose-Headers") == "" {
+				t.Errorf("expected the exposed headers")
+			}
+		})
+	}
+}
+
+func TestCORSUnknownPath(t *testing.T) {
+	c := hc.NewController(service.NewService(&repositoryStub{}), logging.Discard())
+	m := metrics.New()
+
+	s := config.Default().HTTP
+	s.CORS = config.CORS{AllowedOrigins: "*"}
+
+	r := mux.NewRouter()
+	RegisterRoutes(r, *c, auth.NewAuthenticator(&keyStoreMock{keys: map[string]entities.APIKey{}}, nil), m, nil, nil, nil, s)
+
+	for _, url := range []string{"/random-0", "/random-1", "/question/1/random"} {
+		req := httptest.NewRequest("OPTIONS", url, nil)
+		req.Header.Set("Origin", "https://authoring.example.com")
+		req.Header.Set("Access-Control-Request-Method", "GET")
+
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusNotFound {
+			t.Errorf("expected status code (%d) for (%s), got status code (%d)", http.StatusNotFound, url, rr.Code)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+	if strings.Contains(rec.Body.String(), "random") {
+		t.Errorf("expected no metrics of the unknown paths, got metrics (%s)", rec.Body.String())
+	}
+}
+
+func TestCORSDisabled(t *testing.T) {
+	c := hc.NewController(service.NewService(&repositoryStub{}), logging.Discard())
+
+	r := mux.NewRouter()
+	RegisterRoutes(r, *c, nil, nil, nil, nil, nil, config.Default().HTTP)
+
+	req := httptest.NewRequest("OPTIONS", "/question/1", nil)
+	req.Header.Set("Origin", "https://authoring.example.com")
+	req.Header.Set("Access-Control-Request-Method", "DELETE")
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected status code (%d), got status code (%d)", http.StatusMethodNotAllowed, rr.Code)
+	}
+
+	if rr.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Errorf("expected no CORS headers without allowed origins")
+	}
+}
+
+func TestCORSAnyOrigin(t *testing.T) {
+	r := mux.NewRouter()
+	r.Use(CORS(config.CORS{AllowedOrigins: "*"}))
+	r.HandleFunc("/questions", func(rw http.ResponseWriter, r *http.Request) {}).Methods("GET")
+
+	req := httptest.NewRequest("GET", "/questions", nil)
+	req.Header.Set("Origin", "http://localhost:8080")
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	if rr.Header().Get("Access-Control-Allow-Origin") != "http://localhost:8080" {
+		t.Errorf("expected the origin to be allowed, got allowed origin (%s)", rr.Header().Get("Access-Control-Allow-Origin"))
+	}
+
+	if rr.Header().Get("Access-Control-Allow-Credentials") != "" {
+		t.Errorf("expected the credentials not to be allowed")
+	}
+}
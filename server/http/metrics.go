@@ -0,0 +1,26 @@
+package http
+
+import (
+	"github.com/gorilla/mux"
+	"github.com/norby7/questions-rest-api/metrics"
+	"net/http"
+	"time"
+)
+
+// Metrics returns a middleware that records the count and latency of the requests, by method, route and status code
+func Metrics(m *metrics.Metrics) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			rec := &statusRecorder{ResponseWriter: rw}
+
+			next.ServeHTTP(rec, r)
+
+			if rec.status == 0 {
+				rec.status = http.StatusOK
+			}
+
+			m.ObserveRequest(r.Method, routeTemplate(r), rec.status, time.Since(start))
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package http
+
+import (
+	"github.com/gorilla/mux"
+	"github.com/norby7/questions-rest-api/metrics"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMetrics(t *testing.T) {
+	m := metrics.New()
+
+	r := mux.NewRouter()
+	r.Use(Metrics(m))
+	r.HandleFunc("/question/{id:[0-9]+}", func(rw http.ResponseWriter, r *http.Request) {
+		http.Error(rw, "question not found", http.StatusNotFound)
+	}).Methods("DELETE")
+
+	for _, id := range []string{"1", "2"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("DELETE", "/question/"+id, nil))
+	}
+
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body, _ := ioutil.ReadAll(rec.Result().Body)
+
+	expected := `questions_http_requests_total{method="DELETE",route="/question/{id:[0-9]+}",status="404"} 2`
+	if !strings.Contains(string(body), expected) {
+		t.Errorf("expected (%s) in the metrics, got metrics (%s)", expected, string(body))
+	}
+}
+
+func TestMetricsUnmatchedRoute(t *testing.T) {
+	m := metrics.New()
+
+	// the route has no path template, the paths of its requests must not become labels
+	r := mux.NewRouter()
+	r.Use(Metrics(m))
+	r.Methods("OPTIONS").HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.WriteHeader(http.StatusNoContent)
+	})
+
+	for _, path := range []string{"/random-0", "/random-1"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("OPTIONS", path, nil))
+	}
+
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body, _ := ioutil.ReadAll(rec.Result().Body)
+
+	expected := `questions_http_requests_total{method="OPTIONS",route="unmatched",status="204"} 2`
+	if !strings.Contains(string(body), expected) {
+		t.Errorf("expected (%s) in the metrics, got metrics (%s)", expected, string(body))
+	}
+
+	if strings.Contains(string(body), "random") {
+		t.Errorf("expected no request path in the metrics, got metrics (%s)", string(body))
+	}
+}
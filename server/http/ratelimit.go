@@ -0,0 +1,97 @@
+package http
+
+import (
+	"fmt"
+	"github.com/gorilla/mux"
+	"github.com/norby7/questions-rest-api/entities"
+	"github.com/norby7/questions-rest-api/ratelimit"
+	"github.com/norby7/questions-rest-api/usecases/auth"
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+)
+
+// RateLimitAddress returns a middleware that limits the requests of every IP address, reads and writes separately
+// It's used before the authentication, so the requests with missing or rejected credentials are limited too and
+// credentials can't be guessed at the rate the server answers. The limited responses get the headers of RateLimit.
+func RateLimitAddress(l *ratelimit.Limiter, log *slog.Logger) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			res, limited, err := l.AllowAddress(r.Context(), remoteHost(r), ratelimit.RequestClass(r))
+			if err != nil {
+				log.ErrorContext(r.Context(), "unable to apply address rate limit", "error", err.Error())
+			}
+
+			if limited && !writeLimit(rw, res) {
+				return
+			}
+
+			next.ServeHTTP(rw, r)
+		})
+	}
+}
+
+// RateLimit returns a middleware that limits the requests of every client, reads and writes separately
+// Authenticated clients are identified by their tenant and subject, the others by their IP address.
+// The RateLimit-Limit, RateLimit-Remaining and RateLimit-Reset headers are sent with the limited responses,
+// and the requests over the limit get a 429 response with a Retry-After header.
+// When the store of the limiter fails the request is let through, so an outage of the store doesn't stop the api.
+func RateLimit(l *ratelimit.Limiter, log *slog.Logger) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			p, _ := auth.FromContext(r.Context())
+
+			res, limited, err := l.Allow(r.Context(), clientKey(r, p), p, ratelimit.RequestClass(r))
+			if err != nil {
+				log.ErrorContext(r.Context(), "unable to apply rate limit", "error", err.Error())
+			}
+
+			if limited && !writeLimit(rw, res) {
+				return
+			}
+
+			next.ServeHTTP(rw, r)
+		})
+	}
+}
+
+// writeLimit sets the rate limit headers of the result, and answers with 429 when the request isn't allowed
+// It reports whether the request can go on.
+func writeLimit(rw http.ResponseWriter, res ratelimit.Result) bool {
+	h := rw.Header()
+	h.Set("RateLimit-Limit", strconv.Itoa(res.Limit.Burst))
+	h.Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+	h.Set("RateLimit-Reset", strconv.Itoa(ratelimit.Seconds(res.Reset)))
+	h.Set("RateLimit-Policy", fmt.Sprintf("%d;w=%d", res.Limit.Burst, ratelimit.Seconds(res.Limit.Window())))
+
+	if !res.Allowed {
+		retry := ratelimit.Seconds(res.RetryAfter)
+
+		h.Set("Retry-After", strconv.Itoa(retry))
+		http.Error(rw, fmt.Sprintf("rate limit exceeded, retry in %d seconds", retry), http.StatusTooManyRequests)
+		return false
+	}
+
+	return true
+}
+
+// clientKey returns the key identifying the client of a request in the rate limiter
+// Only the anonymous principal of a server without authentication is identified by its IP address.
+func clientKey(r *http.Request, p entities.Principal) string {
+	if p.Method == entities.AuthAPIKey || p.Method == entities.AuthJWT {
+		return fmt.Sprintf("%s:%s/%s", p.Method, p.Tenant, p.Subject)
+	}
+
+	return "ip:" + remoteHost(r)
+}
+
+// remoteHost returns the IP address of the client of a request
+func remoteHost(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
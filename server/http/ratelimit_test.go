@@ -0,0 +1,188 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"github.com/gorilla/mux"
+	"github.com/norby7/questions-rest-api/entities"
+	"github.com/norby7/questions-rest-api/logging"
+	"github.com/norby7/questions-rest-api/ratelimit"
+	"github.com/norby7/questions-rest-api/usecases/auth"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// failingStore is a rate limit store that can't be reached
+type failingStore struct {
+}
+
+func (f *failingStore) Take(context.Context, string, ratelimit.Limit) (ratelimit.Result, error) {
+	return ratelimit.Result{}, fmt.Errorf("unable to reach store")
+}
+
+// newRateLimitRouter returns a router limiting the requests of the principal to a single read and a single write per minute
+func newRateLimitRouter(s ratelimit.Store, p *entities.Principal) *mux.Router {
+	l := ratelimit.NewLimiter(s, ratelimit.Policy{entities.RoleViewer: {
+		ratelimit.ClassRead:  ratelimit.PerMinute(1),
+		ratelimit.ClassWrite: ratelimit.PerMinute(1),
+	}})
+
+	r := mux.NewRouter()
+	r.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(rw, r.WithContext(auth.NewContext(r.Context(), *p)))
+		})
+	}, RateLimit(l, logging.Discard()))
+	r.HandleFunc("/questions", func(http.ResponseWriter, *http.Request) {}).Methods("GET", "POST")
+
+	return r
+}
+
+func TestRateLimit(t *testing.T) {
+	p := &entities.Principal{Subject: "alice", Method: entities.AuthAPIKey, Roles: []entities.Role{entities.RoleViewer}, Tenant: "acme"}
+	r := newRateLimitRouter(ratelimit.NewMemoryStore(), p)
+
+	testCases := []struct {
+		name       string
+		method     string
+		principal  entities.Principal
+		remoteAddr string
+		statusCode int
+		headers    map[string]string
+	}{{
+		name:       "first read",
+		method:     "GET",
+		principal:  *p,
+		statusCode: http.StatusOK,
+		headers:    map[string]string{"RateLimit-Limit": "1", "RateLimit-Remaining": "0", "RateLimit-Reset": "60", "RateLimit-Policy": "1;w=60"},
+	}, {
+		name:       "second read",
+		method:     "GET",
+		principal:  *p,
+		statusCode: http.StatusTooManyRequests,
+		headers:    map[string]string{"RateLimit-Remaining": "0", "Retry-After": "60"},
+	}, {
+		name:       "first write",
+		method:     "POST",
+		principal:  *p,
+		statusCode: http.StatusOK,
+	}, {
+		name:       "other subject",
+		method:     "GET",
+		principal:  entities.Principal{Subject: "bob", Method: entities.AuthAPIKey, Roles: []entities.Role{entities.RoleViewer}, Tenant: "acme"},
+		statusCode: http.StatusOK,
+	}, {
+		name:       "local principal, first address",
+		method:     "GET",
+		principal:  entities.Principal{Subject: "anonymous", Method: entities.AuthLocal, Roles: []entities.Role{entities.RoleViewer}},
+		remoteAddr: "192.0.2.1:4000",
+		statusCode: http.StatusOK,
+	}, {
+		name:       "local principal, same address",
+		method:     "GET",
+		principal:  entities.Principal{Subject: "anonymous", Method: entities.AuthLocal, Roles: []entities.Role{entities.RoleViewer}},
+		remoteAddr: "192.0.2.1:4001",
+		statusCode: http.StatusTooManyRequests,
+	}, {
+		name:       "local principal, other address",
+		method:     "GET",
+		principal:  entities.Principal{Subject: "anonymous", Method: entities.AuthLocal, Roles: []entities.Role{entities.RoleViewer}},
+		remoteAddr: "192.0.2.2:4000",
+		statusCode: http.StatusOK,
+	}, {
+		name:       "unlimited role",
+		method:     "GET",
+		principal:  entities.Principal{Subject: "alice", Method: entities.AuthAPIKey, Roles: []entities.Role{entities.RoleAdmin}, Tenant: "acme"},
+		statusCode: http.StatusOK,
+		headers:    map[string]string{"RateLimit-Limit": ""},
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			*p = tc.principal
+
+			req := httptest.NewRequest(tc.method, "/questions", nil)
+			if tc.remoteAddr != "" {
+				req.RemoteAddr = tc.remoteAddr
+			}
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			if rr.Code != tc.statusCode {
+				t.Errorf("expected status code (%d), got status code (%d)", tc.statusCode, rr.Code)
+			}
+
+			for k, v := range tc.headers {
+				if rr.Header().Get(k) != v {
+					t.Errorf("expected header (%s) to be (%s), got (%s)", k, v, rr.Header().Get(k))
+				}
+			}
+		})
+	}
+}
+
+func TestRateLimitStoreError(t *testing.T) {
+	p := &entities.Principal{Subject: "alice", Method: entities.AuthAPIKey, Roles: []entities.Role{entities.RoleViewer}, Tenant: "acme"}
+	r := newRateLimitRouter(&failingStore{}, p)
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest("GET", "/questions", nil))
+
+	if rr.Code != http.StatusOK {
+		t.Errorf("expected the request to be let through, got status code (%d)", rr.Code)
+	}
+}
+
+func TestRateLimitAddress(t *testing.T) {
+	l := ratelimit.NewLimiter(ratelimit.NewMemoryStore(), nil)
+	l.Address = map[ratelimit.Class]ratelimit.Limit{ratelimit.ClassRead: ratelimit.PerMinute(1)}
+
+	// every credential is rejected, the requests are limited before
+	r := mux.NewRouter()
+	r.Use(RateLimitAddress(l, logging.Discard()), Authenticate(auth.NewAuthenticator(&keyStoreMock{}, nil)))
+	r.HandleFunc("/questions", func(http.ResponseWriter, *http.Request) {}).Methods("GET", "POST")
+
+	testCases := []struct {
+		name       string
+		method     string
+		remoteAddr string
+		statusCode int
+	}{{
+		name:       "first read",
+		method:     "GET",
+		remoteAddr: "192.0.2.1:4000",
+		statusCode: http.StatusUnauthorized,
+	}, {
+		name:       "same address",
+		method:     "GET",
+		remoteAddr: "192.0.2.1:4001",
+		statusCode: http.StatusTooManyRequests,
+	}, {
+		name:       "other address",
+		method:     "GET",
+		remoteAddr: "192.0.2.2:4000",
+		statusCode: http.StatusUnauthorized,
+	}, {
+		name:       "unlimited write",
+		method:     "POST",
+		remoteAddr: "192.0.2.1:4000",
+		statusCode: http.StatusUnauthorized,
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, "/questions", nil)
+			req.Header.Set("X-API-Key", auth.APIKeyPrefix+"guess")
+			req.RemoteAddr = tc.remoteAddr
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			if rr.Code != tc.statusCode {
+				t.Errorf("expected status code (%d), got status code (%d)", tc.statusCode, rr.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package http
+
+import (
+	"github.com/gorilla/mux"
+	"github.com/norby7/questions-rest-api/usecases/requestid"
+	"net/http"
+)
+
+// RequestIDHeader is the header carrying the id of a request, in the request and in its response
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIdLength is the maximum length of a request id sent by the client
+const maxRequestIdLength = 128
+
+// RequestID returns a middleware that adds the request id to the context of every request and to its response
+// The id sent by the client is kept if it's valid, otherwise a new one is generated.
+func RequestID() mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			id := r.Header.Get(RequestIDHeader)
+			if !validRequestId(id) {
+				id = requestid.New()
+			}
+
+			rw.Header().Set(RequestIDHeader, id)
+			next.ServeHTTP(rw, r.WithContext(requestid.NewContext(r.Context(), id)))
+		})
+	}
+}
+
+// validRequestId checks that a request id isn't empty, isn't too long and only contains printable ASCII characters
+func validRequestId(id string) bool {
+	if id == "" || len(id) > maxRequestIdLength {
+		return false
+	}
+
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,58 @@
+package http
+
+import (
+	"github.com/norby7/questions-rest-api/usecases/requestid"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRequestID(t *testing.T) {
+	var got string
+	h := RequestID()(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		got = requestid.FromContext(r.Context())
+	}))
+
+	testCases := []struct {
+		name     string
+		header   string
+		expected string
+	}{{
+		name:     "client id",
+		header:   "build-42",
+		expected: "build-42",
+	}, {
+		name: "missing id",
+	}, {
+		name:   "id with spaces",
+		header: "build 42",
+	}, {
+		name:   "id too long",
+		header: strings.Repeat("a", 129),
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/questions", nil)
+			if tc.header != "" {
+				req.Header.Set(RequestIDHeader, tc.header)
+			}
+
+			rr := httptest.NewRecorder()
+			h.ServeHTTP(rr, req)
+
+			if got == "" || rr.Header().Get(RequestIDHeader) != got {
+				t.Fatalf("expected the context id (%s) in the response header, got (%s)", got, rr.Header().Get(RequestIDHeader))
+			}
+
+			if tc.expected != "" && got != tc.expected {
+				t.Errorf("expected request id (%s), got request id (%s)", tc.expected, got)
+			}
+
+			if tc.expected == "" && got == tc.header {
+				t.Errorf("expected a generated request id, got request id (%s)", got)
+			}
+		})
+	}
+}
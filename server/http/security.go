@@ -0,0 +1,37 @@
+package http
+
+import (
+	"github.com/gorilla/mux"
+	"net/http"
+)
+
+// apiContentSecurityPolicy forbids loading anything from the api responses, they are only data
+const apiContentSecurityPolicy = "default-src 'none'; frame-ancestors 'none'"
+
+// docsContentSecurityPolicy allows the documentation page to load Redoc and its fonts from their CDNs
+const docsContentSecurityPolicy = "default-src 'self'; script-src https://cdn.jsdelivr.net; style-src 'unsafe-inline' https://fonts.googleapis.com; " +
+	"font-src https://fonts.gstatic.com; img-src 'self' data: https:; worker-src blob:; frame-ancestors 'none'"
+
+// SecurityHeaders returns a middleware that sets the security headers of every response
+// The responses can't be sniffed as another content type, framed, or load anything, and the requests send no referrer from them.
+func SecurityHeaders() mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			h := rw.Header()
+			h.Set("X-Content-Type-Options", "nosniff")
+			h.Set("X-Frame-Options", "DENY")
+			h.Set("Referrer-Policy", "no-referrer")
+			h.Set("Content-Security-Policy", apiContentSecurityPolicy)
+
+			next.ServeHTTP(rw, r)
+		})
+	}
+}
+
+// docsSecurityPolicy relaxes the content security policy of h, for the documentation page
+func docsSecurityPolicy(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.Header().Set("Content-Security-Policy", docsContentSecurityPolicy)
+		h.ServeHTTP(rw, r)
+	})
+}
@@ -0,0 +1,60 @@
+package http
+
+import (
+	"github.com/gorilla/mux"
+	"github.com/norby7/questions-rest-api/config"
+	hc "github.com/norby7/questions-rest-api/interfaceAdapters/http"
+	"github.com/norby7/questions-rest-api/logging"
+	"github.com/norby7/questions-rest-api/usecases/service"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSecurityHeaders(t *testing.T) {
+	c := hc.NewController(service.NewService(&repositoryStub{}), logging.Discard())
+
+	r := mux.NewRouter()
+	RegisterRoutes(r, *c, nil, nil, nil, nil, nil, config.Default().HTTP)
+
+	testCases := []struct {
+		name string
+		url  string
+		csp  string
+	}{{
+		name: "api",
+		url:  "/questions",
+		csp:  apiContentSecurityPolicy,
+	}, {
+		name: "not found",
+		url:  "/answers",
+		csp:  "",
+	}, {
+		name: "documentation",
+		url:  "/docs",
+		csp:  docsContentSecurityPolicy,
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, httptest.NewRequest("GET", tc.url, nil))
+
+			if tc.csp == "" {
+				return
+			}
+
+			expected := map[string]string{
+				"X-Content-Type-Options":  "nosniff",
+				"X-Frame-Options":         "DENY",
+				"Referrer-Policy":         "no-referrer",
+				"Content-Security-Policy": tc.csp,
+			}
+
+			for k, v := range expected {
+				if rr.Header().Get(k) != v {
+					t.Errorf("expected header (%s) to be (%s), got (%s)", k, v, rr.Header().Get(k))
+				}
+			}
+		})
+	}
+}
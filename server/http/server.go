@@ -5,68 +5,264 @@ import (
 	"fmt"
 	"github.com/go-openapi/runtime/middleware"
 	"github.com/gorilla/mux"
+	"github.com/norby7/questions-rest-api/config"
+	"github.com/norby7/questions-rest-api/entities"
+	"github.com/norby7/questions-rest-api/health"
 	hc "github.com/norby7/questions-rest-api/interfaceAdapters/http"
-	"log"
+	"github.com/norby7/questions-rest-api/metrics"
+	"github.com/norby7/questions-rest-api/ratelimit"
+	"github.com/norby7/questions-rest-api/usecases/auth"
+	"go.opentelemetry.io/otel/trace"
+	"log/slog"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
-	"strconv"
+	"syscall"
 	"time"
 )
 
 // RegisterRoutes registers the http server routes
-func RegisterRoutes(r *mux.Router, c hc.Controller) {
-	r.HandleFunc("/question", c.Add).Methods("POST")
-	r.HandleFunc("/question/{id:[0-9]+}", c.Update).Methods("PUT")
-	r.HandleFunc("/question/{id:[0-9]+}", c.Delete).Methods("DELETE")
-	r.HandleFunc("/questions", c.GetAll).Methods("GET")
-
+// Every request gets an id, read from the X-Request-ID header or generated, that is sent back in the response and logged with every line of the request.
+// The question routes require the credentials accepted by a, the documentation routes are public.
+// If a is nil authentication is disabled and every request is made by an anonymous admin of the default tenant.
+// If m isn't nil the requests are measured and the metrics are exposed, without authentication, on /metrics.
+// If tp isn't nil every request starts a span of tp, continuing the trace of its traceparent header.
+// If h isn't nil the liveness and readiness probes are served, without authentication, on /healthz and /readyz.
+// If rl isn't nil the requests to the question routes are rate limited by IP address before they're authenticated,
+// then by client once authenticated.
+// Every response gets the security headers, the cross-origin requests of the origins allowed by s are answered and the bodies are limited to the sizes of s.
+func RegisterRoutes(r *mux.Router, c hc.Controller, a *auth.Authenticator, m *metrics.Metrics, tp trace.TracerProvider, h *health.Checker, rl *ratelimit.Limiter, s config.HTTP) {
 	// create Redoc configuration
 	ops := middleware.RedocOpts{
 		SpecURL: "/swagger.yaml",
 	}
 
+	r.Use(RequestID())
+	if tp != nil {
+		r.Use(Tracing(tp))
+	}
+
+	r.Use(AccessLog(c.Logger))
+	if m != nil {
+		r.Use(Metrics(m))
+		r.Handle("/metrics", m.Handler()).Methods("GET")
+	}
+
+	r.Use(SecurityHeaders())
+	if len(s.CORS.Origins()) > 0 {
+		r.Use(CORS(s.CORS))
+	}
+
+	if h != nil {
+		r.Handle("/healthz", h.LiveHandler()).Methods("GET")
+		r.Handle("/readyz", h.ReadyHandler()).Methods("GET")
+	}
+
 	// add swagger documentation routes
 	sh := middleware.Redoc(ops, nil)
-	r.Handle("/docs", sh)
+	r.Handle("/docs", docsSecurityPolicy(sh))
 	r.Handle("/swagger.yaml", http.FileServer(http.Dir("./")))
+
+	api := r.NewRoute().Subrouter()
+	if rl != nil {
+		api.Use(RateLimitAddress(rl, c.Logger))
+	}
+
+	if a != nil {
+		api.Use(Authenticate(a))
+	} else {
+		api.Use(Anonymous(entities.Principal{
+			Subject: "anonymous",
+			Method:  entities.AuthLocal,
+			Roles:   []entities.Role{entities.RoleAdmin},
+			Tenant:  entities.DefaultTenant,
+		}))
+	}
+
+	if rl != nil {
+		api.Use(RateLimit(rl, c.Logger))
+	}
+
+	// the import and batch requests carry many questions, their bodies have a larger limit
+	body := BodyLimit(int64(s.MaxBodyBytes))
+	bulk := BodyLimit(int64(s.MaxBulkBytes))
+
+	api.Handle("/question", body(http.HandlerFunc(c.Add))).Methods("POST")
+	api.Handle("/question/{id:[0-9]+}", body(http.HandlerFunc(c.Update))).Methods("PUT")
+	api.Handle("/question/{id:[0-9]+}", body(http.HandlerFunc(c.Patch))).Methods("PATCH")
+	api.HandleFunc("/question/{id:[0-9]+}", c.Delete).Methods("DELETE")
+	api.Handle("/question/{id:[0-9]+}/options", body(http.HandlerFunc(c.AddOption))).Methods("POST")
+	api.Handle("/question/{id:[0-9]+}/options/reorder", body(http.HandlerFunc(c.ReorderOptions))).Methods("POST")
+	api.Handle("/question/{id:[0-9]+}/options/{optionId:[0-9]+}", body(http.HandlerFunc(c.UpdateOption))).Methods("PUT")
+	api.HandleFunc("/question/{id:[0-9]+}/options/{optionId:[0-9]+}", c.RemoveOption).Methods("DELETE")
+	api.HandleFunc("/question/{id:[0-9]+}/submit", c.SubmitQuestion).Methods("POST")
+	api.Handle("/question/{id:[0-9]+}/approve", body(http.HandlerFunc(c.ApproveQuestion))).Methods("POST")
+	api.Handle("/question/{id:[0-9]+}/reject", body(http.HandlerFunc(c.RejectQuestion))).Methods("POST")
+	api.HandleFunc("/question/{id:[0-9]+}/retire", c.RetireQuestion).Methods("POST")
+	api.HandleFunc("/questions", c.GetAll).Methods("GET")
+	api.Handle("/questions/import", bulk(http.HandlerFunc(c.Import))).Methods("POST")
+	api.HandleFunc("/questions/export", c.Export).Methods("GET")
+	api.HandleFunc("/questions/duplicates", c.Duplicates).Methods("GET")
+	api.Handle("/questions/batch", bulk(http.HandlerFunc(c.Batch))).Methods("POST")
+	api.HandleFunc("/audit", c.AuditLog).Methods("GET")
+	api.HandleFunc("/log/level", c.GetLogLevel).Methods("GET")
+	api.Handle("/log/level", body(http.HandlerFunc(c.SetLogLevel))).Methods("PUT")
+
+	if len(s.CORS.Origins()) > 0 {
+		preflightRoutes(r)
+	}
+}
+
+// BulkPaths are the routes importing, exporting or changing many questions, or going through the whole bank
+// They are bounded by the bulk timeout instead of the request and write timeouts, see BulkTimeout.
+var BulkPaths = []string{"/questions/import", "/questions/export", "/questions/duplicates", "/questions/batch"}
+
+// ShutdownSignals are the signals that shut the server down gracefully, SIGTERM is sent by docker and kubernetes
+var ShutdownSignals = []os.Signal{os.Interrupt, syscall.SIGTERM}
+
+// Server is the http server of the api
+// It's reported ready by its health checker once it's listening, and unready for the drain delay before it's shut down,
+// so the traffic is moved to other instances. With a TLS certificate it serves https, and reloads its certificates on SIGHUP.
+type Server struct {
+	config config.Server
+	logger *slog.Logger
+	health *health.Checker
+	http   *http.Server
+	certs  *Certificates
 }
 
-// StartServer starts a new http server that listens on the given port
-func StartServer(r *mux.Router, port int) {
-	s := &http.Server{
-		Addr:         ":" + strconv.Itoa(port),
-		Handler:      r,
-		IdleTimeout:  120 * time.Second,
-		ReadTimeout:  2 * time.Second,
-		WriteTimeout: 1 * time.Second,
+// NewServer returns a server handling the requests with h, which is given the deadline of the request timeout, or of the
+// bulk timeout for the BulkPaths
+// If hc is nil the readiness of the server isn't reported.
+func NewServer(h http.Handler, c config.Server, l *slog.Logger, hc *health.Checker) (*Server, error) {
+	if hc == nil {
+		hc = health.New()
 	}
 
-	// start server on a different goroutine
-	go func() {
-		log.Println("Starting server on port " + strconv.Itoa(port))
+	s := &Server{
+		config: c,
+		logger: l,
+		health: hc,
+		http: &http.Server{
+			Addr:         c.Address,
+			Handler:      BulkTimeout(c.BulkTimeout, c.WriteTimeout-c.RequestTimeout, Timeout(c.RequestTimeout), BulkPaths...)(h),
+			IdleTimeout:  c.IdleTimeout,
+			ReadTimeout:  c.ReadTimeout,
+			WriteTimeout: c.WriteTimeout,
+		},
+	}
 
-		if err := s.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalln(fmt.Sprintf("unable to start http server: %s", err.Error()))
+	if c.TLS.Enabled() {
+		var err error
+		if s.certs, err = NewCertificates(c.TLS); err != nil {
+			return nil, err
 		}
 
+		s.http.TLSConfig = s.certs.TLSConfig()
+	}
+
+	return s, nil
+}
+
+// Run listens on the configured address and serves the requests until ctx is done, then shuts the server down gracefully
+func (s *Server) Run(ctx context.Context) error {
+	ln, err := net.Listen("tcp", s.config.Address)
+	if err != nil {
+		return fmt.Errorf("unable to start http server: %s", err.Error())
+	}
+
+	return s.Serve(ctx, ln)
+}
+
+// Serve serves the requests accepted by ln until ctx is done or Shutdown is called
+// When ctx is done the server is reported unready for the drain delay, then it stops accepting connections
+// and waits for the open ones until the shutdown timeout. It returns nil once the server is shut down.
+func (s *Server) Serve(ctx context.Context, ln net.Listener) error {
+	if s.certs != nil {
+		stop := s.reloadCertificates()
+		defer stop()
+	}
+
+	errs := make(chan error, 1)
+	go func() {
+		if s.certs != nil {
+			errs <- s.http.ServeTLS(ln, "", "")
+		} else {
+			errs <- s.http.Serve(ln)
+		}
 	}()
 
-	// create a signal channel that will be notified for Interrupt and Kill signals
-	sigChan := make(chan os.Signal)
-	signal.Notify(sigChan, os.Interrupt)
-	signal.Notify(sigChan, os.Kill)
+	s.logger.Info("starting server", "address", ln.Addr().String(), "tls", s.certs != nil)
+	s.health.SetReady()
+
+	select {
+	case err := <-errs:
+		if err != http.ErrServerClosed {
+			return fmt.Errorf("unable to serve http requests: %s", err.Error())
+		}
+		return nil
+	case <-ctx.Done():
+	}
+
+	// the requests keep being handled while the orchestrator notices the server is unready
+	s.logger.Info("shutting down, draining", "drain_delay", s.config.DrainDelay.String())
+	s.health.Drain()
+
+	select {
+	case <-time.After(s.config.DrainDelay):
+	case err := <-errs:
+		// Shutdown was called meanwhile
+		if err != http.ErrServerClosed {
+			return fmt.Errorf("unable to serve http requests: %s", err.Error())
+		}
+		return nil
+	}
 
-	// wait for a signal
-	sig := <-sigChan
-	log.Println("Received terminate, graceful shutdown", sig)
+	s.logger.Info("graceful shutdown")
 
-	// create context with timeout, the server will wait 30 seconds for all connections to finish
-	tc, cancel := context.WithTimeout(context.Background(), time.Second*30)
+	tc, cancel := context.WithTimeout(context.Background(), s.config.ShutdownTimeout)
 	defer cancel()
 
-	if err := s.Shutdown(tc); err != nil {
-		log.Fatalln(fmt.Sprintf("error shuting down server: %s", err.Error()))
+	return s.Shutdown(tc)
+}
+
+// Shutdown reports the server unready, stops accepting connections and waits for the open ones until ctx is done
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.health.Drain()
+
+	if err := s.http.Shutdown(ctx); err != nil {
+		return fmt.Errorf("unable to shut down http server: %s", err.Error())
 	}
 
+	return nil
+}
+
+// reloadCertificates reloads the certificates of the server every time the process receives SIGHUP, until stop is called
+func (s *Server) reloadCertificates() (stop func()) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-hup:
+			case <-done:
+				return
+			}
+
+			if err := s.certs.Reload(); err != nil {
+				s.logger.Error("unable to reload TLS certificates, keeping the previous ones", "error", err.Error())
+				continue
+			}
+
+			s.logger.Info("reloaded TLS certificates")
+		}
+	}()
+
+	return func() {
+		signal.Stop(hup)
+		close(done)
+	}
 }
@@ -0,0 +1,638 @@
+package http
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"github.com/gorilla/mux"
+	"github.com/norby7/questions-rest-api/config"
+	"github.com/norby7/questions-rest-api/entities"
+	"github.com/norby7/questions-rest-api/health"
+	hc "github.com/norby7/questions-rest-api/interfaceAdapters/http"
+	"github.com/norby7/questions-rest-api/logging"
+	"github.com/norby7/questions-rest-api/metrics"
+	"github.com/norby7/questions-rest-api/usecases/auth"
+	"github.com/norby7/questions-rest-api/usecases/repository"
+	"github.com/norby7/questions-rest-api/usecases/service"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// repositoryStub is an empty question bank where every write succeeds, the written question is read back as question 1
+type repositoryStub struct {
+}
+
+func (r *repositoryStub) Add(context.Context, entities.Question) (int64, error) {
+	return 1, nil
+}
+
+func (r *repositoryStub) AddAll(_ context.Context, ql []entities.Question) ([]int64, error) {
+	return make([]int64, len(ql)), nil
+}
+
+func (r *repositoryStub) Update(context.Context, entities.Question) error {
+	return nil
+}
+
+func (r *repositoryStub) Delete(context.Context, int64) error {
+	return nil
+}
+
+func (r *repositoryStub) Get(_ context.Context, id int64) (entities.Question, error) {
+	if id != 1 {
+		return entities.Question{}, repository.QuestionNotFoundError
+	}
+
+	return entities.Question{Id: 1, Body: "Where does the sun set?", Options: []entities.Option{{Id: 1, Body: "East"}, {Id: 2, Body: "West", Correct: true}}}, nil
+}
+
+func (r *repositoryStub) GetAll(context.Context, entities.QuestionFilter) ([]entities.Question, error) {
+	return []entities.Question{}, nil
+}
+
+func (r *repositoryStub) ForEach(context.Context, func(entities.Question) error) error {
+	return nil
+}
+
+func (r *repositoryStub) Batch(ctx context.Context, ops []repository.Operation) ([]int64, error) {
+	return make([]int64, len(ops)), nil
+}
+
+func (r *repositoryStub) SetStatus(context.Context, int64, entities.QuestionStatus, entities.QuestionStatus, string) error {
+	return repository.QuestionNotFoundError
+}
+
+func (r *repositoryStub) InTransaction(ctx context.Context, fn func(context.Context) error) error {
+	return fn(ctx)
+}
+
+const questionJSON = `{"body": "Where does the sun set?", "options": [{"body": "East", "correct": false}, {"body": "West", "correct": true}]}`
+
+// newPolicyRouter returns the application routes, authenticating one api key for every role
+func newPolicyRouter() *mux.Router {
+	keys := map[string]entities.APIKey{}
+	for _, role := range []entities.Role{entities.RoleViewer, entities.RoleEditor, entities.RoleAdmin, entities.RoleCandidate} {
+		h := auth.HashAPIKey(auth.APIKeyPrefix + string(role))
+		keys[h] = entities.APIKey{Subject: string(role), Roles: []entities.Role{role}, Tenant: entities.DefaultTenant, Hash: h}
+	}
+
+	c := hc.NewController(service.NewService(&repositoryStub{}), logging.Discard())
+
+	r := mux.NewRouter()
+	RegisterRoutes(r, *c, auth.NewAuthenticator(&keyStoreMock{keys: keys}, nil), metrics.New(), nil, nil, nil, config.Default().HTTP)
+
+	return r
+}
+
+func TestRoutePolicy(t *testing.T) {
+	r := newPolicyRouter()
+
+	candidates := []entities.Role{entities.RoleCandidate, entities.RoleViewer, entities.RoleEditor, entities.RoleAdmin}
+	readers := []entities.Role{entities.RoleViewer, entities.RoleEditor, entities.RoleAdmin}
+	editors := []entities.Role{entities.RoleEditor, entities.RoleAdmin}
+	admins := []entities.Role{entities.RoleAdmin}
+
+	testCases := []struct {
+		name        string
+		method      string
+		url         string
+		contentType string
+		body        string
+		allowed     []entities.Role
+	}{{
+		name:    "add question",
+		method:  "POST",
+		url:     "/question",
+		body:    questionJSON,
+		allowed: editors,
+	}, {
+		name:    "update question",
+		method:  "PUT",
+		url:     "/question/1",
+		body:    questionJSON,
+		allowed: editors,
+	}, {
+		name:        "patch question",
+		method:      "PATCH",
+		url:         "/question/1",
+		contentType: "application/merge-patch+json",
+		body:        `{"body": "Where does the sun rise?"}`,
+		allowed:     editors,
+	}, {
+		name:    "add option",
+		method:  "POST",
+		url:     "/question/1/options",
+		body:    `{"body": "North", "correct": false}`,
+		allowed: editors,
+	}, {
+		name:    "update option",
+		method:  "PUT",
+		url:     "/question/1/options/2",
+		body:    `{"body": "West", "correct": true}`,
+		allowed: editors,
+	}, {
+		name:    "remove option",
+		method:  "DELETE",
+		url:     "/question/1/options/2",
+		allowed: editors,
+	}, {
+		name:    "reorder options",
+		method:  "POST",
+		url:     "/question/1/options/reorder",
+		body:    `[2, 1]`,
+		allowed: editors,
+	}, {
+		name:    "submit question",
+		method:  "POST",
+		url:     "/question/1/submit",
+		allowed: editors,
+	}, {
+		name:    "approve question",
+		method:  "POST",
+		url:     "/question/1/approve",
+		body:    `{"comment": "Clear and correct"}`,
+		allowed: admins,
+	}, {
+		name:    "reject question",
+		method:  "POST",
+		url:     "/question/1/reject",
+		allowed: admins,
+	}, {
+		name:    "retire question",
+		method:  "POST",
+		url:     "/question/1/retire",
+		allowed: admins,
+	}, {
+		name:    "delete question",
+		method:  "DELETE",
+		url:     "/question/1",
+		allowed: admins,
+	}, {
+		name:    "list questions",
+		method:  "GET",
+		url:     "/questions",
+		allowed: candidates,
+	}, {
+		name:    "import questions",
+		method:  "POST",
+		url:     "/questions/import?format=jsonl",
+		body:    questionJSON,
+		allowed: admins,
+	}, {
+		name:    "export questions",
+		method:  "GET",
+		url:     "/questions/export",
+		allowed: readers,
+	}, {
+		name:    "find duplicates",
+		method:  "GET",
+		url:     "/questions/duplicates",
+		allowed: readers,
+	}, {
+		name:    "batch create and update",
+		method:  "POST",
+		url:     "/questions/batch",
+		body:    `[{"op": "create", "question": ` + questionJSON + `}, {"op": "update", "id": 1, "question": ` + questionJSON + `}]`,
+		allowed: editors,
+	}, {
+		name:    "batch delete",
+		method:  "POST",
+		url:     "/questions/batch",
+		body:    `[{"op": "delete", "id": 1}]`,
+		allowed: admins,
+	}, {
+		name:    "audit log",
+		method:  "GET",
+		url:     "/audit",
+		allowed: admins,
+	}, {
+		name:    "get log level",
+		method:  "GET",
+		url:     "/log/level",
+		allowed: admins,
+	}, {
+		name:    "set log level",
+		method:  "PUT",
+		url:     "/log/level",
+		body:    `{"level": "info"}`,
+		allowed: admins,
+	}}
+
+	for _, tc := range testCases {
+		for _, role := range []entities.Role{entities.RoleViewer, entities.RoleEditor, entities.RoleAdmin, entities.RoleCandidate} {
+			allowed := false
+			for _, a := range tc.allowed {
+				allowed = allowed || a == role
+			}
+
+			t.Run(tc.name+" as "+string(role), func(t *testing.T) {
+				req := httptest.NewRequest(tc.method, tc.url, strings.NewReader(tc.body))
+				req.Header.Set("X-API-Key", auth.APIKeyPrefix+string(role))
+				if tc.contentType != "" {
+					req.Header.Set("Content-Type", tc.contentType)
+				}
+
+				rr := httptest.NewRecorder()
+				r.ServeHTTP(rr, req)
+
+				if rr.Code == http.StatusUnauthorized {
+					t.Fatalf("expected authenticated request, got status code (%d)", rr.Code)
+				}
+
+				if (rr.Code != http.StatusForbidden) != allowed {
+					t.Errorf("expected allowed (%v), got status code (%d) and body (%s)", allowed, rr.Code, rr.Body.String())
+				}
+			})
+		}
+	}
+}
+
+func TestPublicRoutes(t *testing.T) {
+	r := newPolicyRouter()
+
+	testCases := []struct {
+		name   string
+		url    string
+		status int
+	}{{
+		name:   "documentation",
+		url:    "/docs",
+		status: http.StatusOK,
+	}, {
+		name:   "metrics",
+		url:    "/metrics",
+		status: http.StatusOK,
+	}, {
+		name:   "questions",
+		url:    "/questions",
+		status: http.StatusUnauthorized,
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, httptest.NewRequest("GET", tc.url, nil))
+
+			if rr.Code != tc.status {
+				t.Errorf("expected status code (%d), got status code (%d)", tc.status, rr.Code)
+			}
+
+			if rr.Header().Get(RequestIDHeader) == "" {
+				t.Errorf("expected a request id in the response")
+			}
+		})
+	}
+}
+
+func TestDisabledAuthentication(t *testing.T) {
+	c := hc.NewController(service.NewService(&repositoryStub{}), logging.Discard())
+
+	r := mux.NewRouter()
+	RegisterRoutes(r, *c, nil, nil, nil, nil, nil, config.Default().HTTP)
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest("DELETE", "/question/1", nil))
+
+	if rr.Code != http.StatusOK {
+		t.Errorf("expected status code (%d), got status code (%d)", http.StatusOK, rr.Code)
+	}
+}
+
+func TestHealthRoutes(t *testing.T) {
+	c := hc.NewController(service.NewService(&repositoryStub{}), logging.Discard())
+	h := health.New()
+
+	r := mux.NewRouter()
+	RegisterRoutes(r, *c, auth.NewAuthenticator(&keyStoreMock{keys: map[string]entities.APIKey{}}, nil), nil, nil, h, nil, config.Default().HTTP)
+
+	testCases := []struct {
+		name   string
+		url    string
+		ready  bool
+		status int
+	}{{
+		name:   "live",
+		url:    "/healthz",
+		status: http.StatusOK,
+	}, {
+		name:   "starting",
+		url:    "/readyz",
+		status: http.StatusServiceUnavailable,
+	}, {
+		name:   "ready",
+		url:    "/readyz",
+		ready:  true,
+		status: http.StatusOK,
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.ready {
+				h.SetReady()
+			}
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, httptest.NewRequest("GET", tc.url, nil))
+
+			if rr.Code != tc.status {
+				t.Errorf("expected status code (%d), got status code (%d)", tc.status, rr.Code)
+			}
+		})
+	}
+}
+
+// serverConfig returns the configuration of a test server, with a short drain delay
+func serverConfig() config.Server {
+	c := config.Default().Server
+	c.Address = "127.0.0.1:0"
+	c.DrainDelay = 100 * time.Millisecond
+	c.ShutdownTimeout = 5 * time.Second
+
+	return c
+}
+
+// startServer serves s on a new local listener until ctx is done, the error of Serve is sent on the returned channel
+func startServer(t *testing.T, ctx context.Context, s *Server) (string, <-chan error) {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("unable to listen: %s", err.Error())
+	}
+
+	errs := make(chan error, 1)
+	go func() { errs <- s.Serve(ctx, ln) }()
+
+	return ln.Addr().String(), errs
+}
+
+// waitServer returns the error of Serve, or fails the test if it doesn't return in time
+func waitServer(t *testing.T, errs <-chan error) error {
+	t.Helper()
+
+	select {
+	case err := <-errs:
+		return err
+	case <-time.After(5 * time.Second):
+		t.Fatalf("expected the server to stop")
+	}
+
+	return nil
+}
+
+func TestServerServe(t *testing.T) {
+	h := health.New()
+
+	started, release := make(chan struct{}), make(chan struct{})
+
+	r := mux.NewRouter()
+	r.Handle("/readyz", h.ReadyHandler())
+	r.HandleFunc("/slow", func(rw http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+	})
+	r.HandleFunc("/questions", func(rw http.ResponseWriter, r *http.Request) {})
+
+	c := serverConfig()
+	c.DrainDelay = 300 * time.Millisecond
+	c.RequestTimeout = 5 * time.Second
+	c.WriteTimeout = 10 * time.Second
+
+	s, err := NewServer(r, c, logging.Discard(), h)
+	if err != nil {
+		t.Fatalf("unable to create server: %s", err.Error())
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	addr, errs := startServer(t, ctx, s)
+	url := "http://" + addr
+
+	get := func(path string) (int, error) {
+		res, err := (&http.Client{Transport: &http.Transport{DisableKeepAlives: true}}).Get(url + path)
+		if err != nil {
+			return 0, err
+		}
+		_ = res.Body.Close()
+
+		return res.StatusCode, nil
+	}
+
+	if code, err := get("/readyz"); err != nil || code != http.StatusOK {
+		t.Fatalf("expected the server to be ready, got status code (%d) and error (%v)", code, err)
+	}
+
+	// a request still running when the shutdown starts is completed
+	slow := make(chan int, 1)
+	go func() {
+		code, _ := get("/slow")
+		slow <- code
+	}()
+	<-started
+
+	cancel()
+	time.Sleep(50 * time.Millisecond)
+
+	if code, err := get("/readyz"); err != nil || code != http.StatusServiceUnavailable {
+		t.Errorf("expected the server to be unready while draining, got status code (%d) and error (%v)", code, err)
+	}
+
+	if code, err := get("/questions"); err != nil || code != http.StatusOK {
+		t.Errorf("expected the requests to be handled while draining, got status code (%d) and error (%v)", code, err)
+	}
+
+	time.Sleep(c.DrainDelay)
+	select {
+	case err := <-errs:
+		t.Fatalf("expected the server to wait for the running request, got error (%v)", err)
+	default:
+	}
+
+	close(release)
+
+	if code := <-slow; code != http.StatusOK {
+		t.Errorf("expected the running request to complete, got status code (%d)", code)
+	}
+
+	if err = waitServer(t, errs); err != nil {
+		t.Errorf("expected a graceful shutdown, got error (%v)", err)
+	}
+
+	if _, err = get("/questions"); err == nil {
+		t.Errorf("expected the server to be closed")
+	}
+}
+
+func TestServerBulkTimeout(t *testing.T) {
+	// the handlers answer after the request and write timeouts, only the bulk requests can still be answered
+	r := mux.NewRouter()
+	slow := func(rw http.ResponseWriter, r *http.Request) {
+		time.Sleep(300 * time.Millisecond)
+		_, _ = rw.Write([]byte("done"))
+	}
+	r.HandleFunc("/questions", slow)
+	r.HandleFunc("/questions/export", slow)
+
+	c := serverConfig()
+	c.RequestTimeout = 50 * time.Millisecond
+	c.WriteTimeout = 100 * time.Millisecond
+	c.BulkTimeout = 5 * time.Second
+
+	s, err := NewServer(r, c, logging.Discard(), nil)
+	if err != nil {
+		t.Fatalf("unable to create server: %s", err.Error())
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	addr, errs := startServer(t, ctx, s)
+
+	get := func(path string) (string, error) {
+		res, err := http.Get("http://" + addr + path)
+		if err != nil {
+			return "", err
+		}
+		defer res.Body.Close()
+
+		body, err := ioutil.ReadAll(res.Body)
+
+		return string(body), err
+	}
+
+	if body, err := get("/questions/export"); err != nil || body != "done" {
+		t.Errorf("expected the bulk request to complete, got body (%s) and error (%v)", body, err)
+	}
+
+	if body, err := get("/questions"); err == nil && body == "done" {
+		t.Errorf("expected the request to be cut by the write timeout")
+	}
+
+	cancel()
+	if err = waitServer(t, errs); err != nil {
+		t.Errorf("expected a graceful shutdown, got error (%v)", err)
+	}
+}
+
+func TestBulkPaths(t *testing.T) {
+	r := newPolicyRouter()
+
+	for _, p := range BulkPaths {
+		var match mux.RouteMatch
+		if !r.Match(httptest.NewRequest("GET", p, nil), &match) && !r.Match(httptest.NewRequest("POST", p, nil), &match) {
+			t.Errorf("expected a route for the bulk path (%s)", p)
+		}
+	}
+}
+
+func TestServerShutdown(t *testing.T) {
+	h := health.New()
+
+	s, err := NewServer(http.NotFoundHandler(), serverConfig(), logging.Discard(), h)
+	if err != nil {
+		t.Fatalf("unable to create server: %s", err.Error())
+	}
+
+	_, errs := startServer(t, context.Background(), s)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	if err = s.Shutdown(ctx); err != nil {
+		t.Fatalf("unable to shut down server: %s", err.Error())
+	}
+
+	if err = waitServer(t, errs); err != nil {
+		t.Errorf("expected no error, got error (%v)", err)
+	}
+
+	if status := h.Ready(ctx).Status; status != health.StatusDraining {
+		t.Errorf("expected status (%s), got status (%s)", health.StatusDraining, status)
+	}
+}
+
+func TestServerSignal(t *testing.T) {
+	s, err := NewServer(http.NotFoundHandler(), serverConfig(), logging.Discard(), nil)
+	if err != nil {
+		t.Fatalf("unable to create server: %s", err.Error())
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), ShutdownSignals...)
+	defer stop()
+
+	_, errs := startServer(t, ctx, s)
+
+	p, _ := os.FindProcess(os.Getpid())
+	if err = p.Signal(syscall.SIGTERM); err != nil {
+		t.Skipf("unable to send SIGTERM: %s", err.Error())
+	}
+
+	if err = waitServer(t, errs); err != nil {
+		t.Errorf("expected a graceful shutdown on SIGTERM, got error (%v)", err)
+	}
+}
+
+func TestServerRunError(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("unable to listen: %s", err.Error())
+	}
+	defer ln.Close()
+
+	c := serverConfig()
+	c.Address = ln.Addr().String()
+
+	s, err := NewServer(http.NotFoundHandler(), c, logging.Discard(), nil)
+	if err != nil {
+		t.Fatalf("unable to create server: %s", err.Error())
+	}
+
+	if err = s.Run(context.Background()); err == nil {
+		t.Errorf("expected an error listening on an address in use")
+	}
+
+	c.TLS = config.TLS{CertFile: "missing.pem", KeyFile: "missing.pem", ClientAuth: "none", MinVersion: "1.2"}
+	if _, err = NewServer(http.NotFoundHandler(), c, logging.Discard(), nil); err == nil {
+		t.Errorf("expected an error loading missing certificates")
+	}
+}
+
+func TestServerTLS(t *testing.T) {
+	certFile, keyFile, cert := writeTestCertificate(t, t.TempDir())
+
+	c := serverConfig()
+	c.TLS = config.TLS{CertFile: certFile, KeyFile: keyFile, ClientAuth: "none", MinVersion: "1.2"}
+
+	s, err := NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if r.TLS == nil {
+			t.Errorf("expected a TLS request")
+		}
+	}), c, logging.Discard(), nil)
+	if err != nil {
+		t.Fatalf("unable to create server: %s", err.Error())
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	addr, errs := startServer(t, ctx, s)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(cert)
+
+	if _, _, err = tlsGet("https://"+addr, &tls.Config{RootCAs: roots}); err != nil {
+		t.Errorf("unable to make https request: %s", err.Error())
+	}
+
+	cancel()
+	if err = waitServer(t, errs); err != nil {
+		t.Errorf("expected a graceful shutdown, got error (%v)", err)
+	}
+}
@@ -0,0 +1,61 @@
+package http
+
+import (
+	"context"
+	"github.com/gorilla/mux"
+	"net/http"
+	"time"
+)
+
+// Timeout returns a middleware that sets a deadline of d on the context of every request
+// The deadline cancels the SQL statements still running for the request, which then fails with a 503 response.
+func Timeout(d time.Duration) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			ctx, cancel := context.WithTimeout(r.Context(), d)
+			defer cancel()
+
+			next.ServeHTTP(rw, r.WithContext(ctx))
+		})
+	}
+}
+
+// BulkTimeout returns a middleware that sets a deadline of d on the context of the requests to the given paths, the other
+// requests are passed to the timeout middleware t
+// The bulk requests stream large bodies or go through the whole bank, so the read and write deadlines of their connection
+// are also moved to grace after their own deadline, the server ones would cut them.
+func BulkTimeout(d, grace time.Duration, t mux.MiddlewareFunc, paths ...string) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		short := t(next)
+
+		return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			if !isBulkPath(r.URL.Path, paths) {
+				short.ServeHTTP(rw, r)
+				return
+			}
+
+			deadline := time.Now().Add(d)
+
+			// the recorders of the tests don't support deadlines, the server connections do
+			rc := http.NewResponseController(rw)
+			_ = rc.SetReadDeadline(deadline.Add(grace))
+			_ = rc.SetWriteDeadline(deadline.Add(grace))
+
+			ctx, cancel := context.WithDeadline(r.Context(), deadline)
+			defer cancel()
+
+			next.ServeHTTP(rw, r.WithContext(ctx))
+		})
+	}
+}
+
+// isBulkPath reports whether p is one of the bulk paths
+func isBulkPath(p string, paths []string) bool {
+	for _, bp := range paths {
+		if p == bp {
+			return true
+		}
+	}
+
+	return false
+}
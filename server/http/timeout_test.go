@@ -0,0 +1,69 @@
+package http
+
+import (
+	"context"
+	"github.com/gorilla/mux"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestTimeout(t *testing.T) {
+	r := mux.NewRouter()
+	r.Use(Timeout(10 * time.Millisecond))
+	r.HandleFunc("/questions", func(rw http.ResponseWriter, r *http.Request) {
+		if _, ok := r.Context().Deadline(); !ok {
+			t.Errorf("expected a deadline on the request context")
+		}
+
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+			t.Errorf("expected the request context to be done after the timeout")
+		}
+
+		if r.Context().Err() != context.DeadlineExceeded {
+			t.Errorf("expected error (%v), got error (%v)", context.DeadlineExceeded, r.Context().Err())
+		}
+	}).Methods("GET")
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/questions", nil))
+}
+
+func TestBulkTimeout(t *testing.T) {
+	testCases := []struct {
+		name string
+		path string
+		min  time.Duration
+		max  time.Duration
+	}{{
+		name: "request",
+		path: "/questions",
+		max:  10 * time.Millisecond,
+	}, {
+		name: "bulk",
+		path: "/questions/export",
+		min:  time.Minute,
+		max:  time.Hour,
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := mux.NewRouter()
+			r.Use(BulkTimeout(time.Hour, time.Second, Timeout(10*time.Millisecond), "/questions/export"))
+			r.HandleFunc(tc.path, func(rw http.ResponseWriter, r *http.Request) {
+				deadline, ok := r.Context().Deadline()
+				if !ok {
+					t.Fatalf("expected a deadline on the request context")
+				}
+
+				if left := time.Until(deadline); left < tc.min || left > tc.max {
+					t.Errorf("expected a deadline between (%s) and (%s), got (%s)", tc.min, tc.max, left)
+				}
+			}).Methods("GET")
+
+			r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", tc.path, nil))
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package http
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"github.com/norby7/questions-rest-api/config"
+	"os"
+	"sync/atomic"
+)
+
+// Certificates holds the TLS configuration of the server, built from the certificate, key and client CA files
+// Reload reads the files again: the new handshakes use the new certificates, the open connections keep theirs.
+type Certificates struct {
+	c       config.TLS
+	current atomic.Pointer[tls.Config]
+}
+
+// NewCertificates loads the files of c
+func NewCertificates(c config.TLS) (*Certificates, error) {
+	certs := &Certificates{c: c}
+	if err := certs.Reload(); err != nil {
+		return nil, err
+	}
+
+	return certs, nil
+}
+
+// Reload reads the files again, the previous certificates are kept if one of them is invalid
+func (c *Certificates) Reload() error {
+	version, err := c.c.Version()
+	if err != nil {
+		return err
+	}
+
+	ciphers, err := c.c.Ciphers()
+	if err != nil {
+		return err
+	}
+
+	clientAuth, err := c.c.ClientAuthType()
+	if err != nil {
+		return err
+	}
+
+	cert, err := tls.LoadX509KeyPair(c.c.CertFile, c.c.KeyFile)
+	if err != nil {
+		return fmt.Errorf("unable to load certificate: %s", err.Error())
+	}
+
+	conf := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   version,
+		CipherSuites: ciphers,
+		ClientAuth:   clientAuth,
+		NextProtos:   []string{"h2", "http/1.1"},
+	}
+
+	if c.c.ClientCAFile != "" {
+		pem, err := os.ReadFile(c.c.ClientCAFile)
+		if err != nil {
+			return fmt.Errorf("unable to read client CA bundle: %s", err.Error())
+		}
+
+		conf.ClientCAs = x509.NewCertPool()
+		if !conf.ClientCAs.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("unable to read client CA bundle: no certificate found in %s", c.c.ClientCAFile)
+		}
+	}
+
+	c.current.Store(conf)
+	return nil
+}
+
+// TLSConfig returns the configuration of the server, every handshake uses the certificates loaded last
+func (c *Certificates) TLSConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: c.current.Load().MinVersion,
+		NextProtos: []string{"h2", "http/1.1"},
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			return &c.current.Load().Certificates[0], nil
+		},
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			return c.current.Load(), nil
+		},
+	}
+}
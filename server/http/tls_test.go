@@ -0,0 +1,344 @@
+package http
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"github.com/norby7/questions-rest-api/config"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writePEM writes the PEM block of der into a file of dir and returns its path
+func writePEM(t *testing.T, dir, name, blockType string, der []byte) string {
+	t.Helper()
+
+	p := filepath.Join(dir, name)
+	if err := os.WriteFile(p, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0600); err != nil {
+		t.Fatalf("unable to write %s: %s", name, err.Error())
+	}
+
+	return p
+}
+
+// writeTestCertificate writes the certificate and key of the httptest servers into dir, for 127.0.0.1 and example.com
+func writeTestCertificate(t *testing.T, dir string) (string, string, *x509.Certificate) {
+	t.Helper()
+
+	s := httptest.NewUnstartedServer(http.NotFoundHandler())
+	s.StartTLS()
+	s.Close()
+
+	cert := s.TLS.Certificates[0]
+	key, err := x509.MarshalPKCS8PrivateKey(cert.PrivateKey)
+	if err != nil {
+		t.Fatalf("unable to encode key: %s", err.Error())
+	}
+
+	return writePEM(t, dir, "cert.pem", "CERTIFICATE", cert.Certificate[0]), writePEM(t, dir, "key.pem", "PRIVATE KEY", key), s.Certificate()
+}
+
+// newCertificate returns a certificate named cn signed by parent, or self-signed when parent is nil
+func newCertificate(t *testing.T, cn string, parent *tls.Certificate, isCA bool, usage x509.ExtKeyUsage) tls.Certificate {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("unable to generate key: %s", err.Error())
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: cn},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{usage},
+		BasicConstraintsValid: true,
+		IsCA:                  isCA,
+		IPAddresses:           []net.IP{net.IPv4(127, 0, 0, 1)},
+	}
+
+	signer, signerKey := template, interface{}(key)
+	if parent != nil {
+		signer, signerKey = parent.Leaf, parent.PrivateKey
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatalf("unable to create certificate: %s", err.Error())
+	}
+
+	leaf, _ := x509.ParseCertificate(der)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}
+}
+
+// serveTLS serves the name of the client certificate, if any, over TLS with the configuration of certs
+func serveTLS(t *testing.T, certs *Certificates) string {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("unable to listen: %s", err.Error())
+	}
+
+	s := &http.Server{Handler: http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if len(r.TLS.PeerCertificates) > 0 {
+			_, _ = rw.Write([]byte(r.TLS.PeerCertificates[0].Subject.CommonName))
+		}
+	}), TLSConfig: certs.TLSConfig()}
+
+	go func() { _ = s.ServeTLS(ln, "", "") }()
+	t.Cleanup(func() { _ = s.Close() })
+
+	return "https://" + ln.Addr().String()
+}
+
+// tlsGet makes a request on a new connection and returns the server certificate and the response body
+func tlsGet(url string, conf *tls.Config) (*x509.Certificate, string, error) {
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: conf, DisableKeepAlives: true}, Timeout: 5 * time.Second}
+
+	res, err := client.Get(url)
+	if err != nil {
+		return nil, "", err
+	}
+	defer res.Body.Close()
+
+	body := make([]byte, 64)
+	n, _ := res.Body.Read(body)
+
+	return res.TLS.PeerCertificates[0], string(body[:n]), nil
+}
+
+func TestTLS(t *testing.T) {
+	certFile, keyFile, cert := writeTestCertificate(t, t.TempDir())
+
+	roots := x509.NewCertPool()
+	roots.AddCert(cert)
+
+	testCases := []struct {
+		name          string
+		minVersion    string
+		ciphers       string
+		clientVersion uint16
+		clientCiphers []uint16
+		isError       bool
+	}{{
+		name:          "tls 1.3",
+		minVersion:    "1.2",
+		clientVersion: tls.VersionTLS13,
+	}, {
+		name:          "tls 1.2",
+		minVersion:    "1.2",
+		clientVersion: tls.VersionTLS12,
+	}, {
+		name:          "tls 1.1",
+		minVersion:    "1.2",
+		clientVersion: tls.VersionTLS11,
+		isError:       true,
+	}, {
+		name:          "tls 1.2 below the minimum version",
+		minVersion:    "1.3",
+		clientVersion: tls.VersionTLS12,
+		isError:       true,
+	}, {
+		name:          "configured cipher suite",
+		minVersion:    "1.2",
+		ciphers:       "TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384",
+		clientVersion: tls.VersionTLS12,
+		clientCiphers: []uint16{tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384},
+	}, {
+		name:          "other cipher suite",
+		minVersion:    "1.2",
+		ciphers:       "TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384",
+		clientVersion: tls.VersionTLS12,
+		clientCiphers: []uint16{tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256},
+		isError:       true,
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			certs, err := NewCertificates(config.TLS{CertFile: certFile, KeyFile: keyFile, ClientAuth: "none", MinVersion: tc.minVersion, CipherSuites: tc.ciphers})
+			if err != nil {
+				t.Fatalf("unable to load certificates: %s", err.Error())
+			}
+
+			url := serveTLS(t, certs)
+
+			_, _, err = tlsGet(url, &tls.Config{RootCAs: roots, MinVersion: tls.VersionTLS10, MaxVersion: tc.clientVersion, CipherSuites: tc.clientCiphers})
+			if (err != nil) != tc.isError {
+				t.Errorf("expected error (%v), got error (%v)", tc.isError, err)
+			}
+		})
+	}
+}
+
+func TestTLSReload(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile, cert := writeTestCertificate(t, dir)
+
+	certs, err := NewCertificates(config.TLS{CertFile: certFile, KeyFile: keyFile, ClientAuth: "none", MinVersion: "1.2"})
+	if err != nil {
+		t.Fatalf("unable to load certificates: %s", err.Error())
+	}
+
+	url := serveTLS(t, certs)
+
+	renewed := newCertificate(t, "renewed", nil, true, x509.ExtKeyUsageServerAuth)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(cert)
+	roots.AddCert(renewed.Leaf)
+	conf := &tls.Config{RootCAs: roots}
+
+	// an invalid certificate keeps the previous one
+	writePEM(t, dir, "cert.pem", "CERTIFICATE", []byte("invalid"))
+	if err = certs.Reload(); err == nil {
+		t.Errorf("expected an error reloading an invalid certificate")
+	}
+
+	peer, _, err := tlsGet(url, conf)
+	if err != nil {
+		t.Fatalf("unable to make request: %s", err.Error())
+	}
+
+	if !peer.Equal(cert) {
+		t.Errorf("expected the previous certificate, got certificate (%s)", peer.Subject)
+	}
+
+	key, _ := x509.MarshalPKCS8PrivateKey(renewed.PrivateKey)
+	writePEM(t, dir, "cert.pem", "CERTIFICATE", renewed.Certificate[0])
+	writePEM(t, dir, "key.pem", "PRIVATE KEY", key)
+
+	if err = certs.Reload(); err != nil {
+		t.Fatalf("unable to reload certificates: %s", err.Error())
+	}
+
+	peer, _, err = tlsGet(url, conf)
+	if err != nil {
+		t.Fatalf("unable to make request: %s", err.Error())
+	}
+
+	if peer.Subject.CommonName != "renewed" {
+		t.Errorf("expected the renewed certificate, got certificate (%s)", peer.Subject)
+	}
+}
+
+func TestMutualTLS(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile, cert := writeTestCertificate(t, dir)
+
+	ca := newCertificate(t, "services CA", nil, true, x509.ExtKeyUsageClientAuth)
+	caFile := writePEM(t, dir, "ca.pem", "CERTIFICATE", ca.Certificate[0])
+
+	service := newCertificate(t, "grading-service", &ca, false, x509.ExtKeyUsageClientAuth)
+	unknown := newCertificate(t, "unknown-service", nil, false, x509.ExtKeyUsageClientAuth)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(cert)
+
+	testCases := []struct {
+		name       string
+		clientAuth string
+		client     *tls.Certificate
+		expected   string
+		isError    bool
+	}{{
+		name:       "required client certificate",
+		clientAuth: "require",
+		client:     &service,
+		expected:   "grading-service",
+	}, {
+		name:       "missing required client certificate",
+		clientAuth: "require",
+		isError:    true,
+	}, {
+		name:       "unknown client certificate",
+		clientAuth: "require",
+		client:     &unknown,
+		isError:    true,
+	}, {
+		name:       "optional client certificate",
+		clientAuth: "optional",
+		client:     &service,
+		expected:   "grading-service",
+	}, {
+		name:       "missing optional client certificate",
+		clientAuth: "optional",
+	}, {
+		name:       "unknown optional client certificate",
+		clientAuth: "optional",
+		client:     &unknown,
+		isError:    true,
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			certs, err := NewCertificates(config.TLS{CertFile: certFile, KeyFile: keyFile, ClientCAFile: caFile, ClientAuth: tc.clientAuth, MinVersion: "1.2"})
+			if err != nil {
+				t.Fatalf("unable to load certificates: %s", err.Error())
+			}
+
+			conf := &tls.Config{RootCAs: roots}
+			if tc.client != nil {
+				// the certificate is sent even when it isn't signed by one of the CAs the server asks for
+				conf.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+					return tc.client, nil
+				}
+			}
+
+			_, body, err := tlsGet(serveTLS(t, certs), conf)
+			if (err != nil) != tc.isError {
+				t.Fatalf("expected error (%v), got error (%v)", tc.isError, err)
+			}
+
+			if body != tc.expected {
+				t.Errorf("expected client (%s), got client (%s)", tc.expected, body)
+			}
+		})
+	}
+}
+
+func TestCertificatesErrors(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile, _ := writeTestCertificate(t, dir)
+	invalidCA := writePEM(t, dir, "invalid-ca.pem", "PRIVATE KEY", []byte("invalid"))
+
+	testCases := []struct {
+		name string
+		conf config.TLS
+	}{{
+		name: "missing certificate",
+		conf: config.TLS{CertFile: filepath.Join(dir, "missing.pem"), KeyFile: keyFile, ClientAuth: "none", MinVersion: "1.2"},
+	}, {
+		name: "key of another certificate",
+		conf: config.TLS{CertFile: certFile, KeyFile: writePEM(t, dir, "other-key.pem", "PRIVATE KEY", []byte("invalid")), ClientAuth: "none", MinVersion: "1.2"},
+	}, {
+		name: "missing client CA bundle",
+		conf: config.TLS{CertFile: certFile, KeyFile: keyFile, ClientCAFile: filepath.Join(dir, "missing.pem"), ClientAuth: "require", MinVersion: "1.2"},
+	}, {
+		name: "client CA bundle without certificate",
+		conf: config.TLS{CertFile: certFile, KeyFile: keyFile, ClientCAFile: invalidCA, ClientAuth: "require", MinVersion: "1.2"},
+	}, {
+		name: "unsupported version",
+		conf: config.TLS{CertFile: certFile, KeyFile: keyFile, ClientAuth: "none", MinVersion: "1.1"},
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := NewCertificates(tc.conf); err == nil {
+				t.Errorf("expected an error")
+			}
+		})
+	}
+}
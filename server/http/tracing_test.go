@@ -0,0 +1,194 @@
+package http
+
+import (
+	"context"
+	"github.com/gorilla/mux"
+	"github.com/norby7/questions-rest-api/config"
+	"github.com/norby7/questions-rest-api/entities"
+	hc "github.com/norby7/questions-rest-api/interfaceAdapters/http"
+	"github.com/norby7/questions-rest-api/logging"
+	"github.com/norby7/questions-rest-api/tracing"
+	"github.com/norby7/questions-rest-api/usecases/repository"
+	"github.com/norby7/questions-rest-api/usecases/service"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newTracedRepository returns a repository on a new sqlite database, whose SQL statements are traced by tp
+func newTracedRepository(t *testing.T, tp trace.TracerProvider) *repository.SqliteRepository {
+	db, err := tracing.OpenDB(tp)("sqlite3", filepath.Join(t.TempDir(), "questions.db"))
+	if err != nil {
+		t.Fatalf("unable to open database: %s", err.Error())
+	}
+	t.Cleanup(func() { _ = db.Close() })
+
+	schema, err := ioutil.ReadFile("../../database/schema.sql")
+	if err != nil {
+		t.Fatalf("unable to read schema: %s", err.Error())
+	}
+
+	if _, err = db.Exec(string(schema)); err != nil {
+		t.Fatalf("unable to create schema: %s", err.Error())
+	}
+
+	dir := repository.MigrationsDir
+	repository.MigrationsDir = "../../database/migrations"
+	defer func() { repository.MigrationsDir = dir }()
+
+	if err = repository.ValidateSchema(db); err != nil {
+		t.Fatalf("unable to migrate schema: %s", err.Error())
+	}
+
+	return &repository.SqliteRepository{Handler: db}
+}
+
+func TestTracing(t *testing.T) {
+	sr := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))
+
+	repo := newTracedRepository(t, tp)
+
+	ctx := repository.NewTenantContext(context.Background(), entities.DefaultTenant)
+	for _, body := range []string{"Where does the sun set?", "Where does the sun rise?"} {
+		q := entities.Question{Body: body, Options: []entities.Option{{Body: "East"}, {Body: "West", Correct: true}}}
+		if _, err := repo.Add(ctx, q); err != nil {
+			t.Fatalf("unable to add question: %s", err.Error())
+		}
+	}
+
+	if n := len(sr.Ended()); n != 0 {
+		t.Fatalf("expected no spans outside of a traced request, got (%d) spans", n)
+	}
+
+	c := hc.NewController(tracing.NewInteractor(service.NewService(tracing.NewRepository(repo, tp)), tp), logging.Discard())
+
+	r := mux.NewRouter()
+	RegisterRoutes(r, *c, nil, nil, tp, nil, nil, config.Default().HTTP)
+
+	parent := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+	req := httptest.NewRequest("GET", "/questions?size=10", nil)
+	req.Header.Set("traceparent", parent)
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status code (%d), got status code (%d)", http.StatusOK, rr.Code)
+	}
+
+	spans := sr.Ended()
+
+	byId := map[trace.SpanID]sdktrace.ReadOnlySpan{}
+	byName := map[string]sdktrace.ReadOnlySpan{}
+	var statements []sdktrace.ReadOnlySpan
+	for _, s := range spans {
+		if s.SpanContext().TraceID().String() != "4bf92f3577b34da6a3ce929d0e0e4736" {
+			t.Errorf("expected every span in the trace of the traceparent header, got span (%s) in trace (%s)", s.Name(), s.SpanContext().TraceID())
+		}
+
+		byId[s.SpanContext().SpanID()] = s
+		byName[s.Name()] = s
+		if strings.HasPrefix(s.Name(), "sql.") {
+			statements = append(statements, s)
+		}
+	}
+
+	// the spans are chained from the http request to the SQL statements
+	chain := []string{"GET /questions", "service.ListAll", "repository.get_all"}
+	for i, name := range chain {
+		s, ok := byName[name]
+		if !ok {
+			t.Fatalf("expected a (%s) span, got spans (%v)", name, spanNames(spans))
+		}
+
+		expected := "00f067aa0ba902b7"
+		if i > 0 {
+			expected = byName[chain[i-1]].SpanContext().SpanID().String()
+		}
+
+		if s.Parent().SpanID().String() != expected {
+			t.Errorf("expected span (%s) to be a child of (%s), got parent (%s)", name, expected, s.Parent().SpanID())
+		}
+	}
+
+	// a query for the questions, and one for the options of each question
+	if len(statements) < 3 {
+		t.Fatalf("expected a span for every SQL statement, got spans (%v)", spanNames(spans))
+	}
+
+	for _, s := range statements {
+		p, ok := byId[s.Parent().SpanID()]
+		if !ok || p.Name() != "repository.get_all" {
+			t.Errorf("expected statement span (%s) to be a child of the repository span", s.Name())
+		}
+	}
+
+	attrs := map[string]string{}
+	for _, kv := range byName["GET /questions"].Attributes() {
+		attrs[string(kv.Key)] = kv.Value.Emit()
+	}
+
+	expected := map[string]string{"http.request.method": "GET", "http.route": "/questions", "url.path": "/questions", "http.response.status_code": "200"}
+	for k, v := range expected {
+		if attrs[k] != v {
+			t.Errorf("expected attribute (%s) to be (%s), got attributes (%v)", k, v, attrs)
+		}
+	}
+
+	if kind := byName["GET /questions"].SpanKind(); kind != trace.SpanKindServer {
+		t.Errorf("expected a server span, got span kind (%s)", kind)
+	}
+}
+
+func TestTracingServerError(t *testing.T) {
+	sr := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))
+
+	r := mux.NewRouter()
+	r.Use(Tracing(tp))
+	r.HandleFunc("/question/{id:[0-9]+}", func(rw http.ResponseWriter, r *http.Request) {
+		if !trace.SpanContextFromContext(r.Context()).IsValid() {
+			t.Errorf("expected the span in the request context")
+		}
+
+		http.Error(rw, "unable to delete question", http.StatusInternalServerError)
+	}).Methods("DELETE")
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("DELETE", "/question/1", nil))
+
+	spans := sr.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("expected a single span, got spans (%v)", spanNames(spans))
+	}
+
+	if spans[0].Name() != "DELETE /question/{id:[0-9]+}" {
+		t.Errorf("expected the span to be named after the route, got span (%s)", spans[0].Name())
+	}
+
+	if spans[0].Parent().IsValid() {
+		t.Errorf("expected a root span without traceparent header")
+	}
+
+	if spans[0].Status().Code != codes.Error {
+		t.Errorf("expected status (%v), got status (%v)", codes.Error, spans[0].Status().Code)
+	}
+}
+
+// spanNames returns the names of the spans
+func spanNames(spans []sdktrace.ReadOnlySpan) []string {
+	names := make([]string, len(spans))
+	for i, s := range spans {
+		names[i] = s.Name()
+	}
+
+	return names
+}
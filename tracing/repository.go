@@ -0,0 +1,141 @@
+package tracing
+
+import (
+	"context"
+	"github.com/norby7/questions-rest-api/entities"
+	"github.com/norby7/questions-rest-api/usecases/repository"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Repository decorates a question repository, creating a span for every operation
+// The SQL statements of an operation are children of its span, when the database is opened with OpenDB.
+type Repository struct {
+	Next   repository.Repository
+	tracer trace.Tracer
+}
+
+// NewRepository returns a repository tracing the operations of next
+func NewRepository(next repository.Repository, tp trace.TracerProvider) *Repository {
+	return &Repository{Next: next, tracer: Tracer(tp)}
+}
+
+// startQuery starts the span of a repository operation
+func startQuery(ctx context.Context, tracer trace.Tracer, operation string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	attrs = append([]attribute.KeyValue{semconv.DBSystemSqlite, semconv.DBOperation(operation)}, attrs...)
+
+	return tracer.Start(ctx, "repository."+operation, trace.WithAttributes(attrs...))
+}
+
+func (r *Repository) Add(ctx context.Context, q entities.Question) (int64, error) {
+	ctx, span := startQuery(ctx, r.tracer, "add")
+	id, err := r.Next.Add(ctx, q)
+	span.SetAttributes(attribute.Int64("question.id", id))
+	end(span, err)
+
+	return id, err
+}
+
+func (r *Repository) AddAll(ctx context.Context, ql []entities.Question) ([]int64, error) {
+	ctx, span := startQuery(ctx, r.tracer, "add_all", attribute.Int("questions.count", len(ql)))
+	ids, err := r.Next.AddAll(ctx, ql)
+	end(span, err)
+
+	return ids, err
+}
+
+func (r *Repository) Update(ctx context.Context, q entities.Question) error {
+	ctx, span := startQuery(ctx, r.tracer, "update", attribute.Int64("question.id", q.Id))
+	err := r.Next.Update(ctx, q)
+	end(span, err)
+
+	return err
+}
+
+func (r *Repository) Delete(ctx context.Context, id int64) error {
+	ctx, span := startQuery(ctx, r.tracer, "delete", attribute.Int64("question.id", id))
+	err := r.Next.Delete(ctx, id)
+	end(span, err)
+
+	return err
+}
+
+func (r *Repository) Get(ctx context.Context, id int64) (entities.Question, error) {
+	ctx, span := startQuery(ctx, r.tracer, "get", attribute.Int64("question.id", id))
+	q, err := r.Next.Get(ctx, id)
+	end(span, err)
+
+	return q, err
+}
+
+func (r *Repository) GetAll(ctx context.Context, f entities.QuestionFilter) ([]entities.Question, error) {
+	ctx, span := startQuery(ctx, r.tracer, "get_all", sortAttributes(f)...)
+	ql, err := r.Next.GetAll(ctx, f)
+	span.SetAttributes(attribute.Int("questions.count", len(ql)))
+	end(span, err)
+
+	return ql, err
+}
+
+// ForEach traces the iteration over all the questions, including the time spent in fn
+func (r *Repository) ForEach(ctx context.Context, fn func(entities.Question) error) error {
+	ctx, span := startQuery(ctx, r.tracer, "for_each")
+	err := r.Next.ForEach(ctx, fn)
+	end(span, err)
+
+	return err
+}
+
+func (r *Repository) Batch(ctx context.Context, ops []repository.Operation) ([]int64, error) {
+	ctx, span := startQuery(ctx, r.tracer, "batch", attribute.Int("operations.count", len(ops)))
+	ids, err := r.Next.Batch(ctx, ops)
+	end(span, err)
+
+	return ids, err
+}
+
+func (r *Repository) SetStatus(ctx context.Context, id int64, from, to entities.QuestionStatus, comment string) error {
+	ctx, span := startQuery(ctx, r.tracer, "set_status", attribute.Int64("question.id", id), attribute.String("question.status", string(to)))
+	err := r.Next.SetStatus(ctx, id, from, to, comment)
+	end(span, err)
+
+	return err
+}
+
+// InTransaction creates a span for the whole transaction, the spans of the operations of fn are its children
+func (r *Repository) InTransaction(ctx context.Context, fn func(context.Context) error) error {
+	ctx, span := startQuery(ctx, r.tracer, "transaction")
+	err := r.Next.InTransaction(ctx, fn)
+	end(span, err)
+
+	return err
+}
+
+// AuditRepository decorates an audit repository, creating a span for every operation
+type AuditRepository struct {
+	Next   repository.AuditRepository
+	tracer trace.Tracer
+}
+
+// NewAuditRepository returns an audit repository tracing the operations of next
+func NewAuditRepository(next repository.AuditRepository, tp trace.TracerProvider) *AuditRepository {
+	return &AuditRepository{Next: next, tracer: Tracer(tp)}
+}
+
+func (r *AuditRepository) AppendAudit(ctx context.Context, e entities.AuditEntry) error {
+	ctx, span := startQuery(ctx, r.tracer, "append_audit", attribute.String("audit.action", string(e.Action)))
+	err := r.Next.AppendAudit(ctx, e)
+	end(span, err)
+
+	return err
+}
+
+func (r *AuditRepository) ListAudit(ctx context.Context, f entities.AuditFilter) ([]entities.AuditEntry, error) {
+	ctx, span := startQuery(ctx, r.tracer, "list_audit")
+	el, err := r.Next.ListAudit(ctx, f)
+	span.SetAttributes(attribute.Int("audit.count", len(el)))
+	end(span, err)
+
+	return el, err
+}
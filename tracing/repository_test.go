@@ -0,0 +1,69 @@
+package tracing
+
+import (
+	"context"
+	"github.com/norby7/questions-rest-api/entities"
+	"github.com/norby7/questions-rest-api/usecases/repository"
+	"go.opentelemetry.io/otel/codes"
+	"testing"
+)
+
+// repositoryStub is a question bank holding a single question, with id 1
+type repositoryStub struct {
+	repository.Repository
+}
+
+func (r *repositoryStub) Get(_ context.Context, id int64) (entities.Question, error) {
+	if id != 1 {
+		return entities.Question{}, repository.QuestionNotFoundError
+	}
+
+	return entities.Question{Id: 1, Body: "Where does the sun set?"}, nil
+}
+
+func TestRepository(t *testing.T) {
+	testCases := []struct {
+		name   string
+		id     int64
+		status codes.Code
+	}{{
+		name:   "found",
+		id:     1,
+		status: codes.Unset,
+	}, {
+		name:   "not found",
+		id:     2,
+		status: codes.Error,
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tp, sr := newRecorder()
+			r := NewRepository(&repositoryStub{}, tp)
+
+			_, _ = r.Get(context.Background(), tc.id)
+
+			spans := sr.Ended()
+			if len(spans) != 1 {
+				t.Fatalf("expected a single span, got (%d) spans", len(spans))
+			}
+
+			if spans[0].Name() != "repository.get" {
+				t.Errorf("expected span (repository.get), got span (%s)", spans[0].Name())
+			}
+
+			if spans[0].Status().Code != tc.status {
+				t.Errorf("expected status (%v), got status (%v)", tc.status, spans[0].Status().Code)
+			}
+
+			attrs := map[string]interface{}{}
+			for _, kv := range spans[0].Attributes() {
+				attrs[string(kv.Key)] = kv.Value.AsInterface()
+			}
+
+			if attrs["db.system"] != "sqlite" || attrs["db.operation"] != "get" || attrs["question.id"] != tc.id {
+				t.Errorf("expected the database system, operation and question id, got attributes (%v)", attrs)
+			}
+		})
+	}
+}
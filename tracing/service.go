@@ -0,0 +1,162 @@
+package tracing
+
+import (
+	"context"
+	"github.com/norby7/questions-rest-api/entities"
+	"github.com/norby7/questions-rest-api/usecases/service"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Interactor decorates the question service, creating a span for every use case
+type Interactor struct {
+	Next   service.Interactor
+	tracer trace.Tracer
+}
+
+// NewInteractor returns a service tracing the use cases of next
+func NewInteractor(next service.Interactor, tp trace.TracerProvider) *Interactor {
+	return &Interactor{Next: next, tracer: Tracer(tp)}
+}
+
+// start starts the span of a use case
+func (i *Interactor) start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return i.tracer.Start(ctx, "service."+name, trace.WithAttributes(attrs...))
+}
+
+func (i *Interactor) Create(ctx context.Context, q entities.Question) (entities.Question, []service.Duplicate, error) {
+	ctx, span := i.start(ctx, "Create", attribute.Int("question.options", len(q.Options)))
+	q, dl, err := i.Next.Create(ctx, q)
+	span.SetAttributes(attribute.Int64("question.id", q.Id), attribute.Int("duplicates.count", len(dl)))
+	end(span, err)
+
+	return q, dl, err
+}
+
+func (i *Interactor) Update(ctx context.Context, q entities.Question) (entities.Question, error) {
+	ctx, span := i.start(ctx, "Update", attribute.Int64("question.id", q.Id), attribute.Int("question.options", len(q.Options)))
+	q, err := i.Next.Update(ctx, q)
+	end(span, err)
+
+	return q, err
+}
+
+func (i *Interactor) Patch(ctx context.Context, id int64, t service.PatchType, patch []byte) (entities.Question, error) {
+	ctx, span := i.start(ctx, "Patch", attribute.Int64("question.id", id), attribute.String("patch.type", string(t)))
+	q, err := i.Next.Patch(ctx, id, t, patch)
+	end(span, err)
+
+	return q, err
+}
+
+func (i *Interactor) Remove(ctx context.Context, id int64) error {
+	ctx, span := i.start(ctx, "Remove", attribute.Int64("question.id", id))
+	err := i.Next.Remove(ctx, id)
+	end(span, err)
+
+	return err
+}
+
+func (i *Interactor) AddOption(ctx context.Context, questionId int64, o entities.Option) (entities.Option, error) {
+	ctx, span := i.start(ctx, "AddOption", attribute.Int64("question.id", questionId))
+	o, err := i.Next.AddOption(ctx, questionId, o)
+	span.SetAttributes(attribute.Int64("option.id", o.Id))
+	end(span, err)
+
+	return o, err
+}
+
+func (i *Interactor) UpdateOption(ctx context.Context, questionId int64, o entities.Option) (entities.Option, error) {
+	ctx, span := i.start(ctx, "UpdateOption", attribute.Int64("question.id", questionId), attribute.Int64("option.id", o.Id))
+	o, err := i.Next.UpdateOption(ctx, questionId, o)
+	end(span, err)
+
+	return o, err
+}
+
+func (i *Interactor) RemoveOption(ctx context.Context, questionId, optionId int64) error {
+	ctx, span := i.start(ctx, "RemoveOption", attribute.Int64("question.id", questionId), attribute.Int64("option.id", optionId))
+	err := i.Next.RemoveOption(ctx, questionId, optionId)
+	end(span, err)
+
+	return err
+}
+
+func (i *Interactor) ReorderOptions(ctx context.Context, questionId int64, ids []int64) (entities.Question, error) {
+	ctx, span := i.start(ctx, "ReorderOptions", attribute.Int64("question.id", questionId), attribute.Int("question.options", len(ids)))
+	q, err := i.Next.ReorderOptions(ctx, questionId, ids)
+	end(span, err)
+
+	return q, err
+}
+
+func (i *Interactor) Transition(ctx context.Context, id int64, t entities.QuestionTransition, comment string) (entities.Question, error) {
+	ctx, span := i.start(ctx, "Transition", attribute.Int64("question.id", id), attribute.String("question.transition", string(t)))
+	q, err := i.Next.Transition(ctx, id, t, comment)
+	end(span, err)
+
+	return q, err
+}
+
+func (i *Interactor) ListAll(ctx context.Context, f entities.QuestionFilter) ([]entities.Question, error) {
+	ctx, span := i.start(ctx, "ListAll", sortAttributes(f)...)
+	ql, err := i.Next.ListAll(ctx, f)
+	span.SetAttributes(attribute.Int("questions.count", len(ql)))
+	end(span, err)
+
+	return ql, err
+}
+
+func (i *Interactor) Import(ctx context.Context, r service.QuestionReader, mode service.ImportMode, restore bool) (service.ImportReport, error) {
+	ctx, span := i.start(ctx, "Import", attribute.String("import.mode", string(mode)), attribute.Bool("import.restore", restore))
+	report, err := i.Next.Import(ctx, r, mode, restore)
+	span.SetAttributes(attribute.Int("import.total", report.Total), attribute.Int("import.failed", report.Failed))
+	end(span, err)
+
+	return report, err
+}
+
+func (i *Interactor) Export(ctx context.Context, w service.QuestionWriter) error {
+	ctx, span := i.start(ctx, "Export")
+	err := i.Next.Export(ctx, w)
+	end(span, err)
+
+	return err
+}
+
+func (i *Interactor) Duplicates(ctx context.Context, threshold float64) ([]service.DuplicateGroup, error) {
+	ctx, span := i.start(ctx, "Duplicates", attribute.Float64("duplicates.threshold", threshold))
+	gl, err := i.Next.Duplicates(ctx, threshold)
+	span.SetAttributes(attribute.Int("duplicates.groups", len(gl)))
+	end(span, err)
+
+	return gl, err
+}
+
+func (i *Interactor) Batch(ctx context.Context, ops []service.BatchOperation, mode service.BatchMode) (service.BatchReport, error) {
+	ctx, span := i.start(ctx, "Batch", attribute.String("batch.mode", string(mode)), attribute.Int("operations.count", len(ops)))
+	report, err := i.Next.Batch(ctx, ops, mode)
+	span.SetAttributes(attribute.Int("batch.failed", report.Failed))
+	end(span, err)
+
+	return report, err
+}
+
+func (i *Interactor) AuditLog(ctx context.Context, f entities.AuditFilter) ([]entities.AuditEntry, error) {
+	ctx, span := i.start(ctx, "AuditLog")
+	el, err := i.Next.AuditLog(ctx, f)
+	span.SetAttributes(attribute.Int("audit.count", len(el)))
+	end(span, err)
+
+	return el, err
+}
+
+// sortAttributes returns the attributes describing the sort and the page size of a questions list
+func sortAttributes(f entities.QuestionFilter) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.String("page.sort", string(f.Sort)),
+		attribute.String("page.direction", string(f.Direction)),
+		attribute.Bool("page.seek", f.After != nil),
+		attribute.Int("page.size", f.Limit),
+	}
+}
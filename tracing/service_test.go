@@ -0,0 +1,63 @@
+package tracing
+
+import (
+	"context"
+	"github.com/norby7/questions-rest-api/entities"
+	"github.com/norby7/questions-rest-api/usecases/service"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"testing"
+)
+
+// interactorStub lists a page of two questions, checking that it's called inside the service span
+type interactorStub struct {
+	service.Interactor
+	t *testing.T
+}
+
+func (i *interactorStub) ListAll(ctx context.Context, f entities.QuestionFilter) ([]entities.Question, error) {
+	if !trace.SpanContextFromContext(ctx).IsValid() {
+		i.t.Errorf("expected the service span in the context")
+	}
+
+	return []entities.Question{{Id: 1}, {Id: 2}}, nil
+}
+
+func (i *interactorStub) Remove(context.Context, int64) error {
+	return &service.ForbiddenError{Subject: "viewer", Action: service.ActionRemove, Roles: []entities.Role{entities.RoleViewer}}
+}
+
+func TestInteractor(t *testing.T) {
+	tp, sr := newRecorder()
+	i := NewInteractor(&interactorStub{t: t}, tp)
+
+	if _, err := i.ListAll(context.Background(), entities.QuestionFilter{Sort: entities.SortByBody, Direction: entities.Ascending, Limit: 10}); err != nil {
+		t.Fatalf("unable to list questions: %s", err.Error())
+	}
+
+	if err := i.Remove(context.Background(), 1); err == nil {
+		t.Fatalf("expected the error of the service")
+	}
+
+	spans := sr.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("expected two spans, got (%d) spans", len(spans))
+	}
+
+	attrs := map[string]interface{}{}
+	for _, kv := range spans[0].Attributes() {
+		attrs[string(kv.Key)] = kv.Value.AsInterface()
+	}
+
+	if spans[0].Name() != "service.ListAll" || attrs["page.sort"] != "body" || attrs["page.size"] != int64(10) || attrs["questions.count"] != int64(2) {
+		t.Errorf("expected a ListAll span with the sort, the page size and the questions count, got span (%s) with attributes (%v)", spans[0].Name(), attrs)
+	}
+
+	if spans[1].Name() != "service.Remove" || spans[1].Status().Code != codes.Error {
+		t.Errorf("expected a failed Remove span, got span (%s) with status (%v)", spans[1].Name(), spans[1].Status().Code)
+	}
+
+	if len(spans[1].Events()) != 1 || spans[1].Events()[0].Name != "exception" {
+		t.Errorf("expected the error to be recorded, got events (%v)", spans[1].Events())
+	}
+}
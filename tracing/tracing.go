@@ -0,0 +1,78 @@
+package tracing
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"github.com/XSAM/otelsql"
+	"github.com/norby7/questions-rest-api/config"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName is the name of the tracers creating the application spans
+const instrumentationName = "github.com/norby7/questions-rest-api"
+
+// Propagator reads and writes the W3C trace context headers of the requests
+var Propagator propagation.TextMapPropagator = propagation.TraceContext{}
+
+// NewProvider returns a tracer provider exporting the sampled traces to the OTLP/HTTP collector of the configuration
+// The traces are exported in batches, the provider must be shut down to flush the last ones.
+func NewProvider(ctx context.Context, c config.Tracing) (*sdktrace.TracerProvider, error) {
+	opts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(c.Endpoint)}
+	if c.Insecure {
+		opts = append(opts, otlptracehttp.WithInsecure())
+	}
+
+	exporter, err := otlptracehttp.New(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create trace exporter: %s", err.Error())
+	}
+
+	return sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(c.ServiceName))),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(c.SampleRatio))),
+	), nil
+}
+
+// Tracer returns the tracer of the application spans
+func Tracer(tp trace.TracerProvider) trace.Tracer {
+	return tp.Tracer(instrumentationName)
+}
+
+// OpenDB returns a function opening database connections that create a span for every SQL statement
+// Statements are only traced when they are executed with the context of a traced operation,
+// the statements executed on startup or by the background jobs don't create traces on their own.
+func OpenDB(tp trace.TracerProvider) func(string, string) (*sql.DB, error) {
+	return func(driverName, dsn string) (*sql.DB, error) {
+		return otelsql.Open(driverName, dsn,
+			otelsql.WithTracerProvider(tp),
+			otelsql.WithAttributes(semconv.DBSystemSqlite),
+			otelsql.WithSpanOptions(otelsql.SpanOptions{
+				OmitConnResetSession: true,
+				OmitConnPrepare:      true,
+				OmitRows:             true,
+				SpanFilter: func(ctx context.Context, _ otelsql.Method, _ string, _ []driver.NamedValue) bool {
+					return trace.SpanContextFromContext(ctx).IsValid()
+				},
+			}),
+		)
+	}
+}
+
+// end records the error of the operation of span, if any, and ends it
+func end(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	span.End()
+}
@@ -0,0 +1,73 @@
+package tracing
+
+import (
+	"context"
+	"github.com/norby7/questions-rest-api/config"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"path/filepath"
+	"testing"
+)
+
+// newRecorder returns a tracer provider recording the ended spans in memory
+func newRecorder() (*sdktrace.TracerProvider, *tracetest.SpanRecorder) {
+	sr := tracetest.NewSpanRecorder()
+	return sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr)), sr
+}
+
+func TestOpenDB(t *testing.T) {
+	tp, sr := newRecorder()
+
+	db, err := OpenDB(tp)("sqlite3", filepath.Join(t.TempDir(), "questions.db"))
+	if err != nil {
+		t.Fatalf("unable to open database: %s", err.Error())
+	}
+	defer db.Close()
+
+	if _, err = db.Exec(`CREATE TABLE questions (id INTEGER PRIMARY KEY, body TEXT)`); err != nil {
+		t.Fatalf("unable to create table: %s", err.Error())
+	}
+
+	if n := len(sr.Ended()); n != 0 {
+		t.Fatalf("expected no spans for an untraced statement, got (%d) spans", n)
+	}
+
+	ctx, span := Tracer(tp).Start(context.Background(), "parent")
+	if _, err = db.ExecContext(ctx, `INSERT INTO questions (body) VALUES (?)`, "Where does the sun set?"); err != nil {
+		t.Fatalf("unable to insert question: %s", err.Error())
+	}
+	span.End()
+
+	spans := sr.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("expected a statement span and its parent, got (%d) spans", len(spans))
+	}
+
+	if spans[0].Parent().SpanID() != span.SpanContext().SpanID() {
+		t.Errorf("expected the statement span (%s) to be a child of the traced operation", spans[0].Name())
+	}
+
+	attrs := map[string]string{}
+	for _, kv := range spans[0].Attributes() {
+		attrs[string(kv.Key)] = kv.Value.Emit()
+	}
+
+	if attrs["db.system"] != "sqlite" || attrs["db.statement"] != `INSERT INTO questions (body) VALUES (?)` {
+		t.Errorf("expected the database system and statement, got attributes (%v)", attrs)
+	}
+}
+
+func TestNewProvider(t *testing.T) {
+	c := config.Default().Tracing
+	c.Insecure = true
+
+	tp, err := NewProvider(context.Background(), c)
+	if err != nil {
+		t.Fatalf("unable to create tracer provider: %s", err.Error())
+	}
+
+	// no span was started, so nothing is sent to the collector
+	if err = tp.Shutdown(context.Background()); err != nil {
+		t.Errorf("expected the tracer provider to shut down, got error (%v)", err)
+	}
+}
@@ -0,0 +1,89 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"github.com/norby7/questions-rest-api/entities"
+	"github.com/norby7/questions-rest-api/usecases/repository"
+	"strings"
+	"time"
+)
+
+// APIKeyPrefix is the prefix of every locally issued api key, used to tell them apart from bearer tokens
+const APIKeyPrefix = "qk_"
+
+var (
+	SubjectRequiredError = fmt.Errorf("the api key subject is required")
+	RoleRequiredError    = fmt.Errorf("the api key requires at least one role")
+)
+
+// GenerateAPIKey returns a new random api key and its hash
+func GenerateAPIKey() (string, string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", fmt.Errorf("unable to generate api key: %s", err.Error())
+	}
+
+	key := APIKeyPrefix + base64.RawURLEncoding.EncodeToString(b)
+
+	return key, HashAPIKey(key), nil
+}
+
+// HashAPIKey returns the hex encoded SHA-256 hash of the api key, the only form in which keys are stored
+func HashAPIKey(key string) string {
+	h := sha256.Sum256([]byte(key))
+
+	return hex.EncodeToString(h[:])
+}
+
+// IsAPIKey reports whether the credential is a locally issued api key rather than a bearer token
+func IsAPIKey(credential string) bool {
+	return strings.HasPrefix(credential, APIKeyPrefix)
+}
+
+// IssueAPIKey generates and stores a new api key granting the given roles on the tenant bank to the subject
+// The returned key is the only copy of the key in clear, it can't be recovered later.
+func IssueAPIKey(ctx context.Context, r repository.APIKeyRepository, name, subject, tenant string, roles []entities.Role) (string, entities.APIKey, error) {
+	if strings.TrimSpace(subject) == "" {
+		return "", entities.APIKey{}, SubjectRequiredError
+	}
+
+	if strings.TrimSpace(tenant) == "" {
+		return "", entities.APIKey{}, repository.TenantRequiredError
+	}
+
+	if len(roles) == 0 {
+		return "", entities.APIKey{}, RoleRequiredError
+	}
+
+	for _, role := range roles {
+		if _, err := entities.ParseRole(string(role)); err != nil {
+			return "", entities.APIKey{}, err
+		}
+	}
+
+	key, hash, err := GenerateAPIKey()
+	if err != nil {
+		return "", entities.APIKey{}, err
+	}
+
+	k := entities.APIKey{
+		Name:      name,
+		Subject:   subject,
+		Roles:     roles,
+		Tenant:    tenant,
+		Hash:      hash,
+		CreatedAt: time.Now().Unix(),
+	}
+
+	k.Id, err = r.AddAPIKey(ctx, k)
+	if err != nil {
+		return "", entities.APIKey{}, err
+	}
+
+	return key, k, nil
+}
@@ -0,0 +1,68 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/norby7/questions-rest-api/entities"
+	"github.com/norby7/questions-rest-api/usecases/repository"
+)
+
+var (
+	MissingCredentialsError = fmt.Errorf("missing credentials")
+	InvalidAPIKeyError      = fmt.Errorf("invalid api key")
+	InvalidTokenError       = fmt.Errorf("invalid bearer token")
+)
+
+// Authenticator identifies the principal owning an api key or a bearer token
+type Authenticator struct {
+	Keys repository.APIKeyRepository
+	// validator of the bearer tokens, tokens are rejected if nil
+	JWT *JWTValidator
+}
+
+// NewAuthenticator returns an authenticator accepting the api keys stored in keys and, if v is not nil, the tokens validated by v
+func NewAuthenticator(keys repository.APIKeyRepository, v *JWTValidator) *Authenticator {
+	return &Authenticator{Keys: keys, JWT: v}
+}
+
+// Authenticate returns the principal identified by the credential
+// Credentials starting with APIKeyPrefix are api keys, any other credential is validated as a JWT.
+// Rejected credentials return an error wrapping MissingCredentialsError, InvalidAPIKeyError or InvalidTokenError.
+func (a *Authenticator) Authenticate(ctx context.Context, credential string) (entities.Principal, error) {
+	if credential == "" {
+		return entities.Principal{}, MissingCredentialsError
+	}
+
+	if IsAPIKey(credential) {
+		return a.authenticateAPIKey(ctx, credential)
+	}
+
+	if a.JWT == nil {
+		return entities.Principal{}, fmt.Errorf("%w: bearer tokens are not enabled", InvalidTokenError)
+	}
+
+	p, err := a.JWT.Validate(credential)
+	if err != nil {
+		return entities.Principal{}, fmt.Errorf("%w: %s", InvalidTokenError, err.Error())
+	}
+
+	return p, nil
+}
+
+// authenticateAPIKey looks up the api key by its hash
+func (a *Authenticator) authenticateAPIKey(ctx context.Context, key string) (entities.Principal, error) {
+	k, err := a.Keys.GetAPIKey(ctx, HashAPIKey(key))
+	if errors.Is(err, repository.APIKeyNotFoundError) {
+		return entities.Principal{}, InvalidAPIKeyError
+	}
+	if err != nil {
+		return entities.Principal{}, err
+	}
+
+	if k.Revoked {
+		return entities.Principal{}, fmt.Errorf("%w: the key was revoked", InvalidAPIKeyError)
+	}
+
+	return entities.Principal{Subject: k.Subject, Method: entities.AuthAPIKey, Roles: k.Roles, Tenant: k.Tenant}, nil
+}
@@ -0,0 +1,204 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/norby7/questions-rest-api/entities"
+	"github.com/norby7/questions-rest-api/usecases/repository"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// keyStoreMock is an in memory api key repository
+type keyStoreMock struct {
+	keys     []entities.APIKey
+	getError error
+}
+
+func (m *keyStoreMock) AddAPIKey(_ context.Context, k entities.APIKey) (int64, error) {
+	k.Id = int64(len(m.keys) + 1)
+	m.keys = append(m.keys, k)
+
+	return k.Id, nil
+}
+
+func (m *keyStoreMock) GetAPIKey(_ context.Context, hash string) (entities.APIKey, error) {
+	if m.getError != nil {
+		return entities.APIKey{}, m.getError
+	}
+
+	for _, k := range m.keys {
+		if k.Hash == hash {
+			return k, nil
+		}
+	}
+
+	return entities.APIKey{}, repository.APIKeyNotFoundError
+}
+
+func (m *keyStoreMock) ListAPIKeys(context.Context) ([]entities.APIKey, error) {
+	return m.keys, nil
+}
+
+func (m *keyStoreMock) RevokeAPIKey(_ context.Context, id int64) error {
+	for i := range m.keys {
+		if m.keys[i].Id == id {
+			m.keys[i].Revoked = true
+			return nil
+		}
+	}
+
+	return repository.APIKeyNotFoundError
+}
+
+func TestIssueAPIKey(t *testing.T) {
+	m := &keyStoreMock{}
+
+	key, k, err := IssueAPIKey(context.Background(), m, "ci", "build-bot", "acme", []entities.Role{entities.RoleEditor})
+	if err != nil {
+		t.Fatalf("unable to issue api key: %s", err.Error())
+	}
+
+	if !strings.HasPrefix(key, APIKeyPrefix) {
+		t.Errorf("expected key with prefix (%s), got key (%s)", APIKeyPrefix, key)
+	}
+
+	if k.Hash != HashAPIKey(key) || strings.Contains(k.Hash, key) {
+		t.Errorf("expected stored hash (%s), got hash (%s)", HashAPIKey(key), k.Hash)
+	}
+
+	if k.Id != 1 || m.keys[0].Subject != "build-bot" {
+		t.Errorf("expected stored key for (build-bot), got keys (%v)", m.keys)
+	}
+
+	if _, _, err = IssueAPIKey(context.Background(), m, "ci", " ", "acme", []entities.Role{entities.RoleViewer}); err != SubjectRequiredError {
+		t.Errorf("expected error (%v), got error (%v)", SubjectRequiredError, err)
+	}
+
+	if _, _, err = IssueAPIKey(context.Background(), m, "ci", "build-bot", "", []entities.Role{entities.RoleViewer}); err != repository.TenantRequiredError {
+		t.Errorf("expected error (%v), got error (%v)", repository.TenantRequiredError, err)
+	}
+
+	if _, _, err = IssueAPIKey(context.Background(), m, "ci", "build-bot", "acme", nil); err != RoleRequiredError {
+		t.Errorf("expected error (%v), got error (%v)", RoleRequiredError, err)
+	}
+
+	if _, _, err = IssueAPIKey(context.Background(), m, "ci", "build-bot", "acme", []entities.Role{"owner"}); err != entities.RoleError {
+		t.Errorf("expected error (%v), got error (%v)", entities.RoleError, err)
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	m := &keyStoreMock{}
+
+	key, _, err := IssueAPIKey(context.Background(), m, "ci", "build-bot", "acme", []entities.Role{entities.RoleEditor})
+	if err != nil {
+		t.Fatalf("unable to issue api key: %s", err.Error())
+	}
+
+	revoked, k, err := IssueAPIKey(context.Background(), m, "old", "old-bot", "acme", []entities.Role{entities.RoleViewer})
+	if err != nil {
+		t.Fatalf("unable to issue api key: %s", err.Error())
+	}
+	_ = m.RevokeAPIKey(context.Background(), k.Id)
+
+	v, err := NewJWTValidator(testSecret, "", "", "")
+	if err != nil {
+		t.Fatalf("unable to create validator: %s", err.Error())
+	}
+
+	token := signHMAC(t, jwt.MapClaims{"sub": "alice", "roles": []string{"viewer", "owner"}, "exp": time.Now().Add(time.Hour).Unix()})
+
+	testCases := []struct {
+		name       string
+		credential string
+		jwt        *JWTValidator
+		expected   entities.Principal
+		err        error
+	}{{
+		name:       "valid api key",
+		credential: key,
+		jwt:        v,
+		expected:   entities.Principal{Subject: "build-bot", Method: entities.AuthAPIKey, Roles: []entities.Role{entities.RoleEditor}, Tenant: "acme"},
+	}, {
+		name:       "unknown api key",
+		credential: APIKeyPrefix + "unknown",
+		jwt:        v,
+		err:        InvalidAPIKeyError,
+	}, {
+		name:       "revoked api key",
+		credential: revoked,
+		jwt:        v,
+		err:        InvalidAPIKeyError,
+	}, {
+		name:       "valid token",
+		credential: token,
+		jwt:        v,
+		expected:   entities.Principal{Subject: "alice", Method: entities.AuthJWT, Roles: []entities.Role{entities.RoleViewer}, Tenant: entities.DefaultTenant},
+	}, {
+		name:       "invalid token",
+		credential: token + "x",
+		jwt:        v,
+		err:        InvalidTokenError,
+	}, {
+		name:       "tokens not enabled",
+		credential: token,
+		jwt:        nil,
+		err:        InvalidTokenError,
+	}, {
+		name:       "missing credentials",
+		credential: "",
+		jwt:        v,
+		err:        MissingCredentialsError,
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			a := NewAuthenticator(m, tc.jwt)
+
+			p, err := a.Authenticate(context.Background(), tc.credential)
+			if !errors.Is(err, tc.err) || (err != nil) != (tc.err != nil) {
+				t.Fatalf("expected error (%v), got error (%v)", tc.err, err)
+			}
+
+			if !reflect.DeepEqual(p, tc.expected) {
+				t.Errorf("expected principal (%v), got principal (%v)", tc.expected, p)
+			}
+		})
+	}
+}
+
+func TestRepositoryErrorAuthenticate(t *testing.T) {
+	a := NewAuthenticator(&keyStoreMock{getError: fmt.Errorf("database is locked")}, nil)
+
+	_, err := a.Authenticate(context.Background(), APIKeyPrefix+"key")
+	if err == nil || errors.Is(err, InvalidAPIKeyError) {
+		t.Errorf("expected repository error, got error (%v)", err)
+	}
+}
+
+func TestContext(t *testing.T) {
+	if _, ok := FromContext(context.Background()); ok {
+		t.Errorf("expected no principal in empty context")
+	}
+
+	p := entities.Principal{Subject: "alice", Method: entities.AuthJWT, Roles: []entities.Role{entities.RoleAdmin}, Tenant: "acme"}
+	ctx := NewContext(context.Background(), p)
+
+	got, ok := FromContext(ctx)
+	if !ok || !reflect.DeepEqual(got, p) {
+		t.Errorf("expected principal (%v), got principal (%v)", p, got)
+	}
+
+	if tenant, err := repository.TenantFromContext(ctx); tenant != "acme" {
+		t.Errorf("expected tenant (acme), got tenant (%s) and error (%v)", tenant, err)
+	}
+
+	if actor := repository.ActorFromContext(ctx); actor != "alice" {
+		t.Errorf("expected actor (alice), got actor (%s)", actor)
+	}
+}
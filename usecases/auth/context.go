@@ -0,0 +1,27 @@
+package auth
+
+import (
+	"context"
+	"github.com/norby7/questions-rest-api/entities"
+	"github.com/norby7/questions-rest-api/usecases/repository"
+)
+
+// principalKey is the context key of the authenticated principal
+type principalKey struct{}
+
+// NewContext returns a copy of ctx carrying the given principal
+// The repository queries made with the returned context are scoped to the principal tenant,
+// and the questions they create or change are authored by the principal subject.
+func NewContext(ctx context.Context, p entities.Principal) context.Context {
+	ctx = repository.NewTenantContext(ctx, p.Tenant)
+	ctx = repository.NewActorContext(ctx, p.Subject)
+
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// FromContext returns the principal carried by ctx, if any
+func FromContext(ctx context.Context) (entities.Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(entities.Principal)
+
+	return p, ok
+}
@@ -0,0 +1,234 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/norby7/questions-rest-api/entities"
+	"io/ioutil"
+	"math/big"
+	"strings"
+)
+
+var (
+	JWTKeyRequiredError = fmt.Errorf("a HMAC secret or a JWKS file is required to validate bearer tokens")
+	UnknownKeyError     = fmt.Errorf("unknown signing key")
+	SubjectClaimError   = fmt.Errorf("the token has no sub claim")
+)
+
+// jwk is a single key of a JSON Web Key Set, only the fields of RSA, EC and symmetric keys are read
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+	K   string `json:"k"`
+}
+
+// claims are the registered claims and the roles and tenant claims of the bearer tokens
+type claims struct {
+	jwt.RegisteredClaims
+	Roles  roleClaim `json:"roles"`
+	Tenant string    `json:"tenant"`
+}
+
+// roleClaim is a list of roles, encoded either as a JSON array or as a space separated string
+type roleClaim []string
+
+func (r *roleClaim) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*r = strings.Fields(s)
+		return nil
+	}
+
+	var l []string
+	if err := json.Unmarshal(data, &l); err != nil {
+		return fmt.Errorf("the roles claim must be a string or a list of strings")
+	}
+
+	*r = l
+
+	return nil
+}
+
+// JWTValidator validates bearer tokens signed with a HMAC secret or with one of the keys of a JWKS
+type JWTValidator struct {
+	// secret of the HS256, HS384 and HS512 signed tokens
+	Secret []byte
+	// verification keys by key id, loaded from a JWKS file
+	Keys map[string]interface{}
+	// expected iss claim, not checked if empty
+	Issuer string
+	// expected aud claim, not checked if empty
+	Audience string
+}
+
+// NewJWTValidator returns a validator using the given HMAC secret and the keys of the given JWKS file
+// At least one of secret and jwksFile is required.
+func NewJWTValidator(secret, jwksFile, issuer, audience string) (*JWTValidator, error) {
+	if secret == "" && jwksFile == "" {
+		return nil, JWTKeyRequiredError
+	}
+
+	v := &JWTValidator{Issuer: issuer, Audience: audience}
+
+	if secret != "" {
+		v.Secret = []byte(secret)
+	}
+
+	if jwksFile != "" {
+		c, err := ioutil.ReadFile(jwksFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to open JWKS file: %s", err.Error())
+		}
+
+		v.Keys, err = ParseJWKS(c)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return v, nil
+}
+
+// ParseJWKS returns the verification keys of a JSON Web Key Set by key id
+func ParseJWKS(data []byte) (map[string]interface{}, error) {
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("unable to parse JWKS: %s", err.Error())
+	}
+
+	keys := make(map[string]interface{}, len(set.Keys))
+	for i, k := range set.Keys {
+		key, err := k.publicKey()
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse JWKS key %d: %s", i, err.Error())
+		}
+
+		keys[k.Kid] = key
+	}
+
+	return keys, nil
+}
+
+// publicKey returns the key used to verify the token signatures
+func (k jwk) publicKey() (interface{}, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	case "oct":
+		return base64.RawURLEncoding.DecodeString(k.K)
+	}
+
+	return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+}
+
+// decodeBigInt decodes a base64url encoded big endian integer
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid key parameter: %s", err.Error())
+	}
+
+	return new(big.Int).SetBytes(b), nil
+}
+
+// Validate checks the token signature, expiration, issuer and audience and returns the principal it identifies
+// The principal is granted the roles of the roles claim, roles unknown to the service are ignored.
+// Tokens without a tenant claim belong to the default tenant.
+func (v *JWTValidator) Validate(token string) (entities.Principal, error) {
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{"HS256", "HS384", "HS512", "RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512"}),
+		jwt.WithExpirationRequired(),
+	}
+
+	if v.Issuer != "" {
+		opts = append(opts, jwt.WithIssuer(v.Issuer))
+	}
+
+	if v.Audience != "" {
+		opts = append(opts, jwt.WithAudience(v.Audience))
+	}
+
+	var c claims
+	if _, err := jwt.ParseWithClaims(token, &c, v.key, opts...); err != nil {
+		return entities.Principal{}, err
+	}
+
+	if c.Subject == "" {
+		return entities.Principal{}, SubjectClaimError
+	}
+
+	p := entities.Principal{Subject: c.Subject, Method: entities.AuthJWT, Tenant: c.Tenant}
+	if p.Tenant == "" {
+		p.Tenant = entities.DefaultTenant
+	}
+
+	for _, r := range c.Roles {
+		if role, err := entities.ParseRole(r); err == nil {
+			p.Roles = append(p.Roles, role)
+		}
+	}
+
+	return p, nil
+}
+
+// key returns the key verifying the token signature
+// HMAC signed tokens use the secret if there is one, otherwise the key is looked up by the token kid header.
+func (v *JWTValidator) key(t *jwt.Token) (interface{}, error) {
+	if _, ok := t.Method.(*jwt.SigningMethodHMAC); ok && v.Secret != nil {
+		return v.Secret, nil
+	}
+
+	kid, _ := t.Header["kid"].(string)
+	if key, ok := v.Keys[kid]; ok {
+		return key, nil
+	}
+
+	return nil, UnknownKeyError
+}
@@ -0,0 +1,307 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/norby7/questions-rest-api/entities"
+	"reflect"
+	"testing"
+	"time"
+)
+
+const testSecret = "0123456789abcdef0123456789abcdef"
+
+func signHMAC(t *testing.T, claims jwt.MapClaims) string {
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(testSecret))
+	if err != nil {
+		t.Fatalf("unable to sign token: %s", err.Error())
+	}
+
+	return token
+}
+
+func TestHMACValidate(t *testing.T) {
+	v, err := NewJWTValidator(testSecret, "", "questions-issuer", "questions-api")
+	if err != nil {
+		t.Fatalf("unable to create validator: %s", err.Error())
+	}
+
+	exp := time.Now().Add(time.Hour).Unix()
+
+	testCases := []struct {
+		name    string
+		claims  jwt.MapClaims
+		subject string
+		isError bool
+	}{{
+		name:    "valid token",
+		claims:  jwt.MapClaims{"sub": "alice", "iss": "questions-issuer", "aud": "questions-api", "exp": exp},
+		subject: "alice",
+		isError: false,
+	}, {
+		name:    "expired token",
+		claims:  jwt.MapClaims{"sub": "alice", "iss": "questions-issuer", "aud": "questions-api", "exp": time.Now().Add(-time.Hour).Unix()},
+		isError: true,
+	}, {
+		name:    "missing expiration",
+		claims:  jwt.MapClaims{"sub": "alice", "iss": "questions-issuer", "aud": "questions-api"},
+		isError: true,
+	}, {
+		name:    "wrong issuer",
+		claims:  jwt.MapClaims{"sub": "alice", "iss": "someone-else", "aud": "questions-api", "exp": exp},
+		isError: true,
+	}, {
+		name:    "wrong audience",
+		claims:  jwt.MapClaims{"sub": "alice", "iss": "questions-issuer", "aud": "other-api", "exp": exp},
+		isError: true,
+	}, {
+		name:    "missing subject",
+		claims:  jwt.MapClaims{"iss": "questions-issuer", "aud": "questions-api", "exp": exp},
+		isError: true,
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := v.Validate(signHMAC(t, tc.claims))
+
+			if (err != nil) != tc.isError {
+				t.Fatalf("expected error (%v), got error (%v)", tc.isError, err)
+			}
+
+			if p.Subject != tc.subject {
+				t.Errorf("expected subject (%s), got subject (%s)", tc.subject, p.Subject)
+			}
+		})
+	}
+}
+
+func TestRolesValidate(t *testing.T) {
+	v, err := NewJWTValidator(testSecret, "", "", "")
+	if err != nil {
+		t.Fatalf("unable to create validator: %s", err.Error())
+	}
+
+	exp := time.Now().Add(time.Hour).Unix()
+
+	testCases := []struct {
+		name     string
+		roles    interface{}
+		expected []entities.Role
+		isError  bool
+	}{{
+		name:     "list of roles",
+		roles:    []string{"viewer", "editor"},
+		expected: []entities.Role{entities.RoleViewer, entities.RoleEditor},
+	}, {
+		name:     "space separated roles",
+		roles:    "admin candidate",
+		expected: []entities.Role{entities.RoleAdmin, entities.RoleCandidate},
+	}, {
+		name:     "unknown roles ignored",
+		roles:    []string{"owner", "editor"},
+		expected: []entities.Role{entities.RoleEditor},
+	}, {
+		name:    "invalid roles claim",
+		roles:   42,
+		isError: true,
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := v.Validate(signHMAC(t, jwt.MapClaims{"sub": "alice", "roles": tc.roles, "exp": exp}))
+
+			if (err != nil) != tc.isError {
+				t.Fatalf("expected error (%v), got error (%v)", tc.isError, err)
+			}
+
+			if !reflect.DeepEqual(p.Roles, tc.expected) {
+				t.Errorf("expected roles (%v), got roles (%v)", tc.expected, p.Roles)
+			}
+		})
+	}
+}
+
+func TestTenantValidate(t *testing.T) {
+	v, err := NewJWTValidator(testSecret, "", "", "")
+	if err != nil {
+		t.Fatalf("unable to create validator: %s", err.Error())
+	}
+
+	exp := time.Now().Add(time.Hour).Unix()
+
+	testCases := []struct {
+		name     string
+		claims   jwt.MapClaims
+		expected string
+	}{{
+		name:     "tenant claim",
+		claims:   jwt.MapClaims{"sub": "alice", "tenant": "acme", "exp": exp},
+		expected: "acme",
+	}, {
+		name:     "default tenant",
+		claims:   jwt.MapClaims{"sub": "alice", "exp": exp},
+		expected: entities.DefaultTenant,
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := v.Validate(signHMAC(t, tc.claims))
+			if err != nil {
+				t.Fatalf("unable to validate token: %s", err.Error())
+			}
+
+			if p.Tenant != tc.expected {
+				t.Errorf("expected tenant (%s), got tenant (%s)", tc.expected, p.Tenant)
+			}
+		})
+	}
+}
+
+func TestWrongSecretValidate(t *testing.T) {
+	v, err := NewJWTValidator("another secret", "", "", "")
+	if err != nil {
+		t.Fatalf("unable to create validator: %s", err.Error())
+	}
+
+	if _, err = v.Validate(signHMAC(t, jwt.MapClaims{"sub": "alice", "exp": time.Now().Add(time.Hour).Unix()})); err == nil {
+		t.Errorf("expected error for token signed with another secret")
+	}
+}
+
+func TestNoneAlgorithmValidate(t *testing.T) {
+	v, err := NewJWTValidator(testSecret, "", "", "")
+	if err != nil {
+		t.Fatalf("unable to create validator: %s", err.Error())
+	}
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodNone, jwt.MapClaims{"sub": "alice", "exp": time.Now().Add(time.Hour).Unix()}).
+		SignedString(jwt.UnsafeAllowNoneSignatureType)
+	if err != nil {
+		t.Fatalf("unable to sign token: %s", err.Error())
+	}
+
+	if _, err = v.Validate(token); err == nil {
+		t.Errorf("expected error for unsigned token")
+	}
+}
+
+func TestJWKSValidate(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("unable to generate key: %s", err.Error())
+	}
+
+	jwks := fmt.Sprintf(`{"keys": [{"kty": "EC", "kid": "key-1", "crv": "P-256", "x": "%s", "y": "%s"}, {"kty": "oct", "kid": "key-2", "k": "%s"}]}`,
+		base64.RawURLEncoding.EncodeToString(key.X.Bytes()),
+		base64.RawURLEncoding.EncodeToString(key.Y.Bytes()),
+		base64.RawURLEncoding.EncodeToString([]byte(testSecret)))
+
+	keys, err := ParseJWKS([]byte(jwks))
+	if err != nil {
+		t.Fatalf("unable to parse JWKS: %s", err.Error())
+	}
+
+	v := &JWTValidator{Keys: keys}
+	claims := jwt.MapClaims{"sub": "bob", "exp": time.Now().Add(time.Hour).Unix()}
+
+	testCases := []struct {
+		name    string
+		method  jwt.SigningMethod
+		kid     string
+		key     interface{}
+		isError bool
+	}{{
+		name:    "EC key",
+		method:  jwt.SigningMethodES256,
+		kid:     "key-1",
+		key:     key,
+		isError: false,
+	}, {
+		name:    "symmetric key",
+		method:  jwt.SigningMethodHS256,
+		kid:     "key-2",
+		key:     []byte(testSecret),
+		isError: false,
+	}, {
+		name:    "unknown kid",
+		method:  jwt.SigningMethodES256,
+		kid:     "key-3",
+		key:     key,
+		isError: true,
+	}, {
+		name:    "key type mismatch",
+		method:  jwt.SigningMethodHS256,
+		kid:     "key-1",
+		key:     []byte(testSecret),
+		isError: true,
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			token := jwt.NewWithClaims(tc.method, claims)
+			token.Header["kid"] = tc.kid
+
+			s, err := token.SignedString(tc.key)
+			if err != nil {
+				t.Fatalf("unable to sign token: %s", err.Error())
+			}
+
+			p, err := v.Validate(s)
+			if (err != nil) != tc.isError {
+				t.Fatalf("expected error (%v), got error (%v)", tc.isError, err)
+			}
+
+			if !tc.isError && p.Subject != "bob" {
+				t.Errorf("expected subject (bob), got subject (%s)", p.Subject)
+			}
+		})
+	}
+}
+
+func TestParseJWKS(t *testing.T) {
+	testCases := []struct {
+		name    string
+		input   string
+		isError bool
+	}{{
+		name:    "RSA key",
+		input:   `{"keys": [{"kty": "RSA", "kid": "rsa", "n": "sXchDaQebHnPiGvyDOAT4saGEUetSyo9MKLOoWFsueri23bOdgWp4Dy1WlUzewbgBHod5pcM9H95GQRV3JDXboIRROSBigeC5yjU1hGzHHyXss8UDprecbAYxknTcQkhslANGRUZmdTOQ5qTRsLAt6BTYuyvVRdhS8exSZEy_c4gs_7svlJJQ4H9_NxsiIoLwAEk7-Q3UXERGYw_75IDrGA84-lA_-Ct4eTlXHBIY2EaV7t7LjJaynVJCpkv4LKjTTAumiGUIuQhrNhZLuF_RJLqHpM2kgWFLU7-VTdL1VbC2tejvcI2BlMkEpk1BzBZI0KQB0GaDWFLN-aEAw3vRw", "e": "AQAB"}]}`,
+		isError: false,
+	}, {
+		name:    "unsupported curve",
+		input:   `{"keys": [{"kty": "EC", "kid": "ec", "crv": "P-192", "x": "AQ", "y": "AQ"}]}`,
+		isError: true,
+	}, {
+		name:    "unsupported key type",
+		input:   `{"keys": [{"kty": "OKP", "kid": "ed"}]}`,
+		isError: true,
+	}, {
+		name:    "invalid json",
+		input:   `{"keys": `,
+		isError: true,
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ParseJWKS([]byte(tc.input))
+
+			if (err != nil) != tc.isError {
+				t.Errorf("expected error (%v), got error (%v)", tc.isError, err)
+			}
+		})
+	}
+}
+
+func TestNewJWTValidator(t *testing.T) {
+	if _, err := NewJWTValidator("", "", "", ""); err != JWTKeyRequiredError {
+		t.Errorf("expected error (%v), got error (%v)", JWTKeyRequiredError, err)
+	}
+
+	if _, err := NewJWTValidator("", "./missing-jwks.json", "", ""); err == nil {
+		t.Errorf("expected error for missing JWKS file")
+	}
+}
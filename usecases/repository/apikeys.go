@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/norby7/questions-rest-api/entities"
+	"strings"
+)
+
+// AddAPIKey stores a new api key and returns its id
+func (r *SqliteRepository) AddAPIKey(ctx context.Context, k entities.APIKey) (int64, error) {
+	res, err := r.Handler.ExecContext(ctx, `INSERT INTO api_keys(name, subject, roles, tenantId, hash, createdAt, revoked) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		k.Name, k.Subject, joinRoles(k.Roles), k.Tenant, k.Hash, k.CreatedAt, k.Revoked)
+	if err != nil {
+		return 0, fmt.Errorf("unable to insert api key: %s", err.Error())
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("unable to retrieve api key id: %s", err.Error())
+	}
+
+	return id, nil
+}
+
+// GetAPIKey returns the api key with the given hash
+func (r *SqliteRepository) GetAPIKey(ctx context.Context, hash string) (entities.APIKey, error) {
+	k, err := scanAPIKey(r.Handler.QueryRowContext(ctx, `SELECT id, name, subject, roles, tenantId, hash, createdAt, revoked FROM api_keys WHERE hash = ?`, hash))
+	if err == sql.ErrNoRows {
+		return k, APIKeyNotFoundError
+	}
+	if err != nil {
+		return k, fmt.Errorf("unable to query api key: %s", err.Error())
+	}
+
+	return k, nil
+}
+
+// ListAPIKeys returns every issued api key, including the revoked ones
+func (r *SqliteRepository) ListAPIKeys(ctx context.Context) ([]entities.APIKey, error) {
+	rows, err := r.Handler.QueryContext(ctx, `SELECT id, name, subject, roles, tenantId, hash, createdAt, revoked FROM api_keys ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("unable to query api keys: %s", err.Error())
+	}
+	defer rows.Close()
+
+	var kl []entities.APIKey
+	for rows.Next() {
+		k, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read api key: %s", err.Error())
+		}
+
+		kl = append(kl, k)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("unable to read api keys: %s", err.Error())
+	}
+
+	return kl, nil
+}
+
+// RevokeAPIKey marks the api key with the given id as revoked
+func (r *SqliteRepository) RevokeAPIKey(ctx context.Context, id int64) error {
+	res, err := r.Handler.ExecContext(ctx, `UPDATE api_keys SET revoked = 1 WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("unable to revoke api key: %s", err.Error())
+	}
+
+	if !rowsAffected(res) {
+		return APIKeyNotFoundError
+	}
+
+	return nil
+}
+
+// scanner is implemented by sql.Row and sql.Rows
+type scanner interface {
+	Scan(...interface{}) error
+}
+
+// scanAPIKey reads an api key from a row selecting id, name, subject, roles, tenantId, hash, createdAt and revoked
+func scanAPIKey(row scanner) (entities.APIKey, error) {
+	var k entities.APIKey
+	var roles string
+
+	if err := row.Scan(&k.Id, &k.Name, &k.Subject, &roles, &k.Tenant, &k.Hash, &k.CreatedAt, &k.Revoked); err != nil {
+		return k, err
+	}
+
+	k.Roles = splitRoles(roles)
+
+	return k, nil
+}
+
+// joinRoles returns the comma separated list of roles stored in the roles column
+func joinRoles(roles []entities.Role) string {
+	rl := make([]string, len(roles))
+	for i, r := range roles {
+		rl[i] = string(r)
+	}
+
+	return strings.Join(rl, ",")
+}
+
+// splitRoles parses the comma separated list of roles stored in the roles column
+func splitRoles(roles string) []entities.Role {
+	var rl []entities.Role
+	for _, r := range strings.Split(roles, ",") {
+		if r = strings.TrimSpace(r); r != "" {
+			rl = append(rl, entities.Role(r))
+		}
+	}
+
+	return rl
+}
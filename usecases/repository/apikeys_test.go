@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/norby7/questions-rest-api/entities"
+	"testing"
+)
+
+func TestValidAddAPIKey(t *testing.T) {
+	SqlOpen = MockOpener
+	repo, err := NewSqliteRepository("./test.db")
+	if err != nil {
+		t.Fatalf("unable to create mock repository: %s", err.Error())
+	}
+
+	k := entities.APIKey{Name: "ci", Subject: "build-bot", Roles: []entities.Role{entities.RoleViewer, entities.RoleEditor}, Tenant: "acme", Hash: "abc", CreatedAt: 1600000000}
+
+	dbMock.ExpectExec("INSERT INTO api_keys").WithArgs("ci", "build-bot", "viewer,editor", "acme", "abc", int64(1600000000), false).
+		WillReturnResult(sqlmock.NewResult(4, 1))
+
+	id, err := repo.AddAPIKey(context.Background(), k)
+	if err != nil {
+		t.Fatalf("unable to add api key: %s", err.Error())
+	}
+
+	if id != 4 {
+		t.Errorf("expected id (4), got id (%d)", id)
+	}
+
+	if err = dbMock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err.Error())
+	}
+}
+
+func TestGetAPIKey(t *testing.T) {
+	SqlOpen = MockOpener
+	repo, err := NewSqliteRepository("./test.db")
+	if err != nil {
+		t.Fatalf("unable to create mock repository: %s", err.Error())
+	}
+
+	testCases := []struct {
+		name     string
+		rows     *sqlmock.Rows
+		queryErr error
+		err      error
+		isError  bool
+	}{{
+		name: "existing key",
+		rows: sqlmock.NewRows([]string{"id", "name", "subject", "roles", "tenantId", "hash", "createdAt", "revoked"}).
+			AddRow(1, "ci", "build-bot", "viewer,editor", "acme", "abc", 1600000000, false),
+	}, {
+		name:     "missing key",
+		queryErr: sql.ErrNoRows,
+		err:      APIKeyNotFoundError,
+		isError:  true,
+	}, {
+		name:     "query error",
+		queryErr: sql.ErrConnDone,
+		isError:  true,
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			q := dbMock.ExpectQuery("SELECT (.+) FROM api_keys WHERE hash").WithArgs("abc")
+			if tc.queryErr != nil {
+				q.WillReturnError(tc.queryErr)
+			} else {
+				q.WillReturnRows(tc.rows)
+			}
+
+			k, err := repo.GetAPIKey(context.Background(), "abc")
+			if (err != nil) != tc.isError {
+				t.Fatalf("expected error (%v), got error (%v)", tc.isError, err)
+			}
+
+			if tc.err != nil && err != tc.err {
+				t.Errorf("expected error (%v), got error (%v)", tc.err, err)
+			}
+
+			if !tc.isError && (k.Subject != "build-bot" || k.Tenant != "acme" || len(k.Roles) != 2 || k.Roles[1] != entities.RoleEditor) {
+				t.Errorf("expected key of (build-bot) with roles (viewer, editor), got key (%v)", k)
+			}
+		})
+	}
+}
+
+func TestValidListAPIKeys(t *testing.T) {
+	SqlOpen = MockOpener
+	repo, err := NewSqliteRepository("./test.db")
+	if err != nil {
+		t.Fatalf("unable to create mock repository: %s", err.Error())
+	}
+
+	dbMock.ExpectQuery("SELECT (.+) FROM api_keys ORDER BY id").WillReturnRows(
+		sqlmock.NewRows([]string{"id", "name", "subject", "roles", "tenantId", "hash", "createdAt", "revoked"}).
+			AddRow(1, "ci", "build-bot", "viewer,editor", "acme", "abc", 1600000000, false).
+			AddRow(2, "old", "old-bot", "", "default", "def", 1500000000, true))
+
+	kl, err := repo.ListAPIKeys(context.Background())
+	if err != nil {
+		t.Fatalf("unable to list api keys: %s", err.Error())
+	}
+
+	if len(kl) != 2 || !kl[1].Revoked || kl[1].Roles != nil {
+		t.Errorf("expected two keys with the second revoked, got keys (%v)", kl)
+	}
+}
+
+func TestRevokeAPIKey(t *testing.T) {
+	SqlOpen = MockOpener
+	repo, err := NewSqliteRepository("./test.db")
+	if err != nil {
+		t.Fatalf("unable to create mock repository: %s", err.Error())
+	}
+
+	testCases := []struct {
+		name     string
+		affected int64
+		err      error
+	}{{
+		name:     "existing key",
+		affected: 1,
+	}, {
+		name:     "missing key",
+		affected: 0,
+		err:      APIKeyNotFoundError,
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dbMock.ExpectExec("UPDATE api_keys SET revoked = 1").WithArgs(int64(1)).
+				WillReturnResult(sqlmock.NewResult(0, tc.affected))
+
+			if err := repo.RevokeAPIKey(context.Background(), 1); err != tc.err {
+				t.Errorf("expected error (%v), got error (%v)", tc.err, err)
+			}
+		})
+	}
+}
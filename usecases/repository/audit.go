@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"github.com/norby7/questions-rest-api/entities"
+	"strings"
+	"time"
+)
+
+// AppendAudit adds an entry to the audit log of the tenant
+func (r *SqliteRepository) AppendAudit(ctx context.Context, e entities.AuditEntry) error {
+	tenant, err := TenantFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.conn(ctx).ExecContext(ctx, `INSERT INTO audit_log (tenantId, time, actor, requestId, action, questionId, diff) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		tenant, e.Time.UnixNano(), e.Actor, e.RequestId, string(e.Action), e.QuestionId, string(e.Diff))
+	if err != nil {
+		return fmt.Errorf("unable to insert audit entry: %s", err.Error())
+	}
+
+	return nil
+}
+
+// ListAudit returns the audit entries of the tenant matching the filter, ordered by id
+func (r *SqliteRepository) ListAudit(ctx context.Context, f entities.AuditFilter) ([]entities.AuditEntry, error) {
+	tenant, err := TenantFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	conditions := []string{"tenantId = ?"}
+	args := []interface{}{tenant}
+
+	if f.QuestionId != 0 {
+		conditions = append(conditions, "questionId = ?")
+		args = append(args, f.QuestionId)
+	}
+
+	if f.Actor != "" {
+		conditions = append(conditions, "actor = ?")
+		args = append(args, f.Actor)
+	}
+
+	if !f.Since.IsZero() {
+		conditions = append(conditions, "time >= ?")
+		args = append(args, f.Since.UnixNano())
+	}
+
+	if f.AfterId != 0 {
+		conditions = append(conditions, "id > ?")
+		args = append(args, f.AfterId)
+	}
+
+	query := fmt.Sprintf(`SELECT id, tenantId, time, actor, requestId, action, questionId, diff FROM audit_log WHERE %s ORDER BY id`,
+		strings.Join(conditions, " AND "))
+
+	if f.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, f.Limit)
+	}
+
+	rows, err := r.conn(ctx).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("unable to query audit log: %s", err.Error())
+	}
+	defer rows.Close()
+
+	el := []entities.AuditEntry{}
+	for rows.Next() {
+		var e entities.AuditEntry
+		var t int64
+		var action, diff string
+
+		if err = rows.Scan(&e.Id, &e.Tenant, &t, &e.Actor, &e.RequestId, &action, &e.QuestionId, &diff); err != nil {
+			return nil, fmt.Errorf("unable to scan audit entry row: %s", err.Error())
+		}
+
+		e.Time = time.Unix(0, t).UTC()
+		e.Action = entities.AuditAction(action)
+		e.Diff = []byte(diff)
+
+		el = append(el, e)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("unable to read audit entries: %s", err.Error())
+	}
+
+	return el, nil
+}
@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/norby7/questions-rest-api/entities"
+	"testing"
+	"time"
+)
+
+func TestAuditLog(t *testing.T) {
+	repo := newTenantRepository(t)
+	acme := NewTenantContext(context.Background(), "acme")
+	globex := NewTenantContext(context.Background(), "globex")
+
+	start := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+	entries := []entities.AuditEntry{
+		{Time: start, Actor: "alice", RequestId: "r1", Action: entities.AuditCreate, QuestionId: 1, Diff: json.RawMessage(`[{"op":"add","path":"","new":{"id":1}}]`)},
+		{Time: start.Add(time.Hour), Actor: "bob", RequestId: "r2", Action: entities.AuditUpdate, QuestionId: 1, Diff: json.RawMessage(`[]`)},
+		{Time: start.Add(2 * time.Hour), Actor: "alice", RequestId: "r3", Action: entities.AuditDelete, QuestionId: 2, Diff: json.RawMessage(`[]`)},
+	}
+
+	for _, e := range entries {
+		if err := repo.AppendAudit(acme, e); err != nil {
+			t.Fatalf("unable to append audit entry: %s", err.Error())
+		}
+	}
+
+	if err := repo.AppendAudit(globex, entities.AuditEntry{Time: start, Actor: "alice", Action: entities.AuditCreate, QuestionId: 1}); err != nil {
+		t.Fatalf("unable to append audit entry: %s", err.Error())
+	}
+
+	testCases := []struct {
+		name     string
+		ctx      context.Context
+		filter   entities.AuditFilter
+		expected []string
+	}{{
+		name:     "all entries",
+		ctx:      acme,
+		expected: []string{"r1", "r2", "r3"},
+	}, {
+		name:     "question",
+		ctx:      acme,
+		filter:   entities.AuditFilter{QuestionId: 1},
+		expected: []string{"r1", "r2"},
+	}, {
+		name:     "actor",
+		ctx:      acme,
+		filter:   entities.AuditFilter{Actor: "alice"},
+		expected: []string{"r1", "r3"},
+	}, {
+		name:     "since",
+		ctx:      acme,
+		filter:   entities.AuditFilter{Since: start.Add(time.Hour)},
+		expected: []string{"r2", "r3"},
+	}, {
+		name:     "page",
+		ctx:      acme,
+		filter:   entities.AuditFilter{AfterId: 1, Limit: 1},
+		expected: []string{"r2"},
+	}, {
+		name:     "other tenant",
+		ctx:      globex,
+		filter:   entities.AuditFilter{Actor: "bob"},
+		expected: []string{},
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			el, err := repo.ListAudit(tc.ctx, tc.filter)
+			if err != nil {
+				t.Fatalf("unable to list audit entries: %s", err.Error())
+			}
+
+			requests := []string{}
+			for _, e := range el {
+				requests = append(requests, e.RequestId)
+			}
+
+			if len(requests) != len(tc.expected) {
+				t.Fatalf("expected requests (%v), got requests (%v)", tc.expected, requests)
+			}
+
+			for i := range requests {
+				if requests[i] != tc.expected[i] {
+					t.Errorf("expected requests (%v), got requests (%v)", tc.expected, requests)
+				}
+			}
+		})
+	}
+
+	el, err := repo.ListAudit(acme, entities.AuditFilter{Limit: 1})
+	if err != nil {
+		t.Fatalf("unable to list audit entries: %s", err.Error())
+	}
+
+	if !el[0].Time.Equal(start) || el[0].Actor != "alice" || el[0].Action != entities.AuditCreate || string(el[0].Diff) != string(entries[0].Diff) {
+		t.Errorf("expected entry (%v), got entry (%v)", entries[0], el[0])
+	}
+}
+
+func TestAuditLogAppendOnly(t *testing.T) {
+	repo := newTenantRepository(t)
+	acme := NewTenantContext(context.Background(), "acme")
+
+	if err := repo.AppendAudit(acme, entities.AuditEntry{Time: time.Now(), Action: entities.AuditCreate, QuestionId: 1}); err != nil {
+		t.Fatalf("unable to append audit entry: %s", err.Error())
+	}
+
+	if _, err := repo.Handler.Exec(`UPDATE audit_log SET actor = 'mallory'`); err == nil {
+		t.Errorf("expected an error updating the audit log")
+	}
+
+	if _, err := repo.Handler.Exec(`DELETE FROM audit_log`); err == nil {
+		t.Errorf("expected an error deleting the audit log")
+	}
+}
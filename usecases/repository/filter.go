@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"fmt"
+	"github.com/norby7/questions-rest-api/entities"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// sortColumn is the questions column holding the value of a sort key
+type sortColumn struct {
+	name string
+	// the column holds integers, the cursor values are converted before being compared
+	numeric bool
+}
+
+// sortColumns maps the sort keys to their column
+// Only these column names are written in the query, every value given by the filter is a parameter.
+var sortColumns = map[entities.QuestionSortKey]sortColumn{
+	entities.SortById:         {name: "id", numeric: true},
+	entities.SortByBody:       {name: "body"},
+	entities.SortByCreatedAt:  {name: "createdAt", numeric: true},
+	entities.SortByUpdatedAt:  {name: "updatedAt", numeric: true},
+	entities.SortByDifficulty: {name: "difficulty", numeric: true},
+}
+
+// optionCount is the number of options of the question of the current row
+const optionCount = `(SELECT count(*) FROM options o WHERE o.questionId = questions.id)`
+
+// correctCount is the number of correct options of the question of the current row
+const correctCount = `(SELECT count(*) FROM options o WHERE o.questionId = questions.id AND o.correct)`
+
+// questionsQuery returns the query selecting the questions of the tenant matching the filter, with its arguments
+// The questions are ordered by the sort key and then by id, so that the cursor of the last question seeks the next page.
+func questionsQuery(tenant string, f entities.QuestionFilter) (string, []interface{}, error) {
+	if f.Sort == "" {
+		f.Sort = entities.SortById
+	}
+
+	if f.Direction == "" {
+		f.Direction = entities.Descending
+	}
+
+	column, ok := sortColumns[f.Sort]
+	if !ok {
+		return "", nil, fmt.Errorf("%w: %s", entities.QuestionSortError, f.Sort)
+	}
+
+	err := f.Validate()
+	if err != nil {
+		return "", nil, err
+	}
+
+	conditions := []string{"tenantId = ?"}
+	args := []interface{}{tenant}
+
+	if f.MinOptions != 0 {
+		conditions = append(conditions, optionCount+" >= ?")
+		args = append(args, f.MinOptions)
+	}
+
+	if f.MaxOptions != 0 {
+		conditions = append(conditions, optionCount+" <= ?")
+		args = append(args, f.MaxOptions)
+	}
+
+	for _, t := range []struct {
+		condition string
+		value     time.Time
+	}{
+		{"createdAt >= ?", f.CreatedAfter},
+		{"createdAt < ?", f.CreatedBefore},
+		{"updatedAt >= ?", f.UpdatedAfter},
+		{"updatedAt < ?", f.UpdatedBefore},
+	} {
+		if !t.value.IsZero() {
+			conditions = append(conditions, t.condition)
+			args = append(args, t.value.UnixNano())
+		}
+	}
+
+	if f.Status != "" {
+		conditions = append(conditions, "status = ?")
+		args = append(args, f.Status)
+	}
+
+	if f.Author != "" {
+		conditions = append(conditions, "createdBy = ?")
+		args = append(args, f.Author)
+	}
+
+	if f.UpdatedBy != "" {
+		conditions = append(conditions, "updatedBy = ?")
+		args = append(args, f.UpdatedBy)
+	}
+
+	switch f.Type {
+	case entities.SingleChoice:
+		conditions = append(conditions, correctCount+" <= 1")
+	case entities.MultipleChoice:
+		conditions = append(conditions, correctCount+" > 1")
+	}
+
+	op, order := ">", "ASC"
+	if f.Direction == entities.Descending {
+		op, order = "<", "DESC"
+	}
+
+	if f.After != nil {
+		if f.Sort == entities.SortById {
+			conditions = append(conditions, fmt.Sprintf("id %s ?", op))
+			args = append(args, f.After.Id)
+		} else {
+			var value interface{} = f.After.Value
+			if column.numeric {
+				if value, err = strconv.ParseInt(f.After.Value, 10, 64); err != nil {
+					return "", nil, fmt.Errorf("%w: invalid cursor value %q", entities.QuestionFilterError, f.After.Value)
+				}
+			}
+
+			conditions = append(conditions, fmt.Sprintf("(%s, id) %s (?, ?)", column.name, op))
+			args = append(args, value, f.After.Id)
+		}
+	}
+
+	query := fmt.Sprintf(`SELECT `+questionColumns+` FROM questions WHERE %s ORDER BY `, strings.Join(conditions, " AND "))
+	if f.Sort != entities.SortById {
+		query += fmt.Sprintf("%s %s, ", column.name, order)
+	}
+	query += fmt.Sprintf("id %s", order)
+
+	if f.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, f.Limit)
+	}
+
+	return query, args, nil
+}
@@ -0,0 +1,272 @@
+package repository
+
+import (
+	"errors"
+	"github.com/norby7/questions-rest-api/entities"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestGetAllFilter(t *testing.T) {
+	repo := newTenantRepository(t)
+
+	questions := []entities.Question{
+		tenantQuestion("Where does the sun set?"),
+		tenantQuestion("Which planets are gas giants?"),
+		tenantQuestion("Where does the sun rise?"),
+		tenantQuestion("Where does the sun set?"),
+	}
+	questions[1].Options = []entities.Option{{Body: "Jupiter", Correct: true}, {Body: "Mars"}, {Body: "Saturn", Correct: true}}
+	questions[2].Options = append(questions[2].Options, entities.Option{Body: "North"})
+	questions[0].Difficulty, questions[1].Difficulty, questions[3].Difficulty = 3, 5, 3
+
+	ids, err := repo.AddAll(tenantCtx, questions)
+	if err != nil {
+		t.Fatalf("unable to add questions: %s", err.Error())
+	}
+
+	testCases := []struct {
+		name     string
+		filter   entities.QuestionFilter
+		expected []int64
+	}{{
+		name:     "default order",
+		filter:   entities.QuestionFilter{},
+		expected: []int64{ids[3], ids[2], ids[1], ids[0]},
+	}, {
+		name:     "id ascending",
+		filter:   entities.QuestionFilter{Sort: entities.SortById, Direction: entities.Ascending},
+		expected: []int64{ids[0], ids[1], ids[2], ids[3]},
+	}, {
+		name:     "body ascending, same bodies by id",
+		filter:   entities.QuestionFilter{Sort: entities.SortByBody, Direction: entities.Ascending},
+		expected: []int64{ids[2], ids[0], ids[3], ids[1]},
+	}, {
+		name:     "body descending, same bodies by id",
+		filter:   entities.QuestionFilter{Sort: entities.SortByBody, Direction: entities.Descending},
+		expected: []int64{ids[1], ids[3], ids[0], ids[2]},
+	}, {
+		name:     "body after a cursor",
+		filter:   entities.QuestionFilter{Sort: entities.SortByBody, Direction: entities.Ascending, After: &entities.QuestionCursor{Value: "Where does the sun set?", Id: ids[0]}},
+		expected: []int64{ids[3], ids[1]},
+	}, {
+		name:     "difficulty descending, same difficulties by id",
+		filter:   entities.QuestionFilter{Sort: entities.SortByDifficulty, Direction: entities.Descending},
+		expected: []int64{ids[1], ids[3], ids[0], ids[2]},
+	}, {
+		name:     "difficulty after a cursor",
+		filter:   entities.QuestionFilter{Sort: entities.SortByDifficulty, Direction: entities.Ascending, After: &entities.QuestionCursor{Value: "3", Id: ids[0]}},
+		expected: []int64{ids[3], ids[1]},
+	}, {
+		name:     "minimum options",
+		filter:   entities.QuestionFilter{MinOptions: 3},
+		expected: []int64{ids[2], ids[1]},
+	}, {
+		name:     "maximum options",
+		filter:   entities.QuestionFilter{MaxOptions: 2, Sort: entities.SortById, Direction: entities.Ascending},
+		expected: []int64{ids[0], ids[3]},
+	}, {
+		name:     "multiple choice",
+		filter:   entities.QuestionFilter{Type: entities.MultipleChoice},
+		expected: []int64{ids[1]},
+	}, {
+		name:     "single choice with three options",
+		filter:   entities.QuestionFilter{Type: entities.SingleChoice, MinOptions: 3},
+		expected: []int64{ids[2]},
+	}, {
+		name:     "limit",
+		filter:   entities.QuestionFilter{Limit: 2},
+		expected: []int64{ids[3], ids[2]},
+	}, {
+		name:     "quotes are values",
+		filter:   entities.QuestionFilter{Sort: entities.SortByBody, Direction: entities.Ascending, After: &entities.QuestionCursor{Value: "' OR 1=1 --", Id: 1}},
+		expected: []int64{ids[2], ids[0], ids[3], ids[1]},
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ql, err := repo.GetAll(tenantCtx, tc.filter)
+			if err != nil {
+				t.Fatalf("unable to list questions: %s", err.Error())
+			}
+
+			got := []int64{}
+			for _, q := range ql {
+				got = append(got, q.Id)
+			}
+
+			if !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("expected questions (%v), got questions (%v)", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestGetAllHistoryFilter(t *testing.T) {
+	repo := newTenantRepository(t)
+	defer func() { Now = func() time.Time { return testTime } }()
+
+	start := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+	ids := []int64{}
+	for i, actor := range []string{"alice", "bob", "alice"} {
+		Now = func() time.Time { return start.Add(time.Duration(i) * time.Hour) }
+		id, err := repo.Add(NewActorContext(tenantCtx, actor), tenantQuestion("Where does the sun set?"))
+		if err != nil {
+			t.Fatalf("unable to add question: %s", err.Error())
+		}
+		ids = append(ids, id)
+	}
+
+	// bob updates the first question last
+	Now = func() time.Time { return start.Add(3 * time.Hour) }
+	q, err := repo.Get(tenantCtx, ids[0])
+	if err != nil {
+		t.Fatalf("unable to get question: %s", err.Error())
+	}
+	q.Body = "Where does the sun rise?"
+	if err = repo.Update(NewActorContext(tenantCtx, "bob"), q); err != nil {
+		t.Fatalf("unable to update question: %s", err.Error())
+	}
+
+	testCases := []struct {
+		name     string
+		filter   entities.QuestionFilter
+		expected []int64
+	}{{
+		name:     "created after",
+		filter:   entities.QuestionFilter{CreatedAfter: start.Add(time.Hour)},
+		expected: []int64{ids[2], ids[1]},
+	}, {
+		name:     "created before",
+		filter:   entities.QuestionFilter{CreatedBefore: start.Add(time.Hour)},
+		expected: []int64{ids[0]},
+	}, {
+		name:     "updated range",
+		filter:   entities.QuestionFilter{UpdatedAfter: start.Add(30 * time.Minute), UpdatedBefore: start.Add(150 * time.Minute)},
+		expected: []int64{ids[2], ids[1]},
+	}, {
+		name:     "author",
+		filter:   entities.QuestionFilter{Author: "alice"},
+		expected: []int64{ids[2], ids[0]},
+	}, {
+		name:     "updated by",
+		filter:   entities.QuestionFilter{UpdatedBy: "bob"},
+		expected: []int64{ids[1], ids[0]},
+	}, {
+		name:     "updated at ascending",
+		filter:   entities.QuestionFilter{Sort: entities.SortByUpdatedAt, Direction: entities.Ascending},
+		expected: []int64{ids[1], ids[2], ids[0]},
+	}, {
+		name:     "created at after a cursor",
+		filter:   entities.QuestionFilter{Sort: entities.SortByCreatedAt, Direction: entities.Descending, After: &entities.QuestionCursor{Value: "1614603600000000000", Id: ids[1]}},
+		expected: []int64{ids[0]},
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ql, err := repo.GetAll(tenantCtx, tc.filter)
+			if err != nil {
+				t.Fatalf("unable to list questions: %s", err.Error())
+			}
+
+			got := []int64{}
+			for _, q := range ql {
+				got = append(got, q.Id)
+			}
+
+			if !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("expected questions (%v), got questions (%v)", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestGetAllSeekPagination(t *testing.T) {
+	repo := newTenantRepository(t)
+
+	bodies := []string{"Which is the largest ocean?", "Where does the sun set?", "What is the capital of Peru?", "Where does the sun set?", "Who wrote Hamlet?"}
+	questions := []entities.Question{}
+	for i, b := range bodies {
+		q := tenantQuestion(b)
+		q.Difficulty = i % 3
+		questions = append(questions, q)
+	}
+
+	if _, err := repo.AddAll(tenantCtx, questions); err != nil {
+		t.Fatalf("unable to add questions: %s", err.Error())
+	}
+
+	for _, key := range entities.QuestionSortKeys {
+		for _, dir := range []entities.SortDirection{entities.Ascending, entities.Descending} {
+			t.Run(string(key)+" "+string(dir), func(t *testing.T) {
+				f := entities.QuestionFilter{Sort: key, Direction: dir}
+
+				all, err := repo.GetAll(tenantCtx, f)
+				if err != nil {
+					t.Fatalf("unable to list questions: %s", err.Error())
+				}
+
+				// pages of two questions, each one seeking after the last question of the previous page
+				f.Limit = 2
+				paged := []entities.Question{}
+				for {
+					page, err := repo.GetAll(tenantCtx, f)
+					if err != nil {
+						t.Fatalf("unable to list questions: %s", err.Error())
+					}
+
+					paged = append(paged, page...)
+					if len(page) < f.Limit {
+						break
+					}
+
+					c := f.CursorOf(page[len(page)-1])
+					f.After = &c
+				}
+
+				if !reflect.DeepEqual(paged, all) {
+					t.Errorf("expected the pages to hold questions (%v), got questions (%v)", all, paged)
+				}
+			})
+		}
+	}
+}
+
+func TestGetAllInvalidFilter(t *testing.T) {
+	repo := newTenantRepository(t)
+
+	testCases := []struct {
+		name     string
+		filter   entities.QuestionFilter
+		expected error
+	}{{
+		name:     "unknown sort key",
+		filter:   entities.QuestionFilter{Sort: "body; DROP TABLE questions"},
+		expected: entities.QuestionSortError,
+	}, {
+		name:     "unknown type",
+		filter:   entities.QuestionFilter{Type: "essay"},
+		expected: entities.QuestionFilterError,
+	}, {
+		name:     "negative option count",
+		filter:   entities.QuestionFilter{MinOptions: -1},
+		expected: entities.QuestionFilterError,
+	}, {
+		name:     "inverted time range",
+		filter:   entities.QuestionFilter{CreatedAfter: time.Date(2021, 3, 2, 0, 0, 0, 0, time.UTC), CreatedBefore: time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC)},
+		expected: entities.QuestionFilterError,
+	}, {
+		name:     "invalid time cursor",
+		filter:   entities.QuestionFilter{Sort: entities.SortByCreatedAt, After: &entities.QuestionCursor{Value: "yesterday", Id: 1}},
+		expected: entities.QuestionFilterError,
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := repo.GetAll(tenantCtx, tc.filter); !errors.Is(err, tc.expected) {
+				t.Errorf("expected error (%v), got error (%v)", tc.expected, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,29 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+)
+
+// Ping checks that the database can be reached
+func (r *SqliteRepository) Ping(ctx context.Context) error {
+	if err := r.Handler.PingContext(ctx); err != nil {
+		return fmt.Errorf("unable to reach database: %s", err.Error())
+	}
+
+	return nil
+}
+
+// CheckSchema checks that the database schema is at the expected version, it fails with SchemaVersionError otherwise
+func (r *SqliteRepository) CheckSchema(ctx context.Context, expected int) error {
+	var v int
+	if err := r.Handler.QueryRowContext(ctx, `PRAGMA user_version`).Scan(&v); err != nil {
+		return fmt.Errorf("unable to read schema version: %s", err.Error())
+	}
+
+	if v != expected {
+		return fmt.Errorf("%w: version %d, expected %d", SchemaVersionError, v, expected)
+	}
+
+	return nil
+}
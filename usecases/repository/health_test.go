@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestPing(t *testing.T) {
+	repo := newTenantRepository(t)
+
+	if err := repo.Ping(context.Background()); err != nil {
+		t.Errorf("expected a reachable database, got error (%v)", err)
+	}
+
+	_ = repo.Handler.Close()
+
+	if err := repo.Ping(context.Background()); err == nil {
+		t.Errorf("expected an error pinging a closed database")
+	}
+}
+
+func TestCheckSchema(t *testing.T) {
+	repo := newTenantRepository(t)
+
+	dir := MigrationsDir
+	MigrationsDir = "../../database/migrations"
+	latest, err := LatestSchemaVersion()
+	MigrationsDir = dir
+
+	if err != nil {
+		t.Fatalf("unable to read latest schema version: %s", err.Error())
+	}
+
+	testCases := []struct {
+		name     string
+		expected int
+		isError  bool
+	}{{
+		name:     "latest version",
+		expected: latest,
+	}, {
+		name:     "pending migration",
+		expected: latest + 1,
+		isError:  true,
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := repo.CheckSchema(context.Background(), tc.expected)
+			if (err != nil) != tc.isError {
+				t.Fatalf("expected error (%v), got error (%v)", tc.isError, err)
+			}
+
+			if tc.isError && !errors.Is(err, SchemaVersionError) {
+				t.Errorf("expected error (%v), got error (%v)", SchemaVersionError, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// initialSchemaVersion is the schema version created by the schema.sql script
+const initialSchemaVersion = 1
+
+var (
+	MigrationsDir = "./database/migrations" // directory containing the NNNN_name.sql migration scripts
+)
+
+// migration is a sql script that upgrades the database schema to the given version
+type migration struct {
+	version int
+	path    string
+}
+
+// migrations returns the migration scripts found in MigrationsDir ordered by version
+func migrations() ([]migration, error) {
+	files, err := filepath.Glob(filepath.Join(MigrationsDir, "*.sql"))
+	if err != nil {
+		return nil, fmt.Errorf("unable to list migration scripts: %s", err.Error())
+	}
+
+	var ml []migration
+	for _, f := range files {
+		prefix := strings.SplitN(filepath.Base(f), "_", 2)[0]
+
+		v, err := strconv.Atoi(prefix)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration script name %s: %s", f, err.Error())
+		}
+
+		ml = append(ml, migration{version: v, path: f})
+	}
+
+	sort.Slice(ml, func(i, j int) bool {
+		return ml[i].version < ml[j].version
+	})
+
+	return ml, nil
+}
+
+// SchemaVersion returns the version of the database schema, stored in the sqlite user_version pragma
+func SchemaVersion(db *sql.DB) (int, error) {
+	var v int
+	if err := db.QueryRow(`PRAGMA user_version`).Scan(&v); err != nil {
+		return 0, fmt.Errorf("unable to read schema version: %s", err.Error())
+	}
+
+	return v, nil
+}
+
+// LatestSchemaVersion returns the schema version the available migration scripts upgrade the database to
+func LatestSchemaVersion() (int, error) {
+	ml, err := migrations()
+	if err != nil {
+		return 0, err
+	}
+
+	if len(ml) == 0 {
+		return initialSchemaVersion, nil
+	}
+
+	return ml[len(ml)-1].version, nil
+}
+
+// migrate applies, in order and each one in its own transaction, the migration scripts newer than the schema version
+func migrate(db *sql.DB) error {
+	version, err := SchemaVersion(db)
+	if err != nil {
+		return err
+	}
+
+	// databases created before the schema was versioned hold the initial schema
+	if version < initialSchemaVersion {
+		version = initialSchemaVersion
+	}
+
+	ml, err := migrations()
+	if err != nil {
+		return err
+	}
+
+	for _, m := range ml {
+		if m.version <= version {
+			continue
+		}
+
+		c, err := ioutil.ReadFile(m.path)
+		if err != nil {
+			return fmt.Errorf("unable to open migration script: %s", err.Error())
+		}
+
+		tx, err := db.Begin()
+		if err != nil {
+			return fmt.Errorf("unable to start transaction: %s", err.Error())
+		}
+
+		if _, err = tx.Exec(string(c)); err != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("unable to execute migration %s: %s", filepath.Base(m.path), err.Error())
+		}
+
+		if _, err = tx.Exec(fmt.Sprintf(`PRAGMA user_version = %d`, m.version)); err != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("unable to update schema version: %s", err.Error())
+		}
+
+		if err = tx.Commit(); err != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("unable to commit transation: %s", err.Error())
+		}
+
+		version = m.version
+	}
+
+	if _, err = db.Exec(fmt.Sprintf(`PRAGMA user_version = %d`, version)); err != nil {
+		return fmt.Errorf("unable to update schema version: %s", err.Error())
+	}
+
+	return nil
+}
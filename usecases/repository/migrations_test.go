@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"github.com/DATA-DOG/go-sqlmock"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+// writeMigrations creates the given migration scripts in a temporary MigrationsDir
+func writeMigrations(t *testing.T, names ...string) {
+	MigrationsDir = t.TempDir()
+
+	for _, n := range names {
+		if err := ioutil.WriteFile(filepath.Join(MigrationsDir, n), []byte("create table t (id integer);"), 0644); err != nil {
+			t.Fatalf("unable to write migration script: %s", err.Error())
+		}
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	SqlOpen = MockOpener
+	repo, err := NewSqliteRepository("./test.db")
+	if err != nil {
+		t.Fatalf("unable to create mock repository: %s", err.Error())
+	}
+
+	writeMigrations(t, "0003_third.sql", "0002_second.sql")
+
+	// an unversioned database holds the initial schema, both migrations are applied in order
+	dbMock.ExpectQuery("PRAGMA user_version").WillReturnRows(sqlmock.NewRows([]string{"user_version"}).AddRow(0))
+	for _, v := range []string{"2", "3"} {
+		dbMock.ExpectBegin()
+		dbMock.ExpectExec("create table t").WillReturnResult(sqlmock.NewResult(0, 0))
+		dbMock.ExpectExec("PRAGMA user_version = " + v).WillReturnResult(sqlmock.NewResult(0, 0))
+		dbMock.ExpectCommit()
+	}
+	dbMock.ExpectExec("PRAGMA user_version = 3").WillReturnResult(sqlmock.NewResult(0, 0))
+
+	if err = migrate(repo.Handler); err != nil {
+		t.Fatalf("unable to migrate: %s", err.Error())
+	}
+
+	// an up to date database isn't changed
+	dbMock.ExpectQuery("PRAGMA user_version").WillReturnRows(sqlmock.NewRows([]string{"user_version"}).AddRow(3))
+	dbMock.ExpectExec("PRAGMA user_version = 3").WillReturnResult(sqlmock.NewResult(0, 0))
+
+	if err = migrate(repo.Handler); err != nil {
+		t.Fatalf("unable to migrate: %s", err.Error())
+	}
+
+	if err = dbMock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err.Error())
+	}
+}
+
+func TestFailedMigrate(t *testing.T) {
+	SqlOpen = MockOpener
+	repo, err := NewSqliteRepository("./test.db")
+	if err != nil {
+		t.Fatalf("unable to create mock repository: %s", err.Error())
+	}
+
+	writeMigrations(t, "0002_second.sql")
+
+	dbMock.ExpectQuery("PRAGMA user_version").WillReturnRows(sqlmock.NewRows([]string{"user_version"}).AddRow(1))
+	dbMock.ExpectBegin()
+	dbMock.ExpectExec("create table t").WillReturnError(sqlmock.ErrCancelled)
+	dbMock.ExpectRollback()
+
+	if err = migrate(repo.Handler); err == nil {
+		t.Errorf("expected migration error")
+	}
+
+	if err = dbMock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err.Error())
+	}
+}
+
+func TestLatestSchemaVersion(t *testing.T) {
+	writeMigrations(t)
+
+	if v, err := LatestSchemaVersion(); err != nil || v != initialSchemaVersion {
+		t.Errorf("expected version (%d), got version (%d) and error (%v)", initialSchemaVersion, v, err)
+	}
+
+	writeMigrations(t, "0002_second.sql", "0007_seventh.sql")
+
+	if v, err := LatestSchemaVersion(); err != nil || v != 7 {
+		t.Errorf("expected version (7), got version (%d) and error (%v)", v, err)
+	}
+
+	writeMigrations(t, "second.sql")
+
+	if _, err := LatestSchemaVersion(); err == nil {
+		t.Errorf("expected error for invalid script name")
+	}
+}
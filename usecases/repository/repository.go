@@ -1,10 +1,81 @@
 package repository
 
-import "github.com/norby7/questions-rest-api/entities"
+import (
+	"context"
+	"fmt"
+	"github.com/norby7/questions-rest-api/entities"
+)
 
+// OperationType identifies the kind of write performed by an Operation
+type OperationType string
+
+const (
+	OperationCreate OperationType = "create"
+	OperationUpdate OperationType = "update"
+	OperationDelete OperationType = "delete"
+	OperationTag    OperationType = "tag"
+)
+
+var (
+	QuestionNotFoundError = fmt.Errorf("question not found")
+	OptionNotFoundError   = fmt.Errorf("option not found")
+	APIKeyNotFoundError   = fmt.Errorf("api key not found")
+	SchemaVersionError    = fmt.Errorf("database schema version doesn't match the migration scripts")
+)
+
+// Operation is a single write executed by Batch
+// Create and update operations use Question, update, delete and tag operations use the question Id.
+// Tag operations add the AddTags to the question and remove the RemoveTags from it.
+type Operation struct {
+	Type       OperationType
+	Id         int64
+	Question   entities.Question
+	AddTags    []string
+	RemoveTags []string
+}
+
+// BatchError is returned by Batch when one of the operations fails
+type BatchError struct {
+	// position of the failing operation
+	Index int
+	Err   error
+}
+
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("operation %d: %s", e.Index, e.Err.Error())
+}
+
+func (e *BatchError) Unwrap() error {
+	return e.Err
+}
+
+// Repository stores the questions of every tenant
+// Every operation is scoped to the tenant carried by the context, see NewTenantContext. The operations called with the
+// context of InTransaction, the audit log ones included, are applied together or not at all.
 type Repository interface {
-	Add(entities.Question) error
-	Update(entities.Question) error
-	Delete(int64) error
-	GetAll(int, int) ([]entities.Question, error)
+	Add(context.Context, entities.Question) (int64, error)
+	AddAll(context.Context, []entities.Question) ([]int64, error)
+	Update(context.Context, entities.Question) error
+	Delete(context.Context, int64) error
+	Get(context.Context, int64) (entities.Question, error)
+	GetAll(context.Context, entities.QuestionFilter) ([]entities.Question, error)
+	ForEach(context.Context, func(entities.Question) error) error
+	Batch(context.Context, []Operation) ([]int64, error)
+	SetStatus(ctx context.Context, id int64, from, to entities.QuestionStatus, comment string) error
+	InTransaction(ctx context.Context, fn func(context.Context) error) error
+}
+
+// AuditRepository stores the append-only audit log of the question changes
+// Entries are scoped to the tenant carried by the context, like the questions.
+type AuditRepository interface {
+	AppendAudit(context.Context, entities.AuditEntry) error
+	ListAudit(context.Context, entities.AuditFilter) ([]entities.AuditEntry, error)
+}
+
+// APIKeyRepository stores the issued api keys
+type APIKeyRepository interface {
+	AddAPIKey(context.Context, entities.APIKey) (int64, error)
+	GetAPIKey(context.Context, string) (entities.APIKey, error)
+	ListAPIKeys(context.Context) ([]entities.APIKey, error)
+	RevokeAPIKey(context.Context, int64) error
 }
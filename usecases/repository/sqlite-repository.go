@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
@@ -8,6 +9,9 @@ import (
 	"github.com/norby7/questions-rest-api/entities"
 	"io/ioutil"
 	"os"
+	"sort"
+	"strings"
+	"time"
 )
 
 type SqliteRepository struct {
@@ -16,12 +20,24 @@ type SqliteRepository struct {
 
 var (
 	SqlOpen = sql.Open // function that connects to a database and returns a connection handler
+	Now     = time.Now // function that returns the time recorded as the creation or update time of the questions
 )
 
+// questionTags is the comma separated list of the tags of the question of the current row, tags have no commas
+const questionTags = `(SELECT group_concat(t.tag) FROM tags t WHERE t.questionId = questions.id)`
+
+// questionColumns are the columns selected to read a question row, see scanQuestion
+const questionColumns = `id, body, tenantId, createdAt, createdBy, updatedAt, updatedBy, status, reviewComment, difficulty, ` + questionTags
+
+// insertQuestionStatement inserts a question row with its creation and update times and authors and its review status,
+// see insertQuestionArgs
+const insertQuestionStatement = `INSERT INTO questions (tenantId, body, createdAt, createdBy, updatedAt, updatedBy, status, reviewComment, difficulty)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
 // NewSqliteRepository connects to a sqlite database and returns a repository object that contains the database connection handler
 func NewSqliteRepository(p string) (*SqliteRepository, error) {
 
-	db, err := SqlOpen("sqlite3", p)
+	db, err := SqlOpen("sqlite3", immediateTransactions(p))
 	if err != nil {
 		return nil, fmt.Errorf("unable to open sqlite database: %s", err.Error())
 	}
@@ -41,6 +57,7 @@ func CreateDatabase(p string) error {
 }
 
 // ValidateSchema checks if the questions and options schemas exist and creates them if they don't exist
+// The pending migration scripts are then applied to bring the schema to the latest version.
 func ValidateSchema(db *sql.DB) error {
 	dbExists, err := schemaExists(db)
 	if err != nil {
@@ -54,6 +71,10 @@ func ValidateSchema(db *sql.DB) error {
 		}
 	}
 
+	if err = migrate(db); err != nil {
+		return fmt.Errorf("unable to migrate database schema: %s", err.Error())
+	}
+
 	return nil
 }
 
@@ -111,24 +132,22 @@ func initializeSchema(handler *sql.DB) error {
 	return nil
 }
 
-// addOptions inserts all options for a question
-func (r *SqliteRepository) addOptions(options []entities.Option, questionId int64) error {
+// addOptions inserts all options and tags for a question
+func (r *SqliteRepository) addOptions(ctx context.Context, q entities.Question, questionId int64) error {
 	// begin transaction
-	tx, err := r.Handler.Begin()
+	tx, err := r.begin(ctx)
 	if err != nil {
 		return fmt.Errorf("unable to start transaction: %s", err.Error())
 	}
 
-	for i, o := range options {
-		o.QuestionId = questionId
-		o.OptionOrder = i
+	if err = insertOptions(ctx, tx.Tx, q.Options, questionId, 0); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
 
-		// execute insert question statement
-		_, err = tx.Exec(`INSERT INTO options (questionId, body, correct, optionOrder) VALUES (?, ? , ?, ?)`, o.QuestionId, o.Body, o.Correct, o.OptionOrder)
-		if err != nil {
-			_ = tx.Rollback()
-			return fmt.Errorf("unable to execute insert option statement: %s", err.Error())
-		}
+	if err = insertTags(ctx, tx.Tx, entities.NormalizeTags(q.Tags), questionId); err != nil {
+		_ = tx.Rollback()
+		return err
 	}
 
 	// commit transaction
@@ -140,79 +159,103 @@ func (r *SqliteRepository) addOptions(options []entities.Option, questionId int6
 	return nil
 }
 
-// Add inserts a new question into the database and returns an error in case something went wrong
-func (r *SqliteRepository) Add(q entities.Question) error {
+// Add inserts a new question into the database and returns its id, or an error in case something went wrong
+func (r *SqliteRepository) Add(ctx context.Context, q entities.Question) (int64, error) {
+	tenant, err := TenantFromContext(ctx)
+	if err != nil {
+		return 0, err
+	}
+
 	// begin transaction
-	tx, err := r.Handler.Begin()
+	tx, err := r.begin(ctx)
 	if err != nil {
-		return fmt.Errorf("unable to start transaction: %s", err.Error())
+		return 0, fmt.Errorf("unable to start transaction: %s", err.Error())
 	}
 
 	// execute insert question statement
-	res, err := tx.Exec(`INSERT INTO questions (body) VALUES (?)`, q.Body)
+	res, err := tx.ExecContext(ctx, insertQuestionStatement, insertQuestionArgs(ctx, tenant, q)...)
 	if err != nil {
 		_ = tx.Rollback()
-		return fmt.Errorf("unable to execute insert question statement: %s", err.Error())
+		return 0, fmt.Errorf("unable to execute insert question statement: %s", err.Error())
 	}
 
 	// commit transaction
 	if err = tx.Commit(); err != nil {
 		_ = tx.Rollback()
-		return fmt.Errorf("unable to commit transation: %s", err.Error())
+		return 0, fmt.Errorf("unable to commit transation: %s", err.Error())
 	}
 
 	// get new question id
 	id, err := res.LastInsertId()
 	if err != nil {
-		return fmt.Errorf("unable to get last inserted id: %s", err.Error())
+		return 0, fmt.Errorf("unable to get last inserted id: %s", err.Error())
 	}
 
-	err = r.addOptions(q.Options, id)
+	err = r.addOptions(ctx, q, id)
 	if err != nil {
-		// if the options couldn't be inserted, then delete the new question
-		if err := r.Delete(id); err != nil {
-			return fmt.Errorf("unable to insert question options and to delete question: %s", err.Error())
+		// if the options couldn't be inserted, then delete the new question, even when ctx was canceled
+		if err := r.Delete(context.WithoutCancel(ctx), id); err != nil {
+			return 0, fmt.Errorf("unable to insert question options and to delete question: %s", err.Error())
 		}
 
-		return fmt.Errorf("unable to insert question options: %s", err.Error())
+		return 0, fmt.Errorf("unable to insert question options: %s", err.Error())
 	}
 
-	return nil
+	return id, nil
 }
 
-// Update inserts a new question into the database and returns an error in case something went wrong
-func (r *SqliteRepository) Update(q entities.Question) error {
-	// begin transaction
-	tx, err := r.Handler.Begin()
+// AddAll inserts all the given questions and their options in a single transaction, either all of them are stored or none
+// It returns the ids of the new questions, in the order of the slice.
+func (r *SqliteRepository) AddAll(ctx context.Context, ql []entities.Question) ([]int64, error) {
+	tenant, err := TenantFromContext(ctx)
 	if err != nil {
-		return fmt.Errorf("unable to start transaction: %s", err.Error())
+		return nil, err
 	}
 
-	// execute update question statement
-	_, err = tx.Exec(`UPDATE questions SET body = ? WHERE id = ?`, q.Body, q.Id)
+	// begin transaction
+	tx, err := r.begin(ctx)
 	if err != nil {
+		return nil, fmt.Errorf("unable to start transaction: %s", err.Error())
+	}
+
+	ids := make([]int64, len(ql))
+	for i, q := range ql {
+		if ids[i], err = insertQuestion(ctx, tx.Tx, tenant, q); err != nil {
+			_ = tx.Rollback()
+			return nil, err
+		}
+	}
+
+	// commit transaction
+	if err = tx.Commit(); err != nil {
 		_ = tx.Rollback()
-		return fmt.Errorf("unable to execute update question statement: %s", err.Error())
+		return nil, fmt.Errorf("unable to commit transation: %s", err.Error())
 	}
 
-	// delete old options
-	_, err = tx.Exec(`DELETE FROM options WHERE questionId = ?`, q.Id)
+	return ids, nil
+}
+
+// Update inserts a new question into the database and returns an error in case something went wrong
+// Updating a question that doesn't exist in the tenant bank fails with QuestionNotFoundError.
+func (r *SqliteRepository) Update(ctx context.Context, q entities.Question) error {
+	tenant, err := TenantFromContext(ctx)
 	if err != nil {
-		_ = tx.Rollback()
 		return err
 	}
 
-	// insert new options
-	for i, o := range q.Options {
-		o.QuestionId = q.Id
-		o.OptionOrder = i
+	// begin transaction
+	tx, err := r.begin(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to start transaction: %s", err.Error())
+	}
 
-		// execute insert question statement
-		_, err = tx.Exec(`INSERT INTO options (questionId, body, correct, optionOrder) VALUES (?, ? , ?, ?)`, o.QuestionId, o.Body, o.Correct, o.OptionOrder)
-		if err != nil {
-			_ = tx.Rollback()
-			return fmt.Errorf("unable to execute insert option statement: %s", err.Error())
-		}
+	found, err := updateQuestion(ctx, tx.Tx, tenant, q)
+	if err == nil && !found {
+		err = QuestionNotFoundError
+	}
+	if err != nil {
+		_ = tx.Rollback()
+		return err
 	}
 
 	// commit transaction
@@ -225,19 +268,22 @@ func (r *SqliteRepository) Update(q entities.Question) error {
 }
 
 // Delete removes a question from the database and all its options
-func (r *SqliteRepository) Delete(id int64) error {
-	tx, err := r.Handler.Begin()
+// Deleting a question that doesn't exist in the tenant bank fails with QuestionNotFoundError.
+func (r *SqliteRepository) Delete(ctx context.Context, id int64) error {
+	tenant, err := TenantFromContext(ctx)
 	if err != nil {
 		return err
 	}
 
-	_, err = tx.Exec(`DELETE FROM questions WHERE id = ?`, id)
+	tx, err := r.begin(ctx)
 	if err != nil {
-		_ = tx.Rollback()
 		return err
 	}
 
-	_, err = tx.Exec(`DELETE FROM options WHERE questionId = ?`, id)
+	found, err := deleteQuestion(ctx, tx.Tx, tenant, id)
+	if err == nil && !found {
+		err = QuestionNotFoundError
+	}
 	if err != nil {
 		_ = tx.Rollback()
 		return err
@@ -251,13 +297,394 @@ func (r *SqliteRepository) Delete(id int64) error {
 	return nil
 }
 
+// SetStatus moves the question with the given id from status from to status to, replacing its review comment
+// The change is recorded as an update of the question. Changing a question that doesn't exist in the tenant bank fails
+// with QuestionNotFoundError, and one whose status isn't from anymore with entities.StatusTransitionError.
+func (r *SqliteRepository) SetStatus(ctx context.Context, id int64, from, to entities.QuestionStatus, comment string) error {
+	tenant, err := TenantFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	tx, err := r.begin(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to start transaction: %s", err.Error())
+	}
+
+	var status entities.QuestionStatus
+	err = tx.QueryRowContext(ctx, `SELECT status FROM questions WHERE id = ? AND tenantId = ?`, id, tenant).Scan(&status)
+	if err == sql.ErrNoRows {
+		err = QuestionNotFoundError
+	} else if err != nil {
+		err = fmt.Errorf("unable to query database for question: %s", err.Error())
+	} else if status != from {
+		err = fmt.Errorf("%w: the question is %s, not %s", entities.StatusTransitionError, status, from)
+	}
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `UPDATE questions SET status = ?, reviewComment = ?, updatedAt = ?, updatedBy = ? WHERE id = ?`,
+		to, comment, Now().UnixNano(), ActorFromContext(ctx), id)
+	if err != nil {
+		_ = tx.Rollback()
+		return fmt.Errorf("unable to execute update status statement: %s", err.Error())
+	}
+
+	if err = tx.Commit(); err != nil {
+		_ = tx.Rollback()
+		return fmt.Errorf("unable to commit transation: %s", err.Error())
+	}
+
+	return nil
+}
+
+// Batch executes all the operations in a single transaction, if any of them fails none of them is applied
+// It returns the id of the question affected by each operation, the new id for create operations.
+// Updating, deleting or tagging a question that doesn't exist in the tenant bank fails with QuestionNotFoundError.
+func (r *SqliteRepository) Batch(ctx context.Context, ops []Operation) ([]int64, error) {
+	tenant, err := TenantFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// begin transaction
+	tx, err := r.begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to start transaction: %s", err.Error())
+	}
+
+	ids := make([]int64, len(ops))
+	for i, op := range ops {
+		found := true
+
+		switch op.Type {
+		case OperationCreate:
+			ids[i], err = insertQuestion(ctx, tx.Tx, tenant, op.Question)
+		case OperationUpdate:
+			op.Question.Id, ids[i] = op.Id, op.Id
+			found, err = updateQuestion(ctx, tx.Tx, tenant, op.Question)
+		case OperationDelete:
+			ids[i] = op.Id
+			found, err = deleteQuestion(ctx, tx.Tx, tenant, op.Id)
+		case OperationTag:
+			ids[i] = op.Id
+			found, err = tagQuestion(ctx, tx.Tx, tenant, op.Id, op.AddTags, op.RemoveTags)
+		default:
+			err = fmt.Errorf("unknown operation type %q", op.Type)
+		}
+
+		if err == nil && !found {
+			err = QuestionNotFoundError
+		}
+
+		if err != nil {
+			_ = tx.Rollback()
+			return nil, &BatchError{Index: i, Err: err}
+		}
+	}
+
+	// commit transaction
+	if err = tx.Commit(); err != nil {
+		_ = tx.Rollback()
+		return nil, fmt.Errorf("unable to commit transation: %s", err.Error())
+	}
+
+	return ids, nil
+}
+
+// insertQuestion inserts the question of the tenant and its options using the given transaction and returns the new question id
+func insertQuestion(ctx context.Context, tx *sql.Tx, tenant string, q entities.Question) (int64, error) {
+	// execute insert question statement
+	res, err := tx.ExecContext(ctx, insertQuestionStatement, insertQuestionArgs(ctx, tenant, q)...)
+	if err != nil {
+		return 0, fmt.Errorf("unable to execute insert question statement: %s", err.Error())
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("unable to get last inserted id: %s", err.Error())
+	}
+
+	if err = insertOptions(ctx, tx, q.Options, id, 0); err != nil {
+		return 0, err
+	}
+
+	if err = insertTags(ctx, tx, entities.NormalizeTags(q.Tags), id); err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// insertQuestionArgs returns the arguments of insertQuestionStatement for a question of the tenant
+// A question without creation or update time and author is created or updated now by the author of the request,
+// and a question without status is a draft. Imported questions keep the ones they were exported with.
+func insertQuestionArgs(ctx context.Context, tenant string, q entities.Question) []interface{} {
+	now, actor := Now(), ActorFromContext(ctx)
+
+	createdAt, updatedAt := now, now
+	if q.CreatedAt != nil {
+		createdAt = *q.CreatedAt
+	}
+	if q.UpdatedAt != nil {
+		updatedAt = *q.UpdatedAt
+	}
+
+	createdBy, updatedBy := actor, actor
+	if q.CreatedBy != "" {
+		createdBy = q.CreatedBy
+	}
+	if q.UpdatedBy != "" {
+		updatedBy = q.UpdatedBy
+	}
+
+	status := q.Status
+	if status == "" {
+		status = entities.Draft
+	}
+
+	return []interface{}{tenant, q.Body, createdAt.UnixNano(), createdBy, updatedAt.UnixNano(), updatedBy, status, q.ReviewComment, q.Difficulty}
+}
+
+// insertOptions inserts the options of a question using the given transaction, in the order of the slice
+// The order of the options starts at first.
+func insertOptions(ctx context.Context, tx *sql.Tx, options []entities.Option, questionId int64, first int) error {
+	for i, o := range options {
+		o.QuestionId = questionId
+		o.OptionOrder = first + i
+
+		// execute insert option statement
+		_, err := tx.ExecContext(ctx, `INSERT INTO options (questionId, body, correct, optionOrder) VALUES (?, ? , ?, ?)`, o.QuestionId, o.Body, o.Correct, o.OptionOrder)
+		if err != nil {
+			return fmt.Errorf("unable to execute insert option statement: %s", err.Error())
+		}
+	}
+
+	return nil
+}
+
+// insertTags adds the tags to the question using the given transaction, the tags it already has are kept once
+func insertTags(ctx context.Context, tx *sql.Tx, tags []string, questionId int64) error {
+	for _, t := range tags {
+		_, err := tx.ExecContext(ctx, `INSERT OR IGNORE INTO tags (questionId, tag) VALUES (?, ?)`, questionId, t)
+		if err != nil {
+			return fmt.Errorf("unable to execute insert tag statement: %s", err.Error())
+		}
+	}
+
+	return nil
+}
+
+// updateQuestion updates the question body, its difficulty, its options and its tags using the given transaction
+// Only the rows that differ are written: the options with an id are updated in place, the ones without are inserted
+// and the stored options missing from the question are deleted. The order of the options is their position in the slice.
+// The update time and author of the question are only changed when a row is written. A change of the body, the difficulty
+// or the options also moves the question to the status given by entities.QuestionStatus.Edited, without the review
+// comment when the status changes, the tags alone don't.
+// It returns false, without changing anything, if the question doesn't exist in the tenant bank, OptionNotFoundError
+// if an option id isn't one of the question options and entities.StatusTransitionError if the question can't be changed.
+func updateQuestion(ctx context.Context, tx *sql.Tx, tenant string, q entities.Question) (bool, error) {
+	// the options of another tenant question must not be replaced
+	var body, comment string
+	var difficulty int
+	var status entities.QuestionStatus
+	var tags sql.NullString
+	err := tx.QueryRowContext(ctx, `SELECT body, difficulty, status, reviewComment, `+questionTags+` FROM questions WHERE id = ? AND tenantId = ?`, q.Id, tenant).
+		Scan(&body, &difficulty, &status, &comment, &tags)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("unable to query database for question: %s", err.Error())
+	}
+
+	edited := body != q.Body || difficulty != q.Difficulty
+	tagged := false
+
+	// the tags are replaced when they differ
+	if newTags := entities.NormalizeTags(q.Tags); strings.Join(newTags, ",") != strings.Join(splitTags(tags), ",") {
+		if _, err = tx.ExecContext(ctx, `DELETE FROM tags WHERE questionId = ?`, q.Id); err != nil {
+			return false, fmt.Errorf("unable to execute delete tags statement: %s", err.Error())
+		}
+
+		if err = insertTags(ctx, tx, newTags, q.Id); err != nil {
+			return false, err
+		}
+		tagged = true
+	}
+
+	current, err := queryOptions(ctx, tx, q.Id)
+	if err != nil {
+		return false, err
+	}
+
+	stored := make(map[int64]entities.Option, len(current))
+	for _, c := range current {
+		stored[c.Id] = c
+	}
+
+	for i, o := range q.Options {
+		if o.Id == 0 {
+			// insert the new option
+			if err = insertOptions(ctx, tx, []entities.Option{o}, q.Id, i); err != nil {
+				return false, err
+			}
+			edited = true
+			continue
+		}
+
+		c, ok := stored[o.Id]
+		if !ok {
+			return false, fmt.Errorf("%w: %d", OptionNotFoundError, o.Id)
+		}
+		delete(stored, o.Id)
+
+		if c.Body == o.Body && c.Correct == o.Correct && c.OptionOrder == i {
+			continue
+		}
+
+		_, err = tx.ExecContext(ctx, `UPDATE options SET body = ?, correct = ?, optionOrder = ? WHERE id = ?`, o.Body, o.Correct, i, o.Id)
+		if err != nil {
+			return false, fmt.Errorf("unable to execute update option statement: %s", err.Error())
+		}
+		edited = true
+	}
+
+	// delete the options that were removed
+	for _, c := range current {
+		if _, ok := stored[c.Id]; !ok {
+			continue
+		}
+
+		if _, err = tx.ExecContext(ctx, `DELETE FROM options WHERE id = ?`, c.Id); err != nil {
+			return false, fmt.Errorf("unable to execute delete option statement: %s", err.Error())
+		}
+		edited = true
+	}
+
+	if edited {
+		to, err := status.Edited()
+		if err != nil {
+			return false, err
+		}
+
+		if to != status {
+			status, comment = to, ""
+		}
+	}
+
+	if tagged || edited {
+		// execute update question statement
+		_, err = tx.ExecContext(ctx, `UPDATE questions SET body = ?, difficulty = ?, status = ?, reviewComment = ?, updatedAt = ?, updatedBy = ? WHERE id = ?`,
+			q.Body, q.Difficulty, status, comment, Now().UnixNano(), ActorFromContext(ctx), q.Id)
+		if err != nil {
+			return false, fmt.Errorf("unable to execute update question statement: %s", err.Error())
+		}
+	}
+
+	return true, nil
+}
+
+// tagQuestion adds the tags add to the question and removes the tags remove from it, using the given transaction
+// The update time and author of the question are only changed when a tag is added or removed.
+// It returns false, without changing anything, if the question doesn't exist in the tenant bank.
+func tagQuestion(ctx context.Context, tx *sql.Tx, tenant string, id int64, add, remove []string) (bool, error) {
+	var qId int64
+	err := tx.QueryRowContext(ctx, `SELECT id FROM questions WHERE id = ? AND tenantId = ?`, id, tenant).Scan(&qId)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("unable to query database for question: %s", err.Error())
+	}
+
+	changed := false
+	for _, t := range entities.NormalizeTags(add) {
+		res, err := tx.ExecContext(ctx, `INSERT OR IGNORE INTO tags (questionId, tag) VALUES (?, ?)`, id, t)
+		if err != nil {
+			return false, fmt.Errorf("unable to execute insert tag statement: %s", err.Error())
+		}
+		changed = changed || rowsAffected(res)
+	}
+
+	for _, t := range entities.NormalizeTags(remove) {
+		res, err := tx.ExecContext(ctx, `DELETE FROM tags WHERE questionId = ? AND tag = ?`, id, t)
+		if err != nil {
+			return false, fmt.Errorf("unable to execute delete tag statement: %s", err.Error())
+		}
+		changed = changed || rowsAffected(res)
+	}
+
+	if changed {
+		_, err = tx.ExecContext(ctx, `UPDATE questions SET updatedAt = ?, updatedBy = ? WHERE id = ?`, Now().UnixNano(), ActorFromContext(ctx), id)
+		if err != nil {
+			return false, fmt.Errorf("unable to execute update question statement: %s", err.Error())
+		}
+	}
+
+	return true, nil
+}
+
+// splitTags returns the sorted tags of a comma separated list read with questionTags
+func splitTags(tags sql.NullString) []string {
+	if !tags.Valid || tags.String == "" {
+		return []string{}
+	}
+
+	tl := strings.Split(tags.String, ",")
+	sort.Strings(tl)
+
+	return tl
+}
+
+// deleteQuestion removes the question and its options using the given transaction
+// It returns false, without deleting anything, if the question doesn't exist in the tenant bank.
+func deleteQuestion(ctx context.Context, tx *sql.Tx, tenant string, id int64) (bool, error) {
+	res, err := tx.ExecContext(ctx, `DELETE FROM questions WHERE id = ? AND tenantId = ?`, id, tenant)
+	if err != nil {
+		return false, err
+	}
+
+	if !rowsAffected(res) {
+		return false, nil
+	}
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM options WHERE questionId = ?`, id)
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// rowsAffected reports whether the statement changed any row, drivers that can't tell are assumed to have changed one
+func rowsAffected(res sql.Result) bool {
+	n, err := res.RowsAffected()
+
+	return err != nil || n > 0
+}
+
+// queryer is implemented by sql.DB and sql.Tx
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
 // getQuestionOptions returns a list of options for the given question ID
-func (r *SqliteRepository) getQuestionOptions(id int64) ([]entities.Option, error) {
-	rows, err := r.Handler.Query(`SELECT * FROM options WHERE questionId = ? ORDER BY optionOrder`, id)
+func (r *SqliteRepository) getQuestionOptions(ctx context.Context, id int64) ([]entities.Option, error) {
+	return queryOptions(ctx, r.conn(ctx), id)
+}
+
+// queryOptions returns the options of the given question ID in their order, reading them with q
+func queryOptions(ctx context.Context, q queryer, id int64) ([]entities.Option, error) {
+	rows, err := q.QueryContext(ctx, `SELECT * FROM options WHERE questionId = ? ORDER BY optionOrder`, id)
 	if err != nil {
 		return nil, fmt.Errorf("unable to query database for question options: %s", err.Error())
 	}
 
+	defer rows.Close()
+
 	var ol []entities.Option
 	for rows.Next() {
 		var o entities.Option
@@ -269,17 +696,49 @@ func (r *SqliteRepository) getQuestionOptions(id int64) ([]entities.Option, erro
 		ol = append(ol, o)
 	}
 
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("unable to read option rows: %s", err.Error())
+	}
+
 	return ol, nil
 }
 
-// GetAll returns all the questions from the database filtered by the given parameters
-func (r *SqliteRepository) GetAll(lastId, size int) ([]entities.Question, error) {
-	query := `SELECT * FROM questions`
-	if lastId != 0 {
-		query = fmt.Sprintf("%s WHERE id < %d ORDER BY id DESC LIMIT %d", query, lastId, size)
+// Get returns the question of the tenant bank with the given id, or QuestionNotFoundError if there is none
+func (r *SqliteRepository) Get(ctx context.Context, id int64) (entities.Question, error) {
+	tenant, err := TenantFromContext(ctx)
+	if err != nil {
+		return entities.Question{}, err
+	}
+
+	q, err := scanQuestion(r.conn(ctx).QueryRowContext(ctx, `SELECT `+questionColumns+` FROM questions WHERE id = ? AND tenantId = ?`, id, tenant))
+	if err == sql.ErrNoRows {
+		return q, QuestionNotFoundError
+	}
+	if err != nil {
+		return q, fmt.Errorf("unable to query database: %s", err.Error())
 	}
 
-	rows, err := r.Handler.Query(query)
+	q.Options, err = r.getQuestionOptions(ctx, q.Id)
+	if err != nil {
+		return q, err
+	}
+
+	return q, nil
+}
+
+// GetAll returns the questions of the tenant bank matching the filter, in the filter order
+func (r *SqliteRepository) GetAll(ctx context.Context, f entities.QuestionFilter) ([]entities.Question, error) {
+	tenant, err := TenantFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	query, args, err := questionsQuery(tenant, f)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.conn(ctx).QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("unable to query database: %s", err.Error())
 	}
@@ -288,13 +747,12 @@ func (r *SqliteRepository) GetAll(lastId, size int) ([]entities.Question, error)
 
 	ql := []entities.Question{}
 	for rows.Next() {
-		var q entities.Question
-
-		if err = rows.Scan(&q.Id, &q.Body); err != nil {
+		q, err := scanQuestion(rows)
+		if err != nil {
 			return nil, fmt.Errorf("unable to scan question row: %s", err.Error())
 		}
 
-		q.Options, err = r.getQuestionOptions(q.Id)
+		q.Options, err = r.getQuestionOptions(ctx, q.Id)
 		if err != nil {
 			return nil, err
 		}
@@ -302,5 +760,106 @@ func (r *SqliteRepository) GetAll(lastId, size int) ([]entities.Question, error)
 		ql = append(ql, q)
 	}
 
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("unable to read question rows: %s", err.Error())
+	}
+
 	return ql, nil
 }
+
+// ForEach calls fn for every question of the tenant bank, ordered by id, stopping at the first error
+// Questions are read together with their options using a single cursor, so the table is never loaded in memory.
+func (r *SqliteRepository) ForEach(ctx context.Context, fn func(entities.Question) error) error {
+	tenant, err := TenantFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	rows, err := r.conn(ctx).QueryContext(ctx, `SELECT questions.id, questions.body, questions.tenantId, questions.createdAt, questions.createdBy,
+		questions.updatedAt, questions.updatedBy, questions.status, questions.reviewComment, questions.difficulty, `+questionTags+`,
+		o.id, o.questionId, o.body, o.correct, o.optionOrder
+		FROM questions LEFT JOIN options o ON o.questionId = questions.id WHERE questions.tenantId = ? ORDER BY questions.id, o.optionOrder`, tenant)
+	if err != nil {
+		return fmt.Errorf("unable to query database: %s", err.Error())
+	}
+
+	defer rows.Close()
+
+	var q *entities.Question
+	for rows.Next() {
+		// fn can be slow, stop as soon as the request is canceled instead of waiting for the cursor to be closed
+		if err = ctx.Err(); err != nil {
+			return fmt.Errorf("unable to read question rows: %s", err.Error())
+		}
+
+		var oId, oQuestionId, oOrder sql.NullInt64
+		var oBody sql.NullString
+		var oCorrect sql.NullBool
+
+		row, err := scanQuestion(rows, &oId, &oQuestionId, &oBody, &oCorrect, &oOrder)
+		if err != nil {
+			return fmt.Errorf("unable to scan question row: %s", err.Error())
+		}
+
+		// rows of the same question are consecutive, the previous question is complete once the id changes
+		if q == nil || q.Id != row.Id {
+			if q != nil {
+				if err = fn(*q); err != nil {
+					return err
+				}
+			}
+
+			q = &row
+		}
+
+		if oId.Valid {
+			q.Options = append(q.Options, entities.Option{
+				Id:          oId.Int64,
+				QuestionId:  oQuestionId.Int64,
+				Body:        oBody.String,
+				Correct:     oCorrect.Bool,
+				OptionOrder: int(oOrder.Int64),
+			})
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("unable to read question rows: %s", err.Error())
+	}
+
+	if q != nil {
+		return fn(*q)
+	}
+
+	return nil
+}
+
+// scanQuestion reads a question, without its options, from a row selecting the questionColumns followed by the extra columns
+// The questions stored before their history was recorded have no creation and update times.
+func scanQuestion(row scanner, extra ...interface{}) (entities.Question, error) {
+	var q entities.Question
+	var createdAt, updatedAt int64
+	var tags sql.NullString
+
+	dest := append([]interface{}{&q.Id, &q.Body, &q.TenantId, &createdAt, &q.CreatedBy, &updatedAt, &q.UpdatedBy, &q.Status, &q.ReviewComment, &q.Difficulty, &tags}, extra...)
+	if err := row.Scan(dest...); err != nil {
+		return q, err
+	}
+
+	q.CreatedAt, q.UpdatedAt = unixTime(createdAt), unixTime(updatedAt)
+	if tl := splitTags(tags); len(tl) > 0 {
+		q.Tags = tl
+	}
+
+	return q, nil
+}
+
+// unixTime returns the UTC time of the given unix nanoseconds, or nil for 0
+func unixTime(ns int64) *time.Time {
+	if ns == 0 {
+		return nil
+	}
+
+	t := time.Unix(0, ns).UTC()
+	return &t
+}
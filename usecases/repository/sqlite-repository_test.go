@@ -1,17 +1,27 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/norby7/questions-rest-api/entities"
 	"testing"
+	"time"
 )
 
 var (
-	dbMock sqlmock.Sqlmock
+	dbMock    sqlmock.Sqlmock
+	tenantCtx = NewActorContext(NewTenantContext(context.Background(), "acme"), "alice")
+	// testTime is the time the questions are created and updated at in the tests
+	testTime = time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
 )
 
+func init() {
+	Now = func() time.Time { return testTime }
+}
+
 var MockOpener = func(string, string) (*sql.DB, error) {
 	db, mock, err := sqlmock.New()
 
@@ -25,7 +35,8 @@ var MockOpener = func(string, string) (*sql.DB, error) {
 }
 
 var MockErrOpener = func(d string, p string) (*sql.DB, error) {
-	if p == "errPath" {
+	// the data source name is passed with the transaction locking mode
+	if p == "errPath?_txlock=immediate" {
 		return nil, fmt.Errorf("unable to connect to database")
 	}
 
@@ -82,7 +93,7 @@ func TestValidAdd(t *testing.T) {
 	}
 
 	dbMock.ExpectBegin()
-	dbMock.ExpectExec(`INSERT INTO questions`).WithArgs(q.Body).WillReturnResult(sqlmock.NewResult(1, 1))
+	dbMock.ExpectExec(`INSERT INTO questions`).WithArgs("acme", q.Body, testTime.UnixNano(), "alice", testTime.UnixNano(), "alice", entities.Draft, "", 0).WillReturnResult(sqlmock.NewResult(1, 1))
 	dbMock.ExpectCommit()
 
 	var o entities.Option
@@ -93,7 +104,7 @@ func TestValidAdd(t *testing.T) {
 	dbMock.ExpectExec(`INSERT INTO options`).WithArgs(1, o.Body, o.Correct, o.OptionOrder).WillReturnResult(sqlmock.NewResult(1, 1))
 	dbMock.ExpectCommit()
 
-	err = repo.Add(q)
+	_, err = repo.Add(tenantCtx, q)
 	if err != nil {
 		t.Fatalf("unable to execute add call: %s", err.Error())
 	}
@@ -125,7 +136,7 @@ func TestBeginErrorAdd(t *testing.T) {
 
 	dbMock.ExpectBegin().WillReturnError(beginErr)
 
-	err = repo.Add(q)
+	_, err = repo.Add(tenantCtx, q)
 	if err == nil {
 		t.Errorf("expected error (%v), got error nil", beginErr)
 	}
@@ -156,10 +167,10 @@ func TestQuestionInsertErrorAdd(t *testing.T) {
 	execErr := fmt.Errorf("error executing insert question")
 
 	dbMock.ExpectBegin()
-	dbMock.ExpectExec(`INSERT INTO questions`).WithArgs(q.Body).WillReturnError(execErr)
+	dbMock.ExpectExec(`INSERT INTO questions`).WithArgs("acme", q.Body, testTime.UnixNano(), "alice", testTime.UnixNano(), "alice", entities.Draft, "", 0).WillReturnError(execErr)
 	dbMock.ExpectRollback()
 
-	err = repo.Add(q)
+	_, err = repo.Add(tenantCtx, q)
 	if err == nil {
 		t.Errorf("expected error (%v), got error nil", execErr)
 	}
@@ -190,11 +201,11 @@ func TestCommitErrorAdd(t *testing.T) {
 	commitErr := fmt.Errorf("error commiting transaction")
 
 	dbMock.ExpectBegin()
-	dbMock.ExpectExec(`INSERT INTO questions`).WithArgs(q.Body).WillReturnResult(sqlmock.NewResult(1, 1))
+	dbMock.ExpectExec(`INSERT INTO questions`).WithArgs("acme", q.Body, testTime.UnixNano(), "alice", testTime.UnixNano(), "alice", entities.Draft, "", 0).WillReturnResult(sqlmock.NewResult(1, 1))
 	dbMock.ExpectCommit().WillReturnError(commitErr)
 	dbMock.ExpectRollback()
 
-	err = repo.Add(q)
+	_, err = repo.Add(tenantCtx, q)
 	if err == nil {
 		t.Errorf("expected error (%v), got error nil", commitErr)
 	}
@@ -225,18 +236,18 @@ func TestBeginOptionErrorAdd(t *testing.T) {
 	beginErr := fmt.Errorf("error begining transaction")
 
 	dbMock.ExpectBegin()
-	dbMock.ExpectExec(`INSERT INTO questions`).WithArgs(q.Body).WillReturnResult(sqlmock.NewResult(1, 1))
+	dbMock.ExpectExec(`INSERT INTO questions`).WithArgs("acme", q.Body, testTime.UnixNano(), "alice", testTime.UnixNano(), "alice", entities.Draft, "", 0).WillReturnResult(sqlmock.NewResult(1, 1))
 	dbMock.ExpectCommit()
 
 	dbMock.ExpectBegin().WillReturnError(beginErr)
 	dbMock.ExpectRollback()
 
 	dbMock.ExpectBegin()
-	dbMock.ExpectExec(`DELETE FROM questions WHERE id = ?`).WithArgs(1).WillReturnResult(sqlmock.NewResult(1, 1))
+	dbMock.ExpectExec(`DELETE FROM questions WHERE id = ?`).WithArgs(1, "acme").WillReturnResult(sqlmock.NewResult(1, 1))
 	dbMock.ExpectExec(`DELETE FROM options WHERE questionId = ?`).WithArgs(1).WillReturnResult(sqlmock.NewResult(1, 1))
 	dbMock.ExpectCommit()
 
-	err = repo.Add(q)
+	_, err = repo.Add(tenantCtx, q)
 	if err == nil {
 		t.Errorf("expected error (%v), got error nil", beginErr)
 	}
@@ -267,7 +278,7 @@ func TestInsertOptionErrorAdd(t *testing.T) {
 	insertErr := fmt.Errorf("error inserting option")
 
 	dbMock.ExpectBegin()
-	dbMock.ExpectExec(`INSERT INTO questions`).WithArgs(q.Body).WillReturnResult(sqlmock.NewResult(1, 1))
+	dbMock.ExpectExec(`INSERT INTO questions`).WithArgs("acme", q.Body, testTime.UnixNano(), "alice", testTime.UnixNano(), "alice", entities.Draft, "", 0).WillReturnResult(sqlmock.NewResult(1, 1))
 	dbMock.ExpectCommit()
 
 	var o entities.Option
@@ -279,11 +290,11 @@ func TestInsertOptionErrorAdd(t *testing.T) {
 	dbMock.ExpectRollback()
 
 	dbMock.ExpectBegin()
-	dbMock.ExpectExec(`DELETE FROM questions WHERE id = ?`).WithArgs(1).WillReturnResult(sqlmock.NewResult(1, 1))
+	dbMock.ExpectExec(`DELETE FROM questions WHERE id = ?`).WithArgs(1, "acme").WillReturnResult(sqlmock.NewResult(1, 1))
 	dbMock.ExpectExec(`DELETE FROM options WHERE questionId = ?`).WithArgs(1).WillReturnResult(sqlmock.NewResult(1, 1))
 	dbMock.ExpectCommit()
 
-	err = repo.Add(q)
+	_, err = repo.Add(tenantCtx, q)
 	if err == nil {
 		t.Errorf("expected error (%v), got error nil", insertErr)
 	}
@@ -314,7 +325,7 @@ func TestInsertOptionCommitErrorAdd(t *testing.T) {
 	commitErr := fmt.Errorf("error commiting transaction")
 
 	dbMock.ExpectBegin()
-	dbMock.ExpectExec(`INSERT INTO questions`).WithArgs(q.Body).WillReturnResult(sqlmock.NewResult(1, 1))
+	dbMock.ExpectExec(`INSERT INTO questions`).WithArgs("acme", q.Body, testTime.UnixNano(), "alice", testTime.UnixNano(), "alice", entities.Draft, "", 0).WillReturnResult(sqlmock.NewResult(1, 1))
 	dbMock.ExpectCommit()
 
 	var o entities.Option
@@ -327,11 +338,11 @@ func TestInsertOptionCommitErrorAdd(t *testing.T) {
 	dbMock.ExpectRollback()
 
 	dbMock.ExpectBegin()
-	dbMock.ExpectExec(`DELETE FROM questions WHERE id = ?`).WithArgs(1).WillReturnResult(sqlmock.NewResult(1, 1))
+	dbMock.ExpectExec(`DELETE FROM questions WHERE id = ?`).WithArgs(1, "acme").WillReturnResult(sqlmock.NewResult(1, 1))
 	dbMock.ExpectExec(`DELETE FROM options WHERE questionId = ?`).WithArgs(1).WillReturnResult(sqlmock.NewResult(1, 1))
 	dbMock.ExpectCommit()
 
-	err = repo.Add(q)
+	_, err = repo.Add(tenantCtx, q)
 	if err == nil {
 		t.Errorf("expected error (%v), got error nil", commitErr)
 	}
@@ -348,33 +359,166 @@ func TestValidUpdate(t *testing.T) {
 		Id:   1,
 		Body: "Where does the sun set?",
 		Options: []entities.Option{{
+			Id:          1,
 			QuestionId:  1,
 			Body:        "East",
 			Correct:     false,
 			OptionOrder: 0,
 		}, {
+			Id:          2,
 			QuestionId:  1,
 			Body:        "West",
 			Correct:     true,
 			OptionOrder: 1,
 		}},
 	}
-	var o entities.Option
+
+	q.Options = append(q.Options, entities.Option{QuestionId: 1, Body: "North"})
+
+	// East is moved first, West gets a new body, North is added and South removed
+	options := sqlmock.NewRows([]string{"id", "questionId", "body", "correct", "optionOrder"})
+	options.AddRow(2, 1, "Nowhere", 1, 0)
+	options.AddRow(1, 1, "East", 0, 1)
+	options.AddRow(3, 1, "South", 0, 2)
 
 	dbMock.ExpectBegin()
-	dbMock.ExpectExec(`UPDATE questions`).WithArgs(q.Body, q.Id).WillReturnResult(sqlmock.NewResult(1, 1))
-	dbMock.ExpectExec(`DELETE FROM options WHERE questionId = ?`).WithArgs(1).WillReturnResult(sqlmock.NewResult(1, 1))
-	o = q.Options[0]
-	dbMock.ExpectExec(`INSERT INTO options`).WithArgs(o.QuestionId, o.Body, o.Correct, o.OptionOrder).WillReturnResult(sqlmock.NewResult(1, 1))
-	o = q.Options[1]
-	dbMock.ExpectExec(`INSERT INTO options`).WithArgs(1, o.Body, o.Correct, o.OptionOrder).WillReturnResult(sqlmock.NewResult(1, 1))
+	dbMock.ExpectQuery(`SELECT body, (.+) FROM questions`).WithArgs(q.Id, "acme").WillReturnRows(sqlmock.NewRows([]string{"body", "difficulty", "status", "reviewComment", "tags"}).AddRow("Where does the sun rise?", 0, "draft", "", nil))
+	dbMock.ExpectQuery(`SELECT (.+) FROM options`).WithArgs(q.Id).WillReturnRows(options)
+	dbMock.ExpectExec(`UPDATE options`).WithArgs("East", false, 0, 1).WillReturnResult(sqlmock.NewResult(0, 1))
+	dbMock.ExpectExec(`UPDATE options`).WithArgs("West", true, 1, 2).WillReturnResult(sqlmock.NewResult(0, 1))
+	dbMock.ExpectExec(`INSERT INTO options`).WithArgs(1, "North", false, 2).WillReturnResult(sqlmock.NewResult(4, 1))
+	dbMock.ExpectExec(`DELETE FROM options WHERE id = ?`).WithArgs(3).WillReturnResult(sqlmock.NewResult(0, 1))
+	dbMock.ExpectExec(`UPDATE questions`).WithArgs(q.Body, q.Difficulty, entities.Draft, "", testTime.UnixNano(), "alice", q.Id).WillReturnResult(sqlmock.NewResult(0, 1))
 	dbMock.ExpectCommit()
 
-	//dbMock.ExpectQuery().WillReturnRows(sqlmock.NewRows())
+	err = repo.Update(tenantCtx, q)
+	if err != nil {
+		t.Fatalf("unable to execute update call: %s", err.Error())
+	}
+
+	if err = dbMock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %s", err.Error())
+	}
+}
 
-	err = repo.Update(q)
+func TestUnchangedUpdate(t *testing.T) {
+	SqlOpen = MockOpener
+	repo, err := NewSqliteRepository("./test.db")
 	if err != nil {
-		t.Fatalf("unable to execute add call: %s", err.Error())
+		t.Fatalf("unable to create mock repository: %s", err.Error())
+	}
+
+	q := entities.Question{
+		Id:   1,
+		Body: "Where does the sun set?",
+		Options: []entities.Option{{
+			Id:          1,
+			QuestionId:  1,
+			Body:        "East",
+			Correct:     false,
+			OptionOrder: 0,
+		}, {
+			Id:          2,
+			QuestionId:  1,
+			Body:        "West",
+			Correct:     true,
+			OptionOrder: 1,
+		}},
+	}
+
+	options := sqlmock.NewRows([]string{"id", "questionId", "body", "correct", "optionOrder"})
+	options.AddRow(1, 1, "East", 0, 0)
+	options.AddRow(2, 1, "West", 1, 1)
+
+	// nothing changed, so nothing is written
+	dbMock.ExpectBegin()
+	dbMock.ExpectQuery(`SELECT body, (.+) FROM questions`).WithArgs(q.Id, "acme").WillReturnRows(sqlmock.NewRows([]string{"body", "difficulty", "status", "reviewComment", "tags"}).AddRow(q.Body, 0, "draft", "", nil))
+	dbMock.ExpectQuery(`SELECT (.+) FROM options`).WithArgs(q.Id).WillReturnRows(options)
+	dbMock.ExpectCommit()
+
+	err = repo.Update(tenantCtx, q)
+	if err != nil {
+		t.Fatalf("unable to execute update call: %s", err.Error())
+	}
+
+	if err = dbMock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %s", err.Error())
+	}
+}
+
+func TestNotFoundUpdate(t *testing.T) {
+	SqlOpen = MockOpener
+	repo, err := NewSqliteRepository("./test.db")
+	if err != nil {
+		t.Fatalf("unable to create mock repository: %s", err.Error())
+	}
+
+	q := entities.Question{
+		Id:   1,
+		Body: "Where does the sun set?",
+		Options: []entities.Option{{
+			Id:          1,
+			QuestionId:  1,
+			Body:        "East",
+			Correct:     false,
+			OptionOrder: 0,
+		}, {
+			Id:          2,
+			QuestionId:  1,
+			Body:        "West",
+			Correct:     true,
+			OptionOrder: 1,
+		}},
+	}
+
+	dbMock.ExpectBegin()
+	dbMock.ExpectQuery(`SELECT body, (.+) FROM questions`).WithArgs(q.Id, "acme").WillReturnRows(sqlmock.NewRows([]string{"body", "tags"}))
+	dbMock.ExpectRollback()
+
+	err = repo.Update(tenantCtx, q)
+	if !errors.Is(err, QuestionNotFoundError) {
+		t.Errorf("expected error (%v), got error (%v)", QuestionNotFoundError, err)
+	}
+}
+
+func TestOptionNotFoundUpdate(t *testing.T) {
+	SqlOpen = MockOpener
+	repo, err := NewSqliteRepository("./test.db")
+	if err != nil {
+		t.Fatalf("unable to create mock repository: %s", err.Error())
+	}
+
+	q := entities.Question{
+		Id:   1,
+		Body: "Where does the sun set?",
+		Options: []entities.Option{{
+			Id:          1,
+			QuestionId:  1,
+			Body:        "East",
+			Correct:     false,
+			OptionOrder: 0,
+		}, {
+			Id:          2,
+			QuestionId:  1,
+			Body:        "West",
+			Correct:     true,
+			OptionOrder: 1,
+		}},
+	}
+	q.Options[1].Id = 9
+
+	options := sqlmock.NewRows([]string{"id", "questionId", "body", "correct", "optionOrder"})
+	options.AddRow(1, 1, "East", 0, 0)
+	options.AddRow(2, 1, "West", 1, 1)
+
+	dbMock.ExpectBegin()
+	dbMock.ExpectQuery(`SELECT body, (.+) FROM questions`).WithArgs(q.Id, "acme").WillReturnRows(sqlmock.NewRows([]string{"body", "difficulty", "status", "reviewComment", "tags"}).AddRow(q.Body, 0, "draft", "", nil))
+	dbMock.ExpectQuery(`SELECT (.+) FROM options`).WithArgs(q.Id).WillReturnRows(options)
+	dbMock.ExpectRollback()
+
+	err = repo.Update(tenantCtx, q)
+	if !errors.Is(err, OptionNotFoundError) {
+		t.Errorf("expected error (%v), got error (%v)", OptionNotFoundError, err)
 	}
 }
 
@@ -389,11 +533,13 @@ func TestBeginErrorUpdate(t *testing.T) {
 		Id:   1,
 		Body: "Where does the sun set?",
 		Options: []entities.Option{{
+			Id:          1,
 			QuestionId:  1,
 			Body:        "East",
 			Correct:     false,
 			OptionOrder: 0,
 		}, {
+			Id:          2,
 			QuestionId:  1,
 			Body:        "West",
 			Correct:     true,
@@ -404,7 +550,7 @@ func TestBeginErrorUpdate(t *testing.T) {
 
 	dbMock.ExpectBegin().WillReturnError(beginErr)
 
-	err = repo.Update(q)
+	err = repo.Update(tenantCtx, q)
 	if err == nil {
 		t.Errorf("expected error (%v), got error nil", beginErr)
 	}
@@ -421,14 +567,51 @@ func TestQueryErrorUpdate(t *testing.T) {
 		Id:   1,
 		Body: "Where does the sun set?",
 		Options: []entities.Option{{
+			Id:          1,
 			QuestionId:  1,
 			Body:        "East",
 			Correct:     false,
 			OptionOrder: 0,
 		}, {
-			QuestionId: 1,
-			Body:       "West",
+			Id:          2,
+			QuestionId:  1,
+			Body:        "West",
+			Correct:     true,
+			OptionOrder: 1,
+		}},
+	}
+	queryErr := fmt.Errorf("error querying questions")
+
+	dbMock.ExpectBegin()
+	dbMock.ExpectQuery(`SELECT body, (.+) FROM questions`).WithArgs(q.Id, "acme").WillReturnError(queryErr)
+	dbMock.ExpectRollback()
+
+	err = repo.Update(tenantCtx, q)
+	if err == nil {
+		t.Errorf("expected error (%v), got error nil", queryErr)
+	}
+}
 
+func TestUpdateErrorUpdate(t *testing.T) {
+	SqlOpen = MockOpener
+	repo, err := NewSqliteRepository("./test.db")
+	if err != nil {
+		t.Fatalf("unable to create mock repository: %s", err.Error())
+	}
+
+	q := entities.Question{
+		Id:   1,
+		Body: "Where does the sun set?",
+		Options: []entities.Option{{
+			Id:          1,
+			QuestionId:  1,
+			Body:        "East",
+			Correct:     false,
+			OptionOrder: 0,
+		}, {
+			Id:          2,
+			QuestionId:  1,
+			Body:        "West",
 			Correct:     true,
 			OptionOrder: 1,
 		}},
@@ -436,10 +619,12 @@ func TestQueryErrorUpdate(t *testing.T) {
 	updateErr := fmt.Errorf("error updating questions")
 
 	dbMock.ExpectBegin()
-	dbMock.ExpectExec(`UPDATE questions`).WithArgs(q.Body, q.Id).WillReturnError(updateErr)
+	dbMock.ExpectQuery(`SELECT body, (.+) FROM questions`).WithArgs(q.Id, "acme").WillReturnRows(sqlmock.NewRows([]string{"body", "difficulty", "status", "reviewComment", "tags"}).AddRow("Where does the sun rise?", 0, "draft", "", nil))
+	dbMock.ExpectQuery(`SELECT (.+) FROM options`).WithArgs(q.Id).WillReturnRows(sqlmock.NewRows([]string{"id", "questionId", "body", "correct", "optionOrder"}).AddRow(1, 1, "East", 0, 0).AddRow(2, 1, "West", 1, 1))
+	dbMock.ExpectExec(`UPDATE questions`).WithArgs(q.Body, q.Difficulty, entities.Draft, "", testTime.UnixNano(), "alice", q.Id).WillReturnError(updateErr)
 	dbMock.ExpectRollback()
 
-	err = repo.Update(q)
+	err = repo.Update(tenantCtx, q)
 	if err == nil {
 		t.Errorf("expected error (%v), got error nil", updateErr)
 	}
@@ -456,11 +641,13 @@ func TestDeleteErrorUpdate(t *testing.T) {
 		Id:   1,
 		Body: "Where does the sun set?",
 		Options: []entities.Option{{
+			Id:          1,
 			QuestionId:  1,
 			Body:        "East",
 			Correct:     false,
 			OptionOrder: 0,
 		}, {
+			Id:          2,
 			QuestionId:  1,
 			Body:        "West",
 			Correct:     true,
@@ -468,14 +655,19 @@ func TestDeleteErrorUpdate(t *testing.T) {
 		}},
 	}
 
+	options := sqlmock.NewRows([]string{"id", "questionId", "body", "correct", "optionOrder"})
+	options.AddRow(1, 1, "East", 0, 0)
+	options.AddRow(2, 1, "West", 1, 1)
+	options.AddRow(3, 1, "South", 0, 2)
 	deleteErr := fmt.Errorf("error deleting options")
 
 	dbMock.ExpectBegin()
-	dbMock.ExpectExec(`UPDATE questions`).WithArgs(q.Body, q.Id).WillReturnResult(sqlmock.NewResult(1, 1))
-	dbMock.ExpectExec(`DELETE FROM options WHERE questionId = ?`).WithArgs(1).WillReturnError(deleteErr)
+	dbMock.ExpectQuery(`SELECT body, (.+) FROM questions`).WithArgs(q.Id, "acme").WillReturnRows(sqlmock.NewRows([]string{"body", "difficulty", "status", "reviewComment", "tags"}).AddRow(q.Body, 0, "draft", "", nil))
+	dbMock.ExpectQuery(`SELECT (.+) FROM options`).WithArgs(q.Id).WillReturnRows(options)
+	dbMock.ExpectExec(`DELETE FROM options WHERE id = ?`).WithArgs(3).WillReturnError(deleteErr)
 	dbMock.ExpectRollback()
 
-	err = repo.Update(q)
+	err = repo.Update(tenantCtx, q)
 	if err == nil {
 		t.Errorf("expected error (%v), got error nil", deleteErr)
 	}
@@ -492,30 +684,33 @@ func TestInsertOptionErrorUpdate(t *testing.T) {
 		Id:   1,
 		Body: "Where does the sun set?",
 		Options: []entities.Option{{
+			Id:          1,
 			QuestionId:  1,
 			Body:        "East",
 			Correct:     false,
 			OptionOrder: 0,
 		}, {
+			Id:          2,
 			QuestionId:  1,
 			Body:        "West",
 			Correct:     true,
 			OptionOrder: 1,
 		}},
 	}
-	var o entities.Option
+
+	q.Options[1].Id = 0
+
+	options := sqlmock.NewRows([]string{"id", "questionId", "body", "correct", "optionOrder"})
+	options.AddRow(1, 1, "East", 0, 0)
 	insertErr := fmt.Errorf("error inserting options")
 
 	dbMock.ExpectBegin()
-	dbMock.ExpectExec(`UPDATE questions`).WithArgs(q.Body, q.Id).WillReturnResult(sqlmock.NewResult(1, 1))
-	dbMock.ExpectExec(`DELETE FROM options WHERE questionId = ?`).WithArgs(1).WillReturnResult(sqlmock.NewResult(1, 1))
-	o = q.Options[0]
-	dbMock.ExpectExec(`INSERT INTO options`).WithArgs(o.QuestionId, o.Body, o.Correct, o.OptionOrder).WillReturnResult(sqlmock.NewResult(1, 1))
-	o = q.Options[1]
-	dbMock.ExpectExec(`INSERT INTO options`).WithArgs(1, o.Body, o.Correct, o.OptionOrder).WillReturnError(insertErr)
+	dbMock.ExpectQuery(`SELECT body, (.+) FROM questions`).WithArgs(q.Id, "acme").WillReturnRows(sqlmock.NewRows([]string{"body", "difficulty", "status", "reviewComment", "tags"}).AddRow(q.Body, 0, "draft", "", nil))
+	dbMock.ExpectQuery(`SELECT (.+) FROM options`).WithArgs(q.Id).WillReturnRows(options)
+	dbMock.ExpectExec(`INSERT INTO options`).WithArgs(1, "West", true, 1).WillReturnError(insertErr)
 	dbMock.ExpectRollback()
 
-	err = repo.Update(q)
+	err = repo.Update(tenantCtx, q)
 	if err == nil {
 		t.Errorf("expected error (%v), got error nil", insertErr)
 	}
@@ -532,31 +727,34 @@ func TestCommitErrorUpdate(t *testing.T) {
 		Id:   1,
 		Body: "Where does the sun set?",
 		Options: []entities.Option{{
+			Id:          1,
 			QuestionId:  1,
 			Body:        "East",
 			Correct:     false,
 			OptionOrder: 0,
 		}, {
+			Id:          2,
 			QuestionId:  1,
 			Body:        "West",
 			Correct:     true,
 			OptionOrder: 1,
 		}},
 	}
-	var o entities.Option
+
+	q.Options[1].Id = 0
+
+	options := sqlmock.NewRows([]string{"id", "questionId", "body", "correct", "optionOrder"})
+	options.AddRow(1, 1, "East", 0, 0)
 	commitErr := fmt.Errorf("error commiting")
 
 	dbMock.ExpectBegin()
-	dbMock.ExpectExec(`UPDATE questions`).WithArgs(q.Body, q.Id).WillReturnResult(sqlmock.NewResult(1, 1))
-	dbMock.ExpectExec(`DELETE FROM options WHERE questionId = ?`).WithArgs(1).WillReturnResult(sqlmock.NewResult(1, 1))
-	o = q.Options[0]
-	dbMock.ExpectExec(`INSERT INTO options`).WithArgs(o.QuestionId, o.Body, o.Correct, o.OptionOrder).WillReturnResult(sqlmock.NewResult(1, 1))
-	o = q.Options[1]
-	dbMock.ExpectExec(`INSERT INTO options`).WithArgs(1, o.Body, o.Correct, o.OptionOrder).WillReturnResult(sqlmock.NewResult(1, 1))
+	dbMock.ExpectQuery(`SELECT body, (.+) FROM questions`).WithArgs(q.Id, "acme").WillReturnRows(sqlmock.NewRows([]string{"body", "difficulty", "status", "reviewComment", "tags"}).AddRow(q.Body, 0, "draft", "", nil))
+	dbMock.ExpectQuery(`SELECT (.+) FROM options`).WithArgs(q.Id).WillReturnRows(options)
+	dbMock.ExpectExec(`INSERT INTO options`).WithArgs(1, "West", true, 1).WillReturnResult(sqlmock.NewResult(2, 1))
 	dbMock.ExpectCommit().WillReturnError(commitErr)
 	dbMock.ExpectRollback()
 
-	err = repo.Update(q)
+	err = repo.Update(tenantCtx, q)
 	if err == nil {
 		t.Errorf("expected error (%v), got error nil", commitErr)
 	}
@@ -569,9 +767,9 @@ func TestValidGetAll(t *testing.T) {
 		t.Fatalf("unable to create mock repository: %s", err.Error())
 	}
 
-	rows := sqlmock.NewRows([]string{"id", "body"})
-	rows.AddRow(1, "Where does the sun set?")
-	rows.AddRow(2, "Where does the sun rise?")
+	rows := sqlmock.NewRows([]string{"id", "body", "tenantId", "createdAt", "createdBy", "updatedAt", "updatedBy", "status", "reviewComment", "difficulty", "tags"})
+	rows.AddRow(1, "Where does the sun set?", "acme", testTime.UnixNano(), "alice", testTime.UnixNano(), "alice", "published", "Clear and correct", 3, "geography,astronomy")
+	rows.AddRow(2, "Where does the sun rise?", "acme", 0, "", 0, "", "draft", "", 0, nil)
 
 	firstOptions := sqlmock.NewRows([]string{"id", "questionId", "body", "correct", "optionOrder"})
 	firstOptions.AddRow(1, 1, "West", 0, 0)
@@ -581,11 +779,11 @@ func TestValidGetAll(t *testing.T) {
 	secondOptions.AddRow(3, 2, "West", 1, 0)
 	secondOptions.AddRow(4, 2, "East", 0, 0)
 
-	dbMock.ExpectQuery(`SELECT`).WillReturnRows(rows)
+	dbMock.ExpectQuery(`SELECT (.+) FROM questions WHERE tenantId = \? AND id < \? ORDER BY id DESC LIMIT \?`).WithArgs("acme", 10, 10).WillReturnRows(rows)
 	dbMock.ExpectQuery(`SELECT`).WithArgs(1).WillReturnRows(firstOptions)
 	dbMock.ExpectQuery(`SELECT`).WithArgs(2).WillReturnRows(secondOptions)
 
-	_, err = repo.GetAll(10, 10)
+	_, err = repo.GetAll(tenantCtx, entities.QuestionFilter{After: &entities.QuestionCursor{Id: 10}, Limit: 10})
 	if err != nil {
 		t.Fatalf("unable to execute get all call: %s", err.Error())
 	}
@@ -601,7 +799,7 @@ func TestQueryQuestionErrorGetAll(t *testing.T) {
 	queryErr := fmt.Errorf("error fetching data")
 	dbMock.ExpectQuery(`SELECT`).WillReturnError(queryErr)
 
-	_, err = repo.GetAll(0, 0)
+	_, err = repo.GetAll(tenantCtx, entities.QuestionFilter{})
 	if err == nil {
 		t.Errorf("expected error (%v), got error nil", queryErr)
 	}
@@ -616,19 +814,19 @@ func TestQueryOptionErrorGetAll(t *testing.T) {
 
 	queryErr := fmt.Errorf("error fetching data")
 
-	rows := sqlmock.NewRows([]string{"id", "body"})
-	rows.AddRow(1, "Where does the sun set?")
-	rows.AddRow(2, "Where does the sun rise?")
+	rows := sqlmock.NewRows([]string{"id", "body", "tenantId", "createdAt", "createdBy", "updatedAt", "updatedBy", "status", "reviewComment", "difficulty", "tags"})
+	rows.AddRow(1, "Where does the sun set?", "acme", testTime.UnixNano(), "alice", testTime.UnixNano(), "alice", "published", "Clear and correct", 3, "geography,astronomy")
+	rows.AddRow(2, "Where does the sun rise?", "acme", 0, "", 0, "", "draft", "", 0, nil)
 
 	firstOptions := sqlmock.NewRows([]string{"id", "questionId", "body", "correct", "optionOrder"})
 	firstOptions.AddRow(1, 1, "West", 0, 0)
 	firstOptions.AddRow(2, 1, "East", 1, 0)
 
-	dbMock.ExpectQuery(`SELECT`).WillReturnRows(rows)
+	dbMock.ExpectQuery(`SELECT (.+) FROM questions WHERE tenantId = ?`).WithArgs("acme").WillReturnRows(rows)
 	dbMock.ExpectQuery(`SELECT`).WithArgs(1).WillReturnRows(firstOptions)
 	dbMock.ExpectQuery(`SELECT`).WithArgs(2).WillReturnError(queryErr)
 
-	_, err = repo.GetAll(0, 0)
+	_, err = repo.GetAll(tenantCtx, entities.QuestionFilter{})
 	if err == nil {
 		t.Errorf("expected error (%v), got error nil", queryErr)
 	}
@@ -642,11 +840,11 @@ func TestValidDelete(t *testing.T) {
 	}
 
 	dbMock.ExpectBegin()
-	dbMock.ExpectExec(`DELETE FROM questions WHERE id = ?`).WithArgs(1).WillReturnResult(sqlmock.NewResult(1, 1))
+	dbMock.ExpectExec(`DELETE FROM questions WHERE id = ?`).WithArgs(1, "acme").WillReturnResult(sqlmock.NewResult(1, 1))
 	dbMock.ExpectExec(`DELETE FROM options WHERE questionId = ?`).WithArgs(1).WillReturnResult(sqlmock.NewResult(1, 1))
 	dbMock.ExpectCommit()
 
-	err = repo.Delete(1)
+	err = repo.Delete(tenantCtx, 1)
 	if err != nil {
 		t.Fatalf("unable to execute delete call: %s", err.Error())
 	}
@@ -662,12 +860,12 @@ func TestCommitErrorDelete(t *testing.T) {
 	commitErr := fmt.Errorf("error commiting")
 
 	dbMock.ExpectBegin()
-	dbMock.ExpectExec(`DELETE FROM questions WHERE id = ?`).WithArgs(1).WillReturnResult(sqlmock.NewResult(1, 1))
+	dbMock.ExpectExec(`DELETE FROM questions WHERE id = ?`).WithArgs(1, "acme").WillReturnResult(sqlmock.NewResult(1, 1))
 	dbMock.ExpectExec(`DELETE FROM options WHERE questionId = ?`).WithArgs(1).WillReturnResult(sqlmock.NewResult(1, 1))
 	dbMock.ExpectCommit().WillReturnError(commitErr)
 	dbMock.ExpectRollback()
 
-	err = repo.Delete(1)
+	err = repo.Delete(tenantCtx, 1)
 	if err != commitErr && err != nil {
 		t.Fatalf("unable to execute delete call: %s", err.Error())
 	}
@@ -683,10 +881,10 @@ func TestFirstExecErrorDelete(t *testing.T) {
 	execErr := fmt.Errorf("error executing delete questions")
 
 	dbMock.ExpectBegin()
-	dbMock.ExpectExec(`DELETE FROM questions WHERE id = ?`).WithArgs(1).WillReturnError(execErr)
+	dbMock.ExpectExec(`DELETE FROM questions WHERE id = ?`).WithArgs(1, "acme").WillReturnError(execErr)
 	dbMock.ExpectRollback()
 
-	err = repo.Delete(1)
+	err = repo.Delete(tenantCtx, 1)
 	if err != execErr && err != nil {
 		t.Fatalf("unable to execute delete call: %s", err.Error())
 	}
@@ -702,11 +900,11 @@ func TestSecondExecErrorDelete(t *testing.T) {
 	execErr := fmt.Errorf("error executing delete options")
 
 	dbMock.ExpectBegin()
-	dbMock.ExpectExec(`DELETE FROM questions WHERE id = ?`).WithArgs(1).WillReturnResult(sqlmock.NewResult(1, 1))
+	dbMock.ExpectExec(`DELETE FROM questions WHERE id = ?`).WithArgs(1, "acme").WillReturnResult(sqlmock.NewResult(1, 1))
 	dbMock.ExpectExec(`DELETE FROM options WHERE questionId = ?`).WithArgs(1).WillReturnError(execErr)
 	dbMock.ExpectRollback()
 
-	err = repo.Delete(1)
+	err = repo.Delete(tenantCtx, 1)
 	if err != execErr && err != nil {
 		t.Fatalf("unable to execute delete call: %s", err.Error())
 	}
@@ -723,8 +921,419 @@ func TestBeginErrorDelete(t *testing.T) {
 
 	dbMock.ExpectBegin().WillReturnError(beginErr)
 
-	err = repo.Delete(1)
+	err = repo.Delete(tenantCtx, 1)
 	if err != beginErr && err != nil {
 		t.Fatalf("unable to execute delete call: %s", err.Error())
 	}
 }
+
+func TestValidAddAll(t *testing.T) {
+	SqlOpen = MockOpener
+	repo, err := NewSqliteRepository("./test.db")
+	if err != nil {
+		t.Fatalf("unable to create mock repository: %s", err.Error())
+	}
+
+	ql := []entities.Question{{
+		Body: "Where does the sun set?",
+		Options: []entities.Option{{
+			Body:    "East",
+			Correct: false,
+		}, {
+			Body:    "West",
+			Correct: true,
+		}},
+	}, {
+		Body: "Where does the sun rise?",
+		Options: []entities.Option{{
+			Body:    "East",
+			Correct: true,
+		}, {
+			Body:    "West",
+			Correct: false,
+		}},
+	}}
+
+	dbMock.ExpectBegin()
+	for i, q := range ql {
+		id := int64(i + 1)
+		dbMock.ExpectExec(`INSERT INTO questions`).WithArgs("acme", q.Body, testTime.UnixNano(), "alice", testTime.UnixNano(), "alice", entities.Draft, "", 0).WillReturnResult(sqlmock.NewResult(id, 1))
+		for j, o := range q.Options {
+			dbMock.ExpectExec(`INSERT INTO options`).WithArgs(id, o.Body, o.Correct, j).WillReturnResult(sqlmock.NewResult(1, 1))
+		}
+	}
+	dbMock.ExpectCommit()
+
+	_, err = repo.AddAll(tenantCtx, ql)
+	if err != nil {
+		t.Fatalf("unable to execute add all call: %s", err.Error())
+	}
+
+	if err = dbMock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %s", err.Error())
+	}
+}
+
+func TestInsertOptionErrorAddAll(t *testing.T) {
+	SqlOpen = MockOpener
+	repo, err := NewSqliteRepository("./test.db")
+	if err != nil {
+		t.Fatalf("unable to create mock repository: %s", err.Error())
+	}
+
+	q := entities.Question{
+		Body: "Where does the sun set?",
+		Options: []entities.Option{{
+			Body:    "East",
+			Correct: false,
+		}, {
+			Body:    "West",
+			Correct: true,
+		}},
+	}
+
+	insertErr := fmt.Errorf("error inserting option")
+
+	dbMock.ExpectBegin()
+	dbMock.ExpectExec(`INSERT INTO questions`).WithArgs("acme", q.Body, testTime.UnixNano(), "alice", testTime.UnixNano(), "alice", entities.Draft, "", 0).WillReturnResult(sqlmock.NewResult(1, 1))
+	dbMock.ExpectExec(`INSERT INTO options`).WithArgs(1, q.Options[0].Body, q.Options[0].Correct, 0).WillReturnError(insertErr)
+	dbMock.ExpectRollback()
+
+	_, err = repo.AddAll(tenantCtx, []entities.Question{q})
+	if err == nil {
+		t.Errorf("expected error (%v), got error nil", insertErr)
+	}
+}
+
+// forEachColumns are the columns of the rows read by ForEach, the question columns followed by the option columns
+var forEachColumns = []string{"id", "body", "tenantId", "createdAt", "createdBy", "updatedAt", "updatedBy", "status", "reviewComment", "difficulty", "tags",
+	"id", "questionId", "body", "correct", "optionOrder"}
+
+func TestValidForEach(t *testing.T) {
+	SqlOpen = MockOpener
+	repo, err := NewSqliteRepository("./test.db")
+	if err != nil {
+		t.Fatalf("unable to create mock repository: %s", err.Error())
+	}
+
+	rows := sqlmock.NewRows(forEachColumns)
+	rows.AddRow(1, "Where does the sun set?", "acme", testTime.UnixNano(), "alice", testTime.UnixNano(), "bob", "published", "Clear", 3, "geography", 1, 1, "East", 0, 0)
+	rows.AddRow(1, "Where does the sun set?", "acme", testTime.UnixNano(), "alice", testTime.UnixNano(), "bob", "published", "Clear", 3, "geography", 2, 1, "West", 1, 1)
+	rows.AddRow(2, "Where does the sun rise?", "acme", 0, "", 0, "", "published", "", 0, nil, nil, nil, nil, nil, nil)
+	rows.AddRow(3, "Where is the moon?", "acme", testTime.UnixNano(), "alice", testTime.UnixNano(), "alice", "draft", "", 0, nil, 3, 3, "Up", 1, 0)
+
+	dbMock.ExpectQuery(`SELECT`).WillReturnRows(rows)
+
+	var ql []entities.Question
+	err = repo.ForEach(tenantCtx, func(q entities.Question) error {
+		ql = append(ql, q)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unable to execute for each call: %s", err.Error())
+	}
+
+	if len(ql) != 3 || len(ql[0].Options) != 2 || len(ql[1].Options) != 0 || len(ql[2].Options) != 1 {
+		t.Errorf("unexpected questions (%v)", ql)
+	}
+
+	if !ql[0].Options[1].Correct || ql[0].Options[1].OptionOrder != 1 {
+		t.Errorf("unexpected option (%v)", ql[0].Options[1])
+	}
+
+	// the metadata of the questions is exported with them
+	if ql[0].CreatedAt == nil || !ql[0].CreatedAt.Equal(testTime) || ql[0].UpdatedBy != "bob" || ql[0].ReviewComment != "Clear" || ql[2].Status != entities.Draft {
+		t.Errorf("unexpected question metadata (%v)", ql)
+	}
+
+	if ql[1].CreatedAt != nil || ql[1].UpdatedAt != nil {
+		t.Errorf("expected question without times, got question (%v)", ql[1])
+	}
+}
+
+func TestCallbackErrorForEach(t *testing.T) {
+	SqlOpen = MockOpener
+	repo, err := NewSqliteRepository("./test.db")
+	if err != nil {
+		t.Fatalf("unable to create mock repository: %s", err.Error())
+	}
+
+	rows := sqlmock.NewRows(forEachColumns)
+	rows.AddRow(1, "Where does the sun set?", "acme", 0, "", 0, "", "published", "", 0, nil, 1, 1, "East", 0, 0)
+	rows.AddRow(2, "Where does the sun rise?", "acme", 0, "", 0, "", "published", "", 0, nil, 2, 2, "East", 1, 0)
+
+	dbMock.ExpectQuery(`SELECT`).WillReturnRows(rows)
+
+	callbackErr := fmt.Errorf("unable to write question")
+	calls := 0
+	err = repo.ForEach(tenantCtx, func(q entities.Question) error {
+		calls++
+		return callbackErr
+	})
+	if err != callbackErr || calls != 1 {
+		t.Errorf("expected error (%v) after one call, got error (%v) after (%d) calls", callbackErr, err, calls)
+	}
+}
+
+func TestQueryErrorForEach(t *testing.T) {
+	SqlOpen = MockOpener
+	repo, err := NewSqliteRepository("./test.db")
+	if err != nil {
+		t.Fatalf("unable to create mock repository: %s", err.Error())
+	}
+
+	queryErr := fmt.Errorf("error fetching data")
+	dbMock.ExpectQuery(`SELECT`).WillReturnError(queryErr)
+
+	err = repo.ForEach(tenantCtx, func(q entities.Question) error { return nil })
+	if err == nil {
+		t.Errorf("expected error (%v), got error nil", queryErr)
+	}
+}
+
+func TestValidBatch(t *testing.T) {
+	SqlOpen = MockOpener
+	repo, err := NewSqliteRepository("./test.db")
+	if err != nil {
+		t.Fatalf("unable to create mock repository: %s", err.Error())
+	}
+
+	q := entities.Question{
+		Body: "Where does the sun set?",
+		Options: []entities.Option{{
+			Body:    "East",
+			Correct: false,
+		}, {
+			Body:    "West",
+			Correct: true,
+		}},
+	}
+
+	ops := []Operation{
+		{Type: OperationCreate, Question: q},
+		{Type: OperationUpdate, Id: 2, Question: q},
+		{Type: OperationDelete, Id: 3},
+	}
+
+	dbMock.ExpectBegin()
+	dbMock.ExpectExec(`INSERT INTO questions`).WithArgs("acme", q.Body, testTime.UnixNano(), "alice", testTime.UnixNano(), "alice", entities.Draft, "", 0).WillReturnResult(sqlmock.NewResult(7, 1))
+	dbMock.ExpectExec(`INSERT INTO options`).WithArgs(7, "East", false, 0).WillReturnResult(sqlmock.NewResult(1, 1))
+	dbMock.ExpectExec(`INSERT INTO options`).WithArgs(7, "West", true, 1).WillReturnResult(sqlmock.NewResult(2, 1))
+	dbMock.ExpectQuery(`SELECT body, (.+) FROM questions`).WithArgs(2, "acme").WillReturnRows(sqlmock.NewRows([]string{"body", "difficulty", "status", "reviewComment", "tags"}).AddRow("Where does the sun rise?", 0, "draft", "", nil))
+	dbMock.ExpectQuery(`SELECT (.+) FROM options`).WithArgs(2).WillReturnRows(sqlmock.NewRows([]string{"id", "questionId", "body", "correct", "optionOrder"}))
+	dbMock.ExpectExec(`INSERT INTO options`).WithArgs(2, "East", false, 0).WillReturnResult(sqlmock.NewResult(3, 1))
+	dbMock.ExpectExec(`INSERT INTO options`).WithArgs(2, "West", true, 1).WillReturnResult(sqlmock.NewResult(4, 1))
+	dbMock.ExpectExec(`UPDATE questions`).WithArgs(q.Body, q.Difficulty, entities.Draft, "", testTime.UnixNano(), "alice", 2).WillReturnResult(sqlmock.NewResult(0, 1))
+	dbMock.ExpectExec(`DELETE FROM questions WHERE id = ?`).WithArgs(3, "acme").WillReturnResult(sqlmock.NewResult(0, 1))
+	dbMock.ExpectExec(`DELETE FROM options WHERE questionId = ?`).WithArgs(3).WillReturnResult(sqlmock.NewResult(0, 2))
+	dbMock.ExpectCommit()
+
+	ids, err := repo.Batch(tenantCtx, ops)
+	if err != nil {
+		t.Fatalf("unable to execute batch call: %s", err.Error())
+	}
+
+	if len(ids) != 3 || ids[0] != 7 || ids[1] != 2 || ids[2] != 3 {
+		t.Errorf("unexpected ids (%v)", ids)
+	}
+
+	if err = dbMock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %s", err.Error())
+	}
+}
+
+func TestNotFoundBatch(t *testing.T) {
+	SqlOpen = MockOpener
+	repo, err := NewSqliteRepository("./test.db")
+	if err != nil {
+		t.Fatalf("unable to create mock repository: %s", err.Error())
+	}
+
+	dbMock.ExpectBegin()
+	dbMock.ExpectExec(`DELETE FROM questions WHERE id = ?`).WithArgs(1, "acme").WillReturnResult(sqlmock.NewResult(0, 1))
+	dbMock.ExpectExec(`DELETE FROM options WHERE questionId = ?`).WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 2))
+	dbMock.ExpectExec(`DELETE FROM questions WHERE id = ?`).WithArgs(404, "acme").WillReturnResult(sqlmock.NewResult(0, 0))
+	dbMock.ExpectRollback()
+
+	_, err = repo.Batch(tenantCtx, []Operation{{Type: OperationDelete, Id: 1}, {Type: OperationDelete, Id: 404}})
+
+	var batchErr *BatchError
+	if !errors.As(err, &batchErr) || batchErr.Index != 1 || !errors.Is(err, QuestionNotFoundError) {
+		t.Errorf("expected error (%v) for operation 1, got error (%v)", QuestionNotFoundError, err)
+	}
+}
+
+func TestCanceledContext(t *testing.T) {
+	repo := newTenantRepository(t)
+
+	for _, body := range []string{"Where does the sun set?", "Where does the sun rise?"} {
+		if _, err := repo.Add(tenantCtx, tenantQuestion(body)); err != nil {
+			t.Fatalf("unable to add question: %s", err.Error())
+		}
+	}
+
+	canceled, cancel := context.WithCancel(tenantCtx)
+	cancel()
+
+	if _, err := repo.Add(canceled, tenantQuestion("Where does the moon rise?")); err == nil {
+		t.Errorf("expected an error adding a question with a canceled context")
+	}
+
+	if _, err := repo.GetAll(canceled, entities.QuestionFilter{Limit: 10}); err == nil {
+		t.Errorf("expected an error listing the questions with a canceled context")
+	}
+
+	ql, err := repo.GetAll(tenantCtx, entities.QuestionFilter{Limit: 10})
+	if err != nil {
+		t.Fatalf("unable to list questions: %s", err.Error())
+	}
+
+	if len(ql) != 2 {
+		t.Errorf("expected (2) questions, got questions (%v)", ql)
+	}
+
+	// the iteration stops once the request is canceled, even though the cursor still has rows
+	ctx, cancel := context.WithCancel(tenantCtx)
+	defer cancel()
+
+	calls := 0
+	err = repo.ForEach(ctx, func(entities.Question) error {
+		calls++
+		cancel()
+		return nil
+	})
+
+	if err == nil || calls != 1 {
+		t.Errorf("expected an error after a single question, got error (%v) after (%d) questions", err, calls)
+	}
+}
+
+func TestUpdateKeepsOptionRows(t *testing.T) {
+	repo := newTenantRepository(t)
+
+	id, err := repo.Add(tenantCtx, tenantQuestion("Where does the sun set?"))
+	if err != nil {
+		t.Fatalf("unable to add question: %s", err.Error())
+	}
+
+	before, err := repo.Get(tenantCtx, id)
+	if err != nil {
+		t.Fatalf("unable to get question: %s", err.Error())
+	}
+	east, west := before.Options[0], before.Options[1]
+
+	testCases := []struct {
+		name    string
+		options []entities.Option
+		kept    []int64
+	}{{
+		name:    "option changed",
+		options: []entities.Option{{Id: east.Id, Body: "East"}, {Id: west.Id, Body: "West, where it always sets", Correct: true}},
+		kept:    []int64{east.Id, west.Id},
+	}, {
+		name:    "options reordered",
+		options: []entities.Option{{Id: west.Id, Body: "West", Correct: true}, {Id: east.Id, Body: "East"}},
+		kept:    []int64{west.Id, east.Id},
+	}, {
+		name:    "option added",
+		options: []entities.Option{{Id: west.Id, Body: "West", Correct: true}, {Body: "North"}, {Id: east.Id, Body: "East"}},
+		kept:    []int64{west.Id, 0, east.Id},
+	}, {
+		name:    "option removed",
+		options: []entities.Option{{Id: east.Id, Body: "East"}, {Id: west.Id, Body: "West", Correct: true}},
+		kept:    []int64{east.Id, west.Id},
+	}, {
+		name:    "options without ids",
+		options: []entities.Option{{Body: "East"}, {Body: "West", Correct: true}},
+		kept:    []int64{0, 0},
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			q := entities.Question{Id: id, Body: "Where does the sun set?", Options: tc.options}
+			if err := repo.Update(tenantCtx, q); err != nil {
+				t.Fatalf("unable to update question: %s", err.Error())
+			}
+
+			after, err := repo.Get(tenantCtx, id)
+			if err != nil {
+				t.Fatalf("unable to get question: %s", err.Error())
+			}
+
+			if len(after.Options) != len(tc.options) {
+				t.Fatalf("expected options (%v), got options (%v)", tc.options, after.Options)
+			}
+
+			for i, o := range after.Options {
+				if o.Body != tc.options[i].Body || o.Correct != tc.options[i].Correct || o.OptionOrder != i {
+					t.Errorf("expected option (%v) at position (%d), got option (%v)", tc.options[i], i, o)
+				}
+
+				kept := o.Id == east.Id || o.Id == west.Id
+				if (tc.kept[i] != 0 && o.Id != tc.kept[i]) || (tc.kept[i] == 0 && kept) {
+					t.Errorf("expected option row (%d) at position (%d), got option row (%d)", tc.kept[i], i, o.Id)
+				}
+			}
+		})
+	}
+
+	// the options of another question can't be taken over
+	other, err := repo.Add(tenantCtx, tenantQuestion("Where does the sun rise?"))
+	if err != nil {
+		t.Fatalf("unable to add question: %s", err.Error())
+	}
+
+	stolen, err := repo.Get(tenantCtx, other)
+	if err != nil {
+		t.Fatalf("unable to get question: %s", err.Error())
+	}
+
+	q := entities.Question{Id: id, Body: "Where does the sun set?", Options: []entities.Option{{Body: "East"}, {Id: stolen.Options[1].Id, Body: "West", Correct: true}}}
+	if err = repo.Update(tenantCtx, q); !errors.Is(err, OptionNotFoundError) {
+		t.Errorf("expected error (%v), got error (%v)", OptionNotFoundError, err)
+	}
+}
+
+func TestValidSetStatus(t *testing.T) {
+	SqlOpen = MockOpener
+	repo, err := NewSqliteRepository("./test.db")
+	if err != nil {
+		t.Fatalf("unable to create mock repository: %s", err.Error())
+	}
+
+	dbMock.ExpectBegin()
+	dbMock.ExpectQuery(`SELECT status FROM questions`).WithArgs(1, "acme").WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow("in_review"))
+	dbMock.ExpectExec(`UPDATE questions SET status`).WithArgs(entities.Published, "Clear and correct", testTime.UnixNano(), "alice", 1).WillReturnResult(sqlmock.NewResult(0, 1))
+	dbMock.ExpectCommit()
+
+	err = repo.SetStatus(tenantCtx, 1, entities.InReview, entities.Published, "Clear and correct")
+	if err != nil {
+		t.Fatalf("unable to execute set status call: %s", err.Error())
+	}
+
+	if err = dbMock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %s", err.Error())
+	}
+}
+
+func TestChangedStatusSetStatus(t *testing.T) {
+	SqlOpen = MockOpener
+	repo, err := NewSqliteRepository("./test.db")
+	if err != nil {
+		t.Fatalf("unable to create mock repository: %s", err.Error())
+	}
+
+	// the question was approved by another request meanwhile
+	dbMock.ExpectBegin()
+	dbMock.ExpectQuery(`SELECT status FROM questions`).WithArgs(1, "acme").WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow("published"))
+	dbMock.ExpectRollback()
+
+	err = repo.SetStatus(tenantCtx, 1, entities.InReview, entities.Draft, "")
+	if !errors.Is(err, entities.StatusTransitionError) {
+		t.Errorf("expected error (%v), got error (%v)", entities.StatusTransitionError, err)
+	}
+
+	if err = dbMock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %s", err.Error())
+	}
+}
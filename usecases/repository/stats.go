@@ -0,0 +1,17 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+)
+
+// QuestionCount returns the number of questions of all the tenants
+// Unlike the other queries it isn't scoped to a tenant, it's used to monitor the whole database.
+func (r *SqliteRepository) QuestionCount(ctx context.Context) (int64, error) {
+	var n int64
+	if err := r.Handler.QueryRowContext(ctx, `SELECT COUNT(*) FROM questions`).Scan(&n); err != nil {
+		return 0, fmt.Errorf("unable to count questions: %s", err.Error())
+	}
+
+	return n, nil
+}
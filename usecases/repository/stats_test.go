@@ -0,0 +1,27 @@
+package repository
+
+import (
+	"context"
+	"testing"
+)
+
+func TestQuestionCount(t *testing.T) {
+	repo := newTenantRepository(t)
+	acme := NewTenantContext(context.Background(), "acme")
+	globex := NewTenantContext(context.Background(), "globex")
+
+	for _, ctx := range []context.Context{acme, acme, globex} {
+		if _, err := repo.Add(ctx, tenantQuestion("Where does the sun set?")); err != nil {
+			t.Fatalf("unable to add question: %s", err.Error())
+		}
+	}
+
+	n, err := repo.QuestionCount(context.Background())
+	if err != nil {
+		t.Fatalf("unable to count questions: %s", err.Error())
+	}
+
+	if n != 3 {
+		t.Errorf("expected (3) questions, got (%d)", n)
+	}
+}
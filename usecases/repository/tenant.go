@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+)
+
+var (
+	TenantRequiredError = fmt.Errorf("the request has no tenant")
+)
+
+// tenantKey is the context key of the tenant every query is scoped by
+type tenantKey struct{}
+
+// NewTenantContext returns a copy of ctx scoping the repository queries to the given tenant
+func NewTenantContext(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenant)
+}
+
+// TenantFromContext returns the tenant carried by ctx, or TenantRequiredError if there is none
+// Queries without a tenant are refused, so questions can never be read or written across tenants.
+func TenantFromContext(ctx context.Context) (string, error) {
+	tenant, _ := ctx.Value(tenantKey{}).(string)
+	if tenant == "" {
+		return "", TenantRequiredError
+	}
+
+	return tenant, nil
+}
+
+// actorKey is the context key of the subject recorded as the author of the question changes
+type actorKey struct{}
+
+// NewActorContext returns a copy of ctx recording the given subject as the author of the questions it creates or changes
+func NewActorContext(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFromContext returns the author carried by ctx, the changes made without one, like the command line imports, have no author
+func ActorFromContext(ctx context.Context) string {
+	actor, _ := ctx.Value(actorKey{}).(string)
+
+	return actor
+}
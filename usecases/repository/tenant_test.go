@@ -0,0 +1,456 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/norby7/questions-rest-api/entities"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTenantRepository returns a repository on a new sqlite database holding the migrated schema
+func newTenantRepository(t *testing.T) *SqliteRepository {
+	db, err := sql.Open("sqlite3", immediateTransactions(filepath.Join(t.TempDir(), "questions.db")))
+	if err != nil {
+		t.Fatalf("unable to open database: %s", err.Error())
+	}
+	t.Cleanup(func() { _ = db.Close() })
+
+	schema, err := ioutil.ReadFile("../../database/schema.sql")
+	if err != nil {
+		t.Fatalf("unable to read schema: %s", err.Error())
+	}
+
+	if _, err = db.Exec(string(schema)); err != nil {
+		t.Fatalf("unable to create schema: %s", err.Error())
+	}
+
+	dir := MigrationsDir
+	MigrationsDir = "../../database/migrations"
+	defer func() { MigrationsDir = dir }()
+
+	if err = migrate(db); err != nil {
+		t.Fatalf("unable to migrate schema: %s", err.Error())
+	}
+
+	return &SqliteRepository{Handler: db}
+}
+
+// tenantQuestion returns a valid question with the given body
+func tenantQuestion(body string) entities.Question {
+	return entities.Question{Body: body, Options: []entities.Option{{Body: "East", Correct: false}, {Body: "West", Correct: true}}}
+}
+
+func TestTenantIsolation(t *testing.T) {
+	repo := newTenantRepository(t)
+	acme := NewTenantContext(context.Background(), "acme")
+	globex := NewTenantContext(context.Background(), "globex")
+
+	acmeId, err := repo.Add(acme, tenantQuestion("Where does the sun set for acme?"))
+	if err != nil {
+		t.Fatalf("unable to add question: %s", err.Error())
+	}
+
+	if _, err = repo.AddAll(globex, []entities.Question{tenantQuestion("Where does the sun set for globex?")}); err != nil {
+		t.Fatalf("unable to add questions: %s", err.Error())
+	}
+
+	// every tenant only lists its own questions
+	acmeQuestions, err := repo.GetAll(acme, entities.QuestionFilter{Limit: 10})
+	if err != nil {
+		t.Fatalf("unable to list questions: %s", err.Error())
+	}
+
+	if len(acmeQuestions) != 1 || acmeQuestions[0].Id != acmeId || acmeQuestions[0].TenantId != "acme" {
+		t.Fatalf("expected only the acme question, got questions (%v)", acmeQuestions)
+	}
+
+	var globexQuestions []entities.Question
+	err = repo.ForEach(globex, func(q entities.Question) error {
+		globexQuestions = append(globexQuestions, q)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unable to iterate questions: %s", err.Error())
+	}
+
+	if len(globexQuestions) != 1 || globexQuestions[0].Id == acmeId || len(globexQuestions[0].Options) != 2 {
+		t.Fatalf("expected only the globex question, got questions (%v)", globexQuestions)
+	}
+
+	// another tenant can't read, change or delete the question, even knowing its id
+	if _, err = repo.Get(globex, acmeId); !errors.Is(err, QuestionNotFoundError) {
+		t.Errorf("expected error (%v) getting another tenant question, got error (%v)", QuestionNotFoundError, err)
+	}
+
+	q := tenantQuestion("Where does the sun rise for globex?")
+	q.Id = acmeId
+
+	if err = repo.Update(globex, q); !errors.Is(err, QuestionNotFoundError) {
+		t.Errorf("expected error (%v) updating another tenant question, got error (%v)", QuestionNotFoundError, err)
+	}
+
+	if err = repo.Delete(globex, acmeId); !errors.Is(err, QuestionNotFoundError) {
+		t.Errorf("expected error (%v) deleting another tenant question, got error (%v)", QuestionNotFoundError, err)
+	}
+
+	if _, err = repo.Batch(globex, []Operation{{Type: OperationDelete, Id: acmeId}}); !errors.Is(err, QuestionNotFoundError) {
+		t.Errorf("expected error (%v) in batch on another tenant question, got error (%v)", QuestionNotFoundError, err)
+	}
+
+	acmeQuestions, err = repo.GetAll(acme, entities.QuestionFilter{Limit: 10})
+	if err != nil {
+		t.Fatalf("unable to list questions: %s", err.Error())
+	}
+
+	if len(acmeQuestions) != 1 || acmeQuestions[0].Body != "Where does the sun set for acme?" || len(acmeQuestions[0].Options) != 2 {
+		t.Errorf("expected the acme question and its options unchanged, got questions (%v)", acmeQuestions)
+	}
+
+	// the owner can still delete it
+	if err = repo.Delete(acme, acmeId); err != nil {
+		t.Errorf("unable to delete question: %s", err.Error())
+	}
+}
+
+func TestMissingTenant(t *testing.T) {
+	repo := newTenantRepository(t)
+	ctx := context.Background()
+
+	testCases := []struct {
+		name string
+		call func() error
+	}{{
+		name: "add",
+		call: func() error {
+			_, err := repo.Add(ctx, tenantQuestion("Where does the sun set?"))
+			return err
+		},
+	}, {
+		name: "add all",
+		call: func() error {
+			_, err := repo.AddAll(ctx, []entities.Question{tenantQuestion("Where does the sun set?")})
+			return err
+		},
+	}, {
+		name: "update",
+		call: func() error { return repo.Update(ctx, tenantQuestion("Where does the sun set?")) },
+	}, {
+		name: "delete",
+		call: func() error { return repo.Delete(ctx, 1) },
+	}, {
+		name: "get",
+		call: func() error {
+			_, err := repo.Get(ctx, 1)
+			return err
+		},
+	}, {
+		name: "get all",
+		call: func() error {
+			_, err := repo.GetAll(ctx, entities.QuestionFilter{Limit: 10})
+			return err
+		},
+	}, {
+		name: "for each",
+		call: func() error { return repo.ForEach(ctx, func(entities.Question) error { return nil }) },
+	}, {
+		name: "batch",
+		call: func() error {
+			_, err := repo.Batch(ctx, []Operation{{Type: OperationDelete, Id: 1}})
+			return err
+		},
+	}, {
+		name: "set status",
+		call: func() error { return repo.SetStatus(ctx, 1, entities.Draft, entities.InReview, "") },
+	}, {
+		name: "append audit",
+		call: func() error { return repo.AppendAudit(ctx, entities.AuditEntry{Action: entities.AuditCreate}) },
+	}, {
+		name: "list audit",
+		call: func() error {
+			_, err := repo.ListAudit(ctx, entities.AuditFilter{})
+			return err
+		},
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := tc.call(); err != TenantRequiredError {
+				t.Errorf("expected error (%v), got error (%v)", TenantRequiredError, err)
+			}
+		})
+	}
+}
+
+func TestQuestionHistory(t *testing.T) {
+	repo := newTenantRepository(t)
+	defer func() { Now = func() time.Time { return testTime } }()
+
+	created := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+	updated := created.Add(time.Hour)
+	alice := NewActorContext(NewTenantContext(context.Background(), "acme"), "alice")
+	bob := NewActorContext(NewTenantContext(context.Background(), "acme"), "bob")
+
+	Now = func() time.Time { return created }
+	id, err := repo.Add(alice, tenantQuestion("Where does the sun set?"))
+	if err != nil {
+		t.Fatalf("unable to add question: %s", err.Error())
+	}
+
+	// an update without any change keeps the last update
+	Now = func() time.Time { return updated }
+	q, err := repo.Get(bob, id)
+	if err != nil {
+		t.Fatalf("unable to get question: %s", err.Error())
+	}
+
+	if err = repo.Update(bob, q); err != nil {
+		t.Fatalf("unable to update question: %s", err.Error())
+	}
+
+	q, err = repo.Get(bob, id)
+	if err != nil {
+		t.Fatalf("unable to get question: %s", err.Error())
+	}
+
+	if q.CreatedAt == nil || !q.CreatedAt.Equal(created) || q.CreatedBy != "alice" || q.UpdatedAt == nil || !q.UpdatedAt.Equal(created) || q.UpdatedBy != "alice" {
+		t.Errorf("expected created and updated by alice at (%v), got question (%v)", created, q)
+	}
+
+	q.Options[0].Correct = true
+	if err = repo.Update(bob, q); err != nil {
+		t.Fatalf("unable to update question: %s", err.Error())
+	}
+
+	q, err = repo.Get(bob, id)
+	if err != nil {
+		t.Fatalf("unable to get question: %s", err.Error())
+	}
+
+	if !q.CreatedAt.Equal(created) || q.CreatedBy != "alice" || q.UpdatedAt == nil || !q.UpdatedAt.Equal(updated) || q.UpdatedBy != "bob" {
+		t.Errorf("expected updated by bob at (%v), got question (%v)", updated, q)
+	}
+}
+
+func TestQuestionStatus(t *testing.T) {
+	repo := newTenantRepository(t)
+	bob := NewActorContext(tenantCtx, "bob")
+
+	id, err := repo.Add(tenantCtx, tenantQuestion("Where does the sun set?"))
+	if err != nil {
+		t.Fatalf("unable to add question: %s", err.Error())
+	}
+
+	q, err := repo.Get(tenantCtx, id)
+	if err != nil {
+		t.Fatalf("unable to get question: %s", err.Error())
+	}
+
+	if q.Status != entities.Draft {
+		t.Errorf("expected status (%s), got status (%s)", entities.Draft, q.Status)
+	}
+
+	if err = repo.SetStatus(bob, id, entities.InReview, entities.Published, "Clear and correct"); !errors.Is(err, entities.StatusTransitionError) {
+		t.Errorf("expected error (%v), got error (%v)", entities.StatusTransitionError, err)
+	}
+
+	globex := NewTenantContext(context.Background(), "globex")
+	if err = repo.SetStatus(globex, id, entities.Draft, entities.InReview, ""); err != QuestionNotFoundError {
+		t.Errorf("expected error (%v), got error (%v)", QuestionNotFoundError, err)
+	}
+
+	if err = repo.SetStatus(bob, id, entities.Draft, entities.InReview, "Please review"); err != nil {
+		t.Fatalf("unable to set status: %s", err.Error())
+	}
+
+	// the tags alone don't change the status
+	q.Tags = []string{"geography"}
+	if err = repo.Update(tenantCtx, q); err != nil {
+		t.Fatalf("unable to update question: %s", err.Error())
+	}
+
+	q, err = repo.Get(tenantCtx, id)
+	if err != nil {
+		t.Fatalf("unable to get question: %s", err.Error())
+	}
+
+	if q.Status != entities.InReview || q.ReviewComment != "Please review" {
+		t.Errorf("expected status (%s) and comment (Please review), got question (%v)", entities.InReview, q)
+	}
+
+	ql, err := repo.GetAll(tenantCtx, entities.QuestionFilter{Status: entities.InReview})
+	if err != nil {
+		t.Fatalf("unable to list questions: %s", err.Error())
+	}
+
+	if len(ql) != 1 || ql[0].Id != id {
+		t.Errorf("expected question (%d) in review, got questions (%v)", id, ql)
+	}
+
+	// a changed question is reviewed again
+	q.Body = "Where does the sun rise?"
+	if err = repo.Update(tenantCtx, q); err != nil {
+		t.Fatalf("unable to update question: %s", err.Error())
+	}
+
+	q, err = repo.Get(tenantCtx, id)
+	if err != nil {
+		t.Fatalf("unable to get question: %s", err.Error())
+	}
+
+	if q.Status != entities.Draft || q.ReviewComment != "" {
+		t.Errorf("expected status (%s) without comment, got question (%v)", entities.Draft, q)
+	}
+
+	if ql, err = repo.GetAll(tenantCtx, entities.QuestionFilter{Status: entities.Published}); err != nil || len(ql) != 0 {
+		t.Errorf("expected no published question, got questions (%v) and error (%v)", ql, err)
+	}
+
+	// the retired questions can't be changed
+	if _, err = repo.Handler.Exec(`UPDATE questions SET status = ? WHERE id = ?`, entities.Retired, id); err != nil {
+		t.Fatalf("unable to retire question: %s", err.Error())
+	}
+
+	q.Options[0].Body = "North"
+	if err = repo.Update(tenantCtx, q); !errors.Is(err, entities.StatusTransitionError) {
+		t.Errorf("expected error (%v), got error (%v)", entities.StatusTransitionError, err)
+	}
+
+	if q, err = repo.Get(tenantCtx, id); err != nil || q.Options[0].Body != "East" {
+		t.Errorf("expected the retired question unchanged, got question (%v) and error (%v)", q, err)
+	}
+}
+
+func TestImportedQuestionMetadata(t *testing.T) {
+	source := newTenantRepository(t)
+	bob := NewActorContext(tenantCtx, "bob")
+
+	id, err := source.Add(tenantCtx, tenantQuestion("Where does the sun set?"))
+	if err != nil {
+		t.Fatalf("unable to add question: %s", err.Error())
+	}
+
+	if err = source.SetStatus(bob, id, entities.Draft, entities.InReview, "Please review"); err != nil {
+		t.Fatalf("unable to set status: %s", err.Error())
+	}
+
+	var exported []entities.Question
+	err = source.ForEach(tenantCtx, func(q entities.Question) error {
+		exported = append(exported, q)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unable to iterate questions: %s", err.Error())
+	}
+
+	// the questions imported in another bank keep the metadata they were exported with
+	target := newTenantRepository(t)
+	carol := NewActorContext(tenantCtx, "carol")
+	ids, err := target.AddAll(carol, exported)
+	if err != nil {
+		t.Fatalf("unable to add questions: %s", err.Error())
+	}
+
+	q, err := target.Get(carol, ids[0])
+	if err != nil {
+		t.Fatalf("unable to get question: %s", err.Error())
+	}
+
+	e := exported[0]
+	if q.Body != e.Body || len(q.Options) != len(e.Options) || q.CreatedBy != "alice" || q.UpdatedBy != "bob" || q.CreatedAt == nil || !q.CreatedAt.Equal(*e.CreatedAt) ||
+		q.UpdatedAt == nil || !q.UpdatedAt.Equal(*e.UpdatedAt) || q.Status != entities.InReview || q.ReviewComment != "Please review" {
+		t.Errorf("expected question (%v), got question (%v)", e, q)
+	}
+}
+
+func TestQuestionTags(t *testing.T) {
+	repo := newTenantRepository(t)
+
+	q := tenantQuestion("Where does the sun set?")
+	q.Tags = []string{"geography", " astronomy", "geography"}
+
+	id, err := repo.Add(tenantCtx, q)
+	if err != nil {
+		t.Fatalf("unable to add question: %s", err.Error())
+	}
+
+	q, err = repo.Get(tenantCtx, id)
+	if err != nil {
+		t.Fatalf("unable to get question: %s", err.Error())
+	}
+
+	if strings.Join(q.Tags, ",") != "astronomy,geography" {
+		t.Errorf("expected tags (astronomy,geography), got tags (%v)", q.Tags)
+	}
+
+	q.Tags = []string{"astronomy", "solar system"}
+	if err = repo.Update(tenantCtx, q); err != nil {
+		t.Fatalf("unable to update question: %s", err.Error())
+	}
+
+	ops := []Operation{{Type: OperationTag, Id: id, AddTags: []string{"easy"}, RemoveTags: []string{"astronomy"}}}
+	if _, err = repo.Batch(tenantCtx, ops); err != nil {
+		t.Fatalf("unable to tag question: %s", err.Error())
+	}
+
+	var exported []entities.Question
+	err = repo.ForEach(tenantCtx, func(q entities.Question) error {
+		exported = append(exported, q)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unable to iterate questions: %s", err.Error())
+	}
+
+	if len(exported) != 1 || strings.Join(exported[0].Tags, ",") != "easy,solar system" {
+		t.Errorf("expected tags (easy,solar system), got questions (%v)", exported)
+	}
+
+	globex := NewTenantContext(context.Background(), "globex")
+	if _, err = repo.Batch(globex, ops); !errors.Is(err, QuestionNotFoundError) {
+		t.Errorf("expected error (%v), got error (%v)", QuestionNotFoundError, err)
+	}
+
+	// the tags are deleted with their question
+	if err = repo.Delete(tenantCtx, id); err != nil {
+		t.Fatalf("unable to delete question: %s", err.Error())
+	}
+
+	var n int
+	if err = repo.Handler.QueryRow(`SELECT count(*) FROM tags`).Scan(&n); err != nil || n != 0 {
+		t.Errorf("expected no tags, got (%d) tags and error (%v)", n, err)
+	}
+}
+
+func TestQuestionDifficulty(t *testing.T) {
+	repo := newTenantRepository(t)
+
+	q := tenantQuestion("Where does the sun set?")
+	q.Difficulty = 2
+
+	id, err := repo.Add(tenantCtx, q)
+	if err != nil {
+		t.Fatalf("unable to add question: %s", err.Error())
+	}
+
+	if q, err = repo.Get(tenantCtx, id); err != nil || q.Difficulty != 2 {
+		t.Fatalf("expected difficulty (2), got question (%v) and error (%v)", q, err)
+	}
+
+	// a change of the difficulty alone is an update of the question
+	defer func() { Now = func() time.Time { return testTime } }()
+	Now = func() time.Time { return testTime.Add(time.Hour) }
+
+	q.Difficulty = 4
+	if err = repo.Update(NewActorContext(tenantCtx, "bob"), q); err != nil {
+		t.Fatalf("unable to update question: %s", err.Error())
+	}
+
+	if q, err = repo.Get(tenantCtx, id); err != nil || q.Difficulty != 4 || q.UpdatedBy != "bob" || !q.UpdatedAt.Equal(testTime.Add(time.Hour)) {
+		t.Errorf("expected difficulty (4) updated by bob, got question (%v) and error (%v)", q, err)
+	}
+}
@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// txKey is the context key of the transaction the repository operations run in, see InTransaction
+type txKey struct{}
+
+// immediateTransactions returns the data source name p starting the transactions with BEGIN IMMEDIATE, unless p sets
+// the transaction locking mode already
+// The transactions read the questions before changing them: a deferred one fails when another transaction writes
+// meanwhile, an immediate one takes the write lock first, so the concurrent changes wait for each other.
+func immediateTransactions(p string) string {
+	if strings.Contains(p, "_txlock=") {
+		return p
+	}
+
+	if strings.Contains(p, "?") {
+		return p + "&_txlock=immediate"
+	}
+
+	return p + "?_txlock=immediate"
+}
+
+// conn is implemented by sql.DB and sql.Tx
+type conn interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// InTransaction calls fn with a copy of ctx running every repository operation, the audit log ones included, in a single
+// transaction, which is committed when fn returns nil and rolled back otherwise
+// Calls nested in fn join the transaction. An operation that fails inside fn only rolls back its own changes, so fn can
+// go on after handling the error.
+func (r *SqliteRepository) InTransaction(ctx context.Context, fn func(context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return fn(ctx)
+	}
+
+	tx, err := r.Handler.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("unable to start transaction: %s", err.Error())
+	}
+
+	if err = fn(context.WithValue(ctx, txKey{}, tx)); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		_ = tx.Rollback()
+		return fmt.Errorf("unable to commit transation: %s", err.Error())
+	}
+
+	return nil
+}
+
+// conn returns the transaction of ctx, or the database handler when the operation doesn't run in InTransaction
+func (r *SqliteRepository) conn(ctx context.Context) conn {
+	if tx, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return tx
+	}
+
+	return r.Handler
+}
+
+// transaction is the transaction of a single write operation
+// Inside InTransaction it's a savepoint of the outer transaction, committing releases it and rolling back only undoes the
+// changes made since it was created.
+type transaction struct {
+	*sql.Tx
+	savepoint bool
+}
+
+// begin starts the transaction of a write operation
+func (r *SqliteRepository) begin(ctx context.Context) (*transaction, error) {
+	tx, ok := ctx.Value(txKey{}).(*sql.Tx)
+	if !ok {
+		tx, err := r.Handler.BeginTx(ctx, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		return &transaction{Tx: tx}, nil
+	}
+
+	if _, err := tx.ExecContext(ctx, `SAVEPOINT operation`); err != nil {
+		return nil, err
+	}
+
+	return &transaction{Tx: tx, savepoint: true}, nil
+}
+
+// Commit commits the transaction, or releases the savepoint
+func (t *transaction) Commit() error {
+	if !t.savepoint {
+		return t.Tx.Commit()
+	}
+
+	_, err := t.Exec(`RELEASE operation`)
+
+	return err
+}
+
+// Rollback rolls back the transaction, or the changes made since the savepoint
+func (t *transaction) Rollback() error {
+	if !t.savepoint {
+		return t.Tx.Rollback()
+	}
+
+	if _, err := t.Exec(`ROLLBACK TO operation`); err != nil {
+		return err
+	}
+
+	_, err := t.Exec(`RELEASE operation`)
+
+	return err
+}
@@ -0,0 +1,174 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/norby7/questions-rest-api/entities"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestInTransaction(t *testing.T) {
+	failure := errors.New("audit failure")
+
+	testCases := []struct {
+		name      string
+		fn        func(r *SqliteRepository) func(context.Context) error
+		err       error
+		questions int
+		entries   int
+	}{{
+		name: "committed",
+		fn: func(r *SqliteRepository) func(context.Context) error {
+			return func(ctx context.Context) error {
+				id, err := r.Add(ctx, tenantQuestion("Where does the sun set?"))
+				if err != nil {
+					return err
+				}
+
+				return r.AppendAudit(ctx, entities.AuditEntry{Time: time.Now(), Action: entities.AuditCreate, QuestionId: id})
+			}
+		},
+		questions: 1,
+		entries:   1,
+	}, {
+		name: "rolled back",
+		fn: func(r *SqliteRepository) func(context.Context) error {
+			return func(ctx context.Context) error {
+				id, err := r.Add(ctx, tenantQuestion("Where does the sun set?"))
+				if err != nil {
+					return err
+				}
+
+				if err = r.AppendAudit(ctx, entities.AuditEntry{Time: time.Now(), Action: entities.AuditCreate, QuestionId: id}); err != nil {
+					return err
+				}
+
+				return failure
+			}
+		},
+		err: failure,
+	}, {
+		name: "failed operation",
+		fn: func(r *SqliteRepository) func(context.Context) error {
+			return func(ctx context.Context) error {
+				if _, err := r.Add(ctx, tenantQuestion("Where does the sun set?")); err != nil {
+					return err
+				}
+
+				// only the changes of the failed batch are rolled back, the transaction goes on
+				ops := []Operation{{Type: OperationCreate, Question: tenantQuestion("Where does the sun rise?")}, {Type: OperationDelete, Id: 42}}
+				if _, err := r.Batch(ctx, ops); !errors.Is(err, QuestionNotFoundError) {
+					t.Errorf("expected error (%v), got error (%v)", QuestionNotFoundError, err)
+				}
+
+				return nil
+			}
+		},
+		questions: 1,
+	}, {
+		name: "nested",
+		fn: func(r *SqliteRepository) func(context.Context) error {
+			return func(ctx context.Context) error {
+				return r.InTransaction(ctx, func(ctx context.Context) error {
+					if _, err := r.Add(ctx, tenantQuestion("Where does the sun set?")); err != nil {
+						return err
+					}
+
+					return failure
+				})
+			}
+		},
+		err: failure,
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := newTenantRepository(t)
+
+			if err := repo.InTransaction(tenantCtx, tc.fn(repo)); err != tc.err {
+				t.Fatalf("expected error (%v), got error (%v)", tc.err, err)
+			}
+
+			ql, err := repo.GetAll(tenantCtx, entities.QuestionFilter{})
+			if err != nil {
+				t.Fatalf("unable to list questions: %s", err.Error())
+			}
+
+			if len(ql) != tc.questions {
+				t.Errorf("expected (%d) questions, got questions (%v)", tc.questions, ql)
+			}
+
+			el, err := repo.ListAudit(tenantCtx, entities.AuditFilter{})
+			if err != nil {
+				t.Fatalf("unable to list audit entries: %s", err.Error())
+			}
+
+			if len(el) != tc.entries {
+				t.Errorf("expected (%d) audit entries, got entries (%v)", tc.entries, el)
+			}
+		})
+	}
+}
+
+func TestConcurrentTransactions(t *testing.T) {
+	repo := newTenantRepository(t)
+
+	id, err := repo.Add(tenantCtx, tenantQuestion("Where does the sun set?"))
+	if err != nil {
+		t.Fatalf("unable to add question: %s", err.Error())
+	}
+
+	// every transaction reads the question and adds a tag to it, none of the tags must be lost
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(tag string) {
+			defer wg.Done()
+
+			errs <- repo.InTransaction(tenantCtx, func(ctx context.Context) error {
+				q, err := repo.Get(ctx, id)
+				if err != nil {
+					return err
+				}
+
+				q.Tags = append(q.Tags, tag)
+				return repo.Update(ctx, q)
+			})
+		}(fmt.Sprintf("tag-%d", i))
+	}
+
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Errorf("unable to change question: %s", err.Error())
+		}
+	}
+
+	q, err := repo.Get(tenantCtx, id)
+	if err != nil {
+		t.Fatalf("unable to get question: %s", err.Error())
+	}
+
+	if len(q.Tags) != 10 {
+		t.Errorf("expected (10) tags, got tags (%s)", strings.Join(q.Tags, ","))
+	}
+}
+
+func TestImmediateTransactions(t *testing.T) {
+	for p, expected := range map[string]string{
+		"./questions.db":                      "./questions.db?_txlock=immediate",
+		"file:questions.db?cache=shared":      "file:questions.db?cache=shared&_txlock=immediate",
+		"file:questions.db?_txlock=exclusive": "file:questions.db?_txlock=exclusive",
+	} {
+		if dsn := immediateTransactions(p); dsn != expected {
+			t.Errorf("expected data source name (%s), got (%s)", expected, dsn)
+		}
+	}
+}
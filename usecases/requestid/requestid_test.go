@@ -0,0 +1,24 @@
+package requestid
+
+import (
+	"context"
+	"testing"
+)
+
+func TestNew(t *testing.T) {
+	a, b := New(), New()
+
+	if len(a) != 32 || a == b {
+		t.Errorf("expected two different 32 characters ids, got ids (%s) and (%s)", a, b)
+	}
+}
+
+func TestContext(t *testing.T) {
+	if id := FromContext(context.Background()); id != "" {
+		t.Errorf("expected no request id, got id (%s)", id)
+	}
+
+	if id := FromContext(NewContext(context.Background(), "abc")); id != "abc" {
+		t.Errorf("expected request id (abc), got id (%s)", id)
+	}
+}
@@ -0,0 +1,236 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/norby7/questions-rest-api/entities"
+	"github.com/norby7/questions-rest-api/usecases/auth"
+	"github.com/norby7/questions-rest-api/usecases/repository"
+	"github.com/norby7/questions-rest-api/usecases/requestid"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Change is a single difference between two states of a question
+// Path is a JSON pointer to the changed value, Old and New are the values before and after the change.
+type Change struct {
+	Op   string      `json:"op"`
+	Path string      `json:"path"`
+	Old  interface{} `json:"old,omitempty"`
+	New  interface{} `json:"new,omitempty"`
+}
+
+const (
+	ChangeAdd     = "add"
+	ChangeRemove  = "remove"
+	ChangeReplace = "replace"
+)
+
+// AuditLog returns the audit entries of the principal tenant matching the filter
+func (s *Service) AuditLog(ctx context.Context, f entities.AuditFilter) ([]entities.AuditEntry, error) {
+	if err := s.Policy.Authorize(ctx, ActionAudit); err != nil {
+		return nil, err
+	}
+
+	if s.Audit == nil {
+		return []entities.AuditEntry{}, nil
+	}
+
+	return s.Audit.ListAudit(ctx, f)
+}
+
+// audit records a change of the question with the given id, before is nil for created questions and after is nil for deleted ones
+// Nothing is recorded when the service has no audit repository. It must be called in the transaction of the change, see
+// Repository.InTransaction, so that the entry is only recorded with the change.
+func (s *Service) audit(ctx context.Context, action entities.AuditAction, id int64, before, after *entities.Question) error {
+	if s.Audit == nil {
+		return nil
+	}
+
+	diff, err := Diff(before, after)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(diff)
+	if err != nil {
+		return fmt.Errorf("unable to encode audit diff: %s", err.Error())
+	}
+
+	e := entities.AuditEntry{
+		Time:       time.Now().UTC(),
+		RequestId:  requestid.FromContext(ctx),
+		Action:     action,
+		QuestionId: id,
+		Diff:       data,
+	}
+
+	if p, ok := auth.FromContext(ctx); ok {
+		e.Actor = p.Subject
+	}
+
+	if err = s.Audit.AppendAudit(ctx, e); err != nil {
+		return fmt.Errorf("unable to record audit entry: %s", err.Error())
+	}
+
+	return nil
+}
+
+// stored returns q with the review status it's stored with, updated questions keep the status of before
+// New questions without status are drafts, only the imported ones have a status. The status of the updated questions
+// is only changed by Transition, the one sent by clients is ignored.
+func stored(q entities.Question, before *entities.Question) *entities.Question {
+	switch {
+	case before != nil:
+		q.Status, q.ReviewComment = before.Status, before.ReviewComment
+	case q.Status == "":
+		q.Status = entities.Draft
+	}
+
+	return &q
+}
+
+// snapshot returns the current state of the question with the given id, to be recorded before changing it
+// Nothing is returned when the service has no audit repository or the question doesn't exist.
+func (s *Service) snapshot(ctx context.Context, id int64) (*entities.Question, error) {
+	if s.Audit == nil {
+		return nil, nil
+	}
+
+	q, err := s.Repo.Get(ctx, id)
+	if err == repository.QuestionNotFoundError {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &q, nil
+}
+
+// Diff returns the list of changes between two states of a question, either of them can be nil
+// The question id and the creation and update times and authors aren't compared, the audit entries record them already.
+func Diff(before, after *entities.Question) ([]Change, error) {
+	if before != nil {
+		q := before.WithoutMetadata()
+		q.Id = 0
+		before = &q
+	}
+
+	if after != nil {
+		q := after.WithoutMetadata()
+		q.Id = 0
+		after = &q
+	}
+
+	b, err := genericValue(before)
+	if err != nil {
+		return nil, err
+	}
+
+	a, err := genericValue(after)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := []Change{}
+	diffValues("", b, a, &changes)
+
+	return changes, nil
+}
+
+// genericValue converts a question to its JSON representation made of maps, slices and scalars
+func genericValue(q *entities.Question) (interface{}, error) {
+	if q == nil {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(q)
+	if err != nil {
+		return nil, fmt.Errorf("unable to encode question: %s", err.Error())
+	}
+
+	var v interface{}
+	if err = json.Unmarshal(data, &v); err != nil {
+		return nil, fmt.Errorf("unable to decode question: %s", err.Error())
+	}
+
+	return v, nil
+}
+
+// diffValues appends the changes between the JSON values a and b found at path
+func diffValues(path string, a, b interface{}, changes *[]Change) {
+	switch {
+	case a == nil && b == nil:
+		return
+	case a == nil:
+		*changes = append(*changes, Change{Op: ChangeAdd, Path: path, New: b})
+		return
+	case b == nil:
+		*changes = append(*changes, Change{Op: ChangeRemove, Path: path, Old: a})
+		return
+	}
+
+	switch av := a.(type) {
+	case map[string]interface{}:
+		bv, ok := b.(map[string]interface{})
+		if !ok {
+			break
+		}
+
+		keys := make([]string, 0, len(av)+len(bv))
+		for k := range av {
+			keys = append(keys, k)
+		}
+		for k := range bv {
+			if _, ok := av[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			diffValues(path+"/"+escapePointer(k), av[k], bv[k], changes)
+		}
+
+		return
+	case []interface{}:
+		bv, ok := b.([]interface{})
+		if !ok {
+			break
+		}
+
+		n := len(av)
+		if len(bv) > n {
+			n = len(bv)
+		}
+
+		for i := 0; i < n; i++ {
+			var ai, bi interface{}
+			if i < len(av) {
+				ai = av[i]
+			}
+			if i < len(bv) {
+				bi = bv[i]
+			}
+
+			diffValues(path+"/"+strconv.Itoa(i), ai, bi, changes)
+		}
+
+		return
+	default:
+		if a == b {
+			return
+		}
+	}
+
+	*changes = append(*changes, Change{Op: ChangeReplace, Path: path, Old: a, New: b})
+}
+
+// escapePointer escapes a key to be used as a JSON pointer token
+func escapePointer(k string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(k)
+}
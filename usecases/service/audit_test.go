@@ -0,0 +1,237 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/norby7/questions-rest-api/entities"
+	"github.com/norby7/questions-rest-api/usecases/repository"
+	"github.com/norby7/questions-rest-api/usecases/requestid"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// auditMock keeps the appended audit entries in memory
+type auditMock struct {
+	entries []entities.AuditEntry
+}
+
+func (a *auditMock) AppendAudit(ctx context.Context, e entities.AuditEntry) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	a.entries = append(a.entries, e)
+	return nil
+}
+
+func (a *auditMock) ListAudit(ctx context.Context, f entities.AuditFilter) ([]entities.AuditEntry, error) {
+	return a.entries, nil
+}
+
+func TestDiff(t *testing.T) {
+	before := validQuestion("Where does the sun rise?")
+	before.Id = 1
+
+	after := validQuestion("Where does the sun set?")
+	after.Id = 1
+	after.Options[0].Correct = true
+	after.Options = append(after.Options, entities.Option{Body: "North"})
+
+	now := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+	touched := before
+	touched.UpdatedAt, touched.UpdatedBy = &now, "bob"
+
+	testCases := []struct {
+		name     string
+		before   *entities.Question
+		after    *entities.Question
+		expected []Change
+	}{{
+		name:     "unchanged",
+		before:   &before,
+		after:    &before,
+		expected: []Change{},
+	}, {
+		name:   "changed",
+		before: &before,
+		after:  &after,
+		expected: []Change{
+			{Op: ChangeReplace, Path: "/body", Old: "Where does the sun rise?", New: "Where does the sun set?"},
+			{Op: ChangeReplace, Path: "/options/0/correct", Old: false, New: true},
+			{Op: ChangeAdd, Path: "/options/2", New: map[string]interface{}{"body": "North", "correct": false}},
+		},
+	}, {
+		name:     "metadata only",
+		before:   &before,
+		after:    &touched,
+		expected: []Change{},
+	}, {
+		name:  "created",
+		after: &entities.Question{Id: 2, Body: "Where does the sun set?"},
+		expected: []Change{
+			{Op: ChangeAdd, Path: "", New: map[string]interface{}{"body": "Where does the sun set?", "options": nil}},
+		},
+	}, {
+		name:   "deleted",
+		before: &entities.Question{Id: 2, Body: "Where does the sun set?", Options: []entities.Option{}},
+		expected: []Change{
+			{Op: ChangeRemove, Path: "", Old: map[string]interface{}{"body": "Where does the sun set?", "options": []interface{}{}}},
+		},
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			changes, err := Diff(tc.before, tc.after)
+			if err != nil {
+				t.Fatalf("unable to compute diff: %s", err.Error())
+			}
+
+			if !reflect.DeepEqual(changes, tc.expected) {
+				t.Errorf("expected changes (%v), got changes (%v)", tc.expected, changes)
+			}
+		})
+	}
+}
+
+func TestAudit(t *testing.T) {
+	ctx := requestid.NewContext(adminCtx, "request-1")
+	q := validQuestion("Where does the sun set?")
+
+	testCases := []struct {
+		name    string
+		call    func(s *Service) error
+		actions []entities.AuditAction
+		ids     []int64
+		changes int
+	}{{
+		name: "create",
+		call: func(s *Service) error {
+			_, _, err := s.Create(ctx, q)
+			return err
+		},
+		actions: []entities.AuditAction{entities.AuditCreate},
+		ids:     []int64{1},
+		changes: 1,
+	}, {
+		name: "update",
+		call: func(s *Service) error {
+			u := q
+			u.Id = 1
+			_, err := s.Update(ctx, u)
+			return err
+		},
+		actions: []entities.AuditAction{entities.AuditUpdate},
+		ids:     []int64{1},
+		changes: 2,
+	}, {
+		name:    "remove",
+		call:    func(s *Service) error { return s.Remove(ctx, 1) },
+		actions: []entities.AuditAction{entities.AuditDelete},
+		ids:     []int64{1},
+		changes: 1,
+	}, {
+		name: "canceled remove",
+		call: func(s *Service) error {
+			canceled, cancel := context.WithCancel(ctx)
+			cancel()
+
+			// the change and its audit entry are applied in the same transaction, neither of them is once ctx is canceled
+			if err := s.Remove(canceled, 1); err == nil {
+				return fmt.Errorf("expected the canceled remove to fail")
+			}
+
+			return nil
+		},
+	}, {
+		name: "failed remove",
+		call: func(s *Service) error {
+			_ = s.Remove(ctx, 2)
+			return nil
+		},
+	}, {
+		name: "import",
+		call: func(s *Service) error {
+			_, err := s.Import(ctx, &readerMock{questions: []entities.Question{q, q}, errs: []error{nil, nil}}, ImportAtomic, false)
+			return err
+		},
+		actions: []entities.AuditAction{entities.AuditImport, entities.AuditImport},
+		ids:     []int64{1, 2},
+		changes: 1,
+	}, {
+		name: "batch",
+		call: func(s *Service) error {
+			_, err := s.Batch(ctx, []BatchOperation{{Op: repository.OperationCreate, Question: &q}, {Op: repository.OperationDelete, Id: 1}}, BatchBestEffort)
+			return err
+		},
+		actions: []entities.AuditAction{entities.AuditCreate, entities.AuditDelete},
+		ids:     []int64{100, 1},
+		changes: 1,
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			a := &auditMock{}
+			s := &Service{Repo: &RepositoryMock{}, Policy: DefaultPolicy, Audit: a}
+
+			if err := tc.call(s); err != nil {
+				t.Fatalf("unable to call the service: %s", err.Error())
+			}
+
+			if len(a.entries) != len(tc.actions) {
+				t.Fatalf("expected (%d) audit entries, got entries (%v)", len(tc.actions), a.entries)
+			}
+
+			for i, e := range a.entries {
+				if e.Action != tc.actions[i] || e.QuestionId != tc.ids[i] || e.Actor != "admin" || e.RequestId != "request-1" || e.Time.IsZero() {
+					t.Errorf("expected action (%s) of question (%d) by admin, got entry (%v)", tc.actions[i], tc.ids[i], e)
+				}
+
+				var changes []Change
+				if err := json.Unmarshal(e.Diff, &changes); err != nil {
+					t.Fatalf("unable to decode diff: %s", err.Error())
+				}
+
+				if len(changes) != tc.changes {
+					t.Errorf("expected (%d) changes, got changes (%v)", tc.changes, changes)
+				}
+			}
+		})
+	}
+}
+
+func TestAuditLogPolicy(t *testing.T) {
+	s := &Service{Repo: &RepositoryMock{}, Policy: DefaultPolicy, Audit: &auditMock{}}
+
+	if _, err := s.AuditLog(roleCtx(entities.RoleEditor), entities.AuditFilter{}); err == nil {
+		t.Errorf("expected editors not to be allowed to read the audit log")
+	}
+
+	if _, err := s.AuditLog(adminCtx, entities.AuditFilter{}); err != nil {
+		t.Errorf("unable to read the audit log: %s", err.Error())
+	}
+}
+
+func TestAuditStoredQuestion(t *testing.T) {
+	a := &auditMock{}
+	s := NewService(&RepositoryMock{})
+	s.Audit = a
+
+	q := validQuestion("Where does the sun set?")
+	q.Options[0].Id, q.Options[1].Id = 7, 7
+
+	if _, _, err := s.Create(adminCtx, q); err != nil {
+		t.Fatalf("unable to create question: %s", err.Error())
+	}
+
+	var changes []Change
+	if len(a.entries) != 1 || json.Unmarshal(a.entries[0].Diff, &changes) != nil || len(changes) != 1 {
+		t.Fatalf("expected a single create entry, got entries (%v)", a.entries)
+	}
+
+	// the question read back from the repository is recorded, not the requested one
+	if body := changes[0].New.(map[string]interface{})["body"]; body != "Where does the sun rise?" {
+		t.Errorf("expected the stored question to be recorded, got body (%v)", body)
+	}
+}
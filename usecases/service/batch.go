@@ -0,0 +1,340 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/norby7/questions-rest-api/entities"
+	"github.com/norby7/questions-rest-api/usecases/repository"
+)
+
+// BatchMode defines whether the operations of a batch are applied together or independently
+type BatchMode string
+
+const (
+	// BatchAtomic applies all the operations in a single transaction, or none of them if any operation fails
+	BatchAtomic BatchMode = "atomic"
+	// BatchBestEffort applies every valid operation on its own and reports the failing ones
+	BatchBestEffort BatchMode = "best-effort"
+)
+
+// BatchStatus is the outcome of a single batch operation
+type BatchStatus string
+
+const (
+	BatchStatusOk         BatchStatus = "ok"
+	BatchStatusInvalid    BatchStatus = "invalid"
+	BatchStatusNotFound   BatchStatus = "not_found"
+	BatchStatusFailed     BatchStatus = "failed"
+	BatchStatusSkipped    BatchStatus = "skipped"
+	BatchStatusRolledBack BatchStatus = "rolled_back"
+)
+
+var (
+	BatchModeError      = fmt.Errorf("batch mode should be either %s or %s", BatchAtomic, BatchBestEffort)
+	BatchOperationError = fmt.Errorf("operation should be one of %s, %s, %s or %s",
+		repository.OperationCreate, repository.OperationUpdate, repository.OperationDelete, repository.OperationTag)
+	BatchIdError       = fmt.Errorf("operation requires a question id")
+	BatchQuestionError = fmt.Errorf("operation requires a question")
+	BatchTagsError     = fmt.Errorf("tag operation requires tags to add or remove")
+)
+
+// BatchOperation is a single create, update, delete or tag operation of a batch
+type BatchOperation struct {
+	// create, update, delete or tag
+	Op repository.OperationType `json:"op"`
+	// id of the question to update, delete or tag
+	Id int64 `json:"id,omitempty"`
+	// question to create, or new content of the question to update
+	Question *entities.Question `json:"question,omitempty"`
+	// tags added to the question by a tag operation
+	AddTags []string `json:"add_tags,omitempty"`
+	// tags removed from the question by a tag operation
+	RemoveTags []string `json:"remove_tags,omitempty"`
+}
+
+// BatchResult is the outcome of a single batch operation
+type BatchResult struct {
+	// position of the operation inside the batch, starting from 0
+	Index int                      `json:"index"`
+	Op    repository.OperationType `json:"op"`
+	// id of the affected question, the new id for create operations
+	Id         int64       `json:"id,omitempty"`
+	Status     BatchStatus `json:"status"`
+	Error      string      `json:"error,omitempty"`
+	Duplicates []Duplicate `json:"duplicates,omitempty"`
+}
+
+// BatchReport summarizes a batch and contains the result of every operation
+type BatchReport struct {
+	Mode      BatchMode     `json:"mode"`
+	Succeeded int           `json:"succeeded"`
+	Failed    int           `json:"failed"`
+	Results   []BatchResult `json:"results"`
+}
+
+// ParseBatchMode returns the batch mode with the given name, defaulting to BatchAtomic
+func ParseBatchMode(m string) (BatchMode, error) {
+	switch BatchMode(m) {
+	case "", BatchAtomic:
+		return BatchAtomic, nil
+	case BatchBestEffort:
+		return BatchBestEffort, nil
+	}
+
+	return "", BatchModeError
+}
+
+// Batch validates and applies a list of operations according to the batch mode
+// The principal must be allowed to perform every operation of the batch, otherwise nothing is applied.
+func (s *Service) Batch(ctx context.Context, ops []BatchOperation, mode BatchMode) (BatchReport, error) {
+	if mode != BatchAtomic && mode != BatchBestEffort {
+		return BatchReport{}, BatchModeError
+	}
+
+	for _, op := range ops {
+		if a, ok := batchAction(op); ok {
+			if err := s.Policy.Authorize(ctx, a); err != nil {
+				return BatchReport{}, err
+			}
+		}
+	}
+
+	report := BatchReport{Mode: mode, Results: make([]BatchResult, len(ops))}
+	repoOps := make([]repository.Operation, len(ops))
+	invalid := false
+
+	// the questions created in atomic mode aren't stored before the end of the batch, the following creates are compared to them
+	var pending []pendingQuestion
+
+	for i, op := range ops {
+		res := &report.Results[i]
+		res.Index, res.Op, res.Id = i, op.Op, op.Id
+
+		var err error
+		repoOps[i], res.Duplicates, err = s.prepareOperation(ctx, op, pending)
+		if err != nil {
+			res.Status, res.Error = BatchStatusInvalid, err.Error()
+			invalid = true
+			continue
+		}
+
+		if mode == BatchAtomic && op.Op == repository.OperationCreate {
+			pending = append(pending, pendingQuestion{index: i, body: repoOps[i].Question.Body})
+		}
+
+		if mode == BatchBestEffort {
+			// the operations already applied are kept, the remaining ones aren't reported as failed one by one
+			if err := ctx.Err(); err != nil {
+				return BatchReport{}, err
+			}
+
+			// the failed operations are reported, only the errors of the audit log stop the batch
+			err = s.Repo.InTransaction(ctx, func(ctx context.Context) error {
+				before, err := s.operationSnapshot(ctx, repoOps[i])
+				if err != nil {
+					return err
+				}
+
+				ids, err := s.Repo.Batch(ctx, repoOps[i:i+1])
+				if err != nil {
+					res.Status, res.Error = batchErrorStatus(err), batchErrorMessage(err)
+					return nil
+				}
+
+				res.Status, res.Id = BatchStatusOk, ids[0]
+				return s.auditOperation(ctx, repoOps[i], ids[0], before)
+			})
+			if err != nil {
+				return BatchReport{}, err
+			}
+		}
+	}
+
+	if mode == BatchAtomic {
+		if err := s.applyAtomic(ctx, repoOps, invalid, &report); err != nil {
+			return BatchReport{}, err
+		}
+	}
+
+	for _, res := range report.Results {
+		switch res.Status {
+		case BatchStatusOk:
+			report.Succeeded++
+		case BatchStatusInvalid, BatchStatusNotFound, BatchStatusFailed:
+			report.Failed++
+		}
+	}
+
+	return report, nil
+}
+
+// applyAtomic applies all the operations in a single transaction, unless some of them are invalid
+func (s *Service) applyAtomic(ctx context.Context, ops []repository.Operation, invalid bool, report *BatchReport) error {
+	if invalid {
+		for i := range report.Results {
+			if report.Results[i].Status == "" {
+				report.Results[i].Status = BatchStatusSkipped
+			}
+		}
+
+		return nil
+	}
+
+	if len(ops) == 0 {
+		return nil
+	}
+
+	// the operations are audited in the transaction of the batch, a failed audit rolls back the whole batch
+	return s.Repo.InTransaction(ctx, func(ctx context.Context) error {
+		befores := make([]*entities.Question, len(ops))
+		for i, op := range ops {
+			var err error
+			if befores[i], err = s.operationSnapshot(ctx, op); err != nil {
+				return err
+			}
+		}
+
+		ids, err := s.Repo.Batch(ctx, ops)
+		if err != nil {
+			var batchErr *repository.BatchError
+			if !errors.As(err, &batchErr) {
+				return fmt.Errorf("unable to apply batch: %s", err.Error())
+			}
+
+			for i := range report.Results {
+				report.Results[i].Status = BatchStatusRolledBack
+			}
+
+			res := &report.Results[batchErr.Index]
+			res.Status, res.Error = batchErrorStatus(err), batchErrorMessage(err)
+
+			return nil
+		}
+
+		for i, op := range ops {
+			if err = s.auditOperation(ctx, op, ids[i], befores[i]); err != nil {
+				return err
+			}
+		}
+
+		for i := range report.Results {
+			report.Results[i].Status, report.Results[i].Id = BatchStatusOk, ids[i]
+		}
+
+		return nil
+	})
+}
+
+// operationSnapshot returns the state of the question changed by an update or delete operation, before applying it
+func (s *Service) operationSnapshot(ctx context.Context, op repository.Operation) (*entities.Question, error) {
+	if op.Type == repository.OperationCreate {
+		return nil, nil
+	}
+
+	return s.snapshot(ctx, op.Id)
+}
+
+// auditOperation records an applied batch operation, id is the id of the affected question
+func (s *Service) auditOperation(ctx context.Context, op repository.Operation, id int64, before *entities.Question) error {
+	q := op.Question
+	q.Id = id
+
+	switch op.Type {
+	case repository.OperationCreate:
+		return s.audit(ctx, entities.AuditCreate, id, nil, stored(q, nil))
+	case repository.OperationUpdate, repository.OperationTag:
+		// the question is read back, an update can send it back to draft and the tags are merged with its own
+		if s.Audit == nil {
+			return nil
+		}
+
+		after, err := s.Repo.Get(ctx, id)
+		if err != nil {
+			return fmt.Errorf("unable to read the changed question: %s", err.Error())
+		}
+
+		return s.audit(ctx, entities.AuditUpdate, id, before, &after)
+	default:
+		return s.audit(ctx, entities.AuditDelete, id, before, nil)
+	}
+}
+
+// prepareOperation validates a batch operation and converts it into a repository operation
+// Create operations follow the duplicate policy, their near-duplicates are returned, including the pending questions
+// created earlier in the batch.
+func (s *Service) prepareOperation(ctx context.Context, op BatchOperation, pending []pendingQuestion) (repository.Operation, []Duplicate, error) {
+	ro := repository.Operation{Type: op.Op, Id: op.Id}
+
+	switch op.Op {
+	case repository.OperationCreate, repository.OperationUpdate:
+		if op.Question == nil {
+			return ro, nil, BatchQuestionError
+		}
+
+		if op.Op == repository.OperationUpdate && op.Id <= 0 {
+			return ro, nil, BatchIdError
+		}
+
+		ro.Question = *op.Question
+		if op.Op == repository.OperationUpdate {
+			ro.Question.Id = op.Id
+		} else {
+			ro.Question = newQuestion(ro.Question)
+		}
+
+		if err := ro.Question.Validate(); err != nil {
+			return ro, nil, err
+		}
+
+		if op.Op == repository.OperationCreate {
+			duplicates, err := s.checkDuplicates(ctx, ro.Question, pending...)
+			return ro, duplicates, err
+		}
+	case repository.OperationDelete:
+		if op.Id <= 0 {
+			return ro, nil, BatchIdError
+		}
+	case repository.OperationTag:
+		if op.Id <= 0 {
+			return ro, nil, BatchIdError
+		}
+
+		if len(op.AddTags) == 0 && len(op.RemoveTags) == 0 {
+			return ro, nil, BatchTagsError
+		}
+
+		if err := entities.ValidateTags(append(append([]string{}, op.AddTags...), op.RemoveTags...)); err != nil {
+			return ro, nil, err
+		}
+
+		ro.AddTags, ro.RemoveTags = op.AddTags, op.RemoveTags
+	default:
+		return ro, nil, BatchOperationError
+	}
+
+	return ro, nil, nil
+}
+
+// batchErrorStatus returns the status of an operation that failed with the given repository error
+func batchErrorStatus(err error) BatchStatus {
+	if errors.Is(err, repository.QuestionNotFoundError) {
+		return BatchStatusNotFound
+	}
+
+	if errors.Is(err, repository.OptionNotFoundError) {
+		return BatchStatusInvalid
+	}
+
+	return BatchStatusFailed
+}
+
+// batchErrorMessage returns the error message of a failed operation, without the operation position
+func batchErrorMessage(err error) string {
+	var batchErr *repository.BatchError
+	if errors.As(err, &batchErr) {
+		return batchErr.Err.Error()
+	}
+
+	return err.Error()
+}
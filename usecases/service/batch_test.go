@@ -0,0 +1,149 @@
+package service
+
+import (
+	"github.com/norby7/questions-rest-api/entities"
+	"github.com/norby7/questions-rest-api/usecases/repository"
+	"testing"
+)
+
+func TestBatch(t *testing.T) {
+	r := &RepositoryMock{}
+	s := Service{Repo: r, Policy: DefaultPolicy}
+
+	valid := validQuestion("Where does the sun set?")
+	failing := validQuestion("add error question")
+
+	create := BatchOperation{Op: repository.OperationCreate, Question: &valid}
+	update := BatchOperation{Op: repository.OperationUpdate, Id: 3, Question: &valid}
+	remove := BatchOperation{Op: repository.OperationDelete, Id: 4}
+	missing := BatchOperation{Op: repository.OperationDelete, Id: 404}
+	unknownOption := BatchOperation{Op: repository.OperationUpdate, Id: 422, Question: &valid}
+	failingCreate := BatchOperation{Op: repository.OperationCreate, Question: &failing}
+	tag := BatchOperation{Op: repository.OperationTag, Id: 5, AddTags: []string{"geography"}, RemoveTags: []string{"draft"}}
+
+	testCases := []struct {
+		name      string
+		ops       []BatchOperation
+		mode      BatchMode
+		isError   bool
+		succeeded int
+		failed    int
+		statuses  []BatchStatus
+		ids       []int64
+	}{
+		{
+			name:      "atomic, all valid",
+			ops:       []BatchOperation{create, update, remove, tag},
+			mode:      BatchAtomic,
+			succeeded: 4,
+			statuses:  []BatchStatus{BatchStatusOk, BatchStatusOk, BatchStatusOk, BatchStatusOk},
+			ids:       []int64{100, 3, 4, 5},
+		},
+		{
+			name: "atomic, invalid operations",
+			ops: []BatchOperation{create, {Op: "archive", Id: 1}, {Op: repository.OperationUpdate, Question: &valid}, {Op: repository.OperationDelete},
+				{Op: repository.OperationCreate, Question: &entities.Question{}}, {Op: repository.OperationTag, Id: 5}, {Op: repository.OperationTag, AddTags: []string{"geography"}},
+				{Op: repository.OperationTag, Id: 5, AddTags: []string{"geography,astronomy"}}},
+			mode:     BatchAtomic,
+			failed:   7,
+			statuses: []BatchStatus{BatchStatusSkipped, BatchStatusInvalid, BatchStatusInvalid, BatchStatusInvalid, BatchStatusInvalid, BatchStatusInvalid, BatchStatusInvalid, BatchStatusInvalid},
+			ids:      []int64{0, 1, 0, 0, 0, 5, 0, 5},
+		},
+		{
+			name:     "atomic, missing question",
+			ops:      []BatchOperation{create, missing, remove},
+			mode:     BatchAtomic,
+			failed:   1,
+			statuses: []BatchStatus{BatchStatusRolledBack, BatchStatusNotFound, BatchStatusRolledBack},
+			ids:      []int64{0, 404, 4},
+		},
+		{
+			name:     "atomic, unknown option",
+			ops:      []BatchOperation{create, unknownOption},
+			mode:     BatchAtomic,
+			failed:   1,
+			statuses: []BatchStatus{BatchStatusRolledBack, BatchStatusInvalid},
+			ids:      []int64{0, 422},
+		},
+		{
+			name:      "best effort, mixed operations",
+			ops:       []BatchOperation{create, missing, failingCreate, {Op: repository.OperationDelete}, remove},
+			mode:      BatchBestEffort,
+			succeeded: 2,
+			failed:    3,
+			statuses:  []BatchStatus{BatchStatusOk, BatchStatusNotFound, BatchStatusFailed, BatchStatusInvalid, BatchStatusOk},
+			ids:       []int64{100, 404, 0, 0, 4},
+		},
+		{
+			name:    "invalid mode",
+			ops:     []BatchOperation{create},
+			mode:    "partial",
+			isError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			report, err := s.Batch(adminCtx, tc.ops, tc.mode)
+
+			if (err != nil) != tc.isError {
+				t.Fatalf("expected error (%v), got error (%v)", tc.isError, err)
+			}
+
+			if report.Succeeded != tc.succeeded || report.Failed != tc.failed {
+				t.Errorf("expected (%d) succeeded and (%d) failed, got (%d) and (%d)", tc.succeeded, tc.failed, report.Succeeded, report.Failed)
+			}
+
+			for i, res := range report.Results {
+				if res.Index != i || res.Status != tc.statuses[i] || res.Id != tc.ids[i] {
+					t.Errorf("expected operation (%d) status (%v) and id (%d), got (%v) and (%d)", i, tc.statuses[i], tc.ids[i], res.Status, res.Id)
+				}
+			}
+		})
+	}
+}
+
+func TestBatchDuplicates(t *testing.T) {
+	s := Service{Repo: &RepositoryMock{}, Policy: DefaultPolicy, DuplicatePolicy: DuplicatePolicy{Mode: DuplicatesBlock, Threshold: DefaultDuplicateThreshold}}
+
+	q := validQuestion("Which planet is the largest one?")
+	ops := []BatchOperation{{Op: repository.OperationCreate, Question: &q}, {Op: repository.OperationCreate, Question: &q}}
+
+	// the second question is a duplicate of the first one, even though it isn't stored yet
+	report, err := s.Batch(adminCtx, ops, BatchAtomic)
+	if err != nil {
+		t.Fatalf("unable to apply batch: %s", err.Error())
+	}
+
+	if report.Results[0].Status != BatchStatusSkipped || report.Results[1].Status != BatchStatusInvalid {
+		t.Fatalf("expected statuses (%v) and (%v), got results (%v)", BatchStatusSkipped, BatchStatusInvalid, report.Results)
+	}
+
+	duplicates := report.Results[1].Duplicates
+	if len(duplicates) != 1 || duplicates[0].BatchIndex == nil || *duplicates[0].BatchIndex != 0 || duplicates[0].QuestionId != 0 {
+		t.Errorf("expected the first operation as duplicate, got duplicates (%v)", duplicates)
+	}
+}
+
+func TestParseBatchMode(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected BatchMode
+		isError  bool
+	}{
+		{input: "", expected: BatchAtomic},
+		{input: "atomic", expected: BatchAtomic},
+		{input: "best-effort", expected: BatchBestEffort},
+		{input: "partial", isError: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			mode, err := ParseBatchMode(tc.input)
+
+			if (err != nil) != tc.isError || mode != tc.expected {
+				t.Errorf("expected mode (%v) and error (%v), got (%v) and (%v)", tc.expected, tc.isError, mode, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,329 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/norby7/questions-rest-api/entities"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// DuplicateMode defines what Create does when the new question is a near-duplicate of an existing one
+type DuplicateMode string
+
+const (
+	// DuplicatesOff disables the duplicate detection on Create
+	DuplicatesOff DuplicateMode = "off"
+	// DuplicatesWarn creates the question and returns the near-duplicates that were found
+	DuplicatesWarn DuplicateMode = "warn"
+	// DuplicatesBlock refuses to create questions that have near-duplicates
+	DuplicatesBlock DuplicateMode = "block"
+)
+
+const (
+	// DefaultDuplicateThreshold is the similarity above which two questions are considered duplicates
+	DefaultDuplicateThreshold = 0.8
+	// shingleSize is the number of characters in each shingle of a normalized question body
+	shingleSize = 4
+)
+
+var (
+	DuplicateQuestionError  = fmt.Errorf("question is a near-duplicate of an existing question")
+	DuplicateModeError      = fmt.Errorf("duplicate mode should be one of %s, %s or %s", DuplicatesOff, DuplicatesWarn, DuplicatesBlock)
+	DuplicateThresholdError = fmt.Errorf("duplicate threshold should be greater than 0 and at most 1")
+)
+
+// DuplicatePolicy configures the duplicate detection performed on Create
+type DuplicatePolicy struct {
+	Mode      DuplicateMode
+	Threshold float64
+}
+
+// DefaultDuplicatePolicy warns about questions that are at least 80% similar to an existing one
+var DefaultDuplicatePolicy = DuplicatePolicy{Mode: DuplicatesWarn, Threshold: DefaultDuplicateThreshold}
+
+// Duplicate is an existing question similar to another question
+// The duplicates created earlier in the same batch aren't stored yet, they have the position of their operation instead of an id.
+type Duplicate struct {
+	QuestionId int64   `json:"question_id,omitempty"`
+	BatchIndex *int    `json:"batch_index,omitempty"`
+	Body       string  `json:"body"`
+	Similarity float64 `json:"similarity"`
+}
+
+// pendingQuestion is a question created by a batch operation that isn't stored yet
+type pendingQuestion struct {
+	// position of the create operation inside the batch
+	index int
+	body  string
+}
+
+// DuplicatePair is a pair of questions whose similarity is above the threshold
+type DuplicatePair struct {
+	First      int64   `json:"first"`
+	Second     int64   `json:"second"`
+	Similarity float64 `json:"similarity"`
+}
+
+// DuplicateGroup is a set of questions that are likely duplicates of each other
+// The similarity of each question is the highest similarity to another question of the group.
+type DuplicateGroup struct {
+	Questions []Duplicate     `json:"questions"`
+	Pairs     []DuplicatePair `json:"pairs"`
+}
+
+// ParseDuplicateMode returns the duplicate mode with the given name
+func ParseDuplicateMode(m string) (DuplicateMode, error) {
+	switch DuplicateMode(m) {
+	case DuplicatesOff, DuplicatesWarn, DuplicatesBlock:
+		return DuplicateMode(m), nil
+	}
+
+	return "", DuplicateModeError
+}
+
+// normalize lower cases the text and reduces it to words made of letters and digits separated by single spaces
+func normalize(s string) string {
+	words := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+
+	return strings.Join(words, " ")
+}
+
+// shingles returns the set of overlapping character sequences of the normalized text
+func shingles(s string) map[string]struct{} {
+	r := []rune(normalize(s))
+	set := map[string]struct{}{}
+
+	if len(r) <= shingleSize {
+		if len(r) > 0 {
+			set[string(r)] = struct{}{}
+		}
+		return set
+	}
+
+	for i := 0; i+shingleSize <= len(r); i++ {
+		set[string(r[i:i+shingleSize])] = struct{}{}
+	}
+
+	return set
+}
+
+// jaccard returns the size of the intersection of the sets divided by the size of their union
+func jaccard(a, b map[string]struct{}) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+
+	inter := 0
+	for s := range a {
+		if _, ok := b[s]; ok {
+			inter++
+		}
+	}
+
+	return float64(inter) / float64(len(a)+len(b)-inter)
+}
+
+// Similarity returns how similar the bodies of two questions are, from 0 (unrelated) to 1 (same normalized text)
+func Similarity(a, b string) float64 {
+	return jaccard(shingles(a), shingles(b))
+}
+
+// FindDuplicates returns the existing questions whose similarity to q is at least the threshold, most similar first
+func (s *Service) FindDuplicates(ctx context.Context, q entities.Question, threshold float64) ([]Duplicate, error) {
+	if threshold <= 0 || threshold > 1 {
+		return nil, DuplicateThresholdError
+	}
+
+	set := shingles(q.Body)
+	duplicates := []Duplicate{}
+
+	err := s.Repo.ForEach(ctx, func(e entities.Question) error {
+		// an existing question isn't a duplicate of itself
+		if q.Id != 0 && e.Id == q.Id {
+			return nil
+		}
+
+		if sim := jaccard(set, shingles(e.Body)); sim >= threshold {
+			duplicates = append(duplicates, Duplicate{QuestionId: e.Id, Body: e.Body, Similarity: round(sim)})
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to search for duplicates: %s", err.Error())
+	}
+
+	sort.SliceStable(duplicates, func(i, j int) bool {
+		return duplicates[i].Similarity > duplicates[j].Similarity
+	})
+
+	return duplicates, nil
+}
+
+// checkDuplicates applies the duplicate policy to a new question, comparing it to the stored questions and the pending ones
+// It returns the near-duplicates of the question, and DuplicateQuestionError if duplicates are blocked.
+func (s *Service) checkDuplicates(ctx context.Context, q entities.Question, pending ...pendingQuestion) ([]Duplicate, error) {
+	if s.DuplicatePolicy.Mode != DuplicatesWarn && s.DuplicatePolicy.Mode != DuplicatesBlock {
+		return nil, nil
+	}
+
+	duplicates, err := s.FindDuplicates(ctx, q, s.DuplicatePolicy.Threshold)
+	if err != nil {
+		return nil, err
+	}
+
+	set := shingles(q.Body)
+	for _, p := range pending {
+		if sim := jaccard(set, shingles(p.body)); sim >= s.DuplicatePolicy.Threshold {
+			index := p.index
+			duplicates = append(duplicates, Duplicate{BatchIndex: &index, Body: p.body, Similarity: round(sim)})
+		}
+	}
+
+	sort.SliceStable(duplicates, func(i, j int) bool {
+		return duplicates[i].Similarity > duplicates[j].Similarity
+	})
+
+	if len(duplicates) > 0 && s.DuplicatePolicy.Mode == DuplicatesBlock {
+		d := duplicates[0]
+		if d.BatchIndex != nil {
+			return duplicates, fmt.Errorf("%w: operation %d of the batch, similarity %.3f", DuplicateQuestionError, *d.BatchIndex, d.Similarity)
+		}
+
+		return duplicates, fmt.Errorf("%w: question %d, similarity %.3f", DuplicateQuestionError, d.QuestionId, d.Similarity)
+	}
+
+	return duplicates, nil
+}
+
+// Duplicates groups every question of the database with the questions it is likely a duplicate of
+// An inverted index of the shingles is used so each question is only compared to the questions it shares shingles with.
+func (s *Service) Duplicates(ctx context.Context, threshold float64) ([]DuplicateGroup, error) {
+	if err := s.Policy.Authorize(ctx, ActionDuplicates); err != nil {
+		return nil, err
+	}
+
+	if threshold <= 0 || threshold > 1 {
+		return nil, DuplicateThresholdError
+	}
+
+	var questions []Duplicate
+	var sets []map[string]struct{}
+	index := map[string][]int{}
+	var pairs [][2]int
+	var scores []float64
+
+	err := s.Repo.ForEach(ctx, func(q entities.Question) error {
+		i := len(questions)
+		set := shingles(q.Body)
+
+		// count the shingles shared with every previous question
+		shared := map[int]int{}
+		for sh := range set {
+			for _, j := range index[sh] {
+				shared[j]++
+			}
+			index[sh] = append(index[sh], i)
+		}
+
+		candidates := make([]int, 0, len(shared))
+		for j := range shared {
+			candidates = append(candidates, j)
+		}
+		sort.Ints(candidates)
+
+		for _, j := range candidates {
+			inter := shared[j]
+			if sim := float64(inter) / float64(len(set)+len(sets[j])-inter); sim >= threshold {
+				pairs = append(pairs, [2]int{j, i})
+				scores = append(scores, round(sim))
+			}
+		}
+
+		questions = append(questions, Duplicate{QuestionId: q.Id, Body: q.Body})
+		sets = append(sets, set)
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to search for duplicates: %s", err.Error())
+	}
+
+	return groupDuplicates(questions, pairs, scores), nil
+}
+
+// groupDuplicates joins the questions connected by the given pairs into groups, ordered by their highest similarity
+func groupDuplicates(questions []Duplicate, pairs [][2]int, scores []float64) []DuplicateGroup {
+	parent := make([]int, len(questions))
+	for i := range parent {
+		parent[i] = i
+	}
+
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+
+	paired := make([]bool, len(questions))
+	for k, p := range pairs {
+		parent[find(p[0])] = find(p[1])
+
+		for _, i := range p {
+			paired[i] = true
+			if scores[k] > questions[i].Similarity {
+				questions[i].Similarity = scores[k]
+			}
+		}
+	}
+
+	groupIndex := map[int]int{}
+	groups := []DuplicateGroup{}
+	for i, q := range questions {
+		if !paired[i] {
+			continue
+		}
+
+		root := find(i)
+		g, ok := groupIndex[root]
+		if !ok {
+			g = len(groups)
+			groupIndex[root] = g
+			groups = append(groups, DuplicateGroup{})
+		}
+
+		groups[g].Questions = append(groups[g].Questions, q)
+	}
+
+	for k, p := range pairs {
+		g := groupIndex[find(p[0])]
+		groups[g].Pairs = append(groups[g].Pairs, DuplicatePair{First: questions[p[0]].QuestionId, Second: questions[p[1]].QuestionId, Similarity: scores[k]})
+	}
+
+	for _, g := range groups {
+		sort.SliceStable(g.Pairs, func(i, j int) bool {
+			return g.Pairs[i].Similarity > g.Pairs[j].Similarity
+		})
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool {
+		return groups[i].Pairs[0].Similarity > groups[j].Pairs[0].Similarity
+	})
+
+	return groups
+}
+
+// round keeps three decimals of a similarity score
+func round(f float64) float64 {
+	return float64(int(f*1000+0.5)) / 1000
+}
@@ -0,0 +1,221 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/norby7/questions-rest-api/entities"
+	"sync"
+	"testing"
+)
+
+// bankMock is a repository holding a fixed list of questions
+type bankMock struct {
+	RepositoryMock
+	questions []entities.Question
+	added     int
+}
+
+func (b *bankMock) Add(ctx context.Context, q entities.Question) (int64, error) {
+	b.added++
+	return int64(b.added), nil
+}
+
+func (b *bankMock) ForEach(ctx context.Context, fn func(entities.Question) error) error {
+	for _, q := range b.questions {
+		if err := fn(q); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func newBankMock(bodies ...string) *bankMock {
+	b := &bankMock{}
+	for i, body := range bodies {
+		b.questions = append(b.questions, entities.Question{Id: int64(i + 1), Body: body})
+	}
+
+	return b
+}
+
+func TestSimilarity(t *testing.T) {
+	testCases := []struct {
+		name string
+		a, b string
+		min  float64
+		max  float64
+	}{
+		{name: "identical", a: "Where does the sun set?", b: "Where does the sun set?", min: 1, max: 1},
+		{name: "case and punctuation", a: "Where does the sun set?", b: "  where DOES the sun, set ??", min: 1, max: 1},
+		{name: "reworded", a: "Where does the sun set?", b: "Where does the sun set in the evening?", min: 0.5, max: 0.9},
+		{name: "different", a: "Where does the sun set?", b: "What is the capital of France?", min: 0, max: 0.2},
+		{name: "empty", a: "", b: "Where does the sun set?", min: 0, max: 0},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			sim := Similarity(tc.a, tc.b)
+
+			if sim < tc.min || sim > tc.max {
+				t.Errorf("expected similarity between (%v) and (%v), got (%v)", tc.min, tc.max, sim)
+			}
+		})
+	}
+}
+
+func TestCreateDuplicates(t *testing.T) {
+	q := entities.Question{
+		Body: "where does the sun set",
+		Options: []entities.Option{{
+			Body:    "East",
+			Correct: false,
+		}, {
+			Body:    "West",
+			Correct: true,
+		}},
+	}
+
+	testCases := []struct {
+		name          string
+		mode          DuplicateMode
+		duplicates    int
+		added         int
+		expectedError error
+	}{
+		{name: "off", mode: DuplicatesOff, duplicates: 0, added: 1},
+		{name: "warn", mode: DuplicatesWarn, duplicates: 1, added: 1},
+		{name: "block", mode: DuplicatesBlock, duplicates: 1, added: 0, expectedError: DuplicateQuestionError},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := newBankMock("Where does the sun set?", "What is the capital of France?")
+			s := Service{Repo: r, DuplicatePolicy: DuplicatePolicy{Mode: tc.mode, Threshold: 0.8}, Policy: DefaultPolicy}
+
+			_, duplicates, err := s.Create(adminCtx, q)
+
+			if !errors.Is(err, tc.expectedError) {
+				t.Errorf("expected error (%v), got error (%v)", tc.expectedError, err)
+			}
+
+			if len(duplicates) != tc.duplicates || r.added != tc.added {
+				t.Errorf("expected (%d) duplicates and (%d) added, got (%v) and (%d)", tc.duplicates, tc.added, duplicates, r.added)
+			}
+
+			if len(duplicates) > 0 && (duplicates[0].QuestionId != 1 || duplicates[0].Similarity != 1) {
+				t.Errorf("unexpected duplicate (%v)", duplicates[0])
+			}
+		})
+	}
+}
+
+// txBankMock is a bank running its transactions one at a time, like the immediate transactions of the repository
+type txBankMock struct {
+	bankMock
+	mu sync.Mutex
+}
+
+func (b *txBankMock) InTransaction(ctx context.Context, fn func(context.Context) error) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return fn(context.WithValue(ctx, txKey{}, true))
+}
+
+func (b *txBankMock) Add(ctx context.Context, q entities.Question) (int64, error) {
+	id, _ := b.bankMock.Add(ctx, q)
+	q.Id = id
+	b.questions = append(b.questions, q)
+
+	return id, nil
+}
+
+func (b *txBankMock) ForEach(ctx context.Context, fn func(entities.Question) error) error {
+	if ctx.Value(txKey{}) == nil {
+		return fmt.Errorf("the questions are read outside of the transaction")
+	}
+
+	return b.bankMock.ForEach(ctx, fn)
+}
+
+func TestCreateDuplicatesConcurrently(t *testing.T) {
+	r := &txBankMock{}
+	s := Service{Repo: r, DuplicatePolicy: DuplicatePolicy{Mode: DuplicatesBlock, Threshold: 0.8}, Policy: DefaultPolicy}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 5)
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			_, _, err := s.Create(adminCtx, validQuestion("Where does the sun set?"))
+			errs <- err
+		}()
+	}
+
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil && !errors.Is(err, DuplicateQuestionError) {
+			t.Errorf("expected error (%v), got error (%v)", DuplicateQuestionError, err)
+		}
+	}
+
+	if r.added != 1 {
+		t.Errorf("expected a single question to be created, got (%d)", r.added)
+	}
+}
+
+func TestDuplicates(t *testing.T) {
+	r := newBankMock(
+		"Where does the sun set?",
+		"What is the capital of France?",
+		"where does the sun set",
+		"Which city is the capital of France?",
+		"Where does the Sun set ?!",
+		"How many legs does a spider have?",
+	)
+	s := Service{Repo: r, Policy: DefaultPolicy}
+
+	groups, err := s.Duplicates(adminCtx, 0.6)
+	if err != nil {
+		t.Fatalf("unable to search for duplicates: %s", err.Error())
+	}
+
+	if len(groups) != 2 {
+		t.Fatalf("expected (2) groups, got (%v)", groups)
+	}
+
+	var ids []int64
+	for _, q := range groups[0].Questions {
+		ids = append(ids, q.QuestionId)
+	}
+
+	if len(ids) != 3 || ids[0] != 1 || ids[1] != 3 || ids[2] != 5 || len(groups[0].Pairs) != 3 || groups[0].Pairs[0].Similarity != 1 {
+		t.Errorf("unexpected first group (%v)", groups[0])
+	}
+
+	if len(groups[1].Questions) != 2 || groups[1].Questions[0].QuestionId != 2 || groups[1].Questions[1].QuestionId != 4 {
+		t.Errorf("unexpected second group (%v)", groups[1])
+	}
+
+	if _, err = s.Duplicates(adminCtx, 1.5); err != DuplicateThresholdError {
+		t.Errorf("expected error (%v), got error (%v)", DuplicateThresholdError, err)
+	}
+}
+
+func TestParseDuplicateMode(t *testing.T) {
+	for _, m := range []string{"off", "warn", "block"} {
+		if mode, err := ParseDuplicateMode(m); err != nil || string(mode) != m {
+			t.Errorf("expected mode (%v), got mode (%v) with error (%v)", m, mode, err)
+		}
+	}
+
+	if _, err := ParseDuplicateMode("reject"); err != DuplicateModeError {
+		t.Errorf("expected error (%v), got error (%v)", DuplicateModeError, err)
+	}
+}
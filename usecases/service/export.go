@@ -0,0 +1,86 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/norby7/questions-rest-api/entities"
+	"strings"
+)
+
+// QuestionWriter is the destination of a questions export
+type QuestionWriter interface {
+	Write(entities.Question) error
+}
+
+// QuestionChecker is implemented by writers that can't represent every valid question
+type QuestionChecker interface {
+	Check(entities.Question) error
+}
+
+// QuizWriter is implemented by the writers of the quiz formats, whose questions are served to candidates
+// Only the published questions are written to the writers whose Quiz method returns true.
+type QuizWriter interface {
+	Quiz() bool
+}
+
+// ExportProblem describes a question that can't be written by the export writer
+type ExportProblem struct {
+	QuestionId int64  `json:"question_id"`
+	Error      string `json:"error"`
+}
+
+// ExportError is returned when some questions can't be exported, in which case nothing was written
+type ExportError struct {
+	Problems []ExportProblem
+}
+
+func (e *ExportError) Error() string {
+	msgs := make([]string, 0, len(e.Problems))
+	for _, p := range e.Problems {
+		msgs = append(msgs, fmt.Sprintf("question %d: %s", p.QuestionId, p.Error))
+	}
+
+	return fmt.Sprintf("%d questions can't be exported: %s", len(e.Problems), strings.Join(msgs, "; "))
+}
+
+// Export streams every question in the database, with its options, to the given writer
+// If the writer is a QuestionChecker every question is checked first, and nothing is written if any check fails.
+// If the writer is a QuizWriter only the published questions are checked and written.
+func (s *Service) Export(ctx context.Context, w QuestionWriter) error {
+	if err := s.Policy.Authorize(ctx, ActionExport); err != nil {
+		return err
+	}
+
+	forEach := s.Repo.ForEach
+	if qw, ok := w.(QuizWriter); ok && qw.Quiz() {
+		forEach = func(ctx context.Context, fn func(entities.Question) error) error {
+			return s.Repo.ForEach(ctx, func(q entities.Question) error {
+				if q.Status != entities.Published {
+					return nil
+				}
+
+				return fn(q)
+			})
+		}
+	}
+
+	if c, ok := w.(QuestionChecker); ok {
+		var problems []ExportProblem
+		err := forEach(ctx, func(q entities.Question) error {
+			if err := c.Check(q); err != nil {
+				problems = append(problems, ExportProblem{QuestionId: q.Id, Error: err.Error()})
+			}
+
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		if len(problems) > 0 {
+			return &ExportError{Problems: problems}
+		}
+	}
+
+	return forEach(ctx, w.Write)
+}
@@ -0,0 +1,118 @@
+package service
+
+import (
+	"errors"
+	"github.com/norby7/questions-rest-api/entities"
+	"testing"
+)
+
+// writerMock collects the exported questions and fails once it holds max questions
+type writerMock struct {
+	questions []entities.Question
+	max       int
+}
+
+func (w *writerMock) Write(q entities.Question) error {
+	if len(w.questions) == w.max {
+		return exportError
+	}
+
+	w.questions = append(w.questions, q)
+
+	return nil
+}
+
+func TestExport(t *testing.T) {
+	r := &RepositoryMock{}
+	s := Service{Repo: r, Policy: DefaultPolicy}
+
+	testCases := []struct {
+		name          string
+		max           int
+		count         int
+		expectedError error
+	}{
+		{
+			name:  "valid export",
+			max:   10,
+			count: 2,
+		},
+		{
+			name:          "writer error",
+			max:           1,
+			count:         1,
+			expectedError: exportError,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := &writerMock{max: tc.max}
+			err := s.Export(adminCtx, w)
+
+			if !errors.Is(err, tc.expectedError) {
+				t.Errorf("expected error (%v), got error (%v)", tc.expectedError, err)
+			}
+
+			if len(w.questions) != tc.count {
+				t.Errorf("expected (%d) exported questions, got (%d)", tc.count, len(w.questions))
+			}
+		})
+	}
+}
+
+// checkerMock is a writer that can't represent questions without options
+type checkerMock struct {
+	writerMock
+}
+
+func (c *checkerMock) Check(q entities.Question) error {
+	if len(q.Options) == 0 {
+		return exportError
+	}
+
+	return nil
+}
+
+func TestExportChecks(t *testing.T) {
+	r := &RepositoryMock{}
+	s := Service{Repo: r, Policy: DefaultPolicy}
+
+	w := &checkerMock{writerMock{max: 10}}
+	err := s.Export(adminCtx, w)
+
+	var exportErr *ExportError
+	if !errors.As(err, &exportErr) {
+		t.Fatalf("expected export error, got error (%v)", err)
+	}
+
+	if len(exportErr.Problems) != 2 {
+		t.Errorf("expected (2) problems, got (%v)", exportErr.Problems)
+	}
+
+	if len(w.questions) != 0 {
+		t.Errorf("expected no exported questions, got (%d)", len(w.questions))
+	}
+}
+
+// quizMock is a writer whose questions are served to candidates
+type quizMock struct {
+	writerMock
+}
+
+func (q *quizMock) Quiz() bool {
+	return true
+}
+
+func TestExportQuiz(t *testing.T) {
+	s := Service{Repo: &RepositoryMock{}, Policy: DefaultPolicy}
+
+	w := &quizMock{writerMock{max: 10}}
+	if err := s.Export(adminCtx, w); err != nil {
+		t.Fatalf("unable to export questions: %s", err.Error())
+	}
+
+	if len(w.questions) != 1 || w.questions[0].Status != entities.Published {
+		t.Errorf("expected only the published question, got questions (%v)", w.questions)
+	}
+}
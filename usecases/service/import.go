@@ -0,0 +1,188 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/norby7/questions-rest-api/entities"
+	"io"
+)
+
+// ImportMode defines how an import behaves when some of the records are invalid
+type ImportMode string
+
+const (
+	// ImportAtomic imports all the records in a single transaction, or none of them if any record fails
+	ImportAtomic ImportMode = "atomic"
+	// ImportBestEffort imports every valid record and reports the failing ones
+	ImportBestEffort ImportMode = "best-effort"
+)
+
+// ImportStatus is the outcome of importing a single record
+type ImportStatus string
+
+const (
+	ImportStatusImported ImportStatus = "imported"
+	ImportStatusInvalid  ImportStatus = "invalid"
+	ImportStatusFailed   ImportStatus = "failed"
+	ImportStatusSkipped  ImportStatus = "skipped"
+)
+
+var (
+	ImportModeError = fmt.Errorf("import mode should be either %s or %s", ImportAtomic, ImportBestEffort)
+)
+
+// QuestionReader is a stream of questions, Read returns io.EOF when there are no more questions
+// Errors other than io.EOF only concern the current record, reading continues with the next one.
+type QuestionReader interface {
+	Read() (entities.Question, error)
+}
+
+// ImportResult is the outcome of importing a single record of the stream
+type ImportResult struct {
+	// position of the record inside the stream, starting from 1
+	Record int          `json:"record"`
+	Status ImportStatus `json:"status"`
+	Error  string       `json:"error,omitempty"`
+}
+
+// ImportReport summarizes an import and contains the result of every record
+type ImportReport struct {
+	Mode     ImportMode     `json:"mode"`
+	Total    int            `json:"total"`
+	Imported int            `json:"imported"`
+	Failed   int            `json:"failed"`
+	Results  []ImportResult `json:"results"`
+}
+
+// ParseImportMode returns the import mode with the given name, defaulting to ImportAtomic
+func ParseImportMode(m string) (ImportMode, error) {
+	switch ImportMode(m) {
+	case "", ImportAtomic:
+		return ImportAtomic, nil
+	case ImportBestEffort:
+		return ImportBestEffort, nil
+	}
+
+	return "", ImportModeError
+}
+
+// Import reads and validates every question from the stream and inserts them according to the import mode
+// The questions are imported as new drafts of the principal. A restore, which requires ActionRestore, keeps the review
+// status, the review comment and the creation and update times and authors of the records instead, as written by the
+// export of a backup.
+func (s *Service) Import(ctx context.Context, r QuestionReader, mode ImportMode, restore bool) (ImportReport, error) {
+	if err := s.Policy.Authorize(ctx, ActionImport); err != nil {
+		return ImportReport{}, err
+	}
+
+	if restore {
+		if err := s.Policy.Authorize(ctx, ActionRestore); err != nil {
+			return ImportReport{}, err
+		}
+	}
+
+	if mode != ImportAtomic && mode != ImportBestEffort {
+		return ImportReport{}, ImportModeError
+	}
+
+	report := ImportReport{Mode: mode, Results: []ImportResult{}}
+
+	// valid questions are only kept in memory in atomic mode, until the whole stream has been validated
+	var valid []entities.Question
+	var validIndexes []int
+
+	for {
+		// the stream can be long, stop reading it once the request is canceled
+		if err := ctx.Err(); err != nil {
+			return ImportReport{}, err
+		}
+
+		q, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+
+		res := ImportResult{Record: report.Total + 1, Status: ImportStatusImported}
+		report.Total++
+
+		if err == nil {
+			err = validateImported(q)
+		}
+
+		if !restore {
+			q = newQuestion(q)
+		}
+
+		switch {
+		case err != nil:
+			res.Status, res.Error = ImportStatusInvalid, err.Error()
+		case mode == ImportAtomic:
+			valid = append(valid, q)
+			validIndexes = append(validIndexes, len(report.Results))
+		default:
+			// the failed questions are reported, only the errors of the audit log stop the import
+			err = s.Repo.InTransaction(ctx, func(ctx context.Context) error {
+				if q.Id, err = s.Repo.Add(ctx, q); err != nil {
+					res.Status, res.Error = ImportStatusFailed, err.Error()
+					return nil
+				}
+
+				return s.audit(ctx, entities.AuditImport, q.Id, nil, stored(q, nil))
+			})
+			if err != nil {
+				return ImportReport{}, err
+			}
+		}
+
+		report.Results = append(report.Results, res)
+	}
+
+	if mode == ImportAtomic {
+		if len(valid) != len(report.Results) {
+			for _, i := range validIndexes {
+				report.Results[i].Status = ImportStatusSkipped
+			}
+		} else if len(valid) > 0 {
+			err := s.Repo.InTransaction(ctx, func(ctx context.Context) error {
+				ids, err := s.Repo.AddAll(ctx, valid)
+				if err != nil {
+					return fmt.Errorf("unable to import questions: %s", err.Error())
+				}
+
+				for i := range valid {
+					valid[i].Id = ids[i]
+					if err = s.audit(ctx, entities.AuditImport, ids[i], nil, stored(valid[i], nil)); err != nil {
+						return err
+					}
+				}
+
+				return nil
+			})
+			if err != nil {
+				return ImportReport{}, err
+			}
+		}
+	}
+
+	for _, res := range report.Results {
+		switch res.Status {
+		case ImportStatusImported:
+			report.Imported++
+		case ImportStatusInvalid, ImportStatusFailed:
+			report.Failed++
+		}
+	}
+
+	return report, nil
+}
+
+// validateImported validates an imported question and the review status it was exported with
+func validateImported(q entities.Question) error {
+	if q.Status != "" {
+		if err := q.Status.Validate(); err != nil {
+			return err
+		}
+	}
+
+	return q.Validate()
+}
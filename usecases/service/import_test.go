@@ -0,0 +1,268 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/norby7/questions-rest-api/entities"
+	"io"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// readerMock returns the given questions and errors in order, then io.EOF
+type readerMock struct {
+	questions []entities.Question
+	errs      []error
+}
+
+func (r *readerMock) Read() (entities.Question, error) {
+	if len(r.questions) == 0 {
+		return entities.Question{}, io.EOF
+	}
+
+	q, err := r.questions[0], r.errs[0]
+	r.questions, r.errs = r.questions[1:], r.errs[1:]
+
+	return q, err
+}
+
+func validQuestion(body string) entities.Question {
+	return entities.Question{
+		Body: body,
+		Options: []entities.Option{{
+			Body:    "East",
+			Correct: false,
+		}, {
+			Body:    "West",
+			Correct: true,
+		}},
+	}
+}
+
+// statusQuestion returns a valid question exported with the given review status
+func statusQuestion(body string, status entities.QuestionStatus) entities.Question {
+	q := validQuestion(body)
+	q.Status = status
+
+	return q
+}
+
+func TestImport(t *testing.T) {
+	r := &RepositoryMock{}
+	s := Service{Repo: r, Policy: DefaultPolicy}
+
+	parseErr := fmt.Errorf("unable to parse question")
+
+	testCases := []struct {
+		name      string
+		questions []entities.Question
+		errs      []error
+		mode      ImportMode
+		isError   bool
+		imported  int
+		failed    int
+		statuses  []ImportStatus
+	}{
+		{
+			name:      "atomic, all valid",
+			questions: []entities.Question{validQuestion("Where does the sun set?"), validQuestion("Where does the sun set?")},
+			errs:      []error{nil, nil},
+			mode:      ImportAtomic,
+			imported:  2,
+			statuses:  []ImportStatus{ImportStatusImported, ImportStatusImported},
+		},
+		{
+			name:      "atomic, invalid record",
+			questions: []entities.Question{validQuestion("Where does the sun set?"), {}},
+			errs:      []error{nil, nil},
+			mode:      ImportAtomic,
+			failed:    1,
+			statuses:  []ImportStatus{ImportStatusSkipped, ImportStatusInvalid},
+		},
+		{
+			name:      "atomic, parse error",
+			questions: []entities.Question{{}, validQuestion("Where does the sun set?")},
+			errs:      []error{parseErr, nil},
+			mode:      ImportAtomic,
+			failed:    1,
+			statuses:  []ImportStatus{ImportStatusInvalid, ImportStatusSkipped},
+		},
+		{
+			name:      "atomic, unknown status",
+			questions: []entities.Question{validQuestion("Where does the sun set?"), statusQuestion("Where does the sun set?", "archived")},
+			errs:      []error{nil, nil},
+			mode:      ImportAtomic,
+			failed:    1,
+			statuses:  []ImportStatus{ImportStatusSkipped, ImportStatusInvalid},
+		},
+		{
+			name:      "best effort, exported status",
+			questions: []entities.Question{statusQuestion("Where does the sun set?", entities.Published)},
+			errs:      []error{nil},
+			mode:      ImportBestEffort,
+			imported:  1,
+			statuses:  []ImportStatus{ImportStatusImported},
+		},
+		{
+			name:      "atomic, repository error",
+			questions: []entities.Question{validQuestion("add error question")},
+			errs:      []error{nil},
+			mode:      ImportAtomic,
+			isError:   true,
+		},
+		{
+			name:      "best effort, mixed records",
+			questions: []entities.Question{validQuestion("Where does the sun set?"), {}, validQuestion("add error question")},
+			errs:      []error{nil, nil, nil},
+			mode:      ImportBestEffort,
+			imported:  1,
+			failed:    2,
+			statuses:  []ImportStatus{ImportStatusImported, ImportStatusInvalid, ImportStatusFailed},
+		},
+		{
+			name:    "invalid mode",
+			mode:    "partial",
+			isError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			report, err := s.Import(adminCtx, &readerMock{questions: tc.questions, errs: tc.errs}, tc.mode, false)
+
+			if (err != nil) != tc.isError {
+				t.Fatalf("expected error (%v), got error (%v)", tc.isError, err)
+			}
+
+			if report.Imported != tc.imported || report.Failed != tc.failed {
+				t.Errorf("expected (%d) imported and (%d) failed, got (%d) and (%d)", tc.imported, tc.failed, report.Imported, report.Failed)
+			}
+
+			for i, res := range report.Results {
+				if res.Status != tc.statuses[i] {
+					t.Errorf("expected record (%d) status (%v), got (%v)", res.Record, tc.statuses[i], res.Status)
+				}
+			}
+		})
+	}
+}
+
+func TestImportCanceled(t *testing.T) {
+	s := Service{Repo: &RepositoryMock{}, Policy: DefaultPolicy}
+
+	ctx, cancel := context.WithCancel(adminCtx)
+	cancel()
+
+	r := &readerMock{questions: []entities.Question{validQuestion("Where does the sun set?")}, errs: []error{nil}}
+
+	if _, err := s.Import(ctx, r, ImportBestEffort, false); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected error (%v), got error (%v)", context.Canceled, err)
+	}
+
+	if len(r.questions) != 1 {
+		t.Errorf("expected the stream not to be read once the request is canceled")
+	}
+}
+
+// importMock is a repository recording the imported questions
+type importMock struct {
+	RepositoryMock
+	added []entities.Question
+}
+
+func (r *importMock) Add(ctx context.Context, q entities.Question) (int64, error) {
+	r.added = append(r.added, q)
+	return int64(len(r.added)), nil
+}
+
+func (r *importMock) AddAll(ctx context.Context, ql []entities.Question) ([]int64, error) {
+	ids := make([]int64, len(ql))
+	for i, q := range ql {
+		ids[i], _ = r.Add(ctx, q)
+	}
+
+	return ids, nil
+}
+
+func TestImportRestore(t *testing.T) {
+	created := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+	exported := statusQuestion("Where does the sun set?", entities.Published)
+	exported.ReviewComment, exported.CreatedAt, exported.CreatedBy, exported.UpdatedAt, exported.UpdatedBy = "approved", &created, "mallory", &created, "mallory"
+
+	// the editors are allowed to import but not to restore
+	policy := Policy{ActionImport: {entities.RoleEditor, entities.RoleAdmin}, ActionRestore: {entities.RoleAdmin}}
+
+	testCases := []struct {
+		name     string
+		ctx      context.Context
+		restore  bool
+		expected entities.Question
+		isError  bool
+	}{{
+		name:     "import",
+		ctx:      roleCtx(entities.RoleEditor),
+		expected: validQuestion("Where does the sun set?"),
+	}, {
+		name:     "restore",
+		ctx:      adminCtx,
+		restore:  true,
+		expected: exported,
+	}, {
+		name:    "restore by an editor",
+		ctx:     roleCtx(entities.RoleEditor),
+		restore: true,
+		isError: true,
+	}}
+
+	for _, tc := range testCases {
+		for _, mode := range []ImportMode{ImportAtomic, ImportBestEffort} {
+			t.Run(tc.name+" "+string(mode), func(t *testing.T) {
+				r := &importMock{}
+				s := Service{Repo: r, Policy: policy}
+
+				_, err := s.Import(tc.ctx, &readerMock{questions: []entities.Question{exported}, errs: []error{nil}}, mode, tc.restore)
+				if (err != nil) != tc.isError {
+					t.Fatalf("expected error (%v), got error (%v)", tc.isError, err)
+				}
+
+				if tc.isError {
+					var fe *ForbiddenError
+					if !errors.As(err, &fe) || fe.Action != ActionRestore || len(r.added) != 0 {
+						t.Errorf("expected the restore to be forbidden, got error (%v) and questions (%v)", err, r.added)
+					}
+					return
+				}
+
+				// the repository stores the questions without status as drafts
+				if len(r.added) != 1 || !reflect.DeepEqual(r.added[0], tc.expected) {
+					t.Errorf("expected question (%v), got questions (%v)", tc.expected, r.added)
+				}
+			})
+		}
+	}
+}
+
+func TestParseImportMode(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected ImportMode
+		isError  bool
+	}{
+		{input: "", expected: ImportAtomic},
+		{input: "atomic", expected: ImportAtomic},
+		{input: "best-effort", expected: ImportBestEffort},
+		{input: "partial", isError: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			mode, err := ParseImportMode(tc.input)
+
+			if (err != nil) != tc.isError || mode != tc.expected {
+				t.Errorf("expected mode (%v) and error (%v), got (%v) and (%v)", tc.expected, tc.isError, mode, err)
+			}
+		})
+	}
+}
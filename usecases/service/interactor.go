@@ -1,10 +1,26 @@
 package service
 
-import "github.com/norby7/questions-rest-api/entities"
+import (
+	"context"
+	"github.com/norby7/questions-rest-api/entities"
+)
 
+// Interactor is implemented by the question bank use cases
+// Every operation is authorized against the principal carried by the context.
 type Interactor interface {
-	Create(entities.Question) error
-	Update(entities.Question) error
-	Remove(int64) error
-	ListAll(int, int) ([]entities.Question, error)
+	Create(context.Context, entities.Question) (entities.Question, []Duplicate, error)
+	Update(context.Context, entities.Question) (entities.Question, error)
+	Patch(context.Context, int64, PatchType, []byte) (entities.Question, error)
+	Remove(context.Context, int64) error
+	AddOption(context.Context, int64, entities.Option) (entities.Option, error)
+	UpdateOption(context.Context, int64, entities.Option) (entities.Option, error)
+	RemoveOption(context.Context, int64, int64) error
+	ReorderOptions(context.Context, int64, []int64) (entities.Question, error)
+	Transition(context.Context, int64, entities.QuestionTransition, string) (entities.Question, error)
+	ListAll(context.Context, entities.QuestionFilter) ([]entities.Question, error)
+	Import(context.Context, QuestionReader, ImportMode, bool) (ImportReport, error)
+	Export(context.Context, QuestionWriter) error
+	Duplicates(context.Context, float64) ([]DuplicateGroup, error)
+	Batch(context.Context, []BatchOperation, BatchMode) (BatchReport, error)
+	AuditLog(context.Context, entities.AuditFilter) ([]entities.AuditEntry, error)
 }
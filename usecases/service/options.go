@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/norby7/questions-rest-api/entities"
+)
+
+var (
+	InvalidOptionError = fmt.Errorf("the option is invalid")
+	OptionOrderError   = fmt.Errorf("option ids should list every option of the question once")
+)
+
+// AddOption appends the option to the options of the question with the given id and returns it with its new id
+func (s *Service) AddOption(ctx context.Context, questionId int64, o entities.Option) (entities.Option, error) {
+	o.Id, o.QuestionId = 0, questionId
+
+	q, err := s.changeQuestion(ctx, questionId, func(q *entities.Question) error {
+		if err := o.Validate(); err != nil {
+			return fmt.Errorf("%w: %s", InvalidOptionError, err.Error())
+		}
+
+		q.Options = append(q.Options, o)
+		return nil
+	})
+	if err != nil {
+		return entities.Option{}, err
+	}
+
+	// the new option id is assigned by the repository
+	return q.Options[len(q.Options)-1], nil
+}
+
+// UpdateOption changes the body and correctness of the option with the id of o, the option keeps its position
+// The stored option is returned.
+func (s *Service) UpdateOption(ctx context.Context, questionId int64, o entities.Option) (entities.Option, error) {
+	o.QuestionId = questionId
+
+	q, err := s.changeQuestion(ctx, questionId, func(q *entities.Question) error {
+		i, err := optionIndex(q, o.Id)
+		if err != nil {
+			return err
+		}
+
+		if err = o.Validate(); err != nil {
+			return fmt.Errorf("%w: %s", InvalidOptionError, err.Error())
+		}
+
+		o.OptionOrder = i
+		q.Options[i] = o
+		return nil
+	})
+	if err != nil {
+		return entities.Option{}, err
+	}
+
+	i, err := optionIndex(&q, o.Id)
+	if err != nil {
+		return entities.Option{}, fmt.Errorf("unable to read the updated option: %s", err.Error())
+	}
+
+	return q.Options[i], nil
+}
+
+// RemoveOption deletes the option with the given id from the question, the following options move up
+func (s *Service) RemoveOption(ctx context.Context, questionId, optionId int64) error {
+	_, err := s.changeQuestion(ctx, questionId, func(q *entities.Question) error {
+		i, err := optionIndex(q, optionId)
+		if err != nil {
+			return err
+		}
+
+		q.Options = append(q.Options[:i], q.Options[i+1:]...)
+		return nil
+	})
+
+	return err
+}
+
+// ReorderOptions sorts the options of the question in the order of ids, which lists every option id once
+func (s *Service) ReorderOptions(ctx context.Context, questionId int64, ids []int64) (entities.Question, error) {
+	return s.changeQuestion(ctx, questionId, func(q *entities.Question) error {
+		if len(ids) != len(q.Options) {
+			return fmt.Errorf("%w: %d ids for %d options", OptionOrderError, len(ids), len(q.Options))
+		}
+
+		options := make([]entities.Option, len(ids))
+		listed := make(map[int64]bool, len(ids))
+		for i, id := range ids {
+			if listed[id] {
+				return fmt.Errorf("%w: option %d is listed twice", OptionOrderError, id)
+			}
+			listed[id] = true
+
+			j, err := optionIndex(q, id)
+			if err != nil {
+				return err
+			}
+
+			options[i] = q.Options[j]
+			options[i].OptionOrder = i
+		}
+
+		q.Options = options
+		return nil
+	})
+}
+
+// optionIndex returns the position of the option with the given id among the options of q
+func optionIndex(q *entities.Question, id int64) (int, error) {
+	for i, o := range q.Options {
+		if o.Id == id {
+			return i, nil
+		}
+	}
+
+	return 0, fmt.Errorf("%w: %d", OptionNotFoundError, id)
+}
@@ -0,0 +1,205 @@
+package service
+
+import (
+	"errors"
+	"github.com/norby7/questions-rest-api/entities"
+	"reflect"
+	"testing"
+)
+
+func TestAddOption(t *testing.T) {
+	testCases := []struct {
+		name       string
+		questionId int64
+		option     entities.Option
+		expected   error
+	}{{
+		name:       "new option",
+		questionId: 1,
+		option:     entities.Option{Id: 9, Body: "North"},
+	}, {
+		name:       "empty body",
+		questionId: 1,
+		option:     entities.Option{Body: ""},
+		expected:   InvalidOptionError,
+	}, {
+		name:       "missing question",
+		questionId: 2,
+		option:     entities.Option{Body: "North"},
+		expected:   QuestionNotFoundError,
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := newQuestionRepositoryMock()
+			s := NewService(r)
+
+			o, err := s.AddOption(adminCtx, tc.questionId, tc.option)
+			if tc.expected != nil {
+				if !errors.Is(err, tc.expected) || r.updated != nil {
+					t.Errorf("expected error (%v) without update, got error (%v) and update (%v)", tc.expected, err, r.updated)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unable to add option: %s", err.Error())
+			}
+
+			if o.Id != 3 || o.Body != tc.option.Body {
+				t.Errorf("expected option (3, %s), got option (%v)", tc.option.Body, o)
+			}
+
+			if len(r.question.Options) != 3 || r.question.Options[2].Id != 3 {
+				t.Errorf("expected the option to be added last, got options (%v)", r.question.Options)
+			}
+		})
+	}
+}
+
+func TestUpdateOption(t *testing.T) {
+	testCases := []struct {
+		name     string
+		option   entities.Option
+		options  []entities.Option
+		expected error
+	}{{
+		name:    "new body",
+		option:  entities.Option{Id: 1, Body: "East, where it rises"},
+		options: []entities.Option{{Id: 1, QuestionId: 1, Body: "East, where it rises"}, {Id: 2, QuestionId: 1, Body: "West", Correct: true, OptionOrder: 1}},
+	}, {
+		name:    "stored body",
+		option:  entities.Option{Id: 1, Body: " East, where it rises "},
+		options: []entities.Option{{Id: 1, QuestionId: 1, Body: "East, where it rises"}, {Id: 2, QuestionId: 1, Body: "West", Correct: true, OptionOrder: 1}},
+	}, {
+		name:    "new correct option",
+		option:  entities.Option{Id: 1, Body: "East", Correct: true},
+		options: []entities.Option{{Id: 1, QuestionId: 1, Body: "East", Correct: true}, {Id: 2, QuestionId: 1, Body: "West", Correct: true, OptionOrder: 1}},
+	}, {
+		name:     "no correct option left",
+		option:   entities.Option{Id: 2, Body: "West", Correct: false},
+		expected: InvalidQuestionError,
+	}, {
+		name:     "empty body",
+		option:   entities.Option{Id: 2, Body: "", Correct: true},
+		expected: InvalidOptionError,
+	}, {
+		name:     "missing option",
+		option:   entities.Option{Id: 9, Body: "North"},
+		expected: OptionNotFoundError,
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := newQuestionRepositoryMock()
+			s := NewService(r)
+
+			o, err := s.UpdateOption(adminCtx, 1, tc.option)
+			if tc.expected != nil {
+				if !errors.Is(err, tc.expected) || r.updated != nil {
+					t.Errorf("expected error (%v) without update, got error (%v) and update (%v)", tc.expected, err, r.updated)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unable to update option: %s", err.Error())
+			}
+
+			// the stored option is returned, not the updated one
+			if o != tc.options[tc.option.Id-1] {
+				t.Errorf("expected option (%v), got option (%v)", tc.options[tc.option.Id-1], o)
+			}
+
+			if !reflect.DeepEqual(r.question.Options, tc.options) {
+				t.Errorf("expected options (%v), got options (%v)", tc.options, r.question.Options)
+			}
+		})
+	}
+}
+
+func TestRemoveOption(t *testing.T) {
+	r := newQuestionRepositoryMock()
+	s := NewService(r)
+
+	if _, err := s.AddOption(adminCtx, 1, entities.Option{Body: "North"}); err != nil {
+		t.Fatalf("unable to add option: %s", err.Error())
+	}
+
+	if err := s.RemoveOption(adminCtx, 1, 1); err != nil {
+		t.Fatalf("unable to remove option: %s", err.Error())
+	}
+
+	if len(r.question.Options) != 2 || r.question.Options[0].Id != 2 || r.question.Options[1].Id != 3 {
+		t.Errorf("expected options (2, 3), got options (%v)", r.question.Options)
+	}
+
+	// the question keeps two options and a correct one
+	if err := s.RemoveOption(adminCtx, 1, 3); !errors.Is(err, InvalidQuestionError) {
+		t.Errorf("expected error (%v), got error (%v)", InvalidQuestionError, err)
+	}
+
+	if err := s.RemoveOption(adminCtx, 1, 9); !errors.Is(err, OptionNotFoundError) {
+		t.Errorf("expected error (%v), got error (%v)", OptionNotFoundError, err)
+	}
+
+	if len(r.question.Options) != 2 {
+		t.Errorf("expected the failed removals to change nothing, got options (%v)", r.question.Options)
+	}
+}
+
+func TestReorderOptions(t *testing.T) {
+	testCases := []struct {
+		name     string
+		ids      []int64
+		expected error
+	}{{
+		name: "reversed",
+		ids:  []int64{3, 2, 1},
+	}, {
+		name: "same order",
+		ids:  []int64{1, 2, 3},
+	}, {
+		name:     "missing option",
+		ids:      []int64{3, 2},
+		expected: OptionOrderError,
+	}, {
+		name:     "option listed twice",
+		ids:      []int64{3, 3, 1},
+		expected: OptionOrderError,
+	}, {
+		name:     "unknown option",
+		ids:      []int64{3, 2, 9},
+		expected: OptionNotFoundError,
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := newQuestionRepositoryMock()
+			s := NewService(r)
+
+			if _, err := s.AddOption(adminCtx, 1, entities.Option{Body: "North"}); err != nil {
+				t.Fatalf("unable to add option: %s", err.Error())
+			}
+			r.updated = nil
+
+			q, err := s.ReorderOptions(adminCtx, 1, tc.ids)
+			if tc.expected != nil {
+				if !errors.Is(err, tc.expected) || r.updated != nil {
+					t.Errorf("expected error (%v) without update, got error (%v) and update (%v)", tc.expected, err, r.updated)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unable to reorder options: %s", err.Error())
+			}
+
+			for i, o := range q.Options {
+				if o.Id != tc.ids[i] || o.OptionOrder != i || r.question.Options[i].Id != tc.ids[i] {
+					t.Errorf("expected option (%d) at position (%d), got option (%v) and stored option (%v)", tc.ids[i], i, o, r.question.Options[i])
+				}
+			}
+		})
+	}
+}
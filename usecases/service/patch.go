@@ -0,0 +1,412 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/norby7/questions-rest-api/entities"
+	"mime"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// PatchType is the media type of a patch document, it defines how the document is applied to the question
+type PatchType string
+
+const (
+	// MergePatch documents are the members of the question to change, null removing a member (RFC 7386)
+	MergePatch PatchType = "application/merge-patch+json"
+	// JSONPatch documents are lists of operations applied to the question in order (RFC 6902)
+	JSONPatch PatchType = "application/json-patch+json"
+)
+
+var (
+	PatchTypeError     = fmt.Errorf("patch content type should be either %s or %s", MergePatch, JSONPatch)
+	PatchDocumentError = fmt.Errorf("invalid patch document")
+	PatchConflictError = fmt.Errorf("patch can't be applied to the question")
+)
+
+// JSON Patch operations
+const (
+	PatchAdd     = "add"
+	PatchRemove  = "remove"
+	PatchReplace = "replace"
+	PatchMove    = "move"
+	PatchCopy    = "copy"
+	PatchTest    = "test"
+)
+
+// PatchOperation is a single operation of a JSON Patch document
+// Path and From are JSON pointers, Value is used by the add, replace and test operations.
+type PatchOperation struct {
+	Op    string          `json:"op"`
+	Path  *string         `json:"path"`
+	From  *string         `json:"from,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// ParsePatchType returns the patch type matching the given Content-Type header value
+func ParsePatchType(ct string) (PatchType, error) {
+	mt, _, err := mime.ParseMediaType(ct)
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", PatchTypeError, ct)
+	}
+
+	switch t := PatchType(mt); t {
+	case MergePatch, JSONPatch:
+		return t, nil
+	}
+
+	return "", fmt.Errorf("%w: %s", PatchTypeError, ct)
+}
+
+// Patch applies the patch document to the question with the given id and calls the repository to store the result
+// The patched question is validated like an updated one and returned. Only the changed rows are written by the repository.
+func (s *Service) Patch(ctx context.Context, id int64, t PatchType, patch []byte) (entities.Question, error) {
+	return s.changeQuestion(ctx, id, func(q *entities.Question) error {
+		doc, err := genericValue(q)
+		if err != nil {
+			return err
+		}
+
+		switch t {
+		case MergePatch:
+			doc, err = applyMergePatch(doc, patch)
+		case JSONPatch:
+			doc, err = applyJSONPatch(doc, patch)
+		default:
+			err = fmt.Errorf("%w: %s", PatchTypeError, t)
+		}
+		if err != nil {
+			return err
+		}
+
+		*q, err = patchedQuestion(doc)
+		return err
+	})
+}
+
+// patchedQuestion converts the patched JSON value back to a question
+func patchedQuestion(doc interface{}) (entities.Question, error) {
+	var q entities.Question
+
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return q, fmt.Errorf("unable to encode patched question: %s", err.Error())
+	}
+
+	if err = entities.DecodeJSON(bytes.NewReader(data), &q); err != nil {
+		return q, fmt.Errorf("%w: %s", InvalidQuestionError, err.Error())
+	}
+
+	return q, nil
+}
+
+// applyMergePatch merges the patch document into the JSON value doc
+func applyMergePatch(doc interface{}, patch []byte) (interface{}, error) {
+	var p interface{}
+	if err := json.Unmarshal(patch, &p); err != nil {
+		return nil, fmt.Errorf("%w: %s", PatchDocumentError, err.Error())
+	}
+
+	return mergeValues(doc, p), nil
+}
+
+// mergeValues returns the target value with the members of the patch value merged in, as defined by RFC 7386
+func mergeValues(target, patch interface{}) interface{} {
+	p, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	t, ok := target.(map[string]interface{})
+	if !ok {
+		t = map[string]interface{}{}
+	}
+
+	for k, v := range p {
+		if v == nil {
+			delete(t, k)
+			continue
+		}
+
+		t[k] = mergeValues(t[k], v)
+	}
+
+	return t
+}
+
+// applyJSONPatch applies the operations of the patch document to the JSON value doc, either all of them or none
+func applyJSONPatch(doc interface{}, patch []byte) (interface{}, error) {
+	var ops []PatchOperation
+	if err := json.Unmarshal(patch, &ops); err != nil {
+		return nil, fmt.Errorf("%w: %s", PatchDocumentError, err.Error())
+	}
+
+	for i, op := range ops {
+		var err error
+		if doc, err = applyOperation(doc, op); err != nil {
+			return nil, fmt.Errorf("operation %d: %w", i, err)
+		}
+	}
+
+	return doc, nil
+}
+
+// applyOperation applies a single JSON Patch operation to the JSON value doc
+func applyOperation(doc interface{}, op PatchOperation) (interface{}, error) {
+	if op.Path == nil {
+		return nil, fmt.Errorf("%w: %s operation requires a path", PatchDocumentError, op.Op)
+	}
+
+	path, err := parsePointer(*op.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	var from []string
+	switch op.Op {
+	case PatchMove, PatchCopy:
+		if op.From == nil {
+			return nil, fmt.Errorf("%w: %s operation requires from", PatchDocumentError, op.Op)
+		}
+
+		if from, err = parsePointer(*op.From); err != nil {
+			return nil, err
+		}
+	}
+
+	var value interface{}
+	switch op.Op {
+	case PatchAdd, PatchReplace, PatchTest:
+		if op.Value == nil {
+			return nil, fmt.Errorf("%w: %s operation requires a value", PatchDocumentError, op.Op)
+		}
+
+		if err = json.Unmarshal(op.Value, &value); err != nil {
+			return nil, fmt.Errorf("%w: %s", PatchDocumentError, err.Error())
+		}
+	}
+
+	switch op.Op {
+	case PatchAdd:
+		return addValue(doc, path, value)
+	case PatchRemove:
+		return removeValue(doc, path)
+	case PatchReplace:
+		if _, err = getValue(doc, path); err != nil {
+			return nil, err
+		}
+
+		return setValue(doc, path, value)
+	case PatchMove:
+		if strings.HasPrefix(*op.Path, *op.From+"/") {
+			return nil, fmt.Errorf("%w: %s can't be moved into itself", PatchDocumentError, *op.From)
+		}
+
+		if value, err = getValue(doc, from); err != nil {
+			return nil, err
+		}
+
+		if doc, err = removeValue(doc, from); err != nil {
+			return nil, err
+		}
+
+		return addValue(doc, path, value)
+	case PatchCopy:
+		if value, err = getValue(doc, from); err != nil {
+			return nil, err
+		}
+
+		return addValue(doc, path, copyValue(value))
+	case PatchTest:
+		current, err := getValue(doc, path)
+		if err != nil {
+			return nil, err
+		}
+
+		if !reflect.DeepEqual(current, value) {
+			return nil, fmt.Errorf("%w: test failed for %s", PatchConflictError, *op.Path)
+		}
+
+		return doc, nil
+	}
+
+	return nil, fmt.Errorf("%w: unknown operation %q", PatchDocumentError, op.Op)
+}
+
+// parsePointer returns the unescaped tokens of a JSON pointer, the empty pointer refers to the whole document
+func parsePointer(p string) ([]string, error) {
+	if p == "" {
+		return nil, nil
+	}
+
+	if !strings.HasPrefix(p, "/") {
+		return nil, fmt.Errorf("%w: invalid JSON pointer %q", PatchDocumentError, p)
+	}
+
+	tokens := strings.Split(p[1:], "/")
+	for i, t := range tokens {
+		tokens[i] = unescapePointer(t)
+	}
+
+	return tokens, nil
+}
+
+// unescapePointer returns the key of an escaped JSON pointer token
+func unescapePointer(t string) string {
+	return strings.NewReplacer("~1", "/", "~0", "~").Replace(t)
+}
+
+// arrayIndex returns the position referred to by the token in an array of length n
+// The token "-" refers to the position after the last element, it's only accepted when end is true.
+func arrayIndex(t string, n int, end bool) (int, error) {
+	if t == "-" && end {
+		return n, nil
+	}
+
+	i, err := strconv.Atoi(t)
+	if err != nil || strings.TrimLeft(t, "0123456789") != "" || (len(t) > 1 && t[0] == '0') || i > n || (i == n && !end) {
+		return 0, fmt.Errorf("%w: array index %s not found", PatchConflictError, t)
+	}
+
+	return i, nil
+}
+
+// getValue returns the value found at path in doc
+func getValue(doc interface{}, path []string) (interface{}, error) {
+	for _, t := range path {
+		switch v := doc.(type) {
+		case map[string]interface{}:
+			c, ok := v[t]
+			if !ok {
+				return nil, fmt.Errorf("%w: member %s not found", PatchConflictError, t)
+			}
+			doc = c
+		case []interface{}:
+			i, err := arrayIndex(t, len(v), false)
+			if err != nil {
+				return nil, err
+			}
+			doc = v[i]
+		default:
+			return nil, fmt.Errorf("%w: %s is not a container", PatchConflictError, t)
+		}
+	}
+
+	return doc, nil
+}
+
+// updateParent calls f with the container holding the value at path and the last token of path
+// The container returned by f replaces the previous one, so that arrays can grow and shrink.
+func updateParent(doc interface{}, path []string, f func(parent interface{}, t string) (interface{}, error)) (interface{}, error) {
+	parent, err := getValue(doc, path[:len(path)-1])
+	if err != nil {
+		return nil, err
+	}
+
+	parent, err = f(parent, path[len(path)-1])
+	if err != nil {
+		return nil, err
+	}
+
+	return setValue(doc, path[:len(path)-1], parent)
+}
+
+// setValue replaces the existing value at path in doc by value
+func setValue(doc interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+
+	return updateParent(doc, path, func(parent interface{}, t string) (interface{}, error) {
+		switch v := parent.(type) {
+		case map[string]interface{}:
+			v[t] = value
+			return v, nil
+		case []interface{}:
+			i, err := arrayIndex(t, len(v), false)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = value
+			return v, nil
+		}
+
+		return nil, fmt.Errorf("%w: %s is not a container", PatchConflictError, t)
+	})
+}
+
+// addValue adds value at path in doc, inserting it when the parent is an array
+func addValue(doc interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+
+	return updateParent(doc, path, func(parent interface{}, t string) (interface{}, error) {
+		switch v := parent.(type) {
+		case map[string]interface{}:
+			v[t] = value
+			return v, nil
+		case []interface{}:
+			i, err := arrayIndex(t, len(v), true)
+			if err != nil {
+				return nil, err
+			}
+			v = append(v, nil)
+			copy(v[i+1:], v[i:])
+			v[i] = value
+			return v, nil
+		}
+
+		return nil, fmt.Errorf("%w: %s is not a container", PatchConflictError, t)
+	})
+}
+
+// removeValue removes the value at path from doc
+func removeValue(doc interface{}, path []string) (interface{}, error) {
+	if len(path) == 0 {
+		return nil, nil
+	}
+
+	return updateParent(doc, path, func(parent interface{}, t string) (interface{}, error) {
+		switch v := parent.(type) {
+		case map[string]interface{}:
+			if _, ok := v[t]; !ok {
+				return nil, fmt.Errorf("%w: member %s not found", PatchConflictError, t)
+			}
+			delete(v, t)
+			return v, nil
+		case []interface{}:
+			i, err := arrayIndex(t, len(v), false)
+			if err != nil {
+				return nil, err
+			}
+			return append(v[:i], v[i+1:]...), nil
+		}
+
+		return nil, fmt.Errorf("%w: %s is not a container", PatchConflictError, t)
+	})
+}
+
+// copyValue returns a deep copy of a JSON value, so that copied values don't share their containers
+func copyValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, c := range v {
+			m[k] = copyValue(c)
+		}
+		return m
+	case []interface{}:
+		l := make([]interface{}, len(v))
+		for i, c := range v {
+			l[i] = copyValue(c)
+		}
+		return l
+	}
+
+	return value
+}
@@ -0,0 +1,349 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/norby7/questions-rest-api/entities"
+	"github.com/norby7/questions-rest-api/usecases/repository"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// questionRepositoryMock stores a single question, with the id 1
+// Like the database, it assigns an id to the new options of the updated question. It trims the option bodies as well.
+type questionRepositoryMock struct {
+	RepositoryMock
+	question entities.Question
+	updated  *entities.Question
+	lastId   int64
+}
+
+func newQuestionRepositoryMock() *questionRepositoryMock {
+	return &questionRepositoryMock{question: entities.Question{
+		Id:   1,
+		Body: "Where does the sun set?",
+		Options: []entities.Option{
+			{Id: 1, QuestionId: 1, Body: "East", Correct: false, OptionOrder: 0},
+			{Id: 2, QuestionId: 1, Body: "West", Correct: true, OptionOrder: 1},
+		},
+	}, lastId: 2}
+}
+
+func (r *questionRepositoryMock) Get(ctx context.Context, id int64) (entities.Question, error) {
+	if id != r.question.Id {
+		return entities.Question{}, repository.QuestionNotFoundError
+	}
+
+	return r.question, nil
+}
+
+func (r *questionRepositoryMock) Update(ctx context.Context, q entities.Question) error {
+	r.updated = &q
+
+	stored := q
+	stored.Options = make([]entities.Option, len(q.Options))
+	for i, o := range q.Options {
+		if o.Id == 0 {
+			r.lastId++
+			o.Id = r.lastId
+		}
+		o.Body = strings.TrimSpace(o.Body)
+		stored.Options[i] = o
+	}
+	r.question = stored
+
+	return nil
+}
+
+func (r *questionRepositoryMock) SetStatus(ctx context.Context, id int64, from, to entities.QuestionStatus, comment string) error {
+	if id != r.question.Id {
+		return repository.QuestionNotFoundError
+	}
+
+	if r.question.Status != from {
+		return entities.StatusTransitionError
+	}
+
+	r.question.Status, r.question.ReviewComment = to, comment
+	return nil
+}
+
+func TestPatch(t *testing.T) {
+	east := entities.Option{Id: 1, Body: "East", Correct: false}
+	west := entities.Option{Id: 2, Body: "West", Correct: true}
+
+	testCases := []struct {
+		name     string
+		id       int64
+		t        PatchType
+		patch    string
+		body     string
+		options  []entities.Option
+		expected error
+	}{{
+		name:    "merge patch body",
+		id:      1,
+		t:       MergePatch,
+		patch:   `{"body":"Where does the sun rise?"}`,
+		body:    "Where does the sun rise?",
+		options: []entities.Option{east, west},
+	}, {
+		name:    "merge patch options",
+		id:      1,
+		t:       MergePatch,
+		patch:   `{"options":[{"body":"East","correct":true},{"body":"West"}]}`,
+		body:    "Where does the sun set?",
+		options: []entities.Option{{Id: 3, Body: "East", Correct: true}, {Id: 4, Body: "West"}},
+	}, {
+		name:     "merge patch removing the body",
+		id:       1,
+		t:        MergePatch,
+		patch:    `{"body":null}`,
+		expected: InvalidQuestionError,
+	}, {
+		name:     "merge patch unknown member",
+		id:       1,
+		t:        MergePatch,
+		patch:    `{"type":"single_choice"}`,
+		expected: InvalidQuestionError,
+	}, {
+		name:     "merge patch wrong type",
+		id:       1,
+		t:        MergePatch,
+		patch:    `{"body":42}`,
+		expected: InvalidQuestionError,
+	}, {
+		name:     "merge patch invalid json",
+		id:       1,
+		t:        MergePatch,
+		patch:    `{"body":`,
+		expected: PatchDocumentError,
+	}, {
+		name:    "json patch replace",
+		id:      1,
+		t:       JSONPatch,
+		patch:   `[{"op":"test","path":"/options/1/correct","value":true},{"op":"replace","path":"/options/1/body","value":"West, always"}]`,
+		body:    "Where does the sun set?",
+		options: []entities.Option{east, {Id: 2, Body: "West, always", Correct: true}},
+	}, {
+		name:    "json patch add and remove",
+		id:      1,
+		t:       JSONPatch,
+		patch:   `[{"op":"add","path":"/options/-","value":{"body":"North"}},{"op":"add","path":"/options/0","value":{"body":"South"}},{"op":"remove","path":"/options/1"}]`,
+		body:    "Where does the sun set?",
+		options: []entities.Option{{Id: 3, Body: "South"}, west, {Id: 4, Body: "North"}},
+	}, {
+		name:    "json patch move and copy",
+		id:      1,
+		t:       JSONPatch,
+		patch:   `[{"op":"move","from":"/options/1","path":"/options/0"},{"op":"copy","from":"/options/1","path":"/options/-"},{"op":"remove","path":"/options/2/id"},{"op":"replace","path":"/options/2/body","value":"North"}]`,
+		body:    "Where does the sun set?",
+		options: []entities.Option{west, east, {Id: 3, Body: "North"}},
+	}, {
+		name:     "json patch copy keeping the option id",
+		id:       1,
+		t:        JSONPatch,
+		patch:    `[{"op":"copy","from":"/options/1","path":"/options/-"}]`,
+		expected: InvalidQuestionError,
+	}, {
+		name:     "json patch failed test",
+		id:       1,
+		t:        JSONPatch,
+		patch:    `[{"op":"test","path":"/body","value":"Where does the sun rise?"},{"op":"replace","path":"/body","value":"Where does the moon rise?"}]`,
+		expected: PatchConflictError,
+	}, {
+		name:     "json patch missing path",
+		id:       1,
+		t:        JSONPatch,
+		patch:    `[{"op":"replace","path":"/options/5/body","value":"North"}]`,
+		expected: PatchConflictError,
+	}, {
+		name:     "json patch remove missing member",
+		id:       1,
+		t:        JSONPatch,
+		patch:    `[{"op":"remove","path":"/category"}]`,
+		expected: PatchConflictError,
+	}, {
+		name:     "json patch leading zero index",
+		id:       1,
+		t:        JSONPatch,
+		patch:    `[{"op":"replace","path":"/options/01/body","value":"North"}]`,
+		expected: PatchConflictError,
+	}, {
+		name:     "json patch unknown operation",
+		id:       1,
+		t:        JSONPatch,
+		patch:    `[{"op":"rename","path":"/body"}]`,
+		expected: PatchDocumentError,
+	}, {
+		name:     "json patch without value",
+		id:       1,
+		t:        JSONPatch,
+		patch:    `[{"op":"add","path":"/body"}]`,
+		expected: PatchDocumentError,
+	}, {
+		name:     "json patch null value",
+		id:       1,
+		t:        JSONPatch,
+		patch:    `[{"op":"replace","path":"/body","value":null}]`,
+		expected: InvalidQuestionError,
+	}, {
+		name:     "json patch invalid pointer",
+		id:       1,
+		t:        JSONPatch,
+		patch:    `[{"op":"remove","path":"options/0"}]`,
+		expected: PatchDocumentError,
+	}, {
+		name:     "json patch move into itself",
+		id:       1,
+		t:        JSONPatch,
+		patch:    `[{"op":"move","from":"/options","path":"/options/0"}]`,
+		expected: PatchDocumentError,
+	}, {
+		name:     "json patch not a list",
+		id:       1,
+		t:        JSONPatch,
+		patch:    `{"op":"remove","path":"/body"}`,
+		expected: PatchDocumentError,
+	}, {
+		name:     "json patch invalid result",
+		id:       1,
+		t:        JSONPatch,
+		patch:    `[{"op":"remove","path":"/options/1"}]`,
+		expected: InvalidQuestionError,
+	}, {
+		name:     "unknown patch type",
+		id:       1,
+		t:        "application/json",
+		patch:    `{"body":"Where does the sun rise?"}`,
+		expected: PatchTypeError,
+	}, {
+		name:     "question not found",
+		id:       2,
+		t:        MergePatch,
+		patch:    `{"body":"Where does the sun rise?"}`,
+		expected: QuestionNotFoundError,
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := newQuestionRepositoryMock()
+			s := NewService(r)
+
+			q, err := s.Patch(adminCtx, tc.id, tc.t, []byte(tc.patch))
+			if tc.expected != nil {
+				if !errors.Is(err, tc.expected) {
+					t.Errorf("expected error (%v), got error (%v)", tc.expected, err)
+				}
+
+				if r.updated != nil {
+					t.Errorf("expected the question not to be updated, got question (%v)", *r.updated)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unable to patch question: %s", err.Error())
+			}
+
+			if q.Id != tc.id || q.Body != tc.body || !reflect.DeepEqual(q.Options, tc.options) {
+				t.Errorf("expected question (%d, %s, %v), got question (%v)", tc.id, tc.body, tc.options, q)
+			}
+
+			// the stored question is returned, with the ids of the new options
+			if r.updated == nil || !reflect.DeepEqual(r.question, q) {
+				t.Errorf("expected the stored question (%v) to be returned, got question (%v)", r.question, q)
+			}
+		})
+	}
+}
+
+func TestPatchAudit(t *testing.T) {
+	a := &auditMock{}
+	s := NewService(newQuestionRepositoryMock())
+	s.Audit = a
+
+	_, err := s.Patch(adminCtx, 1, JSONPatch, []byte(`[{"op":"replace","path":"/options/0/body","value":"North"}]`))
+	if err != nil {
+		t.Fatalf("unable to patch question: %s", err.Error())
+	}
+
+	if len(a.entries) != 1 || a.entries[0].Action != entities.AuditUpdate || a.entries[0].QuestionId != 1 {
+		t.Fatalf("expected a single update entry for question (1), got entries (%v)", a.entries)
+	}
+
+	expected := `[{"op":"replace","path":"/options/0/body","old":"East","new":"North"}]`
+	if string(a.entries[0].Diff) != expected {
+		t.Errorf("expected diff (%s), got diff (%s)", expected, a.entries[0].Diff)
+	}
+}
+
+// txKey marks the contexts of the transactions of txRepositoryMock
+type txKey struct{}
+
+// txRepositoryMock records, for every read and write of the question, whether it's made in a transaction
+type txRepositoryMock struct {
+	*questionRepositoryMock
+	inTransaction []bool
+}
+
+func (r *txRepositoryMock) InTransaction(ctx context.Context, fn func(context.Context) error) error {
+	return fn(context.WithValue(ctx, txKey{}, true))
+}
+
+func (r *txRepositoryMock) Get(ctx context.Context, id int64) (entities.Question, error) {
+	r.inTransaction = append(r.inTransaction, ctx.Value(txKey{}) != nil)
+	return r.questionRepositoryMock.Get(ctx, id)
+}
+
+func (r *txRepositoryMock) Update(ctx context.Context, q entities.Question) error {
+	r.inTransaction = append(r.inTransaction, ctx.Value(txKey{}) != nil)
+	return r.questionRepositoryMock.Update(ctx, q)
+}
+
+func TestPatchTransaction(t *testing.T) {
+	r := &txRepositoryMock{questionRepositoryMock: newQuestionRepositoryMock()}
+	s := NewService(r)
+
+	if _, err := s.Patch(adminCtx, 1, MergePatch, []byte(`{"body":"Where does the sun rise?"}`)); err != nil {
+		t.Fatalf("unable to patch question: %s", err.Error())
+	}
+
+	// the question is read, stored and read again
+	if !reflect.DeepEqual(r.inTransaction, []bool{true, true, true}) {
+		t.Errorf("expected the question to be read and stored in a single transaction, got (%v)", r.inTransaction)
+	}
+}
+
+func TestParsePatchType(t *testing.T) {
+	testCases := []struct {
+		contentType string
+		expected    PatchType
+		isError     bool
+	}{
+		{contentType: "application/merge-patch+json", expected: MergePatch},
+		{contentType: "application/json-patch+json; charset=utf-8", expected: JSONPatch},
+		{contentType: "application/json", isError: true},
+		{contentType: "", isError: true},
+		{contentType: "application/", isError: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.contentType, func(t *testing.T) {
+			pt, err := ParsePatchType(tc.contentType)
+			if (err != nil) != tc.isError {
+				t.Fatalf("expected error (%v), got error (%v)", tc.isError, err)
+			}
+
+			if tc.isError && !errors.Is(err, PatchTypeError) {
+				t.Errorf("expected error (%v), got error (%v)", PatchTypeError, err)
+			}
+
+			if pt != tc.expected {
+				t.Errorf("expected patch type (%s), got patch type (%s)", tc.expected, pt)
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/norby7/questions-rest-api/entities"
+	"github.com/norby7/questions-rest-api/usecases/auth"
+	"github.com/norby7/questions-rest-api/usecases/repository"
+	"strings"
+)
+
+// Action is an operation whose access is controlled by the policy, most of them are Interactor operations
+type Action string
+
+const (
+	ActionCreate     Action = "create"
+	ActionUpdate     Action = "update"
+	ActionRemove     Action = "remove"
+	ActionList       Action = "list"
+	ActionRead       Action = "read"
+	ActionImport     Action = "import"
+	ActionRestore    Action = "restore"
+	ActionExport     Action = "export"
+	ActionDuplicates Action = "duplicates"
+	ActionAudit      Action = "audit"
+	ActionLogLevel   Action = "log_level"
+	ActionSubmit     Action = "submit"
+	ActionReview     Action = "review"
+	ActionRetire     Action = "retire"
+)
+
+// Actions lists every action controlled by the policy
+var Actions = []Action{
+	ActionCreate, ActionUpdate, ActionRemove, ActionList, ActionRead, ActionImport, ActionRestore, ActionExport, ActionDuplicates,
+	ActionAudit, ActionLogLevel, ActionSubmit, ActionReview, ActionRetire,
+}
+
+var (
+	UnauthenticatedError = fmt.Errorf("the request has no authenticated principal")
+)
+
+// Policy maps every action to the roles allowed to perform it, actions missing from the policy are denied
+type Policy map[Action][]entities.Role
+
+// DefaultPolicy lets viewers read, editors also create, edit and submit, and only admins delete, import, restore, review and retire
+// questions, read the audit log and change the log level
+// Candidates can only read the published questions.
+var DefaultPolicy = Policy{
+	ActionRead:       {entities.RoleCandidate, entities.RoleViewer, entities.RoleEditor, entities.RoleAdmin},
+	ActionList:       {entities.RoleViewer, entities.RoleEditor, entities.RoleAdmin},
+	ActionExport:     {entities.RoleViewer, entities.RoleEditor, entities.RoleAdmin},
+	ActionDuplicates: {entities.RoleViewer, entities.RoleEditor, entities.RoleAdmin},
+	ActionCreate:     {entities.RoleEditor, entities.RoleAdmin},
+	ActionUpdate:     {entities.RoleEditor, entities.RoleAdmin},
+	ActionSubmit:     {entities.RoleEditor, entities.RoleAdmin},
+	ActionRemove:     {entities.RoleAdmin},
+	ActionImport:     {entities.RoleAdmin},
+	ActionRestore:    {entities.RoleAdmin},
+	ActionReview:     {entities.RoleAdmin},
+	ActionRetire:     {entities.RoleAdmin},
+	ActionAudit:      {entities.RoleAdmin},
+	ActionLogLevel:   {entities.RoleAdmin},
+}
+
+// ForbiddenError is returned when the principal isn't granted any of the roles allowed to perform an action
+type ForbiddenError struct {
+	Subject  string          `json:"subject"`
+	Action   Action          `json:"action"`
+	Roles    []entities.Role `json:"roles"`
+	Required []entities.Role `json:"required_roles"`
+}
+
+func (e *ForbiddenError) Error() string {
+	required := make([]string, len(e.Required))
+	for i, r := range e.Required {
+		required[i] = string(r)
+	}
+
+	return fmt.Sprintf("%s is not allowed to perform %s, required roles: %s", e.Subject, e.Action, strings.Join(required, ", "))
+}
+
+// Authorize checks that the principal of the context is allowed to perform the action
+func (p Policy) Authorize(ctx context.Context, a Action) error {
+	principal, ok := auth.FromContext(ctx)
+	if !ok {
+		return UnauthenticatedError
+	}
+
+	for _, r := range p[a] {
+		if principal.HasRole(r) {
+			return nil
+		}
+	}
+
+	return &ForbiddenError{Subject: principal.Subject, Action: a, Roles: principal.Roles, Required: p[a]}
+}
+
+// batchAction returns the action performed by a batch operation
+func batchAction(op BatchOperation) (Action, bool) {
+	switch repository.OperationType(op.Op) {
+	case repository.OperationCreate:
+		return ActionCreate, true
+	case repository.OperationUpdate, repository.OperationTag:
+		return ActionUpdate, true
+	case repository.OperationDelete:
+		return ActionRemove, true
+	}
+
+	return "", false
+}
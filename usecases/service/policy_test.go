@@ -0,0 +1,218 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/norby7/questions-rest-api/entities"
+	"github.com/norby7/questions-rest-api/usecases/auth"
+	"testing"
+)
+
+// roleCtx returns a context carrying a principal with the given roles
+func roleCtx(roles ...entities.Role) context.Context {
+	return auth.NewContext(context.Background(), entities.Principal{Subject: "tester", Roles: roles})
+}
+
+func TestAuthorize(t *testing.T) {
+	allowed := map[entities.Role][]Action{
+		entities.RoleViewer: {ActionRead, ActionList, ActionExport, ActionDuplicates},
+		entities.RoleEditor: {ActionRead, ActionList, ActionExport, ActionDuplicates, ActionCreate, ActionUpdate, ActionSubmit},
+		entities.RoleAdmin: {ActionRead, ActionList, ActionExport, ActionDuplicates, ActionCreate, ActionUpdate, ActionSubmit,
+			ActionRemove, ActionImport, ActionRestore, ActionReview, ActionRetire, ActionAudit, ActionLogLevel},
+		entities.RoleCandidate: {ActionRead},
+	}
+
+	for role, al := range allowed {
+		for _, a := range Actions {
+			expected := false
+			for _, aa := range al {
+				expected = expected || aa == a
+			}
+
+			t.Run(string(role)+" "+string(a), func(t *testing.T) {
+				err := DefaultPolicy.Authorize(roleCtx(role), a)
+
+				if (err == nil) != expected {
+					t.Errorf("expected allowed (%v), got error (%v)", expected, err)
+				}
+
+				var fe *ForbiddenError
+				if err != nil && (!errors.As(err, &fe) || fe.Action != a || fe.Subject != "tester") {
+					t.Errorf("expected forbidden error for (%s), got error (%v)", a, err)
+				}
+			})
+		}
+	}
+}
+
+func TestPolicyActions(t *testing.T) {
+	listed := make(map[Action]bool, len(Actions))
+	for _, a := range Actions {
+		listed[a] = true
+		if _, ok := DefaultPolicy[a]; !ok {
+			t.Errorf("expected action (%s) in the default policy", a)
+		}
+	}
+
+	for a := range DefaultPolicy {
+		if !listed[a] {
+			t.Errorf("expected action (%s) of the default policy in the actions list", a)
+		}
+	}
+}
+
+func TestUnauthenticatedAuthorize(t *testing.T) {
+	if err := DefaultPolicy.Authorize(context.Background(), ActionList); err != UnauthenticatedError {
+		t.Errorf("expected error (%v), got error (%v)", UnauthenticatedError, err)
+	}
+
+	if err := DefaultPolicy.Authorize(roleCtx(), ActionList); err == nil {
+		t.Errorf("expected error for principal without roles")
+	}
+}
+
+func TestServicePolicy(t *testing.T) {
+	s := Service{Repo: &RepositoryMock{}, Policy: DefaultPolicy}
+	viewer := roleCtx(entities.RoleViewer)
+
+	testCases := []struct {
+		name    string
+		call    func(context.Context) error
+		allowed bool
+	}{{
+		name: "list",
+		call: func(ctx context.Context) error {
+			_, err := s.ListAll(ctx, entities.QuestionFilter{Limit: 10})
+			return err
+		},
+		allowed: true,
+	}, {
+		name: "duplicates",
+		call: func(ctx context.Context) error {
+			_, err := s.Duplicates(ctx, 0.8)
+			return err
+		},
+		allowed: true,
+	}, {
+		name: "export",
+		call: func(ctx context.Context) error {
+			return s.Export(ctx, &writerMock{})
+		},
+		allowed: true,
+	}, {
+		name: "create",
+		call: func(ctx context.Context) error {
+			_, _, err := s.Create(ctx, validQuestion("Where does the moon rise?"))
+			return err
+		},
+		allowed: false,
+	}, {
+		name: "update",
+		call: func(ctx context.Context) error {
+			_, err := s.Update(ctx, validQuestion("Where does the moon rise?"))
+			return err
+		},
+		allowed: false,
+	}, {
+		name: "patch",
+		call: func(ctx context.Context) error {
+			_, err := s.Patch(ctx, 1, MergePatch, []byte(`{"body":"Where does the moon rise?"}`))
+			return err
+		},
+		allowed: false,
+	}, {
+		name: "add option",
+		call: func(ctx context.Context) error {
+			_, err := s.AddOption(ctx, 1, entities.Option{Body: "North"})
+			return err
+		},
+		allowed: false,
+	}, {
+		name: "reorder options",
+		call: func(ctx context.Context) error {
+			_, err := s.ReorderOptions(ctx, 1, []int64{2, 1})
+			return err
+		},
+		allowed: false,
+	}, {
+		name: "submit",
+		call: func(ctx context.Context) error {
+			_, err := s.Transition(ctx, 1, entities.Submit, "")
+			return err
+		},
+		allowed: false,
+	}, {
+		name: "remove",
+		call: func(ctx context.Context) error {
+			return s.Remove(ctx, 1)
+		},
+		allowed: false,
+	}, {
+		name: "import",
+		call: func(ctx context.Context) error {
+			_, err := s.Import(ctx, &readerMock{}, ImportAtomic, false)
+			return err
+		},
+		allowed: false,
+	}, {
+		name: "batch",
+		call: func(ctx context.Context) error {
+			_, err := s.Batch(ctx, []BatchOperation{{Op: "delete", Id: 1}}, BatchAtomic)
+			return err
+		},
+		allowed: false,
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.call(viewer)
+
+			var fe *ForbiddenError
+			if errors.As(err, &fe) == tc.allowed {
+				t.Errorf("expected allowed (%v), got error (%v)", tc.allowed, err)
+			}
+		})
+	}
+}
+
+func TestBatchPolicy(t *testing.T) {
+	s := Service{Repo: &RepositoryMock{}, Policy: DefaultPolicy}
+	q := validQuestion("Where does the moon rise?")
+
+	testCases := []struct {
+		name    string
+		ops     []BatchOperation
+		allowed bool
+	}{{
+		name:    "create and update",
+		ops:     []BatchOperation{{Op: "create", Question: &q}, {Op: "update", Id: 1, Question: &q}},
+		allowed: true,
+	}, {
+		name:    "delete",
+		ops:     []BatchOperation{{Op: "create", Question: &q}, {Op: "delete", Id: 1}},
+		allowed: false,
+	}, {
+		name:    "tag",
+		ops:     []BatchOperation{{Op: "tag", Id: 1, AddTags: []string{"geography"}}},
+		allowed: true,
+	}, {
+		name:    "unknown operation",
+		ops:     []BatchOperation{{Op: "archive", Id: 1}},
+		allowed: true,
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := s.Batch(roleCtx(entities.RoleEditor), tc.ops, BatchAtomic)
+
+			var fe *ForbiddenError
+			if errors.As(err, &fe) == tc.allowed {
+				t.Errorf("expected allowed (%v), got error (%v)", tc.allowed, err)
+			}
+
+			if !tc.allowed && fe.Action != ActionRemove {
+				t.Errorf("expected forbidden action (%s), got action (%s)", ActionRemove, fe.Action)
+			}
+		})
+	}
+}
@@ -1,43 +1,201 @@
 package service
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"github.com/norby7/questions-rest-api/entities"
 	"github.com/norby7/questions-rest-api/usecases/repository"
 )
 
+var (
+	// QuestionNotFoundError is returned when the question doesn't exist in the bank of the principal tenant
+	QuestionNotFoundError = repository.QuestionNotFoundError
+	// OptionNotFoundError is returned when an updated option id isn't one of the question options
+	OptionNotFoundError = repository.OptionNotFoundError
+	// InvalidQuestionError is returned when a change would leave the question invalid
+	InvalidQuestionError = fmt.Errorf("the changed question is invalid")
+)
+
 type Service struct {
-	Repo repository.Repository
+	Repo            repository.Repository
+	DuplicatePolicy DuplicatePolicy
+	Policy          Policy
+	// Audit records every change made to the questions, auditing is disabled when it's nil
+	Audit repository.AuditRepository
 }
 
 // NewService returns a new Service object address
 func NewService(r repository.Repository) *Service {
-	return &Service{Repo: r}
+	return &Service{Repo: r, DuplicatePolicy: DefaultDuplicatePolicy, Policy: DefaultPolicy}
 }
 
 // Create validates the question object and calls the repository to insert the question
-func (s *Service) Create(q entities.Question) error {
+// The stored question is returned, with its id, the ids of its options and its creation time. Depending on the duplicate
+// policy, the near-duplicates of the question are returned or prevent its creation.
+func (s *Service) Create(ctx context.Context, q entities.Question) (entities.Question, []Duplicate, error) {
+	if err := s.Policy.Authorize(ctx, ActionCreate); err != nil {
+		return entities.Question{}, nil, err
+	}
+
+	q = newQuestion(q)
+
 	if err := q.Validate(); err != nil {
-		return err
+		return entities.Question{}, nil, err
+	}
+
+	// the duplicates are looked up in the transaction of the insert, so concurrent creations can't both miss each other
+	var created entities.Question
+	var duplicates []Duplicate
+	err := s.Repo.InTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		if duplicates, err = s.checkDuplicates(ctx, q); err != nil {
+			return err
+		}
+
+		id, err := s.Repo.Add(ctx, q)
+		if err != nil {
+			return err
+		}
+
+		if created, err = s.Repo.Get(ctx, id); err != nil {
+			return fmt.Errorf("unable to read the created question: %s", err.Error())
+		}
+
+		return s.audit(ctx, entities.AuditCreate, id, nil, &created)
+	})
+	if errors.Is(err, DuplicateQuestionError) {
+		return entities.Question{}, duplicates, err
 	}
 
-	return s.Repo.Add(q)
+	if err != nil {
+		return entities.Question{}, nil, err
+	}
+
+	return created, duplicates, nil
+}
+
+// newQuestion returns q without its id, the option ids, the creation and update times and authors and the review status
+// They're assigned by the repository to the questions created by the clients, only the imports keep them.
+func newQuestion(q entities.Question) entities.Question {
+	q.Id = 0
+	q = q.WithoutOptionIds()
+	q = q.WithoutMetadata()
+	q.Status, q.ReviewComment = "", ""
+
+	return q
 }
 
 // Update validates the question object and calls the repository to update the question
-func (s *Service) Update(q entities.Question) error {
+// The stored question is returned, with the ids of its new options and its update time.
+func (s *Service) Update(ctx context.Context, q entities.Question) (entities.Question, error) {
+	if err := s.Policy.Authorize(ctx, ActionUpdate); err != nil {
+		return entities.Question{}, err
+	}
+
 	if err := q.Validate(); err != nil {
-		return err
+		return entities.Question{}, err
 	}
 
-	return s.Repo.Update(q)
+	var after entities.Question
+	err := s.Repo.InTransaction(ctx, func(ctx context.Context) error {
+		before, err := s.snapshot(ctx, q.Id)
+		if err != nil {
+			return err
+		}
+
+		if err = s.Repo.Update(ctx, q); err != nil {
+			return err
+		}
+
+		if after, err = s.Repo.Get(ctx, q.Id); err != nil {
+			return fmt.Errorf("unable to read the updated question: %s", err.Error())
+		}
+
+		return s.audit(ctx, entities.AuditUpdate, q.Id, before, &after)
+	})
+	if err != nil {
+		return entities.Question{}, err
+	}
+
+	return after, nil
+}
+
+// changeQuestion applies change to the question with the given id, then validates the result and calls the repository to store it
+// The change is authorized and audited like an update, the question is read, changed and audited in a single transaction. The stored question is returned, with the ids of its new options
+// and its update time.
+func (s *Service) changeQuestion(ctx context.Context, id int64, change func(*entities.Question) error) (entities.Question, error) {
+	if err := s.Policy.Authorize(ctx, ActionUpdate); err != nil {
+		return entities.Question{}, err
+	}
+
+	var after entities.Question
+	err := s.Repo.InTransaction(ctx, func(ctx context.Context) error {
+		before, err := s.Repo.Get(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		// the options of before must not be changed, they're recorded in the audit log
+		q := before
+		q.Options = append([]entities.Option(nil), before.Options...)
+		if err = change(&q); err != nil {
+			return err
+		}
+		q.Id = id
+
+		if err = q.Validate(); err != nil {
+			return fmt.Errorf("%w: %s", InvalidQuestionError, err.Error())
+		}
+
+		if err = s.Repo.Update(ctx, q); err != nil {
+			return err
+		}
+
+		if after, err = s.Repo.Get(ctx, id); err != nil {
+			return fmt.Errorf("unable to read the changed question: %s", err.Error())
+		}
+
+		return s.audit(ctx, entities.AuditUpdate, id, &before, &after)
+	})
+	if err != nil {
+		return entities.Question{}, err
+	}
+
+	return after, nil
 }
 
 // Remove calls the repository to delete the question with the given id
-func (s *Service) Remove(id int64) error {
-	return s.Repo.Delete(id)
+func (s *Service) Remove(ctx context.Context, id int64) error {
+	if err := s.Policy.Authorize(ctx, ActionRemove); err != nil {
+		return err
+	}
+
+	return s.Repo.InTransaction(ctx, func(ctx context.Context) error {
+		before, err := s.snapshot(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		if err = s.Repo.Delete(ctx, id); err != nil {
+			return err
+		}
+
+		return s.audit(ctx, entities.AuditDelete, id, before, nil)
+	})
 }
 
-// ListAll calls the repository to return all questions from the database
-func (s *Service) ListAll(lastId, size int) ([]entities.Question, error) {
-	return s.Repo.GetAll(lastId, size)
+// ListAll calls the repository to return the questions matching the filter, in the filter order
+// The principals allowed to read but not to list the questions only get the published ones, whatever the filter status.
+func (s *Service) ListAll(ctx context.Context, f entities.QuestionFilter) ([]entities.Question, error) {
+	if err := s.Policy.Authorize(ctx, ActionList); err != nil {
+		var forbidden *ForbiddenError
+		if !errors.As(err, &forbidden) || s.Policy.Authorize(ctx, ActionRead) != nil {
+			return nil, err
+		}
+
+		f.Status = entities.Published
+	}
+
+	return s.Repo.GetAll(ctx, f)
 }
@@ -1,37 +1,60 @@
 package service
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"github.com/norby7/questions-rest-api/entities"
+	"github.com/norby7/questions-rest-api/usecases/auth"
+	"github.com/norby7/questions-rest-api/usecases/repository"
 	"testing"
 )
 
+// adminCtx carries a principal allowed to perform every operation
+var adminCtx = auth.NewContext(context.Background(), entities.Principal{Subject: "admin", Roles: []entities.Role{entities.RoleAdmin}})
+
 type RepositoryMock struct {
+	// updated is the question stored by the last update, read back instead of the question 1
+	updated *entities.Question
 }
 
 var (
 	addError    = fmt.Errorf("unable to add the question")
+	addAllError = fmt.Errorf("unable to add the questions")
 	updateError = fmt.Errorf("unable to update the question")
 	deleteError = fmt.Errorf("unable to delete the question")
 	getAllError = fmt.Errorf("unable to fetch questions")
+	exportError = fmt.Errorf("unable to export questions")
 )
 
-func (r *RepositoryMock) Add(u entities.Question) error {
+func (r *RepositoryMock) Add(ctx context.Context, u entities.Question) (int64, error) {
 	if u.Body != "Where does the sun set?" {
-		return addError
+		return 0, addError
 	}
-	return nil
+	return 1, nil
 }
 
-func (r *RepositoryMock) Update(u entities.Question) error {
+func (r *RepositoryMock) AddAll(ctx context.Context, ql []entities.Question) ([]int64, error) {
+	ids := make([]int64, len(ql))
+	for i, q := range ql {
+		if q.Body != "Where does the sun set?" {
+			return nil, addAllError
+		}
+		ids[i] = int64(i + 1)
+	}
+
+	return ids, nil
+}
+
+func (r *RepositoryMock) Update(ctx context.Context, u entities.Question) error {
 	if u.Body != "Where does the sun set?" {
 		return updateError
 	}
 
+	r.updated = &u
 	return nil
 }
-func (r *RepositoryMock) Delete(id int64) error {
+func (r *RepositoryMock) Delete(ctx context.Context, id int64) error {
 	if id != 1 {
 		return deleteError
 	}
@@ -39,17 +62,73 @@ func (r *RepositoryMock) Delete(id int64) error {
 	return nil
 }
 
-func (r *RepositoryMock) GetAll(lastId, size int) ([]entities.Question, error) {
-	if lastId == -2 {
+func (r *RepositoryMock) Get(ctx context.Context, id int64) (entities.Question, error) {
+	if id != 1 {
+		return entities.Question{}, repository.QuestionNotFoundError
+	}
+
+	if r.updated != nil {
+		return *r.updated, nil
+	}
+
+	return entities.Question{Id: 1, Body: "Where does the sun rise?"}, nil
+}
+
+func (r *RepositoryMock) GetAll(ctx context.Context, f entities.QuestionFilter) ([]entities.Question, error) {
+	if f.After != nil && f.After.Id == -2 {
 		return []entities.Question{}, getAllError
 	}
 
 	return []entities.Question{}, nil
 }
 
+func (r *RepositoryMock) ForEach(ctx context.Context, fn func(entities.Question) error) error {
+	// only the first question is published
+	for i, body := range []string{"Where does the sun set?", "Where does the sun rise?"} {
+		status := entities.Published
+		if i > 0 {
+			status = entities.Draft
+		}
+
+		if err := fn(entities.Question{Id: int64(i + 1), Body: body, Status: status}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *RepositoryMock) Batch(ctx context.Context, ops []repository.Operation) ([]int64, error) {
+	ids := make([]int64, len(ops))
+	for i, op := range ops {
+		switch {
+		case op.Id == 404:
+			return nil, &repository.BatchError{Index: i, Err: repository.QuestionNotFoundError}
+		case op.Id == 422:
+			return nil, &repository.BatchError{Index: i, Err: fmt.Errorf("%w: 9", repository.OptionNotFoundError)}
+		case op.Type == repository.OperationCreate && op.Question.Body != "Where does the sun set?":
+			return nil, &repository.BatchError{Index: i, Err: addError}
+		case op.Type == repository.OperationCreate:
+			ids[i] = int64(100 + i)
+		default:
+			ids[i] = op.Id
+		}
+	}
+
+	return ids, nil
+}
+
+func (r *RepositoryMock) SetStatus(ctx context.Context, id int64, from, to entities.QuestionStatus, comment string) error {
+	return nil
+}
+
+func (r *RepositoryMock) InTransaction(ctx context.Context, fn func(context.Context) error) error {
+	return fn(ctx)
+}
+
 func TestAdd(t *testing.T) {
 	r := &RepositoryMock{}
-	s := Service{Repo: r}
+	s := Service{Repo: r, Policy: DefaultPolicy}
 
 	testCases := []struct {
 		name          string
@@ -107,18 +186,35 @@ func TestAdd(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			err := s.Create(tc.input)
+			q, _, err := s.Create(adminCtx, tc.input)
 
 			if !errors.Is(err, tc.expectedError) {
 				t.Errorf("expected error (%v), got error (%v)", tc.expectedError, err.Error())
 			}
+
+			// the question read back from the repository is returned
+			if err == nil && (q.Id != 1 || q.Body != "Where does the sun rise?") {
+				t.Errorf("expected the stored question (1), got question (%v)", q)
+			}
 		})
 	}
 }
 
+func TestNewQuestion(t *testing.T) {
+	q := validQuestion("Where does the sun set?")
+	q.Options[0].Id = 3
+	q.CreatedBy, q.UpdatedBy, q.Status, q.ReviewComment = "mallory", "mallory", entities.Published, "Approved by myself"
+
+	// the questions created by clients don't keep the fields assigned by the repository
+	n := newQuestion(q)
+	if n.Options[0].Id != 0 || n.CreatedBy != "" || n.UpdatedBy != "" || n.Status != "" || n.ReviewComment != "" || n.Body != q.Body {
+		t.Errorf("expected question (%s) without ids, metadata and status, got question (%v)", q.Body, n)
+	}
+}
+
 func TestUpdate(t *testing.T) {
 	r := &RepositoryMock{}
-	s := Service{Repo: r}
+	s := Service{Repo: r, Policy: DefaultPolicy}
 
 	testCases := []struct {
 		name          string
@@ -149,7 +245,7 @@ func TestUpdate(t *testing.T) {
 		{
 			name: "valid question, valid update",
 			input: entities.Question{
-				Id:   0,
+				Id:   1,
 				Body: "Where does the sun set?",
 				Options: []entities.Option{{
 					Id:          0,
@@ -176,18 +272,22 @@ func TestUpdate(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			err := s.Update(tc.input)
+			q, err := s.Update(adminCtx, tc.input)
 
 			if !errors.Is(err, tc.expectedError) {
 				t.Errorf("expected error (%v), got error (%v)", tc.expectedError, err.Error())
 			}
+
+			if err == nil && (q.Id != 1 || q.Body != tc.input.Body) {
+				t.Errorf("expected the stored question (1), got question (%v)", q)
+			}
 		})
 	}
 }
 
 func TestDelete(t *testing.T) {
 	r := &RepositoryMock{}
-	s := Service{Repo: r}
+	s := Service{Repo: r, Policy: DefaultPolicy}
 
 	testCases := []struct {
 		name          string
@@ -208,7 +308,7 @@ func TestDelete(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			err := s.Remove(tc.input)
+			err := s.Remove(adminCtx, tc.input)
 
 			if !errors.Is(err, tc.expectedError) {
 				t.Errorf("expected error (%v), got error (%v)", tc.expectedError, err.Error())
@@ -219,28 +319,28 @@ func TestDelete(t *testing.T) {
 
 func TestListAll(t *testing.T) {
 	r := &RepositoryMock{}
-	s := Service{Repo: r}
+	s := Service{Repo: r, Policy: DefaultPolicy}
 
 	testCases := []struct {
 		name          string
-		input         [2]int
+		input         entities.QuestionFilter
 		expectedError error
 	}{
 		{
 			name:          "valid id, get all error",
-			input:         [2]int{-2, 10},
+			input:         entities.QuestionFilter{After: &entities.QuestionCursor{Id: -2}, Limit: 10},
 			expectedError: getAllError,
 		},
 		{
 			name:          "valid id, no error",
-			input:         [2]int{20, 15},
+			input:         entities.QuestionFilter{After: &entities.QuestionCursor{Id: 20}, Limit: 15},
 			expectedError: nil,
 		},
 	}
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			_, err := s.ListAll(tc.input[0], tc.input[1])
+			_, err := s.ListAll(adminCtx, tc.input)
 
 			if !errors.Is(err, tc.expectedError) {
 				t.Errorf("expected error (%v), got error (%v)", tc.expectedError, err.Error())
@@ -248,3 +348,52 @@ func TestListAll(t *testing.T) {
 		})
 	}
 }
+
+// filterMock is a repository recording the filter of the listed questions
+type filterMock struct {
+	RepositoryMock
+	filter entities.QuestionFilter
+}
+
+func (r *filterMock) GetAll(ctx context.Context, f entities.QuestionFilter) ([]entities.Question, error) {
+	r.filter = f
+	return []entities.Question{}, nil
+}
+
+func TestListAllStatus(t *testing.T) {
+	testCases := []struct {
+		name     string
+		ctx      context.Context
+		input    entities.QuestionStatus
+		expected entities.QuestionStatus
+	}{{
+		name:     "viewer",
+		ctx:      roleCtx(entities.RoleViewer),
+		input:    entities.Draft,
+		expected: entities.Draft,
+	}, {
+		name:     "candidate",
+		ctx:      roleCtx(entities.RoleCandidate),
+		expected: entities.Published,
+	}, {
+		name:     "candidate listing drafts",
+		ctx:      roleCtx(entities.RoleCandidate),
+		input:    entities.Draft,
+		expected: entities.Published,
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := &filterMock{}
+			s := Service{Repo: r, Policy: DefaultPolicy}
+
+			if _, err := s.ListAll(tc.ctx, entities.QuestionFilter{Status: tc.input}); err != nil {
+				t.Fatalf("expected no error, got error (%v)", err)
+			}
+
+			if r.filter.Status != tc.expected {
+				t.Errorf("expected status (%s), got status (%s)", tc.expected, r.filter.Status)
+			}
+		})
+	}
+}
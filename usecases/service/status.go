@@ -0,0 +1,68 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/norby7/questions-rest-api/entities"
+)
+
+// transitionActions maps the review workflow transitions to the action authorizing them
+var transitionActions = map[entities.QuestionTransition]Action{
+	entities.Submit:  ActionSubmit,
+	entities.Approve: ActionReview,
+	entities.Reject:  ActionReview,
+	entities.Retire:  ActionRetire,
+}
+
+// Transition moves the question with the given id through a transition of the review workflow and returns it
+// The comment is the reviewer comment of approvals and rejections, approvals require one. A submitted question loses
+// the comment of its previous review, a retired question keeps the comment of its approval. An unknown transition
+// is refused before being authorized, since no action authorizes it.
+func (s *Service) Transition(ctx context.Context, id int64, t entities.QuestionTransition, comment string) (entities.Question, error) {
+	if err := t.Validate(); err != nil {
+		return entities.Question{}, err
+	}
+
+	if err := s.Policy.Authorize(ctx, transitionActions[t]); err != nil {
+		return entities.Question{}, err
+	}
+
+	if err := t.ValidateComment(comment); err != nil {
+		return entities.Question{}, err
+	}
+
+	var after entities.Question
+	err := s.Repo.InTransaction(ctx, func(ctx context.Context) error {
+		before, err := s.Repo.Get(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		to, err := t.Apply(before.Status)
+		if err != nil {
+			return err
+		}
+
+		switch t {
+		case entities.Submit:
+			comment = ""
+		case entities.Retire:
+			comment = before.ReviewComment
+		}
+
+		if err = s.Repo.SetStatus(ctx, id, before.Status, to, comment); err != nil {
+			return err
+		}
+
+		if after, err = s.Repo.Get(ctx, id); err != nil {
+			return fmt.Errorf("unable to read the changed question: %s", err.Error())
+		}
+
+		return s.audit(ctx, entities.AuditUpdate, id, &before, &after)
+	})
+	if err != nil {
+		return entities.Question{}, err
+	}
+
+	return after, nil
+}
@@ -0,0 +1,123 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/norby7/questions-rest-api/entities"
+	"testing"
+)
+
+func TestTransition(t *testing.T) {
+	r := newQuestionRepositoryMock()
+	r.question.Status = entities.Draft
+	a := &auditMock{}
+	s := &Service{Repo: r, Policy: DefaultPolicy, Audit: a}
+
+	// every step goes through the workflow from the status the previous one left
+	steps := []struct {
+		transition entities.QuestionTransition
+		comment    string
+		status     entities.QuestionStatus
+		review     string
+		expected   error
+	}{
+		{transition: entities.Approve, comment: "Clear and correct", expected: entities.StatusTransitionError},
+		{transition: entities.Submit, status: entities.InReview},
+		{transition: entities.Reject, comment: "West is ambiguous", status: entities.Draft, review: "West is ambiguous"},
+		{transition: entities.Retire, expected: entities.StatusTransitionError},
+		{transition: entities.Submit, status: entities.InReview},
+		{transition: entities.Approve, expected: entities.ReviewCommentError},
+		{transition: entities.Approve, comment: "Clear and correct", status: entities.Published, review: "Clear and correct"},
+		{transition: entities.Submit, expected: entities.StatusTransitionError},
+		{transition: entities.Retire, comment: "ignored", status: entities.Retired, review: "Clear and correct"},
+	}
+
+	entries := 0
+	for _, step := range steps {
+		q, err := s.Transition(adminCtx, 1, step.transition, step.comment)
+		if step.expected != nil {
+			if !errors.Is(err, step.expected) {
+				t.Fatalf("%s: expected error (%v), got error (%v)", step.transition, step.expected, err)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Fatalf("%s: unable to change status: %s", step.transition, err.Error())
+		}
+
+		if q.Status != step.status || q.ReviewComment != step.review {
+			t.Errorf("%s: expected status (%s) and comment (%s), got question (%v)", step.transition, step.status, step.review, q)
+		}
+
+		entries++
+		if len(a.entries) != entries {
+			t.Fatalf("%s: expected (%d) audit entries, got entries (%v)", step.transition, entries, a.entries)
+		}
+
+		var changes []Change
+		if err = json.Unmarshal(a.entries[entries-1].Diff, &changes); err != nil {
+			t.Fatalf("unable to decode diff: %s", err.Error())
+		}
+
+		audited := false
+		for _, c := range changes {
+			audited = audited || (c.Path == "/status" && c.New == string(step.status))
+		}
+
+		if !audited {
+			t.Errorf("%s: expected the status change to be audited, got changes (%v)", step.transition, changes)
+		}
+	}
+}
+
+func TestTransitionPolicy(t *testing.T) {
+	testCases := []struct {
+		name       string
+		role       entities.Role
+		transition entities.QuestionTransition
+		from       entities.QuestionStatus
+		allowed    bool
+	}{
+		{name: "editor submits", role: entities.RoleEditor, transition: entities.Submit, from: entities.Draft, allowed: true},
+		{name: "editor approves", role: entities.RoleEditor, transition: entities.Approve, from: entities.InReview},
+		{name: "editor rejects", role: entities.RoleEditor, transition: entities.Reject, from: entities.InReview},
+		{name: "editor retires", role: entities.RoleEditor, transition: entities.Retire, from: entities.Published},
+		{name: "admin approves", role: entities.RoleAdmin, transition: entities.Approve, from: entities.InReview, allowed: true},
+		{name: "viewer submits", role: entities.RoleViewer, transition: entities.Submit, from: entities.Draft},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := newQuestionRepositoryMock()
+			r.question.Status = tc.from
+			s := NewService(r)
+
+			_, err := s.Transition(roleCtx(tc.role), 1, tc.transition, "Clear and correct")
+
+			var fe *ForbiddenError
+			if errors.As(err, &fe) == tc.allowed || (tc.allowed && err != nil) {
+				t.Errorf("expected allowed (%v), got error (%v)", tc.allowed, err)
+			}
+		})
+	}
+}
+
+func TestUnknownTransition(t *testing.T) {
+	s := NewService(newQuestionRepositoryMock())
+
+	// the unknown transitions are invalid for every principal, even the ones not allowed to change the status
+	for _, role := range []entities.Role{entities.RoleViewer, entities.RoleAdmin} {
+		if _, err := s.Transition(roleCtx(role), 1, "publish", ""); !errors.Is(err, entities.QuestionTransitionError) {
+			t.Errorf("expected error (%v) as %s, got error (%v)", entities.QuestionTransitionError, role, err)
+		}
+	}
+}
+
+func TestTransitionNotFound(t *testing.T) {
+	s := NewService(newQuestionRepositoryMock())
+
+	if _, err := s.Transition(adminCtx, 2, entities.Submit, ""); err != QuestionNotFoundError {
+		t.Errorf("expected error (%v), got error (%v)", QuestionNotFoundError, err)
+	}
+}